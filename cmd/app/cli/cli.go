@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"homework/internal/app/pickuppoint"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -40,26 +42,97 @@ func (ui *ConsoleUi) Run(ctx context.Context) error {
 
 func (ui *ConsoleUi) handleCommand() error {
 	line, err := ui.getLine("")
-	if err != nil {
-		return err
+	if err == nil {
+		cmd, ok := ui.commands[line]
+		if !ok {
+			fmt.Println("no such command found, use `help` for help")
+			return nil
+		}
+		err = cmd(ui)
 	}
-	cmd, ok := ui.commands[line]
-	if !ok {
-		fmt.Println("no such command found, use `help` for help")
-		return nil
+	if errors.Is(err, io.EOF) {
+		return ErrExit
 	}
-	return cmd(ui)
+	return err
 }
 
+// getLine reads a line, failing with io.EOF when the input is over.
 func (ui *ConsoleUi) getLine(prompt string) (string, error) {
 	fmt.Printf("%s > ", prompt)
 	if !ui.scanner.Scan() {
-		return "", ui.scanner.Err()
+		err := ui.scanner.Err()
+		if err == nil {
+			err = io.EOF
+		}
+		return "", err
 	}
 	line := ui.scanner.Text()
 	return strings.TrimSpace(line), nil
 }
 
+// getValidLine reads lines until one passes validate, telling what is wrong with the rejected ones.
+// An empty line is accepted without validation when the value is optional.
+func (ui *ConsoleUi) getValidLine(prompt string, optional bool, validate func(string) error) (string, error) {
+	for {
+		line, err := ui.getLine(prompt)
+		if err != nil {
+			return "", err
+		}
+		if line == "" && optional {
+			return line, nil
+		}
+		err = validate(line)
+		if err == nil {
+			return line, nil
+		}
+		fmt.Printf("%v, try again\n", err)
+	}
+}
+
+// getAddress reads the components of an address one by one.
+func (ui *ConsoleUi) getAddress(prompt string) (pickuppoint.Address, error) {
+	var address pickuppoint.Address
+	for _, field := range []struct {
+		name     string
+		value    *string
+		optional bool
+		validate func(string) error
+	}{
+		{"country", &address.Country, false, pickuppoint.ValidateRequired},
+		{"city", &address.City, false, pickuppoint.ValidateRequired},
+		{"street", &address.Street, false, pickuppoint.ValidateRequired},
+		{"building", &address.Building, false, pickuppoint.ValidateRequired},
+		{"postcode, or leave empty", &address.Postcode, true, pickuppoint.ValidatePostcode},
+	} {
+		var err error
+		*field.value, err = ui.getValidLine(prompt+" "+field.name, field.optional, field.validate)
+		if err != nil {
+			return address, err
+		}
+	}
+	return address, nil
+}
+
+// getContact reads an email and a phone until at least one of them is provided.
+func (ui *ConsoleUi) getContact(prompt string) (pickuppoint.Contact, error) {
+	var contact pickuppoint.Contact
+	for contact.Email == "" && contact.Phone == "" {
+		var err error
+		contact.Email, err = ui.getValidLine(prompt+" email, or leave empty", true, pickuppoint.ValidateEmail)
+		if err != nil {
+			return contact, err
+		}
+		contact.Phone, err = ui.getValidLine(prompt+" phone, or leave empty", true, pickuppoint.ValidatePhone)
+		if err != nil {
+			return contact, err
+		}
+		if contact.Email == "" && contact.Phone == "" {
+			fmt.Println("either email or phone is required, try again")
+		}
+	}
+	return contact, nil
+}
+
 func (ui *ConsoleUi) getUint(prompt string) (uint64, error) {
 	str, err := ui.getLine(prompt)
 	if err != nil {
@@ -71,3 +144,54 @@ func (ui *ConsoleUi) getUint(prompt string) (uint64, error) {
 	}
 	return n, nil
 }
+
+// getLocation reads a location written as latitude and longitude separated by space.
+// An empty line means no location.
+func (ui *ConsoleUi) getLocation(prompt string) (*pickuppoint.Location, error) {
+	str, err := ui.getLine(prompt)
+	if err != nil || str == "" {
+		return nil, err
+	}
+	latStr, lonStr, ok := strings.Cut(str, " ")
+	if !ok {
+		return nil, ErrInvalidInput
+	}
+	var loc pickuppoint.Location
+	loc.Latitude, err = strconv.ParseFloat(latStr, 64)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	loc.Longitude, err = strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	if loc.Validate() != nil {
+		return nil, ErrInvalidInput
+	}
+	return &loc, nil
+}
+
+// getCapacity reads the number of slots and the max weight in kg, where zero means no limit.
+func (ui *ConsoleUi) getCapacity(prompt string) (pickuppoint.Capacity, error) {
+	var capacity pickuppoint.Capacity
+	str, err := ui.getLine(prompt)
+	if err != nil || str == "" {
+		return capacity, err
+	}
+	slotsStr, weightStr, ok := strings.Cut(str, " ")
+	if !ok {
+		return capacity, ErrInvalidInput
+	}
+	capacity.Slots, err = strconv.Atoi(slotsStr)
+	if err != nil {
+		return capacity, ErrInvalidInput
+	}
+	capacity.MaxWeightKg, err = strconv.ParseFloat(strings.TrimSpace(weightStr), 64)
+	if err != nil {
+		return capacity, ErrInvalidInput
+	}
+	if capacity.Validate() != nil {
+		return capacity, ErrInvalidInput
+	}
+	return capacity, nil
+}
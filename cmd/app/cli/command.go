@@ -8,6 +8,9 @@ import (
 	"homework/internal/app/logger"
 	"homework/internal/app/pickuppoint"
 	"homework/internal/app/rwthread"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type PickUpPointCommands struct {
@@ -30,10 +33,14 @@ func (c *PickUpPointCommands) HelpCommand(ui *ConsoleUi) error {
 help	show this help
 exit	exit program
 create	create a new pick-up point
-list	list all existing pick-up points
+list	list all existing pick-up points with their utilisation
 get		show selected pick-up point
 update	update pick-up point
-delete	delete pick-up point`)
+delete	delete pick-up point, it may be restored until purged
+deleted	list deleted pick-up points
+restore	restore deleted pick-up point
+schedule	set working hours of pick-up point
+status	show whether pick-up point is open now`)
 	return nil
 }
 
@@ -51,37 +58,60 @@ func (c *PickUpPointCommands) handleInputError(err error) error {
 
 func (c *PickUpPointCommands) CreateCommand(ui *ConsoleUi) error {
 	var req core.CreatePointRequest
-	var err error
-	req.Id, err = ui.getUint("enter point id")
+	idStr, err := ui.getLine("enter point id to import it, or leave empty to assign one")
+	if err != nil {
+		return c.handleInputError(err)
+	}
+	if idStr != "" {
+		req.Id, err = strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			return c.handleInputError(ErrInvalidInput)
+		}
+		req.Import = true
+	}
+	req.Name, err = ui.getValidLine("enter point name", false, pickuppoint.ValidateRequired)
+	if err != nil {
+		return c.handleInputError(err)
+	}
+	req.Address, err = ui.getAddress("enter point")
 	if err != nil {
 		return c.handleInputError(err)
 	}
-	req.Name, err = ui.getLine("enter point name")
+	req.Contact, err = ui.getContact("enter point")
 	if err != nil {
 		return c.handleInputError(err)
 	}
-	req.Address, err = ui.getLine("enter point address")
+	req.Location, err = ui.getLocation("enter point location as `55.7558 37.6173`, or leave empty")
 	if err != nil {
 		return c.handleInputError(err)
 	}
-	req.Contact, err = ui.getLine("enter point contact")
+	req.Capacity, err = ui.getCapacity("enter point capacity as slots and max weight in kg, e.g. `100 500`, 0 for no limit, or leave empty")
 	if err != nil {
 		return c.handleInputError(err)
 	}
 
 	c.runner.RunWrite(func(ctx context.Context) error {
-		return c.svc.CreatePoint(ctx, req)
+		point, err := c.svc.CreatePoint(ctx, req)
+		if err != nil {
+			return err
+		}
+		c.log.Log("created pick-up point %d", point.Id)
+		return nil
 	})
 	return nil
 }
 
 func (c *PickUpPointCommands) ListCommand(ui *ConsoleUi) error {
 	c.runner.RunRead(func(ctx context.Context) error {
-		points, err := c.svc.ListPoints(ctx)
+		page, err := c.svc.ListPoints(ctx, pickuppoint.ListOptions{})
+		if err != nil {
+			return err
+		}
+		utilisation, err := c.svc.GetUtilisation(ctx, page.Points)
 		if err != nil {
 			return err
 		}
-		c.log.Log(pickuppoint.ListPoints(points))
+		c.log.Log(pickuppoint.ListUtilisation(page.Points, utilisation))
 		return nil
 	})
 	return nil
@@ -98,6 +128,14 @@ func (c *PickUpPointCommands) GetCommand(ui *ConsoleUi) error {
 			return err
 		}
 		c.log.Log(pickuppoint.ListPoints([]pickuppoint.PickUpPoint{point}))
+		if point.Location != nil {
+			c.log.Log("location %v %v", point.Location.Latitude, point.Location.Longitude)
+		}
+		c.log.Log("capacity: %s", point.Capacity)
+		c.log.Log("version: %d", point.Version)
+		if len(point.Schedule.Hours) > 0 {
+			c.log.Log(point.Schedule.String())
+		}
 		return nil
 	})
 	return nil
@@ -110,21 +148,30 @@ func (c *PickUpPointCommands) UpdateCommand(ui *ConsoleUi) error {
 	if err != nil {
 		return c.handleInputError(err)
 	}
-	req.Name, err = ui.getLine("enter new point name")
+	req.Name, err = ui.getValidLine("enter new point name", false, pickuppoint.ValidateRequired)
+	if err != nil {
+		return c.handleInputError(err)
+	}
+	req.Address, err = ui.getAddress("enter new point")
 	if err != nil {
 		return c.handleInputError(err)
 	}
-	req.Address, err = ui.getLine("enter new point address")
+	req.Contact, err = ui.getContact("enter new point")
 	if err != nil {
 		return c.handleInputError(err)
 	}
-	req.Contact, err = ui.getLine("enter new point contact")
+	req.Location, err = ui.getLocation("enter new point location as `55.7558 37.6173`, or leave empty")
+	if err != nil {
+		return c.handleInputError(err)
+	}
+	req.Capacity, err = ui.getCapacity("enter new point capacity as slots and max weight in kg, e.g. `100 500`, 0 for no limit, or leave empty")
 	if err != nil {
 		return c.handleInputError(err)
 	}
 
 	c.runner.RunWrite(func(ctx context.Context) error {
-		return c.svc.UpdatePoint(ctx, req)
+		_, err := c.svc.UpdatePoint(ctx, req)
+		return err
 	})
 	return nil
 }
@@ -135,7 +182,157 @@ func (c *PickUpPointCommands) DeleteCommand(ui *ConsoleUi) error {
 		return c.handleInputError(err)
 	}
 	c.runner.RunWrite(func(ctx context.Context) error {
-		return c.svc.DeletePoint(ctx, id)
+		return c.svc.DeletePoint(ctx, id, 0)
 	})
 	return nil
 }
+
+func (c *PickUpPointCommands) DeletedCommand(ui *ConsoleUi) error {
+	c.runner.RunRead(func(ctx context.Context) error {
+		page, err := c.svc.ListPoints(ctx, pickuppoint.ListOptions{DeletedBefore: time.Now()})
+		if err != nil {
+			return err
+		}
+		c.log.Log(pickuppoint.ListPoints(page.Points))
+		return nil
+	})
+	return nil
+}
+
+func (c *PickUpPointCommands) RestoreCommand(ui *ConsoleUi) error {
+	id, err := ui.getUint("enter point id")
+	if err != nil {
+		return c.handleInputError(err)
+	}
+	c.runner.RunWrite(func(ctx context.Context) error {
+		_, err := c.svc.RestorePoint(ctx, id, 0)
+		if err != nil {
+			return err
+		}
+		c.log.Log("restored pick-up point %d", id)
+		return nil
+	})
+	return nil
+}
+
+func (c *PickUpPointCommands) ScheduleCommand(ui *ConsoleUi) error {
+	id, err := ui.getUint("enter point id")
+	if err != nil {
+		return c.handleInputError(err)
+	}
+	var schedule pickuppoint.Schedule
+	schedule.TimeZone, err = ui.getLine("enter time zone, e.g. Europe/Moscow, or leave empty for UTC")
+	if err != nil {
+		return c.handleInputError(err)
+	}
+	for {
+		line, err := ui.getLine("enter hours as `monday 09:00-21:00`, or leave empty to finish")
+		if err != nil {
+			return c.handleInputError(err)
+		}
+		if line == "" {
+			break
+		}
+		hours, err := parseHours(line)
+		if err != nil {
+			return c.handleInputError(err)
+		}
+		schedule.Hours = append(schedule.Hours, hours)
+	}
+	for {
+		line, err := ui.getLine("enter holiday as `2025-01-01 closed` or `2025-01-02 10:00-16:00`, or leave empty to finish")
+		if err != nil {
+			return c.handleInputError(err)
+		}
+		if line == "" {
+			break
+		}
+		holiday, err := parseHoliday(line)
+		if err != nil {
+			return c.handleInputError(err)
+		}
+		schedule.Holidays = append(schedule.Holidays, holiday)
+	}
+	err = schedule.Validate()
+	if err != nil {
+		c.log.Log("%v", err)
+		return nil
+	}
+
+	c.runner.RunWrite(func(ctx context.Context) error {
+		_, err := c.svc.SetSchedule(ctx, id, 0, schedule)
+		return err
+	})
+	return nil
+}
+
+func (c *PickUpPointCommands) StatusCommand(ui *ConsoleUi) error {
+	id, err := ui.getUint("enter point id")
+	if err != nil {
+		return c.handleInputError(err)
+	}
+	c.runner.RunRead(func(ctx context.Context) error {
+		status, err := c.svc.GetOpenStatus(ctx, id, time.Now())
+		if err != nil {
+			return err
+		}
+		switch {
+		case status.Open:
+			c.log.Log("open until %s", status.ClosesAt.Format(time.DateTime))
+		case status.NextOpening != nil:
+			c.log.Log("closed, opens at %s", status.NextOpening.Format(time.DateTime))
+		default:
+			c.log.Log("closed")
+		}
+		return nil
+	})
+	return nil
+}
+
+// parseHours parses working hours written as `monday 09:00-21:00`.
+func parseHours(line string) (pickuppoint.Hours, error) {
+	var hours pickuppoint.Hours
+	day, interval, ok := strings.Cut(line, " ")
+	if !ok {
+		return hours, ErrInvalidInput
+	}
+	var err error
+	hours.Day, err = pickuppoint.ParseWeekday(day)
+	if err != nil {
+		return hours, ErrInvalidInput
+	}
+	hours.Open, hours.Close, err = parseInterval(interval)
+	return hours, err
+}
+
+// parseHoliday parses a holiday written as `2025-01-01 closed` or `2025-01-02 10:00-16:00`.
+func parseHoliday(line string) (pickuppoint.Holiday, error) {
+	var holiday pickuppoint.Holiday
+	date, interval, ok := strings.Cut(line, " ")
+	if !ok {
+		return holiday, ErrInvalidInput
+	}
+	holiday.Date = date
+	if strings.TrimSpace(interval) == "closed" {
+		return holiday, nil
+	}
+	var err error
+	holiday.Open, holiday.Close, err = parseInterval(interval)
+	return holiday, err
+}
+
+func parseInterval(s string) (pickuppoint.Clock, pickuppoint.Clock, error) {
+	openStr, closeStr, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return 0, 0, ErrInvalidInput
+	}
+	open, err := pickuppoint.ParseClock(openStr)
+	if err != nil {
+		return 0, 0, ErrInvalidInput
+	}
+	closeAt, err := pickuppoint.ParseClock(closeStr)
+	if err != nil {
+		return 0, 0, ErrInvalidInput
+	}
+	return open, closeAt, nil
+}
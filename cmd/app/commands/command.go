@@ -2,18 +2,17 @@ package commands
 
 import (
 	"errors"
-	"os"
 )
 
 type Command func(args []string) error
 
-func Run(commands map[string]Command) error {
-	if len(os.Args) < 2 {
+func Run(commands map[string]Command, args []string) error {
+	if len(args) < 1 {
 		return errors.New("no subcommand specified, see `help` subcommand for details")
 	}
 
-	cmdName := os.Args[1]
-	args := os.Args[2:]
+	cmdName := args[0]
+	args = args[1:]
 
 	cmd, ok := commands[cmdName]
 	if !ok {
@@ -24,10 +24,12 @@ func (c *OrderConsoleCommands) AcceptOrderCommand(args []string) error {
 	fs := createFlagSet(c.help)
 	fs.Uint64Var(&req.OrderId, "order-id", 0, "specify order id")
 	fs.Uint64Var(&req.CustomerId, "customer-id", 0, "specify customer id")
+	fs.Uint64Var(&req.PickUpPointId, "pickup-point-id", 0, "specify pick-up point id")
 	fs.StringVar(&req.KeepDateString, "keep-date", "", "specify keep date")
 	fs.Int64Var(&req.PriceRub, "price", 0, "specify price in rubles")
 	fs.Float64Var(&req.WeightKg, "weight", 0.0, "specify weight in kg")
 	fs.StringVar(&req.PackagingType, "packaging", "", "specify packaging")
+	fs.BoolVar(&req.EnforceCapacity, "enforce-capacity", false, "reject orders without a pick-up point")
 	err := fs.Parse(args)
 	if err != nil {
 		return err
@@ -133,9 +135,10 @@ func (c *OrderConsoleCommands) printOrders(orders []order.Order) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
 	fmt.Fprintf(
 		w,
-		"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 		"Order id",
 		"Customer id",
+		"Point id",
 		"Price RUB",
 		"Weight kg",
 		"Add date",
@@ -3,18 +3,25 @@ package commands
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/redis/go-redis/v9"
 	"golang.org/x/sync/errgroup"
 	"homework/cmd/app/httpserv"
+	"homework/internal/app/auth"
 	"homework/internal/app/cache"
 	"homework/internal/app/core"
+	"homework/internal/app/geoindex"
+	"homework/internal/app/idempotency"
 	"homework/internal/app/kafka"
 	"homework/internal/app/logger"
 	"homework/internal/app/middleware"
+	"homework/internal/app/ratelimit"
 	rediscli "homework/internal/app/redis"
 	"homework/internal/app/reqlog"
+	"homework/internal/app/tracing"
 	"net/http"
+	"os"
 	"os/signal"
 	"strings"
 	"syscall"
@@ -23,35 +30,69 @@ import (
 
 type PickUpPointApiConsoleCommands struct {
 	svc   core.PickUpPointCoreService
+	users *auth.Service
 	log   logger.Logger
 	help  Command
 	topic string
+	// responses keeps responses to requests with idempotency keys in the database of the storage, if it can.
+	responses idempotency.Store
 }
 
-func NewPickUpPointApiConsoleCommands(svc core.PickUpPointCoreService, log logger.Logger, help Command, topic string) *PickUpPointApiConsoleCommands {
-	return &PickUpPointApiConsoleCommands{svc: svc, log: log, help: help, topic: topic}
+func NewPickUpPointApiConsoleCommands(svc core.PickUpPointCoreService, users *auth.Service, log logger.Logger, help Command, topic string) *PickUpPointApiConsoleCommands {
+	return &PickUpPointApiConsoleCommands{svc: svc, users: users, log: log, help: help, topic: topic}
+}
+
+// SetIdempotencyStore lets responses to requests with idempotency keys be kept in the database of the storage.
+func (c *PickUpPointApiConsoleCommands) SetIdempotencyStore(responses idempotency.Store) {
+	c.responses = responses
 }
 
 func (c *PickUpPointApiConsoleCommands) RunPickUpPointApi(args []string) error {
 	var params httpserv.HttpServerParams
-	var username, password string
 	var brokersStr string
+	var tokenKeysPath string
+	var certUsersPath string
+	var tokenTTL, tokenKeysInterval time.Duration
 	var ttl, collectorInterval time.Duration
+	var deletedRetention, purgeInterval time.Duration
 	var redisOptions redis.Options
+	readLimit := ratelimit.Limit{Requests: 20, Period: time.Second, Burst: 40}
+	writeLimit := ratelimit.Limit{Requests: 5, Period: time.Second, Burst: 10}
+	batchLimit := ratelimit.Limit{Requests: 1, Period: time.Minute, Burst: 2}
+	clientLimit := ratelimit.Limit{Requests: 10, Period: time.Second, Burst: 50}
+	var sharedLimit bool
+	var idempotencyStore string
+	var traceCfg tracing.Config
 
 	fs := createFlagSet(c.help)
 	fs.StringVar(&params.HttpsAddr, "https-address", ":9443", "specify https listen address")
 	fs.StringVar(&params.RedirectAddr, "redirect-address", ":9000", "specify redirect listen address")
+	fs.StringVar(&params.AdminAddr, "admin-address", ":9100", "specify admin listen address serving /metrics, metrics are not served when empty")
 	fs.StringVar(&params.CertFile, "tls-cert", "server.crt", "specify tls certificate file")
 	fs.StringVar(&params.KeyFile, "tls-key", "server.key", "specify tls certificate key file")
-	fs.StringVar(&username, "username", "user", "specify access control username")
-	fs.StringVar(&password, "password", "testpassword", "specify access control password")
+	fs.DurationVar(&params.CertReloadInterval, "tls-reload-interval", 10*time.Second, "specify how often certificate files are checked for changes, 0 disables reloading")
+	fs.StringVar(&params.ClientCAFile, "client-ca", "", "specify CA bundle verifying client certificates, they are not requested when empty")
+	fs.StringVar(&certUsersPath, "client-cert-users", "", "specify file mapping client certificate subjects to user names, required with --client-ca")
+	fs.StringVar(&tokenKeysPath, "token-keys", "token_keys.json", "specify access token signing keys file, access tokens are disabled when it does not exist")
+	fs.DurationVar(&tokenTTL, "token-ttl", 15*time.Minute, "specify how long access tokens are valid")
+	fs.DurationVar(&tokenKeysInterval, "token-keys-interval", 10*time.Second, "specify how often the access token signing keys file is checked for changes, 0 disables reloading")
 	fs.StringVar(&brokersStr, "brokers", "127.0.0.1:9091,127.0.0.1:9092,127.0.0.1:9093", "specify broker addresses, separated by comma")
 	fs.DurationVar(&ttl, "cache-ttl", time.Minute, "specify cache TTL (time-to-live)")
 	fs.DurationVar(&collectorInterval, "cache-collector-interval", 10*time.Minute, "specify cache collector interval")
+	fs.DurationVar(&deletedRetention, "deleted-retention", 30*24*time.Hour, "specify how long deleted pick-up points are kept before purging, 0 keeps them forever")
+	fs.DurationVar(&purgeInterval, "purge-interval", time.Hour, "specify how often deleted pick-up points are purged")
 	fs.StringVar(&redisOptions.Addr, "redis-host", "localhost:6379", "specify Redis host")
 	fs.StringVar(&redisOptions.Password, "redis-password", "my-password", "specify Redis password")
 	fs.IntVar(&redisOptions.DB, "redis-db", 0, "specify Redis database")
+	fs.Var(&readLimit, "read-rate-limit", "specify how often each user may read, as <requests>/<period>[:<burst>], 0 disables the limit")
+	fs.Var(&writeLimit, "write-rate-limit", "specify how often each user may change pick-up points and access credentials, 0 disables the limit")
+	fs.Var(&batchLimit, "batch-rate-limit", "specify how often each user may apply batches, 0 disables the limit")
+	fs.Var(&clientLimit, "client-rate-limit", "specify how often each client address may make requests, checked before authentication, 0 disables the limit")
+	fs.BoolVar(&sharedLimit, "shared-rate-limit", false, "share rate limits with every instance using the same Redis")
+	fs.StringVar(&idempotencyStore, "idempotency-store", "redis", "specify where responses to requests with idempotency keys are kept: redis, postgres or none")
+	fs.DurationVar(&params.IdempotencyTTL, "idempotency-ttl", 24*time.Hour, "specify how long responses to requests with idempotency keys are kept")
+	fs.StringVar(&traceCfg.Exporter, "trace-exporter", tracing.None, "specify where spans are exported: otlp or none")
+	fs.StringVar(&traceCfg.Endpoint, "trace-endpoint", "", "specify OTLP collector URL, default: $OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318")
 	err := fs.Parse(args)
 	if err != nil {
 		return err
@@ -59,6 +100,53 @@ func (c *PickUpPointApiConsoleCommands) RunPickUpPointApi(args []string) error {
 
 	brokers := strings.Split(brokersStr, ",")
 
+	switch idempotencyStore {
+	case "redis", "none":
+	case "postgres":
+		if c.responses == nil {
+			return errors.New("idempotency keys may be kept in postgres only with postgres storage")
+		}
+		params.Idempotency = c.responses
+	default:
+		return fmt.Errorf("unknown idempotency store %q, expected one of redis, postgres, none", idempotencyStore)
+	}
+
+	if params.ClientCAFile != "" && certUsersPath == "" {
+		return errors.New("client certificates are requested with --client-ca, map their subjects to users with --client-cert-users")
+	}
+	if certUsersPath != "" {
+		certUsers, err := auth.ReadCertificateUsers(certUsersPath)
+		if err != nil {
+			return err
+		}
+		c.users.SetCertificateUsers(certUsers)
+	}
+
+	keyringFile := auth.NewKeyringFile(tokenKeysPath)
+	keyring, err := keyringFile.Reload()
+	switch {
+	case err == nil:
+		c.users.SetKeyring(keyring, tokenTTL)
+	case errors.Is(err, os.ErrNotExist):
+		c.log.Log("Access tokens are disabled: no signing keys in %s", tokenKeysPath)
+	default:
+		return err
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), traceCfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// spans left are exported on exit
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := shutdownTracing(ctx)
+		if err != nil {
+			c.log.Log("exporting spans: %v", err)
+		}
+	}()
+
 	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 
 	eg, ctx := errgroup.WithContext(ctx)
@@ -94,26 +182,200 @@ func (c *PickUpPointApiConsoleCommands) RunPickUpPointApi(args []string) error {
 
 	redisClient := rediscli.NewRedis(&redisOptions, ttl)
 	c.svc.SetRedis(redisClient)
+	if idempotencyStore == "redis" {
+		params.Idempotency = redisClient
+	}
+
+	if sharedLimit {
+		params.Limiter = redisClient
+	} else {
+		limiter := ratelimit.NewLocal(collectorInterval)
+		eg.Go(func() error {
+			return limiter.Run(ctx)
+		})
+		params.Limiter = limiter
+	}
+
+	c.svc.SetGeoIndex(geoindex.NewIndex(ttl))
+
+	if tokenKeysInterval > 0 {
+		eg.Go(func() error {
+			return c.reloadKeyring(ctx, keyringFile, tokenTTL, tokenKeysInterval)
+		})
+	}
+
+	if deletedRetention > 0 {
+		eg.Go(func() error {
+			return c.purgeDeleted(ctx, deletedRetention, purgeInterval)
+		})
+	}
 
 	handlers := httpserv.NewPickUpPointHandlers(c.svc, c.log)
+	authHandlers := httpserv.NewAuthHandlers(c.users, c.log)
 
 	params.Handlers = map[string]httpserv.PathHandler{
-		"/pickup-point": {Methods: map[string]httpserv.Handler{
-			http.MethodGet:  handlers.ListHandler,
-			http.MethodPost: handlers.CreateHandler,
-		}},
-		"/pickup-point/{id:[0-9]+}": {Methods: map[string]httpserv.Handler{
-			http.MethodGet:    handlers.GetHandler,
-			http.MethodPut:    handlers.UpdateHandler,
-			http.MethodDelete: handlers.DeleteHandler,
-		}},
+		"/auth/token": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodPost:   authHandlers.TokenHandler,
+				http.MethodDelete: authHandlers.RevokeTokenHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodPost:   auth.Viewer,
+				http.MethodDelete: auth.Viewer,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodPost:   writeLimit,
+				http.MethodDelete: writeLimit,
+			},
+		},
+		"/auth/keys": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodGet:  authHandlers.ListKeysHandler,
+				http.MethodPost: authHandlers.CreateKeyHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodGet:  auth.Viewer,
+				http.MethodPost: auth.Viewer,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodGet:  readLimit,
+				http.MethodPost: writeLimit,
+			},
+		},
+		"/auth/keys/{id:[0-9]+}": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodDelete: authHandlers.RevokeKeyHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodDelete: auth.Viewer,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodDelete: writeLimit,
+			},
+		},
+		"/pickup-point": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodGet:  handlers.ListHandler,
+				http.MethodPost: handlers.CreateHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodGet:  auth.Viewer,
+				http.MethodPost: auth.Operator,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodGet:  readLimit,
+				http.MethodPost: writeLimit,
+			},
+		},
+		"/pickup-point/batch": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodPost: handlers.BatchHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodPost: auth.Admin,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodPost: batchLimit,
+			},
+		},
+		"/pickup-point/nearest": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodGet: handlers.NearestHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodGet: auth.Viewer,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodGet: readLimit,
+			},
+		},
+		"/pickup-point/{id:[0-9]+}": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodGet:    handlers.GetHandler,
+				http.MethodPut:    handlers.UpdateHandler,
+				http.MethodPatch:  handlers.PatchHandler,
+				http.MethodDelete: handlers.DeleteHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodGet:    auth.Viewer,
+				http.MethodPut:    auth.Operator,
+				http.MethodPatch:  auth.Operator,
+				http.MethodDelete: auth.Admin,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodGet:    readLimit,
+				http.MethodPut:    writeLimit,
+				http.MethodPatch:  writeLimit,
+				http.MethodDelete: writeLimit,
+			},
+		},
+		"/pickup-point/{id:[0-9]+}/history": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodGet: handlers.HistoryHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodGet: auth.Viewer,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodGet: readLimit,
+			},
+		},
+		"/pickup-point/{id:[0-9]+}/restore": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodPost: handlers.RestoreHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodPost: auth.Admin,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodPost: writeLimit,
+			},
+		},
+		"/pickup-point/{id:[0-9]+}/schedule": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodPut: handlers.ScheduleHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodPut: auth.Operator,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodPut: writeLimit,
+			},
+		},
+		"/pickup-point/{id:[0-9]+}/status": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodGet: handlers.OpenStatusHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodGet: auth.Viewer,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodGet: readLimit,
+			},
+		},
+		"/pickup-point/{id:[0-9]+}/utilisation": {
+			Methods: map[string]httpserv.Handler{
+				http.MethodGet: handlers.UtilisationHandler,
+			},
+			Roles: map[string]auth.Role{
+				http.MethodGet: auth.Viewer,
+			},
+			Limits: map[string]ratelimit.Limit{
+				http.MethodGet: readLimit,
+			},
+		},
 	}
 
 	params.Middlewares = []mux.MiddlewareFunc{
+		middleware.MetricsMiddleware(),
+		middleware.TracingMiddleware(),
+		middleware.RequestIdMiddleware(),
 		middleware.LogMiddleware(reqLog),
-		middleware.AuthMiddleware(username, password),
+		middleware.ClientRateLimitMiddleware(params.Limiter, clientLimit, c.log),
+		middleware.AuthMiddleware(c.users),
 	}
 
+	params.Log = c.log
 	serv := httpserv.NewHttpServer(params)
 	eg.Go(func() error {
 		return serv.Serve(ctx)
@@ -121,3 +383,45 @@ func (c *PickUpPointApiConsoleCommands) RunPickUpPointApi(args []string) error {
 
 	return eg.Wait()
 }
+
+// reloadKeyring checks the access token signing keys file every interval until ctx is done
+// and rotates the keys once it changes, the keys loaded before are kept when the file cannot be loaded.
+func (c *PickUpPointApiConsoleCommands) reloadKeyring(ctx context.Context, file *auth.KeyringFile, ttl time.Duration, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+		keyring, err := file.Reload()
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			c.log.Log("reloading access token signing keys failed, using the ones loaded before: %v", err)
+		case keyring != nil:
+			c.users.SetKeyring(keyring, ttl)
+			c.log.Log("Access token signing keys reloaded")
+		}
+	}
+}
+
+// purgeDeleted removes pick-up points deleted more than retention ago every interval until ctx is done.
+func (c *PickUpPointApiConsoleCommands) purgeDeleted(ctx context.Context, retention time.Duration, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		purged, err := c.svc.PurgePoints(ctx, time.Now().Add(-retention))
+		if err != nil {
+			c.log.Log("purging deleted pick-up points: %v", err)
+		} else if purged > 0 {
+			c.log.Log("purged %d deleted pick-up points", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/app/core"
+	"homework/internal/app/pickuppoint"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+type PickUpPointBatchConsoleCommands struct {
+	svc  core.PickUpPointCoreService
+	help Command
+}
+
+func NewPickUpPointBatchConsoleCommands(svc core.PickUpPointCoreService, help Command) *PickUpPointBatchConsoleCommands {
+	return &PickUpPointBatchConsoleCommands{svc: svc, help: help}
+}
+
+func (c *PickUpPointBatchConsoleCommands) ImportPointsCommand(args []string) error {
+	var path, formatStr string
+	var req core.ImportPointsRequest
+
+	fs := createFlagSet(c.help)
+	fs.StringVar(&path, "file", "", "specify file to import, - for standard input")
+	fs.StringVar(&formatStr, "format", "", "specify file format: csv or json, default: file extension")
+	fs.BoolVar(&req.DryRun, "dry-run", false, "only check pick-up points without creating them")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if path == "" {
+		return errors.New("file is required")
+	}
+	format, err := batchFormat(formatStr, path)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	req.Rows, err = pickuppoint.DecodeBatch(r, format)
+	if err != nil {
+		return err
+	}
+
+	report, err := c.svc.ImportPoints(context.Background(), req)
+	printBatchReport(report)
+	return err
+}
+
+func (c *PickUpPointBatchConsoleCommands) ExportPointsCommand(args []string) error {
+	var path, formatStr string
+	var opts pickuppoint.ListOptions
+
+	fs := createFlagSet(c.help)
+	fs.StringVar(&path, "file", "-", "specify file to export to, - for standard output")
+	fs.StringVar(&formatStr, "format", "", "specify file format: csv or json, default: file extension")
+	fs.BoolVar(&opts.IncludeDeleted, "include-deleted", false, "export deleted pick-up points too")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	format, err := batchFormat(formatStr, path)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.svc.ListPoints(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		return pickuppoint.EncodeBatch(os.Stdout, format, page.Points)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = pickuppoint.EncodeBatch(f, format, page.Points)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// batchFormat returns the format named by the flag, or the one matching the file extension, JSON by default.
+func batchFormat(formatStr string, path string) (pickuppoint.Format, error) {
+	if formatStr == "" {
+		formatStr = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if formatStr != string(pickuppoint.FormatCSV) {
+			return pickuppoint.FormatJSON, nil
+		}
+	}
+	return pickuppoint.ParseFormat(formatStr)
+}
+
+func printBatchReport(report pickuppoint.BatchReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "Row", "Id", "Status", "Error")
+	for _, row := range report.Rows {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", row.Row, row.Id, row.Status, row.Error)
+	}
+	w.Flush()
+	if report.DryRun {
+		fmt.Printf("dry run: %d of %d pick-up points are valid\n", len(report.Rows)-report.Failed, len(report.Rows))
+		return
+	}
+	fmt.Printf("created %d, failed %d pick-up points\n", report.Created, report.Failed)
+}
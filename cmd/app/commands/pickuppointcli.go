@@ -17,8 +17,8 @@ type PickUpPointCliConsoleCommands struct {
 	help Command
 }
 
-func NewPickUpPointCliConsoleCommands(svc core.PickUpPointCoreService, help Command) *PickUpPointCliConsoleCommands {
-	return &PickUpPointCliConsoleCommands{svc: svc, help: help}
+func NewPickUpPointCliConsoleCommands(svc core.PickUpPointCoreService, log logger.Logger, help Command) *PickUpPointCliConsoleCommands {
+	return &PickUpPointCliConsoleCommands{svc: svc, log: log, help: help}
 }
 
 func (c *PickUpPointCliConsoleCommands) ManagePickUpPointsCommand(args []string) error {
@@ -39,13 +39,17 @@ func (c *PickUpPointCliConsoleCommands) ManagePickUpPointsCommand(args []string)
 
 	cmds := cli.NewPickUpPointCommands(c.svc, c.log, runner)
 	cmdMap := map[string]cli.Command{
-		"help":   cmds.HelpCommand,
-		"exit":   cmds.ExitCommand,
-		"create": cmds.CreateCommand,
-		"list":   cmds.ListCommand,
-		"get":    cmds.GetCommand,
-		"update": cmds.UpdateCommand,
-		"delete": cmds.DeleteCommand,
+		"help":     cmds.HelpCommand,
+		"exit":     cmds.ExitCommand,
+		"create":   cmds.CreateCommand,
+		"list":     cmds.ListCommand,
+		"get":      cmds.GetCommand,
+		"update":   cmds.UpdateCommand,
+		"delete":   cmds.DeleteCommand,
+		"deleted":  cmds.DeletedCommand,
+		"restore":  cmds.RestoreCommand,
+		"schedule": cmds.ScheduleCommand,
+		"status":   cmds.StatusCommand,
 	}
 
 	ui := cli.NewConsoleUi(cmdMap)
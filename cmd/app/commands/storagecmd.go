@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/app/storage"
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+type StorageConsoleCommands struct {
+	cfg  storage.Config
+	help Command
+}
+
+func NewStorageConsoleCommands(cfg storage.Config, help Command) *StorageConsoleCommands {
+	return &StorageConsoleCommands{cfg: cfg, help: help}
+}
+
+func (c *StorageConsoleCommands) MigrateStorageCommand(args []string) error {
+	from, to := c.cfg, c.cfg
+
+	fs := createFlagSet(c.help)
+	fs.StringVar(&from.Type, "from", "", "specify source storage")
+	fs.StringVar(&to.Type, "to", "", "specify destination storage")
+	fs.StringVar(&from.BoltFile, "from-storage-path", c.cfg.BoltFile, "specify source embedded storage file")
+	fs.StringVar(&to.BoltFile, "to-storage-path", c.cfg.BoltFile, "specify destination embedded storage file")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if from.Type == "" || to.Type == "" {
+		return errors.New("source and destination storage are required")
+	}
+	if from == to {
+		return errors.New("source and destination storage must differ")
+	}
+
+	ctx := context.Background()
+	src, err := storage.Open(ctx, from)
+	if err != nil {
+		return err
+	}
+	defer closeStorage(src)
+	dst, err := storage.Open(ctx, to)
+	if err != nil {
+		return err
+	}
+	defer closeStorage(dst)
+
+	report, err := storage.Migrate(ctx, src, dst)
+	printMigrationReport(report)
+	return err
+}
+
+func closeStorage(s *storage.Storage) {
+	err := s.Close()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func printMigrationReport(report storage.MigrationReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "Items", "Source", "Created", "Updated", "Unchanged", "Verified")
+	for _, row := range []struct {
+		name   string
+		counts storage.MigrationCounts
+	}{
+		{"Pick-up points", report.Points},
+		{"Orders", report.Orders},
+	} {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\n",
+			row.name, row.counts.Source, row.counts.Created, row.counts.Updated, row.counts.Unchanged, row.counts.Verified)
+	}
+	w.Flush()
+}
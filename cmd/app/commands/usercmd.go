@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/app/auth"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+type UserConsoleCommands struct {
+	svc  *auth.Service
+	help Command
+}
+
+func NewUserConsoleCommands(svc *auth.Service, help Command) *UserConsoleCommands {
+	return &UserConsoleCommands{svc: svc, help: help}
+}
+
+func (c *UserConsoleCommands) AddUserCommand(args []string) error {
+	var name, algStr, roleStr string
+
+	fs := createFlagSet(c.help)
+	fs.StringVar(&name, "name", "", "specify user name")
+	fs.StringVar(&algStr, "hash", string(auth.Bcrypt), "specify password hash algorithm: bcrypt or argon2id")
+	fs.StringVar(&roleStr, "role", string(auth.Viewer), "specify user role: viewer, operator or admin")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	alg, err := auth.ParseAlgorithm(algStr)
+	if err != nil {
+		return err
+	}
+	role, err := auth.ParseRole(roleStr)
+	if err != nil {
+		return err
+	}
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	_, err = c.svc.AddUser(context.Background(), name, password, alg, role)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("user %s added with role %s\n", name, role)
+	return nil
+}
+
+func (c *UserConsoleCommands) SetPasswordCommand(args []string) error {
+	var name, algStr string
+
+	fs := createFlagSet(c.help)
+	fs.StringVar(&name, "name", "", "specify user name")
+	fs.StringVar(&algStr, "hash", string(auth.Bcrypt), "specify password hash algorithm: bcrypt or argon2id")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	alg, err := auth.ParseAlgorithm(algStr)
+	if err != nil {
+		return err
+	}
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	err = c.svc.SetPassword(context.Background(), name, password, alg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("password of user %s changed\n", name)
+	return nil
+}
+
+func (c *UserConsoleCommands) SetRoleCommand(args []string) error {
+	var name, roleStr string
+
+	fs := createFlagSet(c.help)
+	fs.StringVar(&name, "name", "", "specify user name")
+	fs.StringVar(&roleStr, "role", "", "specify user role: viewer, operator or admin")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	role, err := auth.ParseRole(roleStr)
+	if err != nil {
+		return err
+	}
+	err = c.svc.SetRole(context.Background(), name, role)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("user %s now has role %s\n", name, role)
+	return nil
+}
+
+func (c *UserConsoleCommands) DisableUserCommand(args []string) error {
+	var name string
+
+	fs := createFlagSet(c.help)
+	fs.StringVar(&name, "name", "", "specify user name")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	err = c.svc.DisableUser(context.Background(), name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("user %s disabled\n", name)
+	return nil
+}
+
+// readPassword reads the first line of standard input, so that passwords do not show up in the process list or shell history.
+func readPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *UserConsoleCommands) RotateTokenKeyCommand(args []string) error {
+	var path string
+	var keep int
+
+	fs := createFlagSet(c.help)
+	fs.StringVar(&path, "file", "token_keys.json", "specify access token signing keys file")
+	fs.IntVar(&keep, "keep", 2, "specify how many keys to keep, including the new one")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if keep < 1 {
+		return errors.New("at least the new key must be kept")
+	}
+
+	keys, err := auth.ReadSigningKeys(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	key, err := auth.NewSigningKey(time.Now().UTC().Format("20060102T150405Z"))
+	if err != nil {
+		return err
+	}
+	keys = append([]auth.SigningKey{key}, keys...)
+	keys = keys[:min(keep, len(keys))]
+	_, err = auth.NewKeyring(keys)
+	if err != nil {
+		return err
+	}
+	err = auth.WriteSigningKeys(path, keys)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("new signing key %s added, %d kept, restart the API to use it\n", key.Id, len(keys))
+	return nil
+}
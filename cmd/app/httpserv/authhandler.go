@@ -0,0 +1,162 @@
+package httpserv
+
+import (
+	"encoding/json"
+	"errors"
+	"homework/internal/app/auth"
+	"homework/internal/app/logger"
+	"homework/internal/app/middleware"
+	"homework/internal/app/problem"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuthHandlers struct {
+	svc *auth.Service
+	log logger.Logger
+}
+
+func NewAuthHandlers(svc *auth.Service, log logger.Logger) *AuthHandlers {
+	return &AuthHandlers{svc: svc, log: log}
+}
+
+type tokenBody struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type createKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// apiKeyBody shows an API key without its hash, the key itself is only shown when created.
+type apiKeyBody struct {
+	Id        uint64     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Key       string     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func newAPIKeyBody(key auth.APIKey) apiKeyBody {
+	return apiKeyBody{Id: key.Id, Name: key.Name, Prefix: key.Prefix, CreatedAt: key.CreatedAt, RevokedAt: key.RevokedAt}
+}
+
+func (h *AuthHandlers) json(req *http.Request, header http.Header, code int, v any) (int, []byte) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+	header.Set("Content-Type", jsonContentType)
+	return code, body
+}
+
+// fail returns the problem caused by an error of the service.
+func (h *AuthHandlers) fail(req *http.Request, header http.Header, err error) (int, []byte) {
+	return errorResponse(req, header, h.log, err)
+}
+
+// TokenHandler issues an access token to a user authorized with a password,
+// so that a token cannot be used to get new ones and outlive its revocation.
+func (h *AuthHandlers) TokenHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	if _, _, ok := req.BasicAuth(); !ok {
+		return problem.Response(req, header, http.StatusForbidden, problem.Problem{
+			Code:    "password_required",
+			Message: "access tokens are only issued to users authorized with a password",
+		})
+	}
+
+	token, err := h.svc.IssueToken(req.Context(), auth.UserName(req.Context()))
+	if errors.Is(err, auth.ErrNoUser) {
+		err = auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	header.Set("Cache-Control", "no-store")
+	return h.json(req, header, http.StatusOK, tokenBody{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(token.ExpiresAt).Seconds()),
+	})
+}
+
+// RevokeTokenHandler revokes the access token the request is authorized with.
+func (h *AuthHandlers) RevokeTokenHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	token, ok := middleware.BearerToken(req)
+	if !ok {
+		return badRequest(req, header, "token_required", "request must be authorized with the access token to revoke")
+	}
+
+	err := h.svc.RevokeToken(req.Context(), token)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	return http.StatusNoContent, nil
+}
+
+// CreateKeyHandler creates an API key of the user, the key is shown only in this response.
+func (h *AuthHandlers) CreateKeyHandler(httpReq *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	body, err := io.ReadAll(httpReq.Body)
+	if err != nil {
+		return h.fail(httpReq, header, err)
+	}
+
+	var req createKeyRequest
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			return invalidBody(httpReq, header, err)
+		}
+	}
+
+	key, secret, err := h.svc.CreateKey(httpReq.Context(), auth.UserName(httpReq.Context()), req.Name)
+	if err != nil {
+		return h.fail(httpReq, header, err)
+	}
+
+	keyBody := newAPIKeyBody(key)
+	keyBody.Key = secret
+	header.Set("Cache-Control", "no-store")
+	return h.json(httpReq, header, http.StatusCreated, keyBody)
+}
+
+// ListKeysHandler lists API keys of the user, revoked ones included.
+func (h *AuthHandlers) ListKeysHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	keys, err := h.svc.ListKeys(req.Context(), auth.UserName(req.Context()))
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	bodies := make([]apiKeyBody, len(keys))
+	for i, key := range keys {
+		bodies[i] = newAPIKeyBody(key)
+	}
+	return h.json(req, header, http.StatusOK, bodies)
+}
+
+// RevokeKeyHandler revokes an API key of the user, admins may revoke keys of anyone.
+func (h *AuthHandlers) RevokeKeyHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	idStr, ok := vars["id"]
+	if !ok {
+		return invalidId(req, header)
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return invalidId(req, header)
+	}
+
+	err = h.svc.RevokeKey(req.Context(), auth.UserName(req.Context()), auth.UserRole(req.Context()), id)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	return http.StatusNoContent, nil
+}
@@ -0,0 +1,131 @@
+package httpserv
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/auth"
+	"homework/internal/app/db"
+	logmocks "homework/internal/app/logger/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type AuthHandlersTestSuite struct {
+	suite.Suite
+	svc *auth.Service
+	h   *AuthHandlers
+}
+
+func (s *AuthHandlersTestSuite) SetupTest() {
+	s.svc = auth.NewService(auth.NewFileRepository(), auth.NewFileKeyRepository(), auth.NewFileRevocationList(), db.Dummy{})
+	key, err := auth.NewSigningKey("1")
+	s.Require().NoError(err)
+	keyring, err := auth.NewKeyring([]auth.SigningKey{key})
+	s.Require().NoError(err)
+	s.svc.SetKeyring(keyring, 15*time.Minute)
+	for _, name := range []string{"user", "other"} {
+		_, err = s.svc.AddUser(context.Background(), name, "secret", auth.Bcrypt, auth.Operator)
+		s.Require().NoError(err)
+	}
+	s.h = NewAuthHandlers(s.svc, logmocks.NewMockLogger(gomock.NewController(s.T())))
+}
+
+// request returns a request of the user as AuthMiddleware lets it through.
+func (s *AuthHandlersTestSuite) request(method string, target string, body string, user string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := auth.WithUser(req.Context(), user)
+	return req.WithContext(auth.WithRole(ctx, auth.Operator))
+}
+
+func (s *AuthHandlersTestSuite) Test_TokenHandler() {
+	req := s.request(http.MethodPost, "/auth/token", "", "user")
+	req.SetBasicAuth("user", "secret")
+	header := http.Header{}
+	code, body := s.h.TokenHandler(req, nil, header)
+	s.Require().Equal(http.StatusOK, code)
+	s.Equal("application/json", header.Get("Content-Type"))
+	s.Equal("no-store", header.Get("Cache-Control"))
+	var token tokenBody
+	s.Require().NoError(json.Unmarshal(body, &token))
+	s.Equal("Bearer", token.TokenType)
+	s.InDelta(15*60, token.ExpiresIn, 1)
+
+	user, err := s.svc.AuthenticateToken(context.Background(), token.AccessToken)
+	s.Require().NoError(err)
+	s.Equal("user", user.Name)
+
+	req = s.request(http.MethodPost, "/auth/token", "", "user")
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	code, _ = s.h.TokenHandler(req, nil, http.Header{})
+	s.Equal(http.StatusForbidden, code, "tokens must not be issued for tokens")
+
+	s.svc.SetKeyring(nil, 0)
+	req = s.request(http.MethodPost, "/auth/token", "", "user")
+	req.SetBasicAuth("user", "secret")
+	code, _ = s.h.TokenHandler(req, nil, http.Header{})
+	s.Equal(http.StatusNotImplemented, code)
+}
+
+func (s *AuthHandlersTestSuite) Test_RevokeTokenHandler() {
+	token, err := s.svc.IssueToken(context.Background(), "user")
+	s.Require().NoError(err)
+
+	req := s.request(http.MethodDelete, "/auth/token", "", "user")
+	code, _ := s.h.RevokeTokenHandler(req, nil, http.Header{})
+	s.Equal(http.StatusBadRequest, code)
+
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	code, _ = s.h.RevokeTokenHandler(req, nil, http.Header{})
+	s.Require().Equal(http.StatusNoContent, code)
+	_, err = s.svc.AuthenticateToken(context.Background(), token.AccessToken)
+	s.ErrorIs(err, auth.ErrInvalidCredentials)
+}
+
+func (s *AuthHandlersTestSuite) Test_Keys() {
+	header := http.Header{}
+	code, body := s.h.CreateKeyHandler(s.request(http.MethodPost, "/auth/keys", `{"name":"ci"}`, "user"), nil, header)
+	s.Require().Equal(http.StatusCreated, code)
+	s.Equal("no-store", header.Get("Cache-Control"))
+	var created apiKeyBody
+	s.Require().NoError(json.Unmarshal(body, &created))
+	s.Equal("ci", created.Name)
+	s.True(strings.HasPrefix(created.Key, created.Prefix))
+	s.NotContains(string(body), `"hash"`)
+
+	user, err := s.svc.AuthenticateToken(context.Background(), created.Key)
+	s.Require().NoError(err)
+	s.Equal("user", user.Name)
+
+	code, _ = s.h.CreateKeyHandler(s.request(http.MethodPost, "/auth/keys", `{`, "user"), nil, http.Header{})
+	s.Equal(http.StatusBadRequest, code)
+
+	code, body = s.h.ListKeysHandler(s.request(http.MethodGet, "/auth/keys", "", "user"), nil, http.Header{})
+	s.Require().Equal(http.StatusOK, code)
+	var listed []apiKeyBody
+	s.Require().NoError(json.Unmarshal(body, &listed))
+	created.Key = ""
+	s.Equal([]apiKeyBody{created}, listed)
+
+	code, body = s.h.ListKeysHandler(s.request(http.MethodGet, "/auth/keys", "", "other"), nil, http.Header{})
+	s.Require().Equal(http.StatusOK, code)
+	s.Equal("[]", string(body))
+
+	vars := map[string]string{"id": "1"}
+	code, _ = s.h.RevokeKeyHandler(s.request(http.MethodDelete, "/auth/keys/1", "", "other"), vars, http.Header{})
+	s.Equal(http.StatusNotFound, code)
+	code, _ = s.h.RevokeKeyHandler(s.request(http.MethodDelete, "/auth/keys/1", "", "user"), vars, http.Header{})
+	s.Require().Equal(http.StatusNoContent, code)
+	_, err = s.svc.AuthenticateToken(context.Background(), created.Key)
+	s.ErrorIs(err, auth.ErrInvalidCredentials)
+	code, _ = s.h.RevokeKeyHandler(s.request(http.MethodDelete, "/auth/keys/2", "", "user"), map[string]string{"id": "2"}, http.Header{})
+	s.Equal(http.StatusNotFound, code)
+}
+
+func TestAuthHandlers(t *testing.T) {
+	suite.Run(t, new(AuthHandlersTestSuite))
+}
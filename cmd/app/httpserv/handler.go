@@ -3,12 +3,22 @@ package httpserv
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"homework/internal/app/core"
 	"homework/internal/app/logger"
 	"homework/internal/app/pickuppoint"
 	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultNearestLimit = 5
+	defaultListLimit    = core.MaxListLimit
+	defaultHistoryLimit = core.MaxHistoryLimit
 )
 
 type PickUpPointHandlers struct {
@@ -20,146 +30,537 @@ func NewPickUpPointHandlers(svc core.PickUpPointCoreService, log logger.Logger)
 	return &PickUpPointHandlers{svc: svc, log: log}
 }
 
-func (h *PickUpPointHandlers) CreateHandler(httpReq *http.Request, vars map[string]string) (int, []byte) {
+// fail returns the problem caused by an error of the service.
+func (h *PickUpPointHandlers) fail(req *http.Request, header http.Header, err error) (int, []byte) {
+	return errorResponse(req, header, h.log, err)
+}
+
+// etag returns the entity tag of a point version.
+func etag(point pickuppoint.PickUpPoint) string {
+	return `"` + strconv.FormatUint(point.Version, 10) + `"`
+}
+
+// ifMatchVersion returns the point version required by the If-Match header, zero when any version matches.
+// It reports false when the header is not a single entity tag made by etag, as no point could match it.
+func ifMatchVersion(req *http.Request) (uint64, bool) {
+	ifMatch := strings.TrimSpace(req.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+	if len(ifMatch) < 2 || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(ifMatch[1:len(ifMatch)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+func (h *PickUpPointHandlers) CreateHandler(httpReq *http.Request, vars map[string]string, header http.Header) (int, []byte) {
 	body, err := io.ReadAll(httpReq.Body)
 	if err != nil {
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+		return h.fail(httpReq, header, err)
 	}
 
 	var req core.CreatePointRequest
 	err = json.Unmarshal(body, &req)
 	if err != nil {
-		return http.StatusBadRequest, nil
+		return invalidBody(httpReq, header, err)
 	}
+	req.Import = httpReq.URL.Query().Get("mode") == "import"
 
-	err = h.svc.CreatePoint(httpReq.Context(), req)
+	point, err := h.svc.CreatePoint(httpReq.Context(), req)
 	if err != nil {
-		if errors.Is(err, pickuppoint.ErrIdAlreadyExists) {
-			return http.StatusConflict, nil
-		}
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+		return h.fail(httpReq, header, err)
 	}
 
-	pointJson, err := json.Marshal(req)
+	pointJson, err := json.Marshal(point)
 	if err != nil {
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+		return h.fail(httpReq, header, err)
 	}
 
+	header.Set("Location", fmt.Sprintf("/pickup-point/%d", point.Id))
+	header.Set("ETag", etag(point))
 	return http.StatusCreated, pointJson
 }
 
-func (h *PickUpPointHandlers) ListHandler(req *http.Request, vars map[string]string) (int, []byte) {
-	list, err := h.svc.ListPoints(req.Context())
+// BatchHandler creates pick-up points sent as a JSON array or CSV in a single transaction and reports every row.
+// With dry_run=true the batch is only checked.
+func (h *PickUpPointHandlers) BatchHandler(httpReq *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	format := pickuppoint.FormatJSON
+	if contentType := httpReq.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		switch {
+		case err != nil:
+			return unsupportedMediaType(httpReq, header, "application/json or text/csv")
+		case mediaType == "text/csv":
+			format = pickuppoint.FormatCSV
+		case mediaType != "application/json":
+			return unsupportedMediaType(httpReq, header, "application/json or text/csv")
+		}
+	}
+
+	var req core.ImportPointsRequest
+	if dryRunStr := httpReq.URL.Query().Get("dry_run"); dryRunStr != "" {
+		var err error
+		req.DryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			return invalidParameter(httpReq, header, "dry_run")
+		}
+	}
+
+	var err error
+	req.Rows, err = pickuppoint.DecodeBatch(httpReq.Body, format)
+	if err != nil {
+		return invalidBody(httpReq, header, err)
+	}
+
+	report, err := h.svc.ImportPoints(httpReq.Context(), req)
+	code := http.StatusCreated
+	switch {
+	case errors.Is(err, pickuppoint.ErrInvalidBatch):
+		code = http.StatusUnprocessableEntity
+	case err != nil:
+		return h.fail(httpReq, header, err)
+	case req.DryRun:
+		code = http.StatusOK
+	}
+
+	reportJson, err := json.Marshal(report)
+	if err != nil {
+		return h.fail(httpReq, header, err)
+	}
+	return code, reportJson
+}
+
+func (h *PickUpPointHandlers) ListHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	query := req.URL.Query()
+	opts := pickuppoint.ListOptions{
+		Search: query.Get("search"),
+		Limit:  defaultListLimit,
+	}
+	var err error
+	if includeDeletedStr := query.Get("include_deleted"); includeDeletedStr != "" {
+		opts.IncludeDeleted, err = strconv.ParseBool(includeDeletedStr)
+		if err != nil {
+			return invalidParameter(req, header, "include_deleted")
+		}
+	}
+	if sortStr := query.Get("sort"); sortStr != "" {
+		opts.SortBy, opts.Desc, err = pickuppoint.ParseSort(sortStr)
+		if err != nil {
+			return invalidParameter(req, header, "sort")
+		}
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		opts.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || opts.Limit < 1 {
+			return invalidParameter(req, header, "limit")
+		}
+	}
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		cursor, err := pickuppoint.ParseCursor(cursorStr)
+		if err != nil {
+			return invalidParameter(req, header, "cursor")
+		}
+		opts.After = &cursor
+	}
+
+	page, err := h.svc.ListPoints(req.Context(), opts)
 	if err != nil {
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+		return h.fail(req, header, err)
 	}
 
-	listJson, err := json.Marshal(list)
+	listJson, err := json.Marshal(page.Points)
 	if err != nil {
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+		return h.fail(req, header, err)
 	}
 
+	if page.Next != nil {
+		next := *req.URL
+		query.Set("cursor", page.Next.String())
+		next.RawQuery = query.Encode()
+		header.Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
 	return http.StatusOK, listJson
 }
 
-func (h *PickUpPointHandlers) GetHandler(req *http.Request, vars map[string]string) (int, []byte) {
+func (h *PickUpPointHandlers) GetHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
 	idStr, ok := vars["id"]
 	if !ok {
-		return http.StatusBadRequest, nil
+		return invalidId(req, header)
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		return http.StatusBadRequest, nil
+		return invalidId(req, header)
 	}
 
-	point, err := h.svc.GetPoint(req.Context(), id)
-	if err != nil {
-		if errors.Is(err, pickuppoint.ErrNoItemFound) {
-			return http.StatusNotFound, nil
+	getPoint := h.svc.GetPoint
+	if includeDeletedStr := req.URL.Query().Get("include_deleted"); includeDeletedStr != "" {
+		includeDeleted, err := strconv.ParseBool(includeDeletedStr)
+		if err != nil {
+			return invalidParameter(req, header, "include_deleted")
+		}
+		if includeDeleted {
+			getPoint = h.svc.GetPointIncludingDeleted
 		}
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+	}
+
+	point, err := getPoint(req.Context(), id)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	header.Set("ETag", etag(point))
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch == etag(point) || ifNoneMatch == "*" {
+		return http.StatusNotModified, nil
 	}
 
 	pointJson, err := json.Marshal(point)
 	if err != nil {
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+		return h.fail(req, header, err)
 	}
 
 	return http.StatusOK, pointJson
 }
 
-func (h *PickUpPointHandlers) UpdateHandler(httpReq *http.Request, vars map[string]string) (int, []byte) {
+func (h *PickUpPointHandlers) UpdateHandler(httpReq *http.Request, vars map[string]string, header http.Header) (int, []byte) {
 	idStr, ok := vars["id"]
 	if !ok {
-		return http.StatusBadRequest, nil
+		return invalidId(httpReq, header)
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		return http.StatusBadRequest, nil
+		return invalidId(httpReq, header)
 	}
 
 	body, err := io.ReadAll(httpReq.Body)
 	if err != nil {
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+		return h.fail(httpReq, header, err)
 	}
 
 	var req core.UpdatePointRequest
 	err = json.Unmarshal(body, &req)
 	if err != nil {
-		return http.StatusBadRequest, nil
+		return invalidBody(httpReq, header, err)
 	}
 
 	if req.Id != id {
-		return http.StatusBadRequest, nil
+		return badRequest(httpReq, header, "id_mismatch", "id of the body must match the one of the path")
 	}
 
-	err = h.svc.UpdatePoint(httpReq.Context(), req)
+	req.Version, ok = ifMatchVersion(httpReq)
+	if !ok {
+		return invalidIfMatch(httpReq, header)
+	}
+
+	point, err := h.svc.UpdatePoint(httpReq.Context(), req)
 	if err != nil {
-		if errors.Is(err, pickuppoint.ErrNoItemFound) {
-			return http.StatusNotFound, nil
+		return h.fail(httpReq, header, err)
+	}
+
+	pointJson, err := json.Marshal(point)
+	if err != nil {
+		return h.fail(httpReq, header, err)
+	}
+
+	header.Set("ETag", etag(point))
+	return http.StatusOK, pointJson
+}
+
+// PatchHandler applies a JSON Merge Patch to a pick-up point.
+func (h *PickUpPointHandlers) PatchHandler(httpReq *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	idStr, ok := vars["id"]
+	if !ok {
+		return invalidId(httpReq, header)
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return invalidId(httpReq, header)
+	}
+
+	if contentType := httpReq.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+			header.Set("Accept-Patch", "application/merge-patch+json")
+			return unsupportedMediaType(httpReq, header, "application/merge-patch+json")
 		}
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
 	}
 
-	pointJson, err := json.Marshal(req)
+	version, ok := ifMatchVersion(httpReq)
+	if !ok {
+		return invalidIfMatch(httpReq, header)
+	}
+
+	body, err := io.ReadAll(httpReq.Body)
+	if err != nil {
+		return h.fail(httpReq, header, err)
+	}
+
+	point, err := h.svc.PatchPoint(httpReq.Context(), core.PatchPointRequest{Id: id, Version: version, Patch: body})
 	if err != nil {
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+		return h.fail(httpReq, header, err)
 	}
 
+	pointJson, err := json.Marshal(point)
+	if err != nil {
+		return h.fail(httpReq, header, err)
+	}
+
+	header.Set("ETag", etag(point))
 	return http.StatusOK, pointJson
 }
 
-func (h *PickUpPointHandlers) DeleteHandler(req *http.Request, vars map[string]string) (int, []byte) {
+func (h *PickUpPointHandlers) DeleteHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
 	idStr, ok := vars["id"]
 	if !ok {
-		return http.StatusBadRequest, nil
+		return invalidId(req, header)
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		return http.StatusBadRequest, nil
+		return invalidId(req, header)
 	}
 
-	err = h.svc.DeletePoint(req.Context(), id)
+	version, ok := ifMatchVersion(req)
+	if !ok {
+		return invalidIfMatch(req, header)
+	}
+
+	err = h.svc.DeletePoint(req.Context(), id, version)
 	if err != nil {
-		if errors.Is(err, pickuppoint.ErrNoItemFound) {
-			return http.StatusNotFound, nil
-		}
-		h.log.Log("%v", err)
-		return http.StatusInternalServerError, nil
+		return h.fail(req, header, err)
 	}
 
 	return http.StatusNoContent, nil
 }
+
+// RestoreHandler brings back a deleted pick-up point.
+func (h *PickUpPointHandlers) RestoreHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	idStr, ok := vars["id"]
+	if !ok {
+		return invalidId(req, header)
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return invalidId(req, header)
+	}
+
+	version, ok := ifMatchVersion(req)
+	if !ok {
+		return invalidIfMatch(req, header)
+	}
+
+	point, err := h.svc.RestorePoint(req.Context(), id, version)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	pointJson, err := json.Marshal(point)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	header.Set("ETag", etag(point))
+	return http.StatusOK, pointJson
+}
+
+func (h *PickUpPointHandlers) ScheduleHandler(httpReq *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	idStr, ok := vars["id"]
+	if !ok {
+		return invalidId(httpReq, header)
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return invalidId(httpReq, header)
+	}
+
+	version, ok := ifMatchVersion(httpReq)
+	if !ok {
+		return invalidIfMatch(httpReq, header)
+	}
+
+	body, err := io.ReadAll(httpReq.Body)
+	if err != nil {
+		return h.fail(httpReq, header, err)
+	}
+
+	var schedule pickuppoint.Schedule
+	err = json.Unmarshal(body, &schedule)
+	if err != nil {
+		return invalidBody(httpReq, header, err)
+	}
+
+	point, err := h.svc.SetSchedule(httpReq.Context(), id, version, schedule)
+	if err != nil {
+		return h.fail(httpReq, header, err)
+	}
+
+	pointJson, err := json.Marshal(point)
+	if err != nil {
+		return h.fail(httpReq, header, err)
+	}
+
+	header.Set("ETag", etag(point))
+	return http.StatusOK, pointJson
+}
+
+func (h *PickUpPointHandlers) OpenStatusHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	idStr, ok := vars["id"]
+	if !ok {
+		return invalidId(req, header)
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return invalidId(req, header)
+	}
+
+	at := time.Now()
+	if atStr := req.URL.Query().Get("at"); atStr != "" {
+		at, err = time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			return invalidParameter(req, header, "at")
+		}
+	}
+
+	status, err := h.svc.GetOpenStatus(req.Context(), id, at)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	statusJson, err := json.Marshal(status)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	return http.StatusOK, statusJson
+}
+
+// HistoryHandler lists changes made to a pick-up point, newest first, a page of them at a time.
+func (h *PickUpPointHandlers) HistoryHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	idStr, ok := vars["id"]
+	if !ok {
+		return invalidId(req, header)
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return invalidId(req, header)
+	}
+
+	query := req.URL.Query()
+	opts := pickuppoint.HistoryOptions{Limit: defaultHistoryLimit}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		opts.Limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			return invalidParameter(req, header, "limit")
+		}
+	}
+	if beforeStr := query.Get("before"); beforeStr != "" {
+		opts.Before, err = strconv.ParseUint(beforeStr, 10, 64)
+		if err != nil {
+			return invalidParameter(req, header, "before")
+		}
+	}
+
+	page, err := h.svc.GetHistory(req.Context(), id, opts)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	historyJson, err := json.Marshal(page.Entries)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	if page.Next != 0 {
+		next := *req.URL
+		query.Set("before", strconv.FormatUint(page.Next, 10))
+		next.RawQuery = query.Encode()
+		header.Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+	return http.StatusOK, historyJson
+}
+
+func (h *PickUpPointHandlers) UtilisationHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	idStr, ok := vars["id"]
+	if !ok {
+		return invalidId(req, header)
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return invalidId(req, header)
+	}
+
+	point, err := h.svc.GetPoint(req.Context(), id)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	utilisation, err := h.svc.GetUtilisation(req.Context(), []pickuppoint.PickUpPoint{point})
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	utilisationJson, err := json.Marshal(utilisation[id])
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	return http.StatusOK, utilisationJson
+}
+
+func (h *PickUpPointHandlers) NearestHandler(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+	query := req.URL.Query()
+	lat, err := strconv.ParseFloat(query.Get("lat"), 64)
+	if err != nil {
+		return invalidParameter(req, header, "lat")
+	}
+	lon, err := strconv.ParseFloat(query.Get("lon"), 64)
+	if err != nil {
+		return invalidParameter(req, header, "lon")
+	}
+	nearestReq := core.NearestPointsRequest{
+		Location: pickuppoint.Location{Latitude: lat, Longitude: lon},
+		Limit:    defaultNearestLimit,
+		At:       time.Now(),
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		nearestReq.Limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			return invalidParameter(req, header, "limit")
+		}
+	}
+	if openNowStr := query.Get("open_now"); openNowStr != "" {
+		nearestReq.OpenNow, err = strconv.ParseBool(openNowStr)
+		if err != nil {
+			return invalidParameter(req, header, "open_now")
+		}
+	}
+	if hasCapacityStr := query.Get("has_capacity"); hasCapacityStr != "" {
+		nearestReq.HasCapacity, err = strconv.ParseBool(hasCapacityStr)
+		if err != nil {
+			return invalidParameter(req, header, "has_capacity")
+		}
+	}
+
+	nearby, err := h.svc.NearestPoints(req.Context(), nearestReq)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	nearbyJson, err := json.Marshal(nearby)
+	if err != nil {
+		return h.fail(req, header, err)
+	}
+
+	return http.StatusOK, nearbyJson
+}
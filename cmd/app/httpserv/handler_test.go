@@ -1,6 +1,8 @@
 package httpserv
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
@@ -8,62 +10,134 @@ import (
 	coremocks "homework/internal/app/core/mocks"
 	logmocks "homework/internal/app/logger/mocks"
 	"homework/internal/app/pickuppoint"
+	"homework/internal/app/problem"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 type PickUpPointHandlersTestSuite struct {
 	suite.Suite
 }
 
+// assertBody checks the body of a response, error responses must be problems unless another body is wanted.
+func (s *PickUpPointHandlersTestSuite) assertBody(wantCode int, wantBody []byte, body []byte) {
+	if wantBody == nil && wantCode >= http.StatusBadRequest {
+		var p problem.Problem
+		s.Require().NoError(json.Unmarshal(body, &p))
+		s.NotEmpty(p.Code)
+		s.NotEmpty(p.Message)
+		return
+	}
+	s.Equal(wantBody, body)
+}
+
 func (s *PickUpPointHandlersTestSuite) Test_CreateHandler() {
+	point := pickuppoint.PickUpPoint{
+		Id:      1,
+		Name:    "Generic pick-up point",
+		Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+		Contact: pickuppoint.Contact{Email: "test@example.com"},
+	}
 	tests := []struct {
-		name     string
-		reqBody  string
-		coreReq  core.CreatePointRequest
-		coreErr  error
-		wantCode int
-		wantBody []byte
+		name         string
+		target       string
+		reqBody      string
+		coreReq      core.CreatePointRequest
+		corePoint    pickuppoint.PickUpPoint
+		coreErr      error
+		wantCode     int
+		wantBody     []byte
+		wantLocation string
 	}{
 		{
 			name:    "ok",
-			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
+			target:  "/pickup-point",
+			reqBody: "{\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			coreReq: core.CreatePointRequest{
+				Name:    "Generic pick-up point",
+				Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+				Contact: pickuppoint.Contact{Email: "test@example.com"},
+			},
+			corePoint:    point,
+			wantCode:     http.StatusCreated,
+			wantBody:     []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{}}"),
+			wantLocation: "/pickup-point/1",
+		},
+		{
+			name:    "import",
+			target:  "/pickup-point?mode=import",
+			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
 			coreReq: core.CreatePointRequest{
 				Id:      1,
 				Name:    "Generic pick-up point",
-				Address: "5, Test st., Moscow",
-				Contact: "test@example.com",
+				Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+				Contact: pickuppoint.Contact{Email: "test@example.com"},
+				Import:  true,
 			},
-			wantCode: http.StatusCreated,
-			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}"),
+			corePoint:    point,
+			wantCode:     http.StatusCreated,
+			wantBody:     []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{}}"),
+			wantLocation: "/pickup-point/1",
 		},
 		{
 			name:     "invalid body",
+			target:   "/pickup-point",
 			reqBody:  "lhlihiuhilnjiklhni",
 			wantCode: http.StatusBadRequest,
 		},
+		{
+			name:    "unexpected id",
+			target:  "/pickup-point",
+			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			coreReq: core.CreatePointRequest{
+				Id:      1,
+				Name:    "Generic pick-up point",
+				Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+				Contact: pickuppoint.Contact{Email: "test@example.com"},
+			},
+			coreErr:  core.ErrUnexpectedId,
+			wantCode: http.StatusBadRequest,
+		},
 		{
 			name:    "existing id",
-			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
+			target:  "/pickup-point?mode=import",
+			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
 			coreReq: core.CreatePointRequest{
 				Id:      1,
 				Name:    "Generic pick-up point",
-				Address: "5, Test st., Moscow",
-				Contact: "test@example.com",
+				Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+				Contact: pickuppoint.Contact{Email: "test@example.com"},
+				Import:  true,
 			},
 			coreErr:  pickuppoint.ErrIdAlreadyExists,
 			wantCode: http.StatusConflict,
 		},
+		{
+			name:    "invalid details",
+			target:  "/pickup-point",
+			reqBody: "{\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"phone\":\"112\"}}",
+			coreReq: core.CreatePointRequest{
+				Name:    "Generic pick-up point",
+				Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+				Contact: pickuppoint.Contact{Phone: "112"},
+			},
+			coreErr: fmt.Errorf("create: %w", &pickuppoint.ValidationError{Fields: []pickuppoint.FieldError{
+				{Field: "contact.phone", Message: "must be a phone number of 7 to 15 digits, optionally starting with +"},
+			}}),
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: []byte("{\"code\":\"invalid_point\",\"message\":\"invalid pick-up point\",\"fields\":[{\"field\":\"contact.phone\",\"message\":\"must be a phone number of 7 to 15 digits, optionally starting with +\"}]}"),
+		},
 		{
 			name:    "error",
-			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
+			target:  "/pickup-point",
+			reqBody: "{\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
 			coreReq: core.CreatePointRequest{
-				Id:      1,
 				Name:    "Generic pick-up point",
-				Address: "5, Test st., Moscow",
-				Contact: "test@example.com",
+				Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+				Contact: pickuppoint.Contact{Email: "test@example.com"},
 			},
 			coreErr:  assert.AnError,
 			wantCode: http.StatusInternalServerError,
@@ -78,45 +152,235 @@ func (s *PickUpPointHandlersTestSuite) Test_CreateHandler() {
 				svc: svc,
 				log: log,
 			}
-			svc.EXPECT().CreatePoint(gomock.Any(), tt.coreReq).Return(tt.coreErr).AnyTimes()
+			svc.EXPECT().CreatePoint(gomock.Any(), tt.coreReq).Return(tt.corePoint, tt.coreErr).AnyTimes()
+			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.reqBody))
+			header := http.Header{}
+			code, body := h.CreateHandler(req, nil, header)
+			s.Equal(tt.wantCode, code)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+			s.Equal(tt.wantLocation, header.Get("Location"))
+		})
+	}
+}
+
+func (s *PickUpPointHandlersTestSuite) Test_BatchHandler() {
+	point := pickuppoint.PickUpPoint{
+		Id:      1,
+		Name:    "Generic pick-up point",
+		Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+		Contact: pickuppoint.Contact{Email: "test@example.com"},
+	}
+	created := pickuppoint.BatchReport{Created: 1, Rows: []pickuppoint.RowResult{{Row: 1, Id: 1, Status: pickuppoint.RowCreated}}}
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		req         *core.ImportPointsRequest
+		report      pickuppoint.BatchReport
+		coreErr     error
+		wantCode    int
+		wantBody    []byte
+	}{
+		{
+			name:     "json",
+			target:   "/pickup-point/batch",
+			body:     "[{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}]",
+			req:      &core.ImportPointsRequest{Rows: []pickuppoint.BatchRow{{Point: point}}},
+			report:   created,
+			wantCode: http.StatusCreated,
+			wantBody: []byte("{\"dry_run\":false,\"created\":1,\"failed\":0,\"rows\":[{\"row\":1,\"id\":1,\"status\":\"created\"}]}"),
+		},
+		{
+			name:        "csv dry run",
+			target:      "/pickup-point/batch?dry_run=true",
+			contentType: "text/csv; charset=utf-8",
+			body: "id,name,country,city,street,building,postcode,email,phone,lat,lon,slots,max_weight_kg,schedule\n" +
+				"1,Generic pick-up point,Russia,Moscow,Test st.,5,,test@example.com,,,,,,\n",
+			req:      &core.ImportPointsRequest{Rows: []pickuppoint.BatchRow{{Point: point}}, DryRun: true},
+			report:   pickuppoint.BatchReport{DryRun: true, Rows: []pickuppoint.RowResult{{Row: 1, Id: 1, Status: pickuppoint.RowValid}}},
+			wantCode: http.StatusOK,
+			wantBody: []byte("{\"dry_run\":true,\"created\":0,\"failed\":0,\"rows\":[{\"row\":1,\"id\":1,\"status\":\"valid\"}]}"),
+		},
+		{
+			name:     "invalid rows",
+			target:   "/pickup-point/batch",
+			body:     "[{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}]",
+			req:      &core.ImportPointsRequest{Rows: []pickuppoint.BatchRow{{Point: point}}},
+			report:   pickuppoint.BatchReport{Failed: 1, Rows: []pickuppoint.RowResult{{Row: 1, Id: 1, Status: pickuppoint.RowFailed, Error: "item with such id already exists"}}},
+			coreErr:  pickuppoint.ErrInvalidBatch,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: []byte("{\"dry_run\":false,\"created\":0,\"failed\":1,\"rows\":[{\"row\":1,\"id\":1,\"status\":\"failed\",\"error\":\"item with such id already exists\"}]}"),
+		},
+		{
+			name:     "too large",
+			target:   "/pickup-point/batch",
+			body:     "[]",
+			req:      &core.ImportPointsRequest{Rows: []pickuppoint.BatchRow{}},
+			coreErr:  core.ErrBatchTooLarge,
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "malformed",
+			target:   "/pickup-point/batch",
+			body:     "{}",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid dry run",
+			target:   "/pickup-point/batch?dry_run=maybe",
+			body:     "[]",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:        "unsupported media type",
+			target:      "/pickup-point/batch",
+			contentType: "application/xml",
+			body:        "<points/>",
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:     "error",
+			target:   "/pickup-point/batch",
+			body:     "[]",
+			req:      &core.ImportPointsRequest{Rows: []pickuppoint.BatchRow{}},
+			coreErr:  assert.AnError,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			svc := coremocks.NewMockPickUpPointCoreService(ctrl)
+			log := logmocks.NewMockLogger(ctrl)
+			h := &PickUpPointHandlers{
+				svc: svc,
+				log: log,
+			}
+			if tt.req != nil {
+				svc.EXPECT().ImportPoints(gomock.Any(), *tt.req).Return(tt.report, tt.coreErr)
+			}
 			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
-			req := httptest.NewRequest(http.MethodPost, "/pickup-points", strings.NewReader(tt.reqBody))
-			code, body := h.CreateHandler(req, nil)
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			code, body := h.BatchHandler(req, nil, http.Header{})
 			s.Equal(tt.wantCode, code)
-			s.Equal(tt.wantBody, body)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
 		})
 	}
 }
 
 func (s *PickUpPointHandlersTestSuite) Test_ListHandler() {
+	deletedAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	next := pickuppoint.Cursor{SortBy: pickuppoint.SortByName, Desc: true, Key: "Generic pick-up point", Id: 1}
 	tests := []struct {
 		name     string
-		points   []pickuppoint.PickUpPoint
+		target   string
+		opts     pickuppoint.ListOptions
+		page     pickuppoint.Page
 		coreErr  error
 		wantCode int
 		wantBody []byte
+		wantLink string
 	}{
 		{
-			name: "ok",
-			points: []pickuppoint.PickUpPoint{
+			name:   "ok",
+			target: "/pickup-point",
+			opts:   pickuppoint.ListOptions{Limit: defaultListLimit},
+			page: pickuppoint.Page{Points: []pickuppoint.PickUpPoint{
 				{
 					Id:      1,
 					Name:    "Generic pick-up point",
-					Address: "5, Test st., Moscow",
-					Contact: "test@example.com",
+					Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+					Contact: pickuppoint.Contact{Email: "test@example.com"},
 				},
 				{
 					Id:      2,
 					Name:    "Another pick-up point",
-					Address: "19, Sample st., Moscow",
-					Contact: "sample@example.com",
+					Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Sample st.", Building: "19"},
+					Contact: pickuppoint.Contact{Email: "sample@example.com"},
+				},
+			}},
+			wantCode: http.StatusOK,
+			wantBody: []byte("[{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{}},{\"id\":2,\"name\":\"Another pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Sample st.\",\"building\":\"19\"},\"contact\":{\"email\":\"sample@example.com\"},\"schedule\":{},\"capacity\":{}}]"),
+		},
+		{
+			name:   "include deleted",
+			target: "/pickup-point?include_deleted=true",
+			opts:   pickuppoint.ListOptions{IncludeDeleted: true, Limit: defaultListLimit},
+			page: pickuppoint.Page{Points: []pickuppoint.PickUpPoint{
+				{
+					Id:        1,
+					Name:      "Generic pick-up point",
+					Address:   pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+					Contact:   pickuppoint.Contact{Email: "test@example.com"},
+					Version:   2,
+					DeletedAt: &deletedAt,
 				},
+			}},
+			wantCode: http.StatusOK,
+			wantBody: []byte("[{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":2,\"deleted_at\":\"2024-06-01T12:00:00Z\"}]"),
+		},
+		{
+			name:   "search and page",
+			target: "/pickup-point?search=tver&sort=-name&limit=1",
+			opts:   pickuppoint.ListOptions{Search: "tver", SortBy: pickuppoint.SortByName, Desc: true, Limit: 1},
+			page: pickuppoint.Page{
+				Points: []pickuppoint.PickUpPoint{{Id: 1, Name: "Generic pick-up point"}},
+				Next:   &next,
 			},
 			wantCode: http.StatusOK,
-			wantBody: []byte("[{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"},{\"id\":2,\"name\":\"Another pick-up point\",\"address\":\"19, Sample st., Moscow\",\"contact\":\"sample@example.com\"}]"),
+			wantBody: []byte("[{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"\",\"city\":\"\",\"street\":\"\",\"building\":\"\"},\"contact\":{},\"schedule\":{},\"capacity\":{}}]"),
+			wantLink: "</pickup-point?cursor=" + next.String() + "&limit=1&search=tver&sort=-name>; rel=\"next\"",
+		},
+		{
+			name:     "cursor",
+			target:   "/pickup-point?sort=-name&cursor=" + next.String(),
+			opts:     pickuppoint.ListOptions{SortBy: pickuppoint.SortByName, Desc: true, Limit: defaultListLimit, After: &next},
+			wantCode: http.StatusOK,
+			wantBody: []byte("null"),
+		},
+		{
+			name:     "invalid sort",
+			target:   "/pickup-point?sort=address",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid limit",
+			target:   "/pickup-point?limit=0",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "limit too large",
+			target:   "/pickup-point?limit=1000",
+			opts:     pickuppoint.ListOptions{Limit: 1000},
+			coreErr:  core.ErrInvalidLimit,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid cursor",
+			target:   "/pickup-point?cursor=abc!",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "cursor for another sort",
+			target:   "/pickup-point?cursor=" + next.String(),
+			opts:     pickuppoint.ListOptions{Limit: defaultListLimit, After: &next},
+			coreErr:  pickuppoint.ErrInvalidCursor,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid include deleted",
+			target:   "/pickup-point?include_deleted=maybe",
+			wantCode: http.StatusBadRequest,
 		},
 		{
 			name:     "error",
+			target:   "/pickup-point",
+			opts:     pickuppoint.ListOptions{Limit: defaultListLimit},
 			coreErr:  assert.AnError,
 			wantCode: http.StatusInternalServerError,
 		},
@@ -130,38 +394,84 @@ func (s *PickUpPointHandlersTestSuite) Test_ListHandler() {
 				svc: svc,
 				log: log,
 			}
-			svc.EXPECT().ListPoints(gomock.Any()).Return(tt.points, tt.coreErr)
+			svc.EXPECT().ListPoints(gomock.Any(), tt.opts).Return(tt.page, tt.coreErr).AnyTimes()
 			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
-			req := httptest.NewRequest(http.MethodGet, "/pickup-points", nil)
-			code, body := h.ListHandler(req, nil)
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			header := http.Header{}
+			code, body := h.ListHandler(req, nil, header)
 			s.Equal(tt.wantCode, code)
-			s.Equal(tt.wantBody, body)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+			s.Equal(tt.wantLink, header.Get("Link"))
 		})
 	}
 }
 
 func (s *PickUpPointHandlersTestSuite) Test_GetHandler() {
+	point := pickuppoint.PickUpPoint{
+		Id:      1,
+		Name:    "Generic pick-up point",
+		Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+		Contact: pickuppoint.Contact{Email: "test@example.com"},
+		Version: 2,
+	}
+	deletedAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	deleted := point
+	deleted.DeletedAt = &deletedAt
 	tests := []struct {
-		name     string
-		idStr    string
-		id       uint64
-		point    pickuppoint.PickUpPoint
-		coreErr  error
-		wantCode int
-		wantBody []byte
+		name           string
+		idStr          string
+		id             uint64
+		includeDeleted string
+		ifNoneMatch    string
+		point          pickuppoint.PickUpPoint
+		coreErr        error
+		wantCode       int
+		wantBody       []byte
+		wantETag       string
 	}{
 		{
-			name:  "ok",
-			idStr: "1",
-			id:    1,
-			point: pickuppoint.PickUpPoint{
-				Id:      1,
-				Name:    "Generic pick-up point",
-				Address: "5, Test st., Moscow",
-				Contact: "test@example.com",
-			},
+			name:     "ok",
+			idStr:    "1",
+			id:       1,
+			point:    point,
 			wantCode: http.StatusOK,
-			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}"),
+			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":2}"),
+			wantETag: "\"2\"",
+		},
+		{
+			name:        "modified",
+			idStr:       "1",
+			id:          1,
+			ifNoneMatch: "\"1\"",
+			point:       point,
+			wantCode:    http.StatusOK,
+			wantBody:    []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":2}"),
+			wantETag:    "\"2\"",
+		},
+		{
+			name:        "not modified",
+			idStr:       "1",
+			id:          1,
+			ifNoneMatch: "\"2\"",
+			point:       point,
+			wantCode:    http.StatusNotModified,
+			wantETag:    "\"2\"",
+		},
+		{
+			name:           "include deleted",
+			idStr:          "1",
+			id:             1,
+			includeDeleted: "true",
+			point:          deleted,
+			wantCode:       http.StatusOK,
+			wantBody:       []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":2,\"deleted_at\":\"2024-06-01T12:00:00Z\"}"),
+			wantETag:       "\"2\"",
+		},
+		{
+			name:           "invalid include deleted",
+			idStr:          "1",
+			includeDeleted: "maybe",
+			wantCode:       http.StatusBadRequest,
 		},
 		{
 			name:     "invalid id string",
@@ -192,38 +502,89 @@ func (s *PickUpPointHandlersTestSuite) Test_GetHandler() {
 				svc: svc,
 				log: log,
 			}
-			svc.EXPECT().GetPoint(gomock.Any(), tt.id).Return(tt.point, tt.coreErr).AnyTimes()
+			if tt.includeDeleted == "true" {
+				svc.EXPECT().GetPointIncludingDeleted(gomock.Any(), tt.id).Return(tt.point, tt.coreErr)
+			} else {
+				svc.EXPECT().GetPoint(gomock.Any(), tt.id).Return(tt.point, tt.coreErr).AnyTimes()
+			}
 			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
-			req := httptest.NewRequest(http.MethodGet, "/pickup-points", nil)
-			code, body := h.GetHandler(req, map[string]string{"id": tt.idStr})
+			req := httptest.NewRequest(http.MethodGet, "/pickup-points?include_deleted="+tt.includeDeleted, nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			header := http.Header{}
+			code, body := h.GetHandler(req, map[string]string{"id": tt.idStr}, header)
 			s.Equal(tt.wantCode, code)
-			s.Equal(tt.wantBody, body)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+			s.Equal(tt.wantETag, header.Get("ETag"))
 		})
 	}
 }
 
 func (s *PickUpPointHandlersTestSuite) Test_UpdateHandler() {
+	coreReq := core.UpdatePointRequest{
+		Id:      1,
+		Name:    "Generic pick-up point",
+		Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+		Contact: pickuppoint.Contact{Email: "test@example.com"},
+	}
+	versionedReq := coreReq
+	versionedReq.Version = 2
+	point := pickuppoint.PickUpPoint{
+		Id:      1,
+		Name:    "Generic pick-up point",
+		Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+		Contact: pickuppoint.Contact{Email: "test@example.com"},
+		Version: 3,
+	}
 	tests := []struct {
-		name     string
-		idStr    string
-		reqBody  string
-		coreReq  core.UpdatePointRequest
-		coreErr  error
-		wantCode int
-		wantBody []byte
+		name      string
+		idStr     string
+		ifMatch   string
+		reqBody   string
+		coreReq   core.UpdatePointRequest
+		corePoint pickuppoint.PickUpPoint
+		coreErr   error
+		wantCode  int
+		wantBody  []byte
+		wantETag  string
 	}{
 		{
-			name:    "ok",
-			idStr:   "1",
-			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
-			coreReq: core.UpdatePointRequest{
-				Id:      1,
-				Name:    "Generic pick-up point",
-				Address: "5, Test st., Moscow",
-				Contact: "test@example.com",
-			},
-			wantCode: http.StatusOK,
-			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}"),
+			name:      "ok",
+			idStr:     "1",
+			reqBody:   "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			coreReq:   coreReq,
+			corePoint: point,
+			wantCode:  http.StatusOK,
+			wantBody:  []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":3}"),
+			wantETag:  "\"3\"",
+		},
+		{
+			name:      "if match",
+			idStr:     "1",
+			ifMatch:   "\"2\"",
+			reqBody:   "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			coreReq:   versionedReq,
+			corePoint: point,
+			wantCode:  http.StatusOK,
+			wantBody:  []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":3}"),
+			wantETag:  "\"3\"",
+		},
+		{
+			name:     "version mismatch",
+			idStr:    "1",
+			ifMatch:  "\"2\"",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			coreReq:  versionedReq,
+			coreErr:  pickuppoint.ErrVersionMismatch,
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "weak if match",
+			idStr:    "1",
+			ifMatch:  "W/\"2\"",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			wantCode: http.StatusPreconditionFailed,
 		},
 		{
 			name:     "invalid id string",
@@ -239,32 +600,38 @@ func (s *PickUpPointHandlersTestSuite) Test_UpdateHandler() {
 		{
 			name:     "id and body mismatch",
 			idStr:    "2",
-			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
 			wantCode: http.StatusBadRequest,
 		},
 		{
-			name:    "not found",
-			idStr:   "1",
-			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
-			coreReq: core.UpdatePointRequest{
-				Id:      1,
-				Name:    "Generic pick-up point",
-				Address: "5, Test st., Moscow",
-				Contact: "test@example.com",
-			},
+			name:     "not found",
+			idStr:    "1",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			coreReq:  coreReq,
 			coreErr:  pickuppoint.ErrNoItemFound,
 			wantCode: http.StatusNotFound,
 		},
 		{
-			name:    "error",
+			name:    "invalid details",
 			idStr:   "1",
-			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
+			reqBody: "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\"},\"contact\":{}}",
 			coreReq: core.UpdatePointRequest{
 				Id:      1,
 				Name:    "Generic pick-up point",
-				Address: "5, Test st., Moscow",
-				Contact: "test@example.com",
+				Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st."},
 			},
+			coreErr: &pickuppoint.ValidationError{Fields: []pickuppoint.FieldError{
+				{Field: "address.building", Message: "must not be empty"},
+				{Field: "contact", Message: "either email or phone is required"},
+			}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: []byte("{\"code\":\"invalid_point\",\"message\":\"invalid pick-up point\",\"fields\":[{\"field\":\"address.building\",\"message\":\"must not be empty\"},{\"field\":\"contact\",\"message\":\"either email or phone is required\"}]}"),
+		},
+		{
+			name:     "error",
+			idStr:    "1",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			coreReq:  coreReq,
 			coreErr:  assert.AnError,
 			wantCode: http.StatusInternalServerError,
 		},
@@ -278,12 +645,154 @@ func (s *PickUpPointHandlersTestSuite) Test_UpdateHandler() {
 				svc: svc,
 				log: log,
 			}
-			svc.EXPECT().UpdatePoint(gomock.Any(), tt.coreReq).Return(tt.coreErr).AnyTimes()
+			svc.EXPECT().UpdatePoint(gomock.Any(), tt.coreReq).Return(tt.corePoint, tt.coreErr).AnyTimes()
 			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
 			req := httptest.NewRequest(http.MethodPut, "/pickup-points", strings.NewReader(tt.reqBody))
-			code, body := h.UpdateHandler(req, map[string]string{"id": tt.idStr})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			header := http.Header{}
+			code, body := h.UpdateHandler(req, map[string]string{"id": tt.idStr}, header)
+			s.Equal(tt.wantCode, code)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+			s.Equal(tt.wantETag, header.Get("ETag"))
+		})
+	}
+}
+
+func (s *PickUpPointHandlersTestSuite) Test_PatchHandler() {
+	point := pickuppoint.PickUpPoint{
+		Id:      1,
+		Name:    "Generic pick-up point",
+		Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+		Contact: pickuppoint.Contact{Email: "test@example.com"},
+		Version: 3,
+	}
+	patch := "{\"name\":\"Generic pick-up point\"}"
+	tests := []struct {
+		name        string
+		idStr       string
+		contentType string
+		ifMatch     string
+		reqBody     string
+		coreReq     core.PatchPointRequest
+		corePoint   pickuppoint.PickUpPoint
+		coreErr     error
+		wantCode    int
+		wantBody    []byte
+		wantETag    string
+	}{
+		{
+			name:        "ok",
+			idStr:       "1",
+			contentType: "application/merge-patch+json",
+			ifMatch:     "\"2\"",
+			reqBody:     patch,
+			coreReq:     core.PatchPointRequest{Id: 1, Version: 2, Patch: []byte(patch)},
+			corePoint:   point,
+			wantCode:    http.StatusOK,
+			wantBody:    []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":3}"),
+			wantETag:    "\"3\"",
+		},
+		{
+			name:      "any version",
+			idStr:     "1",
+			ifMatch:   "*",
+			reqBody:   patch,
+			coreReq:   core.PatchPointRequest{Id: 1, Patch: []byte(patch)},
+			corePoint: point,
+			wantCode:  http.StatusOK,
+			wantBody:  []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":3}"),
+			wantETag:  "\"3\"",
+		},
+		{
+			name:        "unsupported content type",
+			idStr:       "1",
+			contentType: "application/json-patch+json",
+			reqBody:     "[]",
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:     "invalid id string",
+			idStr:    "dfsdfsf",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid patch",
+			idStr:    "1",
+			reqBody:  "[]",
+			coreReq:  core.PatchPointRequest{Id: 1, Patch: []byte("[]")},
+			coreErr:  pickuppoint.ErrInvalidPatch,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "id changed",
+			idStr:    "1",
+			reqBody:  "{\"id\":2}",
+			coreReq:  core.PatchPointRequest{Id: 1, Patch: []byte("{\"id\":2}")},
+			coreErr:  core.ErrIdChanged,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:    "invalid details",
+			idStr:   "1",
+			reqBody: "{\"contact\":null}",
+			coreReq: core.PatchPointRequest{Id: 1, Patch: []byte("{\"contact\":null}")},
+			coreErr: &pickuppoint.ValidationError{Fields: []pickuppoint.FieldError{
+				{Field: "contact", Message: "either email or phone is required"},
+			}},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: []byte("{\"code\":\"invalid_point\",\"message\":\"invalid pick-up point\",\"fields\":[{\"field\":\"contact\",\"message\":\"either email or phone is required\"}]}"),
+		},
+		{
+			name:     "version mismatch",
+			idStr:    "1",
+			ifMatch:  "\"2\"",
+			reqBody:  patch,
+			coreReq:  core.PatchPointRequest{Id: 1, Version: 2, Patch: []byte(patch)},
+			coreErr:  pickuppoint.ErrVersionMismatch,
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "not found",
+			idStr:    "1",
+			reqBody:  patch,
+			coreReq:  core.PatchPointRequest{Id: 1, Patch: []byte(patch)},
+			coreErr:  pickuppoint.ErrNoItemFound,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "error",
+			idStr:    "1",
+			reqBody:  patch,
+			coreReq:  core.PatchPointRequest{Id: 1, Patch: []byte(patch)},
+			coreErr:  assert.AnError,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			svc := coremocks.NewMockPickUpPointCoreService(ctrl)
+			log := logmocks.NewMockLogger(ctrl)
+			h := &PickUpPointHandlers{
+				svc: svc,
+				log: log,
+			}
+			svc.EXPECT().PatchPoint(gomock.Any(), tt.coreReq).Return(tt.corePoint, tt.coreErr).AnyTimes()
+			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			req := httptest.NewRequest(http.MethodPatch, "/pickup-points", strings.NewReader(tt.reqBody))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			header := http.Header{}
+			code, body := h.PatchHandler(req, map[string]string{"id": tt.idStr}, header)
 			s.Equal(tt.wantCode, code)
-			s.Equal(tt.wantBody, body)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+			s.Equal(tt.wantETag, header.Get("ETag"))
 		})
 	}
 }
@@ -293,6 +802,8 @@ func (s *PickUpPointHandlersTestSuite) Test_DeleteHandler() {
 		name     string
 		idStr    string
 		id       uint64
+		ifMatch  string
+		version  uint64
 		coreErr  error
 		wantCode int
 	}{
@@ -302,6 +813,29 @@ func (s *PickUpPointHandlersTestSuite) Test_DeleteHandler() {
 			id:       1,
 			wantCode: http.StatusNoContent,
 		},
+		{
+			name:     "if match",
+			idStr:    "1",
+			id:       1,
+			ifMatch:  "\"2\"",
+			version:  2,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "version mismatch",
+			idStr:    "1",
+			id:       1,
+			ifMatch:  "\"2\"",
+			version:  2,
+			coreErr:  pickuppoint.ErrVersionMismatch,
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "several entity tags",
+			idStr:    "1",
+			ifMatch:  "\"1\", \"2\"",
+			wantCode: http.StatusPreconditionFailed,
+		},
 		{
 			name:     "invalid id string",
 			idStr:    "dfsdfsf",
@@ -331,11 +865,485 @@ func (s *PickUpPointHandlersTestSuite) Test_DeleteHandler() {
 				svc: svc,
 				log: log,
 			}
-			svc.EXPECT().DeletePoint(gomock.Any(), tt.id).Return(tt.coreErr).AnyTimes()
+			svc.EXPECT().DeletePoint(gomock.Any(), tt.id, tt.version).Return(tt.coreErr).AnyTimes()
 			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
 			req := httptest.NewRequest(http.MethodDelete, "/pickup-points", nil)
-			code, _ := h.DeleteHandler(req, map[string]string{"id": tt.idStr})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			code, _ := h.DeleteHandler(req, map[string]string{"id": tt.idStr}, http.Header{})
+			s.Equal(tt.wantCode, code)
+		})
+	}
+}
+
+func (s *PickUpPointHandlersTestSuite) Test_RestoreHandler() {
+	point := pickuppoint.PickUpPoint{
+		Id:      1,
+		Name:    "Generic pick-up point",
+		Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+		Contact: pickuppoint.Contact{Email: "test@example.com"},
+		Version: 3,
+	}
+	tests := []struct {
+		name     string
+		idStr    string
+		id       uint64
+		ifMatch  string
+		version  uint64
+		point    pickuppoint.PickUpPoint
+		coreErr  error
+		wantCode int
+		wantBody []byte
+		wantETag string
+	}{
+		{
+			name:     "ok",
+			idStr:    "1",
+			id:       1,
+			ifMatch:  "\"2\"",
+			version:  2,
+			point:    point,
+			wantCode: http.StatusOK,
+			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":3}"),
+			wantETag: "\"3\"",
+		},
+		{
+			name:     "invalid id string",
+			idStr:    "dfsdfsf",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "not deleted",
+			idStr:    "1",
+			id:       1,
+			coreErr:  pickuppoint.ErrNotDeleted,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "version mismatch",
+			idStr:    "1",
+			id:       1,
+			ifMatch:  "\"2\"",
+			version:  2,
+			coreErr:  pickuppoint.ErrVersionMismatch,
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "not found",
+			idStr:    "1",
+			id:       1,
+			coreErr:  pickuppoint.ErrNoItemFound,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "error",
+			idStr:    "1",
+			id:       1,
+			coreErr:  assert.AnError,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			svc := coremocks.NewMockPickUpPointCoreService(ctrl)
+			log := logmocks.NewMockLogger(ctrl)
+			h := &PickUpPointHandlers{
+				svc: svc,
+				log: log,
+			}
+			svc.EXPECT().RestorePoint(gomock.Any(), tt.id, tt.version).Return(tt.point, tt.coreErr).AnyTimes()
+			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			req := httptest.NewRequest(http.MethodPost, "/pickup-points", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			header := http.Header{}
+			code, body := h.RestoreHandler(req, map[string]string{"id": tt.idStr}, header)
+			s.Equal(tt.wantCode, code)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+			s.Equal(tt.wantETag, header.Get("ETag"))
+		})
+	}
+}
+
+func (s *PickUpPointHandlersTestSuite) Test_ScheduleHandler() {
+	schedule := pickuppoint.Schedule{
+		TimeZone: "Europe/Moscow",
+		Hours:    []pickuppoint.Hours{{Day: pickuppoint.Weekday(time.Monday), Open: 9 * 60, Close: 21 * 60}},
+	}
+	tests := []struct {
+		name     string
+		idStr    string
+		id       uint64
+		ifMatch  string
+		version  uint64
+		reqBody  string
+		point    pickuppoint.PickUpPoint
+		coreErr  error
+		wantCode int
+		wantBody []byte
+	}{
+		{
+			name:    "ok",
+			idStr:   "1",
+			id:      1,
+			ifMatch: "\"2\"",
+			version: 2,
+			reqBody: "{\"time_zone\":\"Europe/Moscow\",\"hours\":[{\"day\":\"monday\",\"open\":\"09:00\",\"close\":\"21:00\"}]}",
+			point: pickuppoint.PickUpPoint{
+				Id:       1,
+				Name:     "Generic pick-up point",
+				Address:  pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+				Contact:  pickuppoint.Contact{Email: "test@example.com"},
+				Schedule: schedule,
+				Version:  3,
+			},
+			wantCode: http.StatusOK,
+			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{\"time_zone\":\"Europe/Moscow\",\"hours\":[{\"day\":\"monday\",\"open\":\"09:00\",\"close\":\"21:00\"}]},\"capacity\":{},\"version\":3}"),
+		},
+		{
+			name:     "version mismatch",
+			idStr:    "1",
+			id:       1,
+			ifMatch:  "\"2\"",
+			version:  2,
+			reqBody:  "{\"time_zone\":\"Europe/Moscow\",\"hours\":[{\"day\":\"monday\",\"open\":\"09:00\",\"close\":\"21:00\"}]}",
+			coreErr:  pickuppoint.ErrVersionMismatch,
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "weak if match",
+			idStr:    "1",
+			ifMatch:  "W/\"2\"",
+			reqBody:  "{\"time_zone\":\"Europe/Moscow\",\"hours\":[{\"day\":\"monday\",\"open\":\"09:00\",\"close\":\"21:00\"}]}",
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "invalid body",
+			idStr:    "1",
+			reqBody:  "{\"hours\":[{\"day\":\"someday\"}]}",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid schedule",
+			idStr:    "1",
+			id:       1,
+			reqBody:  "{\"time_zone\":\"Europe/Moscow\",\"hours\":[{\"day\":\"monday\",\"open\":\"09:00\",\"close\":\"21:00\"}]}",
+			coreErr:  pickuppoint.ErrInvalidSchedule,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "not found",
+			idStr:    "1",
+			id:       1,
+			reqBody:  "{\"time_zone\":\"Europe/Moscow\",\"hours\":[{\"day\":\"monday\",\"open\":\"09:00\",\"close\":\"21:00\"}]}",
+			coreErr:  pickuppoint.ErrNoItemFound,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			svc := coremocks.NewMockPickUpPointCoreService(ctrl)
+			log := logmocks.NewMockLogger(ctrl)
+			h := &PickUpPointHandlers{
+				svc: svc,
+				log: log,
+			}
+			svc.EXPECT().SetSchedule(gomock.Any(), tt.id, tt.version, schedule).Return(tt.point, tt.coreErr).AnyTimes()
+			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			req := httptest.NewRequest(http.MethodPut, "/pickup-point/1/schedule", strings.NewReader(tt.reqBody))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			code, body := h.ScheduleHandler(req, map[string]string{"id": tt.idStr}, http.Header{})
+			s.Equal(tt.wantCode, code)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+		})
+	}
+}
+
+func (s *PickUpPointHandlersTestSuite) Test_OpenStatusHandler() {
+	at := time.Date(2024, 4, 29, 10, 0, 0, 0, time.UTC)
+	closesAt := time.Date(2024, 4, 29, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		target   string
+		status   pickuppoint.OpenStatus
+		coreErr  error
+		wantCode int
+		wantBody []byte
+	}{
+		{
+			name:     "ok",
+			target:   "/pickup-point/1/status?at=2024-04-29T10:00:00Z",
+			status:   pickuppoint.OpenStatus{Open: true, ClosesAt: &closesAt},
+			wantCode: http.StatusOK,
+			wantBody: []byte("{\"open\":true,\"closes_at\":\"2024-04-29T18:00:00Z\"}"),
+		},
+		{
+			name:     "invalid time",
+			target:   "/pickup-point/1/status?at=yesterday",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "not found",
+			target:   "/pickup-point/1/status?at=2024-04-29T10:00:00Z",
+			coreErr:  pickuppoint.ErrNoItemFound,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			svc := coremocks.NewMockPickUpPointCoreService(ctrl)
+			log := logmocks.NewMockLogger(ctrl)
+			h := &PickUpPointHandlers{
+				svc: svc,
+				log: log,
+			}
+			svc.EXPECT().GetOpenStatus(gomock.Any(), uint64(1), at).Return(tt.status, tt.coreErr).AnyTimes()
+			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			code, body := h.OpenStatusHandler(req, map[string]string{"id": "1"}, http.Header{})
+			s.Equal(tt.wantCode, code)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+		})
+	}
+}
+
+func (s *PickUpPointHandlersTestSuite) Test_HistoryHandler() {
+	changedAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	old := pickuppoint.Snapshot{Id: 1, Name: "Generic pick-up point", Version: 1}
+	updated := pickuppoint.Snapshot{Id: 1, Name: "Renamed pick-up point", Version: 2}
+	tests := []struct {
+		name     string
+		idStr    string
+		target   string
+		opts     pickuppoint.HistoryOptions
+		page     pickuppoint.HistoryPage
+		coreErr  error
+		wantCode int
+		wantBody []byte
+		wantLink string
+	}{
+		{
+			name:   "ok",
+			idStr:  "1",
+			target: "/pickup-point/1/history?limit=1",
+			opts:   pickuppoint.HistoryOptions{Limit: 1},
+			page: pickuppoint.HistoryPage{
+				Entries: []pickuppoint.HistoryEntry{{Id: 7, PointId: 1, Version: 2, Action: pickuppoint.ActionUpdate, Old: &old, New: &updated, User: "user", ChangedAt: changedAt}},
+				Next:    7,
+			},
+			wantCode: http.StatusOK,
+			wantBody: []byte("[{\"id\":7,\"point_id\":1,\"version\":2,\"action\":\"update\",\"old\":{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"\",\"city\":\"\",\"street\":\"\",\"building\":\"\"},\"contact\":{},\"schedule\":{},\"capacity\":{},\"version\":1},\"new\":{\"id\":1,\"name\":\"Renamed pick-up point\",\"address\":{\"country\":\"\",\"city\":\"\",\"street\":\"\",\"building\":\"\"},\"contact\":{},\"schedule\":{},\"capacity\":{},\"version\":2},\"user\":\"user\",\"changed_at\":\"2024-06-01T12:00:00Z\"}]"),
+			wantLink: "</pickup-point/1/history?before=7&limit=1>; rel=\"next\"",
+		},
+		{
+			name:     "default limit and before",
+			idStr:    "1",
+			target:   "/pickup-point/1/history?before=7",
+			opts:     pickuppoint.HistoryOptions{Before: 7, Limit: defaultHistoryLimit},
+			wantCode: http.StatusOK,
+			wantBody: []byte("null"),
+		},
+		{
+			name:     "invalid id string",
+			idStr:    "dfsdfsf",
+			target:   "/pickup-point/dfsdfsf/history",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid before",
+			idStr:    "1",
+			target:   "/pickup-point/1/history?before=-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid limit",
+			idStr:    "1",
+			target:   "/pickup-point/1/history?limit=0",
+			opts:     pickuppoint.HistoryOptions{Limit: 0},
+			coreErr:  core.ErrInvalidLimit,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "not found",
+			idStr:    "1",
+			target:   "/pickup-point/1/history",
+			opts:     pickuppoint.HistoryOptions{Limit: defaultHistoryLimit},
+			coreErr:  pickuppoint.ErrNoItemFound,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "error",
+			idStr:    "1",
+			target:   "/pickup-point/1/history",
+			opts:     pickuppoint.HistoryOptions{Limit: defaultHistoryLimit},
+			coreErr:  assert.AnError,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			svc := coremocks.NewMockPickUpPointCoreService(ctrl)
+			log := logmocks.NewMockLogger(ctrl)
+			h := &PickUpPointHandlers{
+				svc: svc,
+				log: log,
+			}
+			svc.EXPECT().GetHistory(gomock.Any(), uint64(1), tt.opts).Return(tt.page, tt.coreErr).AnyTimes()
+			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			header := http.Header{}
+			code, body := h.HistoryHandler(req, map[string]string{"id": tt.idStr}, header)
+			s.Equal(tt.wantCode, code)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+			s.Equal(tt.wantLink, header.Get("Link"))
+		})
+	}
+}
+
+func (s *PickUpPointHandlersTestSuite) Test_UtilisationHandler() {
+	point := pickuppoint.PickUpPoint{Id: 1, Capacity: pickuppoint.Capacity{Slots: 10, MaxWeightKg: 50}}
+	tests := []struct {
+		name           string
+		getErr         error
+		utilisation    map[uint64]pickuppoint.Utilisation
+		utilisationErr error
+		wantCode       int
+		wantBody       []byte
+	}{
+		{
+			name:        "ok",
+			utilisation: map[uint64]pickuppoint.Utilisation{1: point.Capacity.Utilisation(10, 20)},
+			wantCode:    http.StatusOK,
+			wantBody:    []byte("{\"capacity\":{\"slots\":10,\"max_weight_kg\":50},\"orders\":10,\"weight_kg\":20,\"full\":true}"),
+		},
+		{
+			name:     "not found",
+			getErr:   pickuppoint.ErrNoItemFound,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:           "error",
+			utilisationErr: assert.AnError,
+			wantCode:       http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			svc := coremocks.NewMockPickUpPointCoreService(ctrl)
+			log := logmocks.NewMockLogger(ctrl)
+			h := &PickUpPointHandlers{
+				svc: svc,
+				log: log,
+			}
+			svc.EXPECT().GetPoint(gomock.Any(), uint64(1)).Return(point, tt.getErr)
+			svc.EXPECT().GetUtilisation(gomock.Any(), []pickuppoint.PickUpPoint{point}).Return(tt.utilisation, tt.utilisationErr).AnyTimes()
+			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			req := httptest.NewRequest(http.MethodGet, "/pickup-point/1/utilisation", nil)
+			code, body := h.UtilisationHandler(req, map[string]string{"id": "1"}, http.Header{})
+			s.Equal(tt.wantCode, code)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+		})
+	}
+}
+
+func (s *PickUpPointHandlersTestSuite) Test_NearestHandler() {
+	location := pickuppoint.Location{Latitude: 55.75, Longitude: 37.62}
+	nearby := []pickuppoint.Nearby{{PickUpPoint: pickuppoint.PickUpPoint{Id: 1, Name: "name", Location: &location}, DistanceKm: 0}}
+	tests := []struct {
+		name        string
+		target      string
+		wantReq     core.NearestPointsRequest
+		callService bool
+		coreErr     error
+		wantCode    int
+		wantBody    []byte
+	}{
+		{
+			name:        "ok",
+			target:      "/pickup-point/nearest?lat=55.75&lon=37.62",
+			wantReq:     core.NearestPointsRequest{Location: location, Limit: defaultNearestLimit},
+			callService: true,
+			wantCode:    http.StatusOK,
+			wantBody:    []byte("[{\"id\":1,\"name\":\"name\",\"address\":{\"country\":\"\",\"city\":\"\",\"street\":\"\",\"building\":\"\"},\"contact\":{},\"schedule\":{},\"location\":{\"lat\":55.75,\"lon\":37.62},\"capacity\":{},\"distance_km\":0}]"),
+		},
+		{
+			name:        "limit and open now",
+			target:      "/pickup-point/nearest?lat=55.75&lon=37.62&limit=1&open_now=true",
+			wantReq:     core.NearestPointsRequest{Location: location, Limit: 1, OpenNow: true},
+			callService: true,
+			wantCode:    http.StatusOK,
+			wantBody:    []byte("[{\"id\":1,\"name\":\"name\",\"address\":{\"country\":\"\",\"city\":\"\",\"street\":\"\",\"building\":\"\"},\"contact\":{},\"schedule\":{},\"location\":{\"lat\":55.75,\"lon\":37.62},\"capacity\":{},\"distance_km\":0}]"),
+		},
+		{
+			name:        "has capacity",
+			target:      "/pickup-point/nearest?lat=55.75&lon=37.62&has_capacity=1",
+			wantReq:     core.NearestPointsRequest{Location: location, Limit: defaultNearestLimit, HasCapacity: true},
+			callService: true,
+			wantCode:    http.StatusOK,
+			wantBody:    []byte("[{\"id\":1,\"name\":\"name\",\"address\":{\"country\":\"\",\"city\":\"\",\"street\":\"\",\"building\":\"\"},\"contact\":{},\"schedule\":{},\"location\":{\"lat\":55.75,\"lon\":37.62},\"capacity\":{},\"distance_km\":0}]"),
+		},
+		{
+			name:     "missing longitude",
+			target:   "/pickup-point/nearest?lat=55.75",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid open now",
+			target:   "/pickup-point/nearest?lat=55.75&lon=37.62&open_now=maybe",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:        "invalid location",
+			target:      "/pickup-point/nearest?lat=55.75&lon=37.62",
+			wantReq:     core.NearestPointsRequest{Location: location, Limit: defaultNearestLimit},
+			callService: true,
+			coreErr:     pickuppoint.ErrInvalidLocation,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "invalid limit",
+			target:      "/pickup-point/nearest?lat=55.75&lon=37.62",
+			wantReq:     core.NearestPointsRequest{Location: location, Limit: defaultNearestLimit},
+			callService: true,
+			coreErr:     core.ErrInvalidLimit,
+			wantCode:    http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			svc := coremocks.NewMockPickUpPointCoreService(ctrl)
+			log := logmocks.NewMockLogger(ctrl)
+			h := &PickUpPointHandlers{
+				svc: svc,
+				log: log,
+			}
+			if tt.callService {
+				svc.EXPECT().NearestPoints(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ any, req core.NearestPointsRequest) ([]pickuppoint.Nearby, error) {
+						s.False(req.At.IsZero())
+						req.At = time.Time{}
+						s.Equal(tt.wantReq, req)
+						if tt.coreErr != nil {
+							return nil, tt.coreErr
+						}
+						return nearby, nil
+					})
+			}
+			log.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			code, body := h.NearestHandler(req, map[string]string{}, http.Header{})
 			s.Equal(tt.wantCode, code)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
 		})
 	}
 }
@@ -2,16 +2,46 @@ package httpserv
 
 import (
 	"context"
+	"crypto/tls"
 	"github.com/gorilla/mux"
 	"golang.org/x/sync/errgroup"
+	"homework/internal/app/auth"
+	"homework/internal/app/idempotency"
+	"homework/internal/app/logger"
+	"homework/internal/app/metrics"
+	"homework/internal/app/middleware"
+	"homework/internal/app/problem"
+	"homework/internal/app/ratelimit"
 	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 )
 
-type Handler func(req *http.Request, vars map[string]string) (int, []byte)
+// Handler handles a request with path variables vars and returns the response code and body.
+// Response headers may be set through header.
+type Handler func(req *http.Request, vars map[string]string, header http.Header) (int, []byte)
 
 type PathHandler struct {
 	Methods map[string]Handler
+	// Roles tells the least role a user needs to call each method, methods missing from it are only allowed to admins.
+	Roles map[string]auth.Role
+	// Limits tells how often each user may call each method, methods missing from it are not limited.
+	Limits map[string]ratelimit.Limit
+}
+
+// roles returns the role required for every method handled.
+func (h PathHandler) roles() map[string]auth.Role {
+	roles := make(map[string]auth.Role, len(h.Methods))
+	for method := range h.Methods {
+		role, ok := h.Roles[method]
+		if !ok {
+			role = auth.Admin
+		}
+		roles[method] = role
+	}
+	return roles
 }
 
 type HttpServerParams struct {
@@ -19,10 +49,21 @@ type HttpServerParams struct {
 	Middlewares  []mux.MiddlewareFunc
 	HttpsAddr    string
 	RedirectAddr string
-	CertFile     string
-	KeyFile      string
-	Username     string
-	Password     string
+	// AdminAddr is where metrics are served over plain HTTP at /metrics, they are not served when it is empty.
+	AdminAddr string
+	CertFile  string
+	KeyFile   string
+	// ClientCAFile is a bundle of CAs verifying client certificates, they are not requested when it is empty.
+	ClientCAFile string
+	// CertReloadInterval tells how often certificate files are checked for changes, they are not reloaded when it is zero.
+	CertReloadInterval time.Duration
+	Log                logger.Logger
+	// Limiter enforces the limits of handlers, requests are not limited when it is nil.
+	Limiter middleware.RateLimiter
+	// Idempotency keeps responses to POST requests with idempotency keys for IdempotencyTTL,
+	// the keys are ignored when it is nil.
+	Idempotency    idempotency.Store
+	IdempotencyTTL time.Duration
 }
 
 type HttpServer struct {
@@ -36,7 +77,14 @@ func NewHttpServer(params HttpServerParams) *HttpServer {
 func (s *HttpServer) Serve(ctx context.Context) error {
 	router := mux.NewRouter()
 	for path, pathHandler := range s.params.Handlers {
-		router.HandleFunc(path, s.makeHandlerFunc(pathHandler))
+		var h http.Handler = s.makeHandlerFunc(pathHandler)
+		if s.params.Idempotency != nil {
+			h = middleware.IdempotencyMiddleware(s.params.Idempotency, path, s.params.IdempotencyTTL, s.params.Log)(h)
+		}
+		if s.params.Limiter != nil {
+			h = middleware.RateLimitMiddleware(s.params.Limiter, path, pathHandler.Limits, s.params.Log)(h)
+		}
+		router.Handle(path, middleware.RoleMiddleware(pathHandler.roles())(h))
 	}
 	for _, middleware := range s.params.Middlewares {
 		router.Use(middleware)
@@ -47,9 +95,15 @@ func (s *HttpServer) Serve(ctx context.Context) error {
 		return err
 	}
 
+	certs, err := newCertReloader(s.params.CertFile, s.params.KeyFile, s.params.ClientCAFile, s.params.Log)
+	if err != nil {
+		return err
+	}
+
 	httpsServer := http.Server{
-		Addr:    s.params.HttpsAddr,
-		Handler: router,
+		Addr:      s.params.HttpsAddr,
+		Handler:   router,
+		TLSConfig: &tls.Config{GetCertificate: certs.getCertificate, GetConfigForClient: certs.getConfigForClient},
 	}
 	redirectServer := http.Server{
 		Addr: s.params.RedirectAddr,
@@ -66,15 +120,32 @@ func (s *HttpServer) Serve(ctx context.Context) error {
 		}),
 	}
 
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/metrics", metrics.Handler())
+	adminServer := http.Server{
+		Addr:    s.params.AdminAddr,
+		Handler: adminMux,
+	}
+
 	eg, ctx := errgroup.WithContext(ctx)
 
 	eg.Go(func() error {
-		return httpsServer.ListenAndServeTLS(s.params.CertFile, s.params.KeyFile)
+		// certificates are served by the config
+		return httpsServer.ListenAndServeTLS("", "")
 	})
 	eg.Go(redirectServer.ListenAndServe)
+	if s.params.CertReloadInterval > 0 {
+		eg.Go(func() error {
+			return certs.run(ctx, s.params.CertReloadInterval)
+		})
+	}
+	if s.params.AdminAddr != "" {
+		eg.Go(adminServer.ListenAndServe)
+	}
 
 	eg.Go(func() error {
 		<-ctx.Done()
+		adminServer.Shutdown(context.Background())
 		redirectServer.Shutdown(context.Background())
 		httpsServer.Shutdown(context.Background())
 		return nil
@@ -84,23 +155,28 @@ func (s *HttpServer) Serve(ctx context.Context) error {
 }
 
 func (s *HttpServer) makeHandlerFunc(pathHandler PathHandler) http.HandlerFunc {
-	var methods string
+	allowed := make([]string, 0, len(pathHandler.Methods))
 	for method := range pathHandler.Methods {
-		if methods == "" {
-			methods = method
-			continue
-		}
-		methods = methods + ", " + method
+		allowed = append(allowed, method)
 	}
+	slices.Sort(allowed)
+	methods := strings.Join(allowed, ", ")
 	return func(w http.ResponseWriter, req *http.Request) {
 		var code int
 		var body []byte
 		handler, ok := pathHandler.Methods[req.Method]
 		if ok {
-			code, body = handler(req, mux.Vars(req))
+			code, body = handler(req, mux.Vars(req), w.Header())
 		} else {
 			w.Header().Set("Allow", methods)
-			code = http.StatusMethodNotAllowed
+			code, body = problem.Response(req, w.Header(), http.StatusMethodNotAllowed, problem.Problem{
+				Code:    "method_not_allowed",
+				Message: "method must be one of " + methods,
+			})
+		}
+		// handlers only set the type of bodies that are not JSON
+		if body != nil && w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", jsonContentType)
 		}
 		w.WriteHeader(code)
 		if body != nil {
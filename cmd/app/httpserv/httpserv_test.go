@@ -0,0 +1,96 @@
+package httpserv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"homework/internal/app/auth"
+	"homework/internal/app/requestid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathHandlerRoles(t *testing.T) {
+	handler := func(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+		return http.StatusOK, nil
+	}
+	h := PathHandler{
+		Methods: map[string]Handler{
+			http.MethodGet:    handler,
+			http.MethodPost:   handler,
+			http.MethodDelete: handler,
+		},
+		Roles: map[string]auth.Role{
+			http.MethodGet:  auth.Viewer,
+			http.MethodPost: auth.Operator,
+			http.MethodPut:  auth.Viewer,
+		},
+	}
+	assert.Equal(t, map[string]auth.Role{
+		http.MethodGet:    auth.Viewer,
+		http.MethodPost:   auth.Operator,
+		http.MethodDelete: auth.Admin,
+	}, h.roles())
+}
+
+func TestMakeHandlerFunc(t *testing.T) {
+	s := NewHttpServer(HttpServerParams{})
+	h := s.makeHandlerFunc(PathHandler{
+		Methods: map[string]Handler{
+			http.MethodGet: func(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+				return http.StatusOK, []byte(`{"id":1}`)
+			},
+			http.MethodPost: func(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+				header.Set("Content-Type", "text/csv")
+				return http.StatusOK, []byte("id\n1\n")
+			},
+			http.MethodDelete: func(req *http.Request, vars map[string]string, header http.Header) (int, []byte) {
+				return http.StatusNoContent, nil
+			},
+		},
+	})
+	tests := []struct {
+		name        string
+		method      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "json body",
+			method:      http.MethodGet,
+			code:        http.StatusOK,
+			contentType: "application/json",
+			body:        `{"id":1}`,
+		},
+		{
+			name:        "typed body",
+			method:      http.MethodPost,
+			code:        http.StatusOK,
+			contentType: "text/csv",
+			body:        "id\n1\n",
+		},
+		{
+			name:   "no body",
+			method: http.MethodDelete,
+			code:   http.StatusNoContent,
+		},
+		{
+			name:        "method not allowed",
+			method:      http.MethodPut,
+			code:        http.StatusMethodNotAllowed,
+			contentType: "application/problem+json",
+			body:        `{"code":"method_not_allowed","message":"method must be one of DELETE, GET, POST","request_id":"42"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/pickup-point", nil)
+			req = req.WithContext(requestid.With(req.Context(), "42", requestid.NewTraceparent()))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
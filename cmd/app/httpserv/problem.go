@@ -0,0 +1,107 @@
+package httpserv
+
+import (
+	"errors"
+	"homework/internal/app/auth"
+	"homework/internal/app/core"
+	"homework/internal/app/logger"
+	"homework/internal/app/pickuppoint"
+	"homework/internal/app/problem"
+	"net/http"
+)
+
+const jsonContentType = "application/json"
+
+// errorProblems tells the status and the code of problems caused by known errors of the services.
+var errorProblems = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{pickuppoint.ErrNoItemFound, http.StatusNotFound, "not_found"},
+	{pickuppoint.ErrIdAlreadyExists, http.StatusConflict, "already_exists"},
+	{pickuppoint.ErrNotDeleted, http.StatusConflict, "not_deleted"},
+	{pickuppoint.ErrChanged, http.StatusConflict, "try_again"},
+	{pickuppoint.ErrFull, http.StatusConflict, "point_full"},
+	{pickuppoint.ErrVersionMismatch, http.StatusPreconditionFailed, "version_mismatch"},
+	{pickuppoint.ErrInvalidSchedule, http.StatusBadRequest, "invalid_schedule"},
+	{pickuppoint.ErrInvalidLocation, http.StatusBadRequest, "invalid_location"},
+	{pickuppoint.ErrInvalidCapacity, http.StatusBadRequest, "invalid_capacity"},
+	{pickuppoint.ErrInvalidPatch, http.StatusBadRequest, "invalid_patch"},
+	{pickuppoint.ErrInvalidSort, http.StatusBadRequest, "invalid_sort"},
+	{pickuppoint.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+	{core.ErrUnexpectedId, http.StatusBadRequest, "unexpected_id"},
+	{core.ErrIdRequired, http.StatusBadRequest, "id_required"},
+	{core.ErrIdChanged, http.StatusBadRequest, "id_changed"},
+	{core.ErrInvalidLimit, http.StatusBadRequest, "invalid_limit"},
+	{core.ErrBatchTooLarge, http.StatusRequestEntityTooLarge, "batch_too_large"},
+	{auth.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+	{auth.ErrInvalidToken, http.StatusBadRequest, "invalid_token"},
+	{auth.ErrTokensDisabled, http.StatusNotImplemented, "tokens_disabled"},
+	{auth.ErrNoKey, http.StatusNotFound, "not_found"},
+}
+
+// badRequest returns a 400 Bad Request problem with the code and the message.
+func badRequest(req *http.Request, header http.Header, code string, message string) (int, []byte) {
+	return problem.Response(req, header, http.StatusBadRequest, problem.Problem{Code: code, Message: message})
+}
+
+// invalidId returns the problem of an id in the path that is not a number.
+func invalidId(req *http.Request, header http.Header) (int, []byte) {
+	return badRequest(req, header, "invalid_id", "id must be a non-negative integer")
+}
+
+// invalidParameter returns the problem of a malformed query parameter.
+func invalidParameter(req *http.Request, header http.Header, name string) (int, []byte) {
+	return badRequest(req, header, "invalid_parameter", "query parameter "+name+" is malformed")
+}
+
+// invalidBody returns the problem of a request body that could not be decoded.
+func invalidBody(req *http.Request, header http.Header, err error) (int, []byte) {
+	return badRequest(req, header, "invalid_body", "request body is malformed: "+err.Error())
+}
+
+// invalidIfMatch returns the problem of an If-Match header that no pick-up point version could match.
+func invalidIfMatch(req *http.Request, header http.Header) (int, []byte) {
+	return problem.Response(req, header, http.StatusPreconditionFailed, problem.Problem{
+		Code:    "invalid_if_match",
+		Message: "If-Match must be a single entity tag returned by the server",
+	})
+}
+
+// unsupportedMediaType returns the problem of a request body of a type the handler does not read.
+func unsupportedMediaType(req *http.Request, header http.Header, supported string) (int, []byte) {
+	return problem.Response(req, header, http.StatusUnsupportedMediaType, problem.Problem{
+		Code:    "unsupported_media_type",
+		Message: "Content-Type must be " + supported,
+	})
+}
+
+// problemFields returns the invalid fields of a pick-up point as fields of a problem.
+func problemFields(fields []pickuppoint.FieldError) []problem.Field {
+	result := make([]problem.Field, 0, len(fields))
+	for _, f := range fields {
+		result = append(result, problem.Field{Field: f.Field, Message: f.Message})
+	}
+	return result
+}
+
+// errorResponse returns the problem caused by an error of a service.
+// Unknown errors are logged and reported as internal errors without details.
+func errorResponse(req *http.Request, header http.Header, log logger.Logger, err error) (int, []byte) {
+	var validationErr *pickuppoint.ValidationError
+	if errors.As(err, &validationErr) {
+		return problem.Response(req, header, http.StatusUnprocessableEntity, problem.Problem{
+			Code:    "invalid_point",
+			Message: pickuppoint.ErrInvalidPoint.Error(),
+			Fields:  problemFields(validationErr.Fields),
+		})
+	}
+	for _, p := range errorProblems {
+		if errors.Is(err, p.err) {
+			return problem.Response(req, header, p.status, problem.Problem{Code: p.code, Message: err.Error()})
+		}
+	}
+	logger.WithContext(req.Context(), log).Log("%v", err)
+	return problem.Response(req, header, http.StatusInternalServerError, problem.Problem{Code: "internal_error", Message: "internal server error"})
+}
@@ -0,0 +1,81 @@
+package httpserv
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/auth"
+	"homework/internal/app/core"
+	logmocks "homework/internal/app/logger/mocks"
+	"homework/internal/app/pickuppoint"
+	"homework/internal/app/requestid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+		logged   bool
+	}{
+		{
+			name:     "not found",
+			err:      fmt.Errorf("get: %w", pickuppoint.ErrNoItemFound),
+			wantCode: http.StatusNotFound,
+			wantBody: `{"code":"not_found","message":"get: no such item found","request_id":"42"}`,
+		},
+		{
+			name:     "version mismatch",
+			err:      pickuppoint.ErrVersionMismatch,
+			wantCode: http.StatusPreconditionFailed,
+			wantBody: `{"code":"version_mismatch","message":"item version does not match the expected one","request_id":"42"}`,
+		},
+		{
+			name:     "batch too large",
+			err:      core.ErrBatchTooLarge,
+			wantCode: http.StatusRequestEntityTooLarge,
+			wantBody: `{"code":"batch_too_large","message":"batch must not have more than 1000 pick-up points","request_id":"42"}`,
+		},
+		{
+			name:     "tokens disabled",
+			err:      auth.ErrTokensDisabled,
+			wantCode: http.StatusNotImplemented,
+			wantBody: `{"code":"tokens_disabled","message":"access tokens are disabled, no signing keys are configured","request_id":"42"}`,
+		},
+		{
+			name: "invalid point",
+			err: fmt.Errorf("update: %w", &pickuppoint.ValidationError{Fields: []pickuppoint.FieldError{
+				{Field: "name", Message: "must not be empty"},
+			}}),
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `{"code":"invalid_point","message":"invalid pick-up point","fields":[{"field":"name","message":"must not be empty"}],"request_id":"42"}`,
+		},
+		{
+			name:     "unexpected error",
+			err:      fmt.Errorf("connection to 10.0.0.1 refused"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":"internal_error","message":"internal server error","request_id":"42"}`,
+			logged:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			log := logmocks.NewMockLogger(ctrl)
+			if tt.logged {
+				log.EXPECT().Log("[request %s] %v", "42", tt.err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/pickup-point/1", nil)
+			req = req.WithContext(requestid.With(req.Context(), "42", requestid.NewTraceparent()))
+			header := http.Header{}
+			code, body := errorResponse(req, header, log, tt.err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantBody, string(body))
+			assert.Equal(t, "application/problem+json", header.Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package httpserv
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"homework/internal/app/logger"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// certReloader serves the server certificate and the client CA bundle read from files,
+// checking them on a ticker and reading them again after any of them has changed, so that they are renewed without a restart.
+// When the new files cannot be loaded, the ones loaded before are served on.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	// caFile is a bundle of CAs verifying client certificates, they are not requested when it is empty.
+	caFile string
+	log    logger.Logger
+	// modTimes are only used by reload, which is never run concurrently.
+	modTimes []time.Time
+	// config is served to handshakes without locking, it is replaced whole on reload.
+	config atomic.Pointer[tls.Config]
+}
+
+func newCertReloader(certFile string, keyFile string, caFile string, log logger.Logger) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, caFile: caFile, log: log}
+	modTimes, err := r.stat()
+	if err != nil {
+		return nil, err
+	}
+	config, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.config.Store(config)
+	r.modTimes = modTimes
+	return r, nil
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+func (r *certReloader) stat() ([]time.Time, error) {
+	files := r.files()
+	modTimes := make([]time.Time, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+func (r *certReloader) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if r.caFile == "" {
+		return config, nil
+	}
+	bundle, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("no CA certificates found in %s", r.caFile)
+	}
+	config.ClientCAs = pool
+	// clients without certificates may still authenticate with passwords or tokens
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	return config, nil
+}
+
+// run reloads the files every interval until ctx is done.
+func (r *certReloader) run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			r.reload()
+		}
+	}
+}
+
+// reload loads the files again when any of them has changed since they were loaded.
+func (r *certReloader) reload() {
+	modTimes, err := r.stat()
+	if err == nil && !slices.Equal(modTimes, r.modTimes) {
+		var config *tls.Config
+		config, err = r.load()
+		if err == nil {
+			r.config.Store(config)
+		}
+		// a file being rewritten is tried again once it changes next
+		r.modTimes = modTimes
+	}
+	if err != nil {
+		r.log.Log("reloading TLS certificates failed, serving the ones loaded before: %v", err)
+	}
+}
+
+// getConfigForClient returns the config loaded from the files last.
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return r.config.Load(), nil
+}
+
+// getCertificate returns the server certificate loaded from the files last.
+func (r *certReloader) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	config, err := r.getConfigForClient(hello)
+	if err != nil {
+		return nil, err
+	}
+	return &config.Certificates[0], nil
+}
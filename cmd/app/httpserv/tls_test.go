@@ -0,0 +1,219 @@
+package httpserv
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	logmocks "homework/internal/app/logger/mocks"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type issuedCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate with the subject signed by parent, or a self-signed CA when parent is nil.
+func issue(s *suite.Suite, subject string, serial int64, parent *issuedCert) issuedCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: subject},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer := issuedCert{cert: template, key: key}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer = *parent
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer.cert, &key.PublicKey, signer.key)
+	s.Require().NoError(err)
+	cert, err := x509.ParseCertificate(der)
+	s.Require().NoError(err)
+	return issuedCert{cert: cert, key: key}
+}
+
+func (c issuedCert) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+func (c issuedCert) keyPEM(s *suite.Suite) []byte {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	s.Require().NoError(err)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c issuedCert) tlsCert(s *suite.Suite) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM(), c.keyPEM(s))
+	s.Require().NoError(err)
+	return cert
+}
+
+type CertReloaderTestSuite struct {
+	suite.Suite
+	dir      string
+	ca       issuedCert
+	reloader *certReloader
+	listener net.Listener
+	verified chan [][]*x509.Certificate
+}
+
+func (s *CertReloaderTestSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	s.ca = issue(&s.Suite, "ca", 1, nil)
+	s.writeServerCert(2, time.Now().Add(-time.Minute))
+	s.write("ca.crt", s.ca.certPEM(), time.Now().Add(-time.Minute))
+
+	var err error
+	s.reloader, err = newCertReloader(s.path("server.crt"), s.path("server.key"), s.path("ca.crt"), logmocks.NewMockLogger(gomock.NewController(s.T())))
+	s.Require().NoError(err)
+	s.listener, err = tls.Listen("tcp", "127.0.0.1:0", &tls.Config{GetCertificate: s.reloader.getCertificate, GetConfigForClient: s.reloader.getConfigForClient})
+	s.Require().NoError(err)
+	s.verified = make(chan [][]*x509.Certificate, 1)
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			tlsConn := conn.(*tls.Conn)
+			if tlsConn.Handshake() == nil {
+				s.verified <- tlsConn.ConnectionState().VerifiedChains
+			}
+			conn.Close()
+		}
+	}()
+}
+
+func (s *CertReloaderTestSuite) TearDownTest() {
+	s.listener.Close()
+}
+
+func (s *CertReloaderTestSuite) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+func (s *CertReloaderTestSuite) write(name string, data []byte, modTime time.Time) {
+	s.Require().NoError(os.WriteFile(s.path(name), data, 0600))
+	s.Require().NoError(os.Chtimes(s.path(name), modTime, modTime))
+}
+
+func (s *CertReloaderTestSuite) writeServerCert(serial int64, modTime time.Time) {
+	server := issue(&s.Suite, "localhost", serial, &s.ca)
+	s.write("server.crt", server.certPEM(), modTime)
+	s.write("server.key", server.keyPEM(&s.Suite), modTime)
+}
+
+// dial returns the serial number of the server certificate and the client certificate chains verified by the server.
+func (s *CertReloaderTestSuite) dial(clientCerts ...tls.Certificate) (int64, [][]*x509.Certificate, error) {
+	roots := x509.NewCertPool()
+	roots.AddCert(s.ca.cert)
+	conn, err := tls.Dial("tcp", s.listener.Addr().String(), &tls.Config{
+		RootCAs:      roots,
+		ServerName:   "localhost",
+		Certificates: clientCerts,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	defer conn.Close()
+	// the server verifies the client certificate after the client has finished its part of the handshake
+	_, err = conn.Read(make([]byte, 1))
+	serial := conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	select {
+	case verified := <-s.verified:
+		return serial, verified, nil
+	case <-time.After(time.Second):
+		return serial, nil, err
+	}
+}
+
+func (s *CertReloaderTestSuite) Test_ClientCertificates() {
+	_, verified, err := s.dial()
+	s.Require().NoError(err)
+	s.Empty(verified, "clients without certificates are let through")
+
+	client := issue(&s.Suite, "svc", 10, &s.ca)
+	_, verified, err = s.dial(client.tlsCert(&s.Suite))
+	s.Require().NoError(err)
+	s.Require().NotEmpty(verified)
+	s.Equal("svc", verified[0][0].Subject.CommonName)
+
+	otherCA := issue(&s.Suite, "other", 1, nil)
+	stranger := issue(&s.Suite, "svc", 11, &otherCA)
+	_, verified, err = s.dial(stranger.tlsCert(&s.Suite))
+	s.Require().NoError(err, "certificates of unknown CAs are not sent")
+	s.Empty(verified)
+}
+
+func (s *CertReloaderTestSuite) Test_Reload() {
+	serial, _, err := s.dial()
+	s.Require().NoError(err)
+	s.Equal(int64(2), serial)
+
+	s.writeServerCert(3, time.Now())
+	s.reloader.reload()
+	serial, _, err = s.dial()
+	s.Require().NoError(err)
+	s.Equal(int64(3), serial)
+
+	newCA := issue(&s.Suite, "new ca", 1, nil)
+	s.write("ca.crt", append(s.ca.certPEM(), newCA.certPEM()...), time.Now().Add(time.Second))
+	s.reloader.reload()
+	client := issue(&s.Suite, "svc", 10, &newCA)
+	_, verified, err := s.dial(client.tlsCert(&s.Suite))
+	s.Require().NoError(err)
+	s.NotEmpty(verified, "clients of a CA added to the bundle are verified")
+}
+
+func (s *CertReloaderTestSuite) Test_ReloadFailure() {
+	log := logmocks.NewMockLogger(gomock.NewController(s.T()))
+	log.EXPECT().Log(gomock.Any(), gomock.Any()).MinTimes(1)
+	s.reloader.log = log
+
+	s.write("server.key", []byte("broken"), time.Now())
+	s.reloader.reload()
+	serial, _, err := s.dial()
+	s.Require().NoError(err)
+	s.Equal(int64(2), serial, "the certificate loaded before is served on")
+}
+
+func (s *CertReloaderTestSuite) Test_Run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.reloader.run(ctx, 10*time.Millisecond)
+	}()
+
+	s.writeServerCert(3, time.Now())
+	s.Eventually(func() bool {
+		serial, _, err := s.dial()
+		return err == nil && serial == 3
+	}, time.Second, 10*time.Millisecond, "changed files are reloaded on the ticker")
+
+	cancel()
+	s.NoError(<-done)
+}
+
+func TestCertReloader(t *testing.T) {
+	suite.Run(t, new(CertReloaderTestSuite))
+}
@@ -2,23 +2,31 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"homework/cmd/app/commands"
+	"homework/internal/app/auth"
 	"homework/internal/app/core"
-	"homework/internal/app/db"
 	"homework/internal/app/logger"
 	"homework/internal/app/order"
 	"homework/internal/app/packaging"
 	"homework/internal/app/pickuppoint"
+	"homework/internal/app/storage"
 	"log"
 	"os"
 )
 
 const (
-	ORDERS_FILEPATH = "orders.json"
-	POINTS_FILEPATH = "points.json"
-	filePerm        = 0777
-	topic           = "requests"
+	ORDERS_FILEPATH  = "orders.json"
+	POINTS_FILEPATH  = "points.json"
+	HISTORY_FILEPATH = "points_history.json"
+	USERS_FILEPATH   = "users.json"
+	KEYS_FILEPATH    = "api_keys.json"
+	REVOKED_FILEPATH = "revoked_tokens.json"
+	STORAGE_FILEPATH = "storage.db"
+	filePerm         = 0777
+	topic            = "requests"
 )
 
 func main() {
@@ -28,40 +36,77 @@ func main() {
 	}
 }
 
+func getEnv(key string, defaultValue string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
 func run() error {
 	helpCommand := func(args []string) error {
 		help()
 		return nil
 	}
 
+	cfg := storage.Config{
+		OrdersFile:  ORDERS_FILEPATH,
+		PointsFile:  POINTS_FILEPATH,
+		HistoryFile: HISTORY_FILEPATH,
+		UsersFile:   USERS_FILEPATH,
+		KeysFile:    KEYS_FILEPATH,
+		RevokedFile: REVOKED_FILEPATH,
+		FilePerm:    filePerm,
+	}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.Usage = help
+	fs.StringVar(&cfg.Type, "storage", getEnv("STORAGE", ""), "specify storage backend")
+	fs.StringVar(&cfg.BoltFile, "storage-path", getEnv("STORAGE_PATH", STORAGE_FILEPATH), "specify embedded storage file")
+	err := fs.Parse(os.Args[1:])
+	if err != nil {
+		return err
+	}
+
 	logCtx, stopLog := context.WithCancel(context.Background())
 	defer stopLog()
 	log := logger.NewLogger()
 	go log.Run(logCtx)
 
-	pointFileRepo, closePointFileRepo, err := initPickUpPointFileRepository(POINTS_FILEPATH, filePerm)
-	if err != nil {
-		return err
+	// every command uses the same storage, the file one when none is configured
+	implicitStorage := cfg.Type == ""
+	if implicitStorage {
+		cfg.Type = storage.File
 	}
-	defer closePointFileRepo()
 
-	cliCommands := commands.NewPickUpPointCliConsoleCommands(
-		core.NewPickUpPointCoreService(pickuppoint.NewService(pointFileRepo, db.Dummy{}), log),
-		helpCommand,
-	)
-
-	tm, err := db.NewTransactionManager(context.Background())
-	if err != nil {
-		return err
+	// withStorage opens the configured storage only when the command is run.
+	withStorage := func(cmd func(store *storage.Storage) commands.Command) commands.Command {
+		return func(args []string) error {
+			store, err := storage.Open(context.Background(), cfg)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				err := store.Close()
+				if err != nil {
+					log.Log("%v", err)
+				}
+			}()
+			return cmd(store)(args)
+		}
 	}
 
-	apiCommands := commands.NewPickUpPointApiConsoleCommands(core.NewPickUpPointCoreService(pickuppoint.NewService(pickuppoint.NewPostgresRepository(db.NewDatabase(tm)), tm), log), log, helpCommand, topic)
+	pickUpPoints := func(store *storage.Storage) *pickuppoint.Service {
+		svc := pickuppoint.NewService(store.Points, store.Tm)
+		svc.SetHistory(store.History)
+		return svc
+	}
 
-	orderFileRepo, closeOrderFileRepo, err := initOrderFileRepository(ORDERS_FILEPATH, filePerm)
-	if err != nil {
-		return err
+	pointService := func(store *storage.Storage) core.PickUpPointCoreService {
+		svc := core.NewPickUpPointCoreService(pickUpPoints(store), log)
+		svc.SetOccupancy(order.NewService(store.Orders, store.Tm))
+		return svc
 	}
-	defer closeOrderFileRepo()
 
 	packagingTypes := map[packaging.Type]packaging.Packaging{
 		packaging.BagType:  packaging.Bag{},
@@ -69,87 +114,98 @@ func run() error {
 		packaging.FilmType: packaging.Film{},
 	}
 
-	orderCommands := commands.NewOrderConsoleCommands(
-		core.NewOrderCoreService(order.NewService(orderFileRepo), packagingTypes),
-		helpCommand,
-	)
-
-	cmdMap := map[string]commands.Command{
-		"help":                  helpCommand,
-		"manage-pickup-points":  cliCommands.ManagePickUpPointsCommand,
-		"run-pickup-points-api": apiCommands.RunPickUpPointApi,
-		"accept-order":          orderCommands.AcceptOrderCommand,
-		"return-order":          orderCommands.ReturnOrderCommand,
-		"give-orders":           orderCommands.GiveOrdersCommand,
-		"list-orders":           orderCommands.ListOrdersCommand,
-		"accept-return":         orderCommands.AcceptReturnCommand,
-		"list-returns":          orderCommands.ListReturnsCommand,
+	orderCommands := func(store *storage.Storage) *commands.OrderConsoleCommands {
+		return commands.NewOrderConsoleCommands(
+			core.NewOrderCoreService(order.NewService(store.Orders, store.Tm), pickUpPoints(store), packagingTypes),
+			helpCommand,
+		)
 	}
-	return commands.Run(cmdMap)
-}
 
-func initPickUpPointFileRepository(pointsFilePath string, filePerm os.FileMode) (*pickuppoint.FileRepository, func(), error) {
-	file, err := os.OpenFile(pointsFilePath, os.O_CREATE|os.O_RDONLY, filePerm)
-	if err != nil {
-		return nil, nil, err
-	}
-	repo, err := pickuppoint.NewFileRepository(file)
-	if err != nil {
-		closeErr := file.Close()
-		if closeErr != nil {
-			log.Println(closeErr)
-		}
-		return nil, nil, err
-	}
-	err = file.Close()
-	if err != nil {
-		return nil, nil, err
-	}
-	f := func() {
-		file, err := os.OpenFile(POINTS_FILEPATH, os.O_CREATE|os.O_WRONLY, filePerm)
-		if err != nil {
-			log.Println(err)
-		}
-		err = repo.Close(file)
-		if err != nil {
-			log.Println(err)
-		}
+	users := func(store *storage.Storage) *auth.Service {
+		return auth.NewService(store.Users, store.Keys, store.Revoked, store.Tm)
 	}
-	return repo, f, nil
-}
 
-func initOrderFileRepository(orderFilePath string, filePerm os.FileMode) (*order.FileRepository, func(), error) {
-	file, err := os.OpenFile(orderFilePath, os.O_CREATE|os.O_RDONLY, filePerm)
-	if err != nil {
-		return nil, nil, err
+	userCommands := func(store *storage.Storage) *commands.UserConsoleCommands {
+		return commands.NewUserConsoleCommands(users(store), helpCommand)
 	}
-	repo, err := order.NewFileRepository(file)
-	if err != nil {
-		closeErr := file.Close()
-		if closeErr != nil {
-			log.Println(closeErr)
+
+	// requireStorage refuses to run cmd on the default storage, so that it does not serve data
+	// other than the one expected by accident.
+	requireStorage := func(cmd commands.Command) commands.Command {
+		return func(args []string) error {
+			if implicitStorage {
+				return errors.New("storage must be set with --storage or $STORAGE to run the API")
+			}
+			return cmd(args)
 		}
-		return nil, nil, err
-	}
-	err = file.Close()
-	if err != nil {
-		return nil, nil, err
 	}
-	f := func() {
-		file, err := os.OpenFile(ORDERS_FILEPATH, os.O_CREATE|os.O_WRONLY, filePerm)
-		if err != nil {
-			log.Println(err)
-		}
-		err = repo.Close(file)
-		if err != nil {
-			log.Println(err)
-		}
+
+	storageCommands := commands.NewStorageConsoleCommands(cfg, helpCommand)
+
+	cmdMap := map[string]commands.Command{
+		"help": helpCommand,
+		"manage-pickup-points": withStorage(func(store *storage.Storage) commands.Command {
+			return commands.NewPickUpPointCliConsoleCommands(pointService(store), log, helpCommand).ManagePickUpPointsCommand
+		}),
+		"run-pickup-points-api": requireStorage(withStorage(func(store *storage.Storage) commands.Command {
+			api := commands.NewPickUpPointApiConsoleCommands(pointService(store), users(store), log, helpCommand, topic)
+			api.SetIdempotencyStore(store.Idempotency)
+			return api.RunPickUpPointApi
+		})),
+		"accept-order": withStorage(func(store *storage.Storage) commands.Command {
+			return orderCommands(store).AcceptOrderCommand
+		}),
+		"return-order": withStorage(func(store *storage.Storage) commands.Command {
+			return orderCommands(store).ReturnOrderCommand
+		}),
+		"give-orders": withStorage(func(store *storage.Storage) commands.Command {
+			return orderCommands(store).GiveOrdersCommand
+		}),
+		"list-orders": withStorage(func(store *storage.Storage) commands.Command {
+			return orderCommands(store).ListOrdersCommand
+		}),
+		"accept-return": withStorage(func(store *storage.Storage) commands.Command {
+			return orderCommands(store).AcceptReturnCommand
+		}),
+		"list-returns": withStorage(func(store *storage.Storage) commands.Command {
+			return orderCommands(store).ListReturnsCommand
+		}),
+		"import-points": withStorage(func(store *storage.Storage) commands.Command {
+			return commands.NewPickUpPointBatchConsoleCommands(pointService(store), helpCommand).ImportPointsCommand
+		}),
+		"export-points": withStorage(func(store *storage.Storage) commands.Command {
+			return commands.NewPickUpPointBatchConsoleCommands(pointService(store), helpCommand).ExportPointsCommand
+		}),
+		"add-user": withStorage(func(store *storage.Storage) commands.Command {
+			return userCommands(store).AddUserCommand
+		}),
+		"set-password": withStorage(func(store *storage.Storage) commands.Command {
+			return userCommands(store).SetPasswordCommand
+		}),
+		"set-role": withStorage(func(store *storage.Storage) commands.Command {
+			return userCommands(store).SetRoleCommand
+		}),
+		"disable-user": withStorage(func(store *storage.Storage) commands.Command {
+			return userCommands(store).DisableUserCommand
+		}),
+		"rotate-token-key": func(args []string) error {
+			return commands.NewUserConsoleCommands(nil, helpCommand).RotateTokenKeyCommand(args)
+		},
+		"migrate-storage": storageCommands.MigrateStorageCommand,
 	}
-	return repo, f, nil
+	return commands.Run(cmdMap, fs.Args())
 }
 
 func help() {
-	fmt.Fprintln(os.Stderr, `Available commands:
+	fmt.Fprintln(os.Stderr, `Usage: [--storage <storage>] [--storage-path <path>] <command> [<args>]
+
+Global options:
+	--storage		specify storage backend used by every command: file (JSON files),
+				bolt (embedded key-value store) or postgres, default: $STORAGE or file;
+				run-pickup-points-api requires it to be set explicitly
+	--storage-path	specify embedded key-value store file, default: $STORAGE_PATH or storage.db
+
+Available commands:
 
 	help
 		Show this help message
@@ -158,23 +214,94 @@ func help() {
 		Starts interactive mode for managing pick-up points
 
 	run-pickup-points-api
-		Starts a HTTPS API server for managing pick-up points
+		Starts a HTTPS API server for managing pick-up points,
+		requests are authenticated with Basic auth against users added by add-user,
+		or with access tokens from POST /auth/token and API keys from POST /auth/keys,
+		viewers may only read pick-up points, operators may also create and change them,
+		admins may also delete, restore and import them
 		--https-address		specify HTTPS listen address, default: :9443
 		--redirect-address	specify redirect listen address, default: :9000
+		--admin-address		specify plain HTTP listen address serving Prometheus metrics at /metrics,
+						empty disables it, default: :9100
 		--tls-cert			specify TLS certificate file, default: server.crt
 		--tls-key			specify TLS certificate key file, default: server.key
-		--username			specify access control username, default: user
-		--password			specify access control password, default: testpassword
+		--client-ca			specify CA bundle verifying client certificates, enables mutual TLS:
+						a verified certificate authenticates the user its subject is mapped to by --client-cert-users
+		--client-cert-users	specify JSON file mapping client certificate subjects to user names, required with --client-ca,
+						certificates with subjects not mapped are rejected
+		--tls-reload-interval	specify how often certificates, keys and the CA bundle are checked, they are read again
+						when changed, without a restart, 0 disables reloading, default: 10s
+		--token-keys		specify access token signing keys file made by rotate-token-key,
+						access tokens are disabled when it does not exist, default: token_keys.json
+		--token-ttl			specify how long access tokens are valid, default: 15m
+		--token-keys-interval	specify how often the signing keys file is checked, keys are rotated
+						without a restart once it changes, 0 disables reloading, default: 10s
 		--brokers			specify broker addresses, separated by comma, default: 127.0.0.1:9091,127.0.0.1:9092,127.0.0.1:9093
+		--deleted-retention	specify how long deleted pick-up points are kept before purging, 0 keeps them forever, default: 720h
+		--purge-interval	specify how often deleted pick-up points are purged, default: 1h
+		--read-rate-limit	specify how often each user may read, as <requests>/<period>[:<burst>],
+						0 disables the limit, default: 20/1s:40
+		--write-rate-limit	specify how often each user may change pick-up points and access credentials, default: 5/1s:10
+		--batch-rate-limit	specify how often each user may apply batches, default: 1/1m0s:2
+		--client-rate-limit	specify how often each client address may make requests, checked before authentication,
+						so that it limits failed logins too, default: 10/1s:50
+		--shared-rate-limit	share rate limits with every instance using the same Redis
+		--idempotency-store	specify where responses to POST requests with an Idempotency-Key header are kept:
+						redis, postgres (with postgres storage only) or none, default: redis
+		--idempotency-ttl	specify how long responses to requests with idempotency keys are kept, default: 24h
+		--trace-exporter	specify where spans of requests, Postgres, Redis and Kafka calls are exported:
+						otlp (OpenTelemetry collector over HTTP) or none, default: none
+		--trace-endpoint	specify OTLP collector URL, default: $OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318
+
+	import-points --file <path> [--format <format>] [--dry-run]
+		Creates pick-up points from a CSV or JSON file in a single transaction, all of them or none,
+		and reports every row
+		--file			specify file to import, - for standard input
+		--format		specify file format: csv or json, default: file extension or json
+		--dry-run		only check pick-up points without creating them
+
+	export-points [--file <path>] [--format <format>] [--include-deleted]
+		Writes all pick-up points to a CSV or JSON file that import-points can read
+		--file			specify file to export to, - for standard output, default: -
+		--format		specify file format: csv or json, default: file extension or json
+		--include-deleted	export deleted pick-up points too
 
-	accept-order --order-id <order-id> --customer-id <customer-id> --keep-date <keep-date> --price <price> --weight <weight>
-		Accepts order from a courier
+	add-user --name <name> [--hash <algorithm>] [--role <role>]
+		Adds a user allowed to use the API, the password is read from standard input
+		--name			specify user name
+		--hash			specify password hash algorithm: bcrypt or argon2id, default: bcrypt
+		--role			specify user role: viewer, operator or admin, default: viewer
+
+	set-password --name <name> [--hash <algorithm>]
+		Changes the password of a user, the new password is read from standard input
+		--name			specify user name
+		--hash			specify password hash algorithm: bcrypt or argon2id, default: bcrypt
+
+	set-role --name <name> --role <role>
+		Changes the role of a user
+		--name			specify user name
+		--role			specify user role: viewer, operator or admin
+
+	disable-user --name <name>
+		Keeps a user from using the API, changes made by the user stay in the history
+		--name			specify user name
+
+	rotate-token-key [--file <path>] [--keep <number>]
+		Adds a new access token signing key ahead of the old ones and drops the oldest,
+		tokens signed with kept keys stay valid until they expire
+		--file			specify access token signing keys file, default: token_keys.json
+		--keep			specify how many keys to keep, including the new one, default: 2
+
+	accept-order --order-id <order-id> --customer-id <customer-id> --keep-date <keep-date> --price <price> --weight <weight> [--pickup-point-id <pickup-point-id>] [--enforce-capacity]
+		Accepts order from a courier, failing when the pick-up point has no room left for it
 		--order-id		specify an order id
 		--customer-id	specify a customer id
+		--pickup-point-id	specify a pick-up point storing the order to check its capacity
 		--keep-date		specify a keep date in YYYY-MM-DD format
 		--price			specify price in rubles
 		--weight		specify weight in kg
 		--packaging		specify packaging type
+		--enforce-capacity	reject orders without a pick-up point, whose capacity cannot be checked
 
 	return-order --order-id <order-id>
 		Returns order to a courier
@@ -197,5 +324,13 @@ func help() {
 	list-returns -n <number-of-entries> [--page <page-num>]
 		Lists all stored returned orders
 		-n				specify number of entries to display on a page
-		--page			specify page number, starting with 0`)
+		--page			specify page number, starting with 0
+
+	migrate-storage --from <storage> --to <storage>
+		Copies pick-up points and orders between storage backends and verifies the result,
+		items already present in the destination are updated, so it can be safely run again
+		--from				specify source storage
+		--to				specify destination storage
+		--from-storage-path	specify source embedded key-value store file, default: --storage-path
+		--to-storage-path	specify destination embedded key-value store file, default: --storage-path`)
 }
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// apiKeyPrefix tells API keys from access tokens.
+const apiKeyPrefix = "ppk_"
+
+// apiKeyShownLen is how much of an API key is kept in clear text to tell keys apart.
+const apiKeyShownLen = len(apiKeyPrefix) + 6
+
+var ErrNoKey = errors.New("no such API key found")
+
+// APIKey is a long-lived credential of a service acting on behalf of a user with the user's role.
+// Only a hash of the key is stored, the key itself is shown once when created.
+type APIKey struct {
+	Id        uint64     `json:"id" db:"id"`
+	User      string     `json:"user" db:"user_name"`
+	Name      string     `json:"name" db:"name"`
+	Prefix    string     `json:"prefix" db:"prefix"`
+	Hash      string     `json:"hash" db:"key_hash"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// Revoked reports whether the key may no longer be used.
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+type KeyRepository interface {
+	Create(ctx context.Context, key APIKey) (APIKey, error)
+	Get(ctx context.Context, id uint64) (APIKey, error)
+	GetByHash(ctx context.Context, hash string) (APIKey, error)
+	List(ctx context.Context, user string) ([]APIKey, error)
+	Update(ctx context.Context, key APIKey) error
+}
+
+// RevocationList keeps ids of access tokens revoked before they expire.
+type RevocationList interface {
+	// Revoke adds the token id to the list until the token expires, dropping ids of tokens expired by now.
+	Revoke(ctx context.Context, tokenId string, expiresAt time.Time, now time.Time) error
+	Revoked(ctx context.Context, tokenId string) (bool, error)
+}
+
+// Revocation is an id of a revoked access token, kept until the token expires.
+type Revocation struct {
+	Id        uint64    `json:"id" db:"id"`
+	TokenId   string    `json:"token_id" db:"token_id"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// newAPIKey returns a random API key.
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIKey returns the hash an API key is looked up by, keys are random enough for a plain hash.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"homework/internal/app/kvstore"
+)
+
+// usersBucket holds users keyed by their names, ids are taken from the bucket sequence.
+var usersBucket = []byte("users")
+
+// BoltRepository provides a user Repository with an embedded key-value store as a backend.
+type BoltRepository struct {
+	db *kvstore.DB
+}
+
+// NewBoltRepository returns a new BoltRepository with provided store.
+func NewBoltRepository(db *kvstore.DB) (*BoltRepository, error) {
+	err := db.Update(context.Background(), func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(usersBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltRepository{db: db}, nil
+}
+
+// Create creates a new user, its id is the next value of the bucket sequence.
+func (s *BoltRepository) Create(ctx context.Context, user User) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		if b.Get([]byte(user.Name)) != nil {
+			return ErrUserExists
+		}
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		user.Id = id
+		return putUser(b, user)
+	})
+}
+
+// Get returns the user with the provided name.
+func (s *BoltRepository) Get(ctx context.Context, name string) (User, error) {
+	var user User
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		v := tx.Bucket(usersBucket).Get([]byte(name))
+		if v == nil {
+			return ErrNoUser
+		}
+		return json.Unmarshal(v, &user)
+	})
+	return user, err
+}
+
+// Update replaces the password hash, the role and the state of the user with the same name.
+func (s *BoltRepository) Update(ctx context.Context, user User) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		v := b.Get([]byte(user.Name))
+		if v == nil {
+			return ErrNoUser
+		}
+		var stored User
+		err := json.Unmarshal(v, &stored)
+		if err != nil {
+			return err
+		}
+		stored.PasswordHash = user.PasswordHash
+		stored.Role = user.Role
+		stored.Disabled = user.Disabled
+		return putUser(b, stored)
+	})
+}
+
+func putUser(b *bbolt.Bucket, user User) error {
+	bytes, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(user.Name), bytes)
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"homework/internal/app/kvstore"
+	"time"
+)
+
+var (
+	// keysBucket holds API keys keyed by id, and a nested bucket of ids keyed by key hashes.
+	keysBucket    = []byte("api_keys")
+	keyHashBucket = []byte("by_hash")
+	revokedBucket = []byte("revoked_tokens")
+)
+
+// BoltKeyRepository provides a KeyRepository with an embedded key-value store as a backend.
+type BoltKeyRepository struct {
+	db *kvstore.DB
+}
+
+// NewBoltKeyRepository returns a new BoltKeyRepository with provided store.
+func NewBoltKeyRepository(db *kvstore.DB) (*BoltKeyRepository, error) {
+	err := db.Update(context.Background(), func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(keysBucket)
+		if err != nil {
+			return err
+		}
+		_, err = b.CreateBucketIfNotExists(keyHashBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltKeyRepository{db: db}, nil
+}
+
+// Create stores a new API key, its id is the next value of the bucket sequence.
+func (s *BoltKeyRepository) Create(ctx context.Context, key APIKey) (APIKey, error) {
+	err := s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(keysBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		key.Id = id
+		err = b.Bucket(keyHashBucket).Put([]byte(key.Hash), kvstore.Key(key.Id))
+		if err != nil {
+			return err
+		}
+		return putKey(b, key)
+	})
+	if err != nil {
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+// Get returns the API key represented by id.
+func (s *BoltKeyRepository) Get(ctx context.Context, id uint64) (APIKey, error) {
+	var key APIKey
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		var err error
+		key, err = getKey(tx.Bucket(keysBucket), kvstore.Key(id))
+		return err
+	})
+	return key, err
+}
+
+// GetByHash returns the API key with the hash.
+func (s *BoltKeyRepository) GetByHash(ctx context.Context, hash string) (APIKey, error) {
+	var key APIKey
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(keysBucket)
+		k := b.Bucket(keyHashBucket).Get([]byte(hash))
+		if k == nil {
+			return ErrNoKey
+		}
+		var err error
+		key, err = getKey(b, k)
+		return err
+	})
+	return key, err
+}
+
+// List returns API keys of the user ordered by id.
+func (s *BoltKeyRepository) List(ctx context.Context, user string) ([]APIKey, error) {
+	keys := make([]APIKey, 0)
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		return tx.Bucket(keysBucket).ForEach(func(k, v []byte) error {
+			// nested buckets have no value
+			if v == nil {
+				return nil
+			}
+			var key APIKey
+			err := json.Unmarshal(v, &key)
+			if err != nil {
+				return err
+			}
+			if key.User == user {
+				keys = append(keys, key)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Update replaces the revocation time of the API key.
+func (s *BoltKeyRepository) Update(ctx context.Context, key APIKey) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(keysBucket)
+		stored, err := getKey(b, kvstore.Key(key.Id))
+		if err != nil {
+			return err
+		}
+		stored.RevokedAt = key.RevokedAt
+		return putKey(b, stored)
+	})
+}
+
+func getKey(b *bbolt.Bucket, k []byte) (APIKey, error) {
+	var key APIKey
+	v := b.Get(k)
+	if v == nil {
+		return key, ErrNoKey
+	}
+	err := json.Unmarshal(v, &key)
+	return key, err
+}
+
+func putKey(b *bbolt.Bucket, key APIKey) error {
+	bytes, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return b.Put(kvstore.Key(key.Id), bytes)
+}
+
+// BoltRevocationList provides a RevocationList with an embedded key-value store as a backend.
+type BoltRevocationList struct {
+	db *kvstore.DB
+}
+
+// NewBoltRevocationList returns a new BoltRevocationList with provided store.
+func NewBoltRevocationList(db *kvstore.DB) (*BoltRevocationList, error) {
+	err := db.Update(context.Background(), func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(revokedBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltRevocationList{db: db}, nil
+}
+
+// Revoke adds the token id to the list until the token expires, dropping ids of tokens expired by now.
+func (s *BoltRevocationList) Revoke(ctx context.Context, tokenId string, expiresAt time.Time, now time.Time) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(revokedBucket)
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var revocation Revocation
+			err := json.Unmarshal(v, &revocation)
+			if err != nil {
+				return err
+			}
+			if !revocation.ExpiresAt.After(now) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			err = b.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+		bytes, err := json.Marshal(Revocation{TokenId: tokenId, ExpiresAt: expiresAt})
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(tokenId), bytes)
+	})
+}
+
+// Revoked reports whether the token id is on the list.
+func (s *BoltRevocationList) Revoked(ctx context.Context, tokenId string) (bool, error) {
+	var revoked bool
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		revoked = tx.Bucket(revokedBucket).Get([]byte(tokenId)) != nil
+		return nil
+	})
+	return revoked, err
+}
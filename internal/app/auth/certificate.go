@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ReadCertificateUsers reads a JSON object mapping client certificate subjects, as written by pkix.Name.String,
+// to names of users they authenticate.
+func ReadCertificateUsers(path string) (map[string]string, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var users map[string]string
+	err = json.Unmarshal(bytes, &users)
+	if err != nil {
+		return nil, fmt.Errorf("reading certificate users from %s: %w", path, err)
+	}
+	return users, nil
+}
+
+// SetCertificateUsers maps client certificate subjects to names of users they authenticate,
+// certificates with other subjects authenticate no one.
+func (s *Service) SetCertificateUsers(users map[string]string) {
+	s.certUsers = users
+}
+
+// AuthenticateCertificate returns the enabled user a verified client certificate with the subject was issued to.
+// It fails with ErrInvalidCredentials when the subject is not mapped to a user, there is no such user or the user is disabled.
+func (s *Service) AuthenticateCertificate(ctx context.Context, subject pkix.Name) (User, error) {
+	name, ok := s.certUsers[subject.String()]
+	if !ok || name == "" {
+		return User{}, ErrInvalidCredentials
+	}
+	user, err := s.enabledUser(ctx, name)
+	if errors.Is(err, ErrNoUser) {
+		return User{}, ErrInvalidCredentials
+	}
+	return user, err
+}
@@ -0,0 +1,29 @@
+package auth
+
+import "context"
+
+type userKey struct{}
+
+type roleKey struct{}
+
+// WithUser returns a copy of ctx carrying the name of the authenticated user.
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserName returns the name of the authenticated user carried by ctx, or an empty string.
+func UserName(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// WithRole returns a copy of ctx carrying the role of the authenticated user.
+func WithRole(ctx context.Context, role Role) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// UserRole returns the role of the authenticated user carried by ctx, or an empty role allowing nothing.
+func UserRole(ctx context.Context) Role {
+	role, _ := ctx.Value(roleKey{}).(Role)
+	return role
+}
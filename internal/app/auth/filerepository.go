@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"go.uber.org/multierr"
+	"homework/internal/app/journal"
+	"os"
+	"sync"
+)
+
+// FileRepository provides a user Repository with a JSON file as a backend.
+type FileRepository struct {
+	users   map[uint64]User
+	journal *journal.Journal[User]
+	mutex   sync.Mutex
+}
+
+// NewFileRepository returns a new in-memory FileRepository.
+func NewFileRepository() *FileRepository {
+	return &FileRepository{users: make(map[uint64]User)}
+}
+
+// OpenFileRepository returns a new FileRepository persisted to the file stored in the provided path.
+func OpenFileRepository(path string, perm os.FileMode) (*FileRepository, error) {
+	j, users, err := journal.Open[User](path, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &FileRepository{users: users, journal: j}, nil
+}
+
+// Close writes a snapshot of users into file when needed and releases it.
+func (s *FileRepository) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.Close(s.users)
+}
+
+// sync runs f with users brought up to date with changes made by other processes,
+// keeping them from changing users until f returns.
+func (s *FileRepository) sync(f func() error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
+		return f()
+	}
+	_, err := s.journal.Lock(s.users)
+	if err != nil {
+		return err
+	}
+	err = f()
+	return multierr.Combine(err, s.journal.Unlock())
+}
+
+func (s *FileRepository) put(user User) error {
+	if s.journal != nil {
+		if s.journal.NeedsCompaction() {
+			err := s.journal.Compact(s.users)
+			if err != nil {
+				return err
+			}
+		}
+		err := s.journal.Put(user.Id, user)
+		if err != nil {
+			return err
+		}
+	}
+	s.users[user.Id] = user
+	return nil
+}
+
+func (s *FileRepository) find(name string) (User, bool) {
+	for _, user := range s.users {
+		if user.Name == name {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+// Create creates a new user, its id is the next one after the largest stored.
+func (s *FileRepository) Create(ctx context.Context, user User) error {
+	return s.sync(func() error {
+		if _, ok := s.find(user.Name); ok {
+			return ErrUserExists
+		}
+		user.Id = 0
+		for id := range s.users {
+			user.Id = max(user.Id, id)
+		}
+		user.Id++
+		return s.put(user)
+	})
+}
+
+// Get returns the user with the provided name.
+func (s *FileRepository) Get(ctx context.Context, name string) (User, error) {
+	var user User
+	err := s.sync(func() error {
+		var ok bool
+		user, ok = s.find(name)
+		if !ok {
+			return ErrNoUser
+		}
+		return nil
+	})
+	return user, err
+}
+
+// Update replaces the password hash, the role and the state of the user with the same name.
+func (s *FileRepository) Update(ctx context.Context, user User) error {
+	return s.sync(func() error {
+		stored, ok := s.find(user.Name)
+		if !ok {
+			return ErrNoUser
+		}
+		stored.PasswordHash = user.PasswordHash
+		stored.Role = user.Role
+		stored.Disabled = user.Disabled
+		return s.put(stored)
+	})
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"cmp"
+	"context"
+	"go.uber.org/multierr"
+	"homework/internal/app/journal"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FileKeyRepository provides a KeyRepository with a JSON file as a backend.
+type FileKeyRepository struct {
+	keys    map[uint64]APIKey
+	journal *journal.Journal[APIKey]
+	mutex   sync.Mutex
+}
+
+// NewFileKeyRepository returns a new in-memory FileKeyRepository.
+func NewFileKeyRepository() *FileKeyRepository {
+	return &FileKeyRepository{keys: make(map[uint64]APIKey)}
+}
+
+// OpenFileKeyRepository returns a new FileKeyRepository persisted to the file stored in the provided path.
+func OpenFileKeyRepository(path string, perm os.FileMode) (*FileKeyRepository, error) {
+	j, keys, err := journal.Open[APIKey](path, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &FileKeyRepository{keys: keys, journal: j}, nil
+}
+
+// Close writes a snapshot of API keys into file when needed and releases it.
+func (s *FileKeyRepository) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.Close(s.keys)
+}
+
+// sync runs f with API keys brought up to date with changes made by other processes,
+// keeping them from changing API keys until f returns.
+func (s *FileKeyRepository) sync(f func() error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
+		return f()
+	}
+	_, err := s.journal.Lock(s.keys)
+	if err != nil {
+		return err
+	}
+	err = f()
+	return multierr.Combine(err, s.journal.Unlock())
+}
+
+func (s *FileKeyRepository) put(key APIKey) error {
+	if s.journal != nil {
+		if s.journal.NeedsCompaction() {
+			err := s.journal.Compact(s.keys)
+			if err != nil {
+				return err
+			}
+		}
+		err := s.journal.Put(key.Id, key)
+		if err != nil {
+			return err
+		}
+	}
+	s.keys[key.Id] = key
+	return nil
+}
+
+// Create stores a new API key, its id is the next one after the largest stored.
+func (s *FileKeyRepository) Create(ctx context.Context, key APIKey) (APIKey, error) {
+	err := s.sync(func() error {
+		key.Id = 0
+		for id := range s.keys {
+			key.Id = max(key.Id, id)
+		}
+		key.Id++
+		return s.put(key)
+	})
+	if err != nil {
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+// Get returns the API key represented by id.
+func (s *FileKeyRepository) Get(ctx context.Context, id uint64) (APIKey, error) {
+	var key APIKey
+	err := s.sync(func() error {
+		var ok bool
+		key, ok = s.keys[id]
+		if !ok {
+			return ErrNoKey
+		}
+		return nil
+	})
+	return key, err
+}
+
+// GetByHash returns the API key with the hash.
+func (s *FileKeyRepository) GetByHash(ctx context.Context, hash string) (APIKey, error) {
+	var key APIKey
+	err := s.sync(func() error {
+		for _, stored := range s.keys {
+			if stored.Hash == hash {
+				key = stored
+				return nil
+			}
+		}
+		return ErrNoKey
+	})
+	return key, err
+}
+
+// List returns API keys of the user ordered by id.
+func (s *FileKeyRepository) List(ctx context.Context, user string) ([]APIKey, error) {
+	keys := make([]APIKey, 0)
+	err := s.sync(func() error {
+		for _, key := range s.keys {
+			if key.User == user {
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	slices.SortFunc(keys, func(a, b APIKey) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return keys, err
+}
+
+// Update replaces the revocation time of the API key.
+func (s *FileKeyRepository) Update(ctx context.Context, key APIKey) error {
+	return s.sync(func() error {
+		stored, ok := s.keys[key.Id]
+		if !ok {
+			return ErrNoKey
+		}
+		stored.RevokedAt = key.RevokedAt
+		return s.put(stored)
+	})
+}
+
+// FileRevocationList provides a RevocationList with a JSON file as a backend.
+type FileRevocationList struct {
+	revocations map[uint64]Revocation
+	journal     *journal.Journal[Revocation]
+	mutex       sync.Mutex
+}
+
+// NewFileRevocationList returns a new in-memory FileRevocationList.
+func NewFileRevocationList() *FileRevocationList {
+	return &FileRevocationList{revocations: make(map[uint64]Revocation)}
+}
+
+// OpenFileRevocationList returns a new FileRevocationList persisted to the file stored in the provided path.
+func OpenFileRevocationList(path string, perm os.FileMode) (*FileRevocationList, error) {
+	j, revocations, err := journal.Open[Revocation](path, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &FileRevocationList{revocations: revocations, journal: j}, nil
+}
+
+// Close writes a snapshot of the list into file when needed and releases it.
+func (s *FileRevocationList) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.Close(s.revocations)
+}
+
+// sync runs f with the list brought up to date with changes made by other processes,
+// keeping them from changing it until f returns.
+func (s *FileRevocationList) sync(f func() error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
+		return f()
+	}
+	_, err := s.journal.Lock(s.revocations)
+	if err != nil {
+		return err
+	}
+	err = f()
+	return multierr.Combine(err, s.journal.Unlock())
+}
+
+// Revoke adds the token id to the list until the token expires, dropping ids of tokens expired by now.
+func (s *FileRevocationList) Revoke(ctx context.Context, tokenId string, expiresAt time.Time, now time.Time) error {
+	return s.sync(func() error {
+		revocation := Revocation{TokenId: tokenId, ExpiresAt: expiresAt}
+		for id, stored := range s.revocations {
+			revocation.Id = max(revocation.Id, id)
+			if stored.ExpiresAt.After(now) {
+				continue
+			}
+			if s.journal != nil {
+				err := s.journal.Delete(id)
+				if err != nil {
+					return err
+				}
+			}
+			delete(s.revocations, id)
+		}
+		revocation.Id++
+		if s.journal != nil {
+			if s.journal.NeedsCompaction() {
+				err := s.journal.Compact(s.revocations)
+				if err != nil {
+					return err
+				}
+			}
+			err := s.journal.Put(revocation.Id, revocation)
+			if err != nil {
+				return err
+			}
+		}
+		s.revocations[revocation.Id] = revocation
+		return nil
+	})
+}
+
+// Revoked reports whether the token id is on the list.
+func (s *FileRevocationList) Revoked(ctx context.Context, tokenId string) (bool, error) {
+	var revoked bool
+	err := s.sync(func() error {
+		for _, revocation := range s.revocations {
+			if revocation.TokenId == tokenId {
+				revoked = true
+				break
+			}
+		}
+		return nil
+	})
+	return revoked, err
+}
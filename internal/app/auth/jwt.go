@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// minSecretLen is the least length of a signing key secret, RFC 7518 requires keys as long as the HMAC output.
+const minSecretLen = sha256.Size
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// SigningKey is a secret signing access tokens, the id tells tokens which key signed them.
+type SigningKey struct {
+	Id     string `json:"id"`
+	Secret []byte `json:"secret"`
+}
+
+// NewSigningKey generates a random signing key.
+func NewSigningKey(id string) (SigningKey, error) {
+	secret := make([]byte, minSecretLen)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return SigningKey{Id: id, Secret: secret}, nil
+}
+
+// Keyring holds signing keys, newest first. Tokens are signed with the newest key and checked with any of them,
+// so that a new key may be added ahead of the old ones, which are dropped once tokens signed with them expire.
+type Keyring struct {
+	keys []SigningKey
+}
+
+// NewKeyring checks that there is at least one key, every key has a unique id and a long enough secret.
+func NewKeyring(keys []SigningKey) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring must hold at least one signing key")
+	}
+	ids := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if key.Id == "" {
+			return nil, errors.New("signing key id must not be empty")
+		}
+		if _, ok := ids[key.Id]; ok {
+			return nil, fmt.Errorf("signing key id %q is not unique", key.Id)
+		}
+		ids[key.Id] = struct{}{}
+		if len(key.Secret) < minSecretLen {
+			return nil, fmt.Errorf("signing key %q must be at least %d bytes long", key.Id, minSecretLen)
+		}
+	}
+	return &Keyring{keys: keys}, nil
+}
+
+// LoadKeyring reads a keyring from a JSON array of signing keys, newest first.
+func LoadKeyring(path string) (*Keyring, error) {
+	keys, err := ReadSigningKeys(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(keys)
+}
+
+// KeyringFile is a keyring file read again once it changes, so that keys are rotated without a restart.
+type KeyringFile struct {
+	path    string
+	modTime time.Time
+}
+
+// NewKeyringFile returns a KeyringFile not read yet.
+func NewKeyringFile(path string) *KeyringFile {
+	return &KeyringFile{path: path}
+}
+
+// Reload returns the keyring read from the file when it has changed since it was last read, nil when it has not.
+// A file failing to load is tried again once it changes next.
+func (f *KeyringFile) Reload() (*Keyring, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil, nil
+	}
+	f.modTime = info.ModTime()
+	return LoadKeyring(f.path)
+}
+
+// ReadSigningKeys reads a JSON array of signing keys.
+func ReadSigningKeys(path string) ([]SigningKey, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []SigningKey
+	err = json.Unmarshal(bytes, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing keys from %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// WriteSigningKeys replaces the file with a JSON array of signing keys readable only by its owner.
+// The file is replaced at once, so that a KeyringFile never reads it half written.
+func WriteSigningKeys(path string, keys []SigningKey) error {
+	bytes, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, bytes, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (k *Keyring) key(id string) ([]byte, bool) {
+	for _, key := range k.keys {
+		if key.Id == id {
+			return key.Secret, true
+		}
+	}
+	return nil, false
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// Claims are what an access token tells about its user.
+type Claims struct {
+	Id        string `json:"jti"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sign returns a JWT with the claims signed by HMAC-SHA256 with the newest key.
+func (k *Keyring) Sign(claims Claims) (string, error) {
+	key := k.keys[0]
+	header, err := json.Marshal(tokenHeader{Alg: "HS256", Typ: "JWT", Kid: key.Id})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sign(key.Secret, unsigned)), nil
+}
+
+// Parse returns the claims of a token signed by any key of the keyring, unless it has expired by now.
+func (k *Keyring) Parse(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+	var header tokenHeader
+	err := decodeSegment(parts[0], &header)
+	if err != nil || header.Alg != "HS256" {
+		return Claims{}, ErrInvalidToken
+	}
+	secret, ok := k.key(header.Kid)
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, sign(secret, parts[0]+"."+parts[1])) {
+		return Claims{}, ErrInvalidToken
+	}
+	var claims Claims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil || claims.Id == "" || claims.Subject == "" || now.Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func sign(secret []byte, unsigned string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v any) error {
+	bytes, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, v)
+}
+
+// randomId returns n random bytes in hex.
+func randomId(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKey(t *testing.T, id string) SigningKey {
+	key, err := NewSigningKey(id)
+	require.NoError(t, err)
+	return key
+}
+
+func TestNewKeyring(t *testing.T) {
+	key := testKey(t, "1")
+	tests := []struct {
+		name    string
+		keys    []SigningKey
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			keys: []SigningKey{key, testKey(t, "0")},
+		},
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name:    "no id",
+			keys:    []SigningKey{{Secret: key.Secret}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate id",
+			keys:    []SigningKey{key, key},
+			wantErr: true,
+		},
+		{
+			name:    "short secret",
+			keys:    []SigningKey{{Id: "1", Secret: []byte("secret")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.keys)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKeyringParse(t *testing.T) {
+	now := time.Date(2024, 7, 10, 12, 0, 0, 0, time.UTC)
+	oldKey, newKey := testKey(t, "old"), testKey(t, "new")
+	old, err := NewKeyring([]SigningKey{oldKey})
+	require.NoError(t, err)
+	rotated, err := NewKeyring([]SigningKey{newKey, oldKey})
+	require.NoError(t, err)
+	other, err := NewKeyring([]SigningKey{{Id: "old", Secret: bytes.Repeat([]byte{1}, minSecretLen)}})
+	require.NoError(t, err)
+
+	claims := Claims{Id: "1", Subject: "user", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+	signedByOld, err := old.Sign(claims)
+	require.NoError(t, err)
+	signedByNew, err := rotated.Sign(claims)
+	require.NoError(t, err)
+	parts := strings.Split(signedByOld, ".")
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		token   string
+		now     time.Time
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			keyring: old,
+			token:   signedByOld,
+			now:     now,
+		},
+		{
+			name:    "signed by an older key",
+			keyring: rotated,
+			token:   signedByOld,
+			now:     now,
+		},
+		{
+			name:    "signed by a newer key",
+			keyring: old,
+			token:   signedByNew,
+			now:     now,
+			wantErr: true,
+		},
+		{
+			name:    "signed by another key with the same id",
+			keyring: other,
+			token:   signedByOld,
+			now:     now,
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			keyring: old,
+			token:   signedByOld,
+			now:     now.Add(time.Minute),
+			wantErr: true,
+		},
+		{
+			name:    "tampered",
+			keyring: old,
+			token:   parts[0] + "." + parts[0] + "." + parts[2],
+			now:     now,
+			wantErr: true,
+		},
+		{
+			name:    "unsigned",
+			keyring: old,
+			token:   parts[0] + "." + parts[1] + ".",
+			now:     now,
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			keyring: old,
+			token:   "token",
+			now:     now,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keyring.Parse(tt.token, tt.now)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, claims, got)
+		})
+	}
+}
+
+func TestSigningKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	keys := []SigningKey{testKey(t, "2"), testKey(t, "1")}
+	require.NoError(t, WriteSigningKeys(path, keys))
+	got, err := ReadSigningKeys(path)
+	require.NoError(t, err)
+	assert.Equal(t, keys, got)
+	_, err = LoadKeyring(path)
+	assert.NoError(t, err)
+}
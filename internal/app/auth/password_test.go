@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestCheckPassword(t *testing.T) {
+	for _, alg := range []Algorithm{Bcrypt, Argon2id} {
+		t.Run(string(alg), func(t *testing.T) {
+			hash, err := HashPassword("secret", alg)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, "$"))
+			assert.NotContains(t, hash, "secret")
+			assert.True(t, CheckPassword(hash, "secret"))
+			assert.False(t, CheckPassword(hash, "Secret"))
+			assert.False(t, CheckPassword(hash, ""))
+
+			other, err := HashPassword("secret", alg)
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other, "hashes must be salted")
+		})
+	}
+}
+
+func TestCheckPasswordMalformed(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$argon2id$v=19$m=65536,t=1,p=4$salt",
+		"$argon2id$v=18$m=65536,t=1,p=4$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=4$c2FsdA$a2V5",
+		"$argon2id$v=19$m=65536,t=1,p=4$!$a2V5",
+	} {
+		assert.False(t, CheckPassword(hash, "secret"), hash)
+	}
+}
+
+func TestHashPasswordUnknownAlgorithm(t *testing.T) {
+	_, err := HashPassword("secret", "md5")
+	assert.ErrorIs(t, err, ErrInvalidAlgorithm)
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"homework/internal/app/db"
+)
+
+// PostgresRepository provides a user Repository with a PostgreSQL database as a backend.
+type PostgresRepository struct {
+	db db.Database
+}
+
+// NewPostgresRepository returns a new PostgresRepository with provided database.
+func NewPostgresRepository(db db.Database) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// Create creates a new user, its id is generated by the database.
+func (s *PostgresRepository) Create(ctx context.Context, user User) error {
+	_, err := s.db.Exec(ctx, "INSERT INTO users (name, password_hash, role, disabled, created_at) VALUES ($1, $2, $3, $4, $5);", user.Name, user.PasswordHash, user.Role, user.Disabled, user.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName != "" {
+		return ErrUserExists
+	}
+	return err
+}
+
+// Get returns the user with the provided name.
+func (s *PostgresRepository) Get(ctx context.Context, name string) (User, error) {
+	var user User
+	err := s.db.Get(ctx, &user, "SELECT id, name, password_hash, role, disabled, created_at FROM users WHERE name = $1;", name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return user, ErrNoUser
+	}
+	return user, err
+}
+
+// Update replaces the password hash, the role and the state of the user with the same name.
+func (s *PostgresRepository) Update(ctx context.Context, user User) error {
+	tag, err := s.db.Exec(ctx, "UPDATE users SET password_hash = $2, role = $3, disabled = $4 WHERE name = $1;", user.Name, user.PasswordHash, user.Role, user.Disabled)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoUser
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/db/mocks"
+	"testing"
+	"time"
+)
+
+var sampleUser = User{
+	Id:           1,
+	Name:         "user",
+	PasswordHash: "$2a$10$hash",
+	Role:         Operator,
+	CreatedAt:    time.Date(2024, 6, 26, 12, 0, 0, 0, time.UTC),
+}
+
+type PostgresRepositoryTestSuite struct {
+	suite.Suite
+}
+
+func (s *PostgresRepositoryTestSuite) Test_Create() {
+	tests := []struct {
+		name  string
+		dbErr error
+		err   error
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:  "existing name",
+			dbErr: &pgconn.PgError{ConstraintName: "users_name_key"},
+			err:   ErrUserExists,
+		},
+		{
+			name:  "error",
+			dbErr: assert.AnError,
+			err:   assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresRepository(db)
+			db.EXPECT().
+				Exec(gomock.Any(),
+					"INSERT INTO users (name, password_hash, role, disabled, created_at) VALUES ($1, $2, $3, $4, $5);",
+					sampleUser.Name, sampleUser.PasswordHash, sampleUser.Role, sampleUser.Disabled, sampleUser.CreatedAt).
+				Return(nil, tt.dbErr)
+			err := repo.Create(context.Background(), sampleUser)
+			s.ErrorIs(err, tt.err)
+		})
+	}
+}
+
+func (s *PostgresRepositoryTestSuite) Test_Get() {
+	tests := []struct {
+		name  string
+		dbErr error
+		want  User
+		err   error
+	}{
+		{
+			name: "ok",
+			want: sampleUser,
+		},
+		{
+			name:  "not found",
+			dbErr: pgx.ErrNoRows,
+			err:   ErrNoUser,
+		},
+		{
+			name:  "error",
+			dbErr: assert.AnError,
+			err:   assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresRepository(db)
+			db.EXPECT().
+				Get(gomock.Any(), gomock.Any(),
+					"SELECT id, name, password_hash, role, disabled, created_at FROM users WHERE name = $1;",
+					sampleUser.Name).
+				DoAndReturn(func(ctx context.Context, dest *User, query string, args ...interface{}) error {
+					*dest = tt.want
+					return tt.dbErr
+				})
+			user, err := repo.Get(context.Background(), sampleUser.Name)
+			if tt.err != nil {
+				s.ErrorIs(err, tt.err)
+				return
+			}
+			s.NoError(err)
+			s.Equal(tt.want, user)
+		})
+	}
+}
+
+func (s *PostgresRepositoryTestSuite) Test_Update() {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		dbErr        error
+		err          error
+	}{
+		{
+			name:         "ok",
+			rowsAffected: 1,
+		},
+		{
+			name: "not found",
+			err:  ErrNoUser,
+		},
+		{
+			name:  "error",
+			dbErr: assert.AnError,
+			err:   assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresRepository(db)
+			tag := mocks.NewMockCommandTag(ctrl)
+			tag.EXPECT().RowsAffected().AnyTimes().Return(tt.rowsAffected)
+			db.EXPECT().
+				Exec(gomock.Any(),
+					"UPDATE users SET password_hash = $2, role = $3, disabled = $4 WHERE name = $1;",
+					sampleUser.Name, sampleUser.PasswordHash, sampleUser.Role, true).
+				Return(tag, tt.dbErr)
+			user := sampleUser
+			user.Disabled = true
+			err := repo.Update(context.Background(), user)
+			s.ErrorIs(err, tt.err)
+		})
+	}
+}
+
+func TestPostgresRepository(t *testing.T) {
+	suite.Run(t, new(PostgresRepositoryTestSuite))
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"homework/internal/app/db"
+	"time"
+)
+
+// PostgresKeyRepository provides a KeyRepository with a PostgreSQL database as a backend.
+type PostgresKeyRepository struct {
+	db db.Database
+}
+
+// NewPostgresKeyRepository returns a new PostgresKeyRepository with provided database.
+func NewPostgresKeyRepository(db db.Database) *PostgresKeyRepository {
+	return &PostgresKeyRepository{db: db}
+}
+
+// Create stores a new API key, its id is generated by the database.
+func (s *PostgresKeyRepository) Create(ctx context.Context, key APIKey) (APIKey, error) {
+	err := s.db.ExecQueryRow(ctx, "INSERT INTO api_keys (user_name, name, prefix, key_hash, created_at, revoked_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;", key.User, key.Name, key.Prefix, key.Hash, key.CreatedAt, key.RevokedAt).Scan(&key.Id)
+	if err != nil {
+		return APIKey{}, err
+	}
+	return key, nil
+}
+
+// Get returns the API key represented by id.
+func (s *PostgresKeyRepository) Get(ctx context.Context, id uint64) (APIKey, error) {
+	return s.get(ctx, "SELECT id, user_name, name, prefix, key_hash, created_at, revoked_at FROM api_keys WHERE id = $1;", id)
+}
+
+// GetByHash returns the API key with the hash.
+func (s *PostgresKeyRepository) GetByHash(ctx context.Context, hash string) (APIKey, error) {
+	return s.get(ctx, "SELECT id, user_name, name, prefix, key_hash, created_at, revoked_at FROM api_keys WHERE key_hash = $1;", hash)
+}
+
+func (s *PostgresKeyRepository) get(ctx context.Context, query string, arg any) (APIKey, error) {
+	var key APIKey
+	err := s.db.Get(ctx, &key, query, arg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return key, ErrNoKey
+	}
+	return key, err
+}
+
+// List returns API keys of the user ordered by id.
+func (s *PostgresKeyRepository) List(ctx context.Context, user string) ([]APIKey, error) {
+	var keys []APIKey
+	err := s.db.Select(ctx, &keys, "SELECT id, user_name, name, prefix, key_hash, created_at, revoked_at FROM api_keys WHERE user_name = $1 ORDER BY id;", user)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Update replaces the revocation time of the API key.
+func (s *PostgresKeyRepository) Update(ctx context.Context, key APIKey) error {
+	tag, err := s.db.Exec(ctx, "UPDATE api_keys SET revoked_at = $2 WHERE id = $1;", key.Id, key.RevokedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoKey
+	}
+	return nil
+}
+
+// PostgresRevocationList provides a RevocationList with a PostgreSQL database as a backend.
+type PostgresRevocationList struct {
+	db db.Database
+}
+
+// NewPostgresRevocationList returns a new PostgresRevocationList with provided database.
+func NewPostgresRevocationList(db db.Database) *PostgresRevocationList {
+	return &PostgresRevocationList{db: db}
+}
+
+// Revoke adds the token id to the list until the token expires, dropping ids of tokens expired by now.
+func (s *PostgresRevocationList) Revoke(ctx context.Context, tokenId string, expiresAt time.Time, now time.Time) error {
+	_, err := s.db.Exec(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= $1;", now)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(ctx, "INSERT INTO revoked_tokens (token_id, expires_at) VALUES ($1, $2) ON CONFLICT (token_id) DO NOTHING;", tokenId, expiresAt)
+	return err
+}
+
+// Revoked reports whether the token id is on the list.
+func (s *PostgresRevocationList) Revoked(ctx context.Context, tokenId string) (bool, error) {
+	var revoked bool
+	err := s.db.Get(ctx, &revoked, "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE token_id = $1);", tokenId)
+	return revoked, err
+}
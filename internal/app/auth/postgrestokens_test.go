@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/db/mocks"
+	"testing"
+	"time"
+)
+
+var sampleKey = APIKey{
+	Id:        1,
+	User:      "user",
+	Name:      "ci",
+	Prefix:    "ppk_abcdef",
+	Hash:      "hash",
+	CreatedAt: time.Date(2024, 7, 10, 12, 0, 0, 0, time.UTC),
+}
+
+type row struct {
+	id  uint64
+	err error
+}
+
+func (r row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*uint64) = r.id
+	return nil
+}
+
+type PostgresTokensTestSuite struct {
+	suite.Suite
+}
+
+func (s *PostgresTokensTestSuite) Test_CreateKey() {
+	tests := []struct {
+		name string
+		row  row
+		err  error
+	}{
+		{
+			name: "valid",
+			row:  row{id: 1},
+		},
+		{
+			name: "error",
+			row:  row{err: assert.AnError},
+			err:  assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresKeyRepository(db)
+			key := sampleKey
+			key.Id = 0
+			db.EXPECT().
+				ExecQueryRow(gomock.Any(),
+					"INSERT INTO api_keys (user_name, name, prefix, key_hash, created_at, revoked_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;",
+					key.User, key.Name, key.Prefix, key.Hash, key.CreatedAt, key.RevokedAt).
+				Return(tt.row)
+			created, err := repo.Create(context.Background(), key)
+			if tt.err != nil {
+				s.ErrorIs(err, tt.err)
+				return
+			}
+			s.NoError(err)
+			s.Equal(sampleKey, created)
+		})
+	}
+}
+
+func (s *PostgresTokensTestSuite) Test_GetKeyByHash() {
+	tests := []struct {
+		name  string
+		dbErr error
+		want  APIKey
+		err   error
+	}{
+		{
+			name: "ok",
+			want: sampleKey,
+		},
+		{
+			name:  "not found",
+			dbErr: pgx.ErrNoRows,
+			err:   ErrNoKey,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresKeyRepository(db)
+			db.EXPECT().
+				Get(gomock.Any(), gomock.Any(),
+					"SELECT id, user_name, name, prefix, key_hash, created_at, revoked_at FROM api_keys WHERE key_hash = $1;",
+					sampleKey.Hash).
+				DoAndReturn(func(ctx context.Context, dest *APIKey, query string, args ...interface{}) error {
+					*dest = tt.want
+					return tt.dbErr
+				})
+			key, err := repo.GetByHash(context.Background(), sampleKey.Hash)
+			if tt.err != nil {
+				s.ErrorIs(err, tt.err)
+				return
+			}
+			s.NoError(err)
+			s.Equal(tt.want, key)
+		})
+	}
+}
+
+func (s *PostgresTokensTestSuite) Test_ListKeys() {
+	ctrl := gomock.NewController(s.T())
+	db := mocks.NewMockDatabase(ctrl)
+	repo := NewPostgresKeyRepository(db)
+	db.EXPECT().
+		Select(gomock.Any(), gomock.Any(),
+			"SELECT id, user_name, name, prefix, key_hash, created_at, revoked_at FROM api_keys WHERE user_name = $1 ORDER BY id;",
+			"user").
+		DoAndReturn(func(ctx context.Context, dest *[]APIKey, query string, args ...interface{}) error {
+			*dest = []APIKey{sampleKey}
+			return nil
+		})
+	keys, err := repo.List(context.Background(), "user")
+	s.NoError(err)
+	s.Equal([]APIKey{sampleKey}, keys)
+}
+
+func (s *PostgresTokensTestSuite) Test_UpdateKey() {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		err          error
+	}{
+		{
+			name:         "ok",
+			rowsAffected: 1,
+		},
+		{
+			name: "not found",
+			err:  ErrNoKey,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresKeyRepository(db)
+			key := sampleKey
+			revokedAt := key.CreatedAt.Add(time.Hour)
+			key.RevokedAt = &revokedAt
+			tag := mocks.NewMockCommandTag(ctrl)
+			tag.EXPECT().RowsAffected().AnyTimes().Return(tt.rowsAffected)
+			db.EXPECT().
+				Exec(gomock.Any(), "UPDATE api_keys SET revoked_at = $2 WHERE id = $1;", key.Id, key.RevokedAt).
+				Return(tag, nil)
+			err := repo.Update(context.Background(), key)
+			s.ErrorIs(err, tt.err)
+		})
+	}
+}
+
+func (s *PostgresTokensTestSuite) Test_Revoke() {
+	ctrl := gomock.NewController(s.T())
+	db := mocks.NewMockDatabase(ctrl)
+	list := NewPostgresRevocationList(db)
+	now := sampleKey.CreatedAt
+	expiresAt := now.Add(time.Minute)
+	gomock.InOrder(
+		db.EXPECT().
+			Exec(gomock.Any(), "DELETE FROM revoked_tokens WHERE expires_at <= $1;", now).
+			Return(nil, nil),
+		db.EXPECT().
+			Exec(gomock.Any(), "INSERT INTO revoked_tokens (token_id, expires_at) VALUES ($1, $2) ON CONFLICT (token_id) DO NOTHING;", "jti", expiresAt).
+			Return(nil, nil),
+	)
+	s.NoError(list.Revoke(context.Background(), "jti", expiresAt, now))
+}
+
+func (s *PostgresTokensTestSuite) Test_Revoked() {
+	ctrl := gomock.NewController(s.T())
+	db := mocks.NewMockDatabase(ctrl)
+	list := NewPostgresRevocationList(db)
+	db.EXPECT().
+		Get(gomock.Any(), gomock.Any(), "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE token_id = $1);", "jti").
+		DoAndReturn(func(ctx context.Context, dest *bool, query string, args ...interface{}) error {
+			*dest = true
+			return nil
+		})
+	revoked, err := list.Revoked(context.Background(), "jti")
+	s.NoError(err)
+	s.True(revoked)
+}
+
+func TestPostgresTokens(t *testing.T) {
+	suite.Run(t, new(PostgresTokensTestSuite))
+}
@@ -0,0 +1,38 @@
+package auth
+
+import "errors"
+
+var ErrInvalidRole = errors.New("role must be viewer, operator or admin")
+
+// Role tells what a user may do, every role may do whatever the ones before it may.
+type Role string
+
+const (
+	// Viewer may only read pick-up points, users stored without a role are viewers.
+	Viewer Role = "viewer"
+	// Operator may also create and change pick-up points.
+	Operator Role = "operator"
+	// Admin may do anything, including purging and batch imports.
+	Admin Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	Viewer:   1,
+	Operator: 2,
+	Admin:    3,
+}
+
+// ParseRole checks that s names a role.
+func ParseRole(s string) (Role, error) {
+	switch r := Role(s); r {
+	case Viewer, Operator, Admin:
+		return r, nil
+	}
+	return "", ErrInvalidRole
+}
+
+// Allows reports whether a user with the role may do what requires the other one.
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[required]
+}
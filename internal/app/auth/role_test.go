@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		want     bool
+	}{
+		{Viewer, Viewer, true},
+		{Viewer, Operator, false},
+		{Viewer, Admin, false},
+		{Operator, Viewer, true},
+		{Operator, Operator, true},
+		{Operator, Admin, false},
+		{Admin, Viewer, true},
+		{Admin, Admin, true},
+		{"", Viewer, false},
+		{"root", Viewer, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.role.Allows(tt.required), "%q allows %q", tt.role, tt.required)
+	}
+}
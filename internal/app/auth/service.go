@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Service manages users and their API keys, issues access tokens and checks credentials.
+type Service struct {
+	repo    Repository
+	keys    KeyRepository
+	revoked RevocationList
+	tm      TransactionManager
+	// tokens holds the keyring signing access tokens, they are disabled until it is set.
+	tokens atomic.Pointer[tokenKeys]
+	// certUsers maps client certificate subjects to user names, certificates with other subjects are rejected.
+	certUsers map[string]string
+	now       func() time.Time
+	// dummyHash is checked for unknown users, so that they take as long to reject as known ones.
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+// NewService creates a new Service, access tokens are disabled until a keyring is set.
+func NewService(repo Repository, keys KeyRepository, revoked RevocationList, tm TransactionManager) *Service {
+	return &Service{
+		repo:    repo,
+		keys:    keys,
+		revoked: revoked,
+		tm:      tm,
+		now: func() time.Time {
+			return time.Now().UTC()
+		},
+	}
+}
+
+// AddUser creates an enabled user with the role and the password hashed by the algorithm.
+func (s *Service) AddUser(ctx context.Context, name string, password string, alg Algorithm, role Role) (User, error) {
+	if name == "" {
+		return User{}, ErrEmptyName
+	}
+	_, err := ParseRole(string(role))
+	if err != nil {
+		return User{}, err
+	}
+	hash, err := hashPassword(password, alg)
+	if err != nil {
+		return User{}, err
+	}
+	user := User{
+		Name:         name,
+		PasswordHash: hash,
+		Role:         role,
+		CreatedAt:    s.now(),
+	}
+	err = s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		return s.repo.Create(ctxTX, user)
+	})
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// SetPassword replaces the password of a user with the one hashed by the algorithm.
+func (s *Service) SetPassword(ctx context.Context, name string, password string, alg Algorithm) error {
+	hash, err := hashPassword(password, alg)
+	if err != nil {
+		return err
+	}
+	return s.change(ctx, name, func(user *User) {
+		user.PasswordHash = hash
+	})
+}
+
+// SetRole replaces the role of a user.
+func (s *Service) SetRole(ctx context.Context, name string, role Role) error {
+	_, err := ParseRole(string(role))
+	if err != nil {
+		return err
+	}
+	return s.change(ctx, name, func(user *User) {
+		user.Role = role
+	})
+}
+
+// DisableUser keeps a user from being authenticated, the user is kept to tell who made earlier changes.
+func (s *Service) DisableUser(ctx context.Context, name string) error {
+	return s.change(ctx, name, func(user *User) {
+		user.Disabled = true
+	})
+}
+
+// Authenticate returns the enabled user with the provided name and password.
+// It fails with ErrInvalidCredentials when there is no such user, the user is disabled or the password is wrong,
+// taking about the same time in every case.
+func (s *Service) Authenticate(ctx context.Context, name string, password string) (User, error) {
+	var user User
+	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		var err error
+		user, err = s.repo.Get(ctxTX, name)
+		return err
+	})
+	if errors.Is(err, ErrNoUser) {
+		CheckPassword(s.dummy(), password)
+		return User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return User{}, err
+	}
+	if !CheckPassword(user.PasswordHash, password) || user.Disabled {
+		return User{}, ErrInvalidCredentials
+	}
+	if user.Role == "" {
+		user.Role = Viewer
+	}
+	return user, nil
+}
+
+func (s *Service) change(ctx context.Context, name string, change func(user *User)) error {
+	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		user, err := s.repo.Get(ctxTX, name)
+		if err != nil {
+			return err
+		}
+		change(&user)
+		return s.repo.Update(ctxTX, user)
+	})
+}
+
+func (s *Service) dummy() string {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = HashPassword("dummy password", Bcrypt)
+	})
+	return s.dummyHash
+}
+
+func hashPassword(password string, alg Algorithm) (string, error) {
+	if password == "" {
+		return "", ErrEmptyPassword
+	}
+	return HashPassword(password, alg)
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"homework/internal/app/db"
+	"testing"
+)
+
+type ServiceTestSuite struct {
+	suite.Suite
+	svc *Service
+}
+
+func (s *ServiceTestSuite) SetupTest() {
+	s.svc = NewService(NewFileRepository(), NewFileKeyRepository(), NewFileRevocationList(), db.Dummy{})
+	_, err := s.svc.AddUser(context.Background(), "user", "secret", Bcrypt, Operator)
+	s.Require().NoError(err)
+}
+
+func (s *ServiceTestSuite) Test_AddUser() {
+	ctx := context.Background()
+	user, err := s.svc.AddUser(ctx, "admin", "secret", Argon2id, Admin)
+	s.Require().NoError(err)
+	s.Equal("admin", user.Name)
+	s.Equal(Admin, user.Role)
+	s.False(user.Disabled)
+
+	_, err = s.svc.AddUser(ctx, "admin", "other", Bcrypt, Admin)
+	s.ErrorIs(err, ErrUserExists)
+	_, err = s.svc.AddUser(ctx, "", "secret", Bcrypt, Viewer)
+	s.ErrorIs(err, ErrEmptyName)
+	_, err = s.svc.AddUser(ctx, "empty", "", Bcrypt, Viewer)
+	s.ErrorIs(err, ErrEmptyPassword)
+	_, err = s.svc.AddUser(ctx, "root", "secret", Bcrypt, "root")
+	s.ErrorIs(err, ErrInvalidRole)
+}
+
+func (s *ServiceTestSuite) Test_Authenticate() {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		err      error
+	}{
+		{
+			name:     "valid",
+			user:     "user",
+			password: "secret",
+		},
+		{
+			name:     "wrong password",
+			user:     "user",
+			password: "wrong",
+			err:      ErrInvalidCredentials,
+		},
+		{
+			name:     "unknown user",
+			user:     "nobody",
+			password: "secret",
+			err:      ErrInvalidCredentials,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			user, err := s.svc.Authenticate(context.Background(), tt.user, tt.password)
+			if tt.err != nil {
+				s.ErrorIs(err, tt.err)
+				return
+			}
+			s.NoError(err)
+			s.Equal(tt.user, user.Name)
+			s.Equal(Operator, user.Role)
+		})
+	}
+}
+
+func (s *ServiceTestSuite) Test_SetPassword() {
+	ctx := context.Background()
+	s.Require().NoError(s.svc.SetPassword(ctx, "user", "changed", Argon2id))
+	_, err := s.svc.Authenticate(ctx, "user", "secret")
+	s.ErrorIs(err, ErrInvalidCredentials)
+	_, err = s.svc.Authenticate(ctx, "user", "changed")
+	s.NoError(err)
+
+	s.ErrorIs(s.svc.SetPassword(ctx, "nobody", "changed", Bcrypt), ErrNoUser)
+	s.ErrorIs(s.svc.SetPassword(ctx, "user", "", Bcrypt), ErrEmptyPassword)
+}
+
+func (s *ServiceTestSuite) Test_SetRole() {
+	ctx := context.Background()
+	s.Require().NoError(s.svc.SetRole(ctx, "user", Admin))
+	user, err := s.svc.Authenticate(ctx, "user", "secret")
+	s.Require().NoError(err)
+	s.Equal(Admin, user.Role)
+
+	s.ErrorIs(s.svc.SetRole(ctx, "nobody", Admin), ErrNoUser)
+	s.ErrorIs(s.svc.SetRole(ctx, "user", "root"), ErrInvalidRole)
+}
+
+func (s *ServiceTestSuite) Test_DisableUser() {
+	ctx := context.Background()
+	s.Require().NoError(s.svc.DisableUser(ctx, "user"))
+	_, err := s.svc.Authenticate(ctx, "user", "secret")
+	s.ErrorIs(err, ErrInvalidCredentials)
+
+	s.ErrorIs(s.svc.DisableUser(ctx, "nobody"), ErrNoUser)
+}
+
+func TestService(t *testing.T) {
+	suite.Run(t, new(ServiceTestSuite))
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrTokensDisabled = errors.New("access tokens are disabled, no signing keys are configured")
+
+// Token is a signed access token.
+type Token struct {
+	AccessToken string
+	ExpiresAt   time.Time
+}
+
+// tokenKeys is a keyring with the ttl of tokens it signs, replaced together.
+type tokenKeys struct {
+	keyring *Keyring
+	ttl     time.Duration
+}
+
+// SetKeyring enables access tokens signed with the keyring, expiring after ttl, or disables them when it is nil.
+// It may be called while tokens are checked, to rotate the keys.
+func (s *Service) SetKeyring(keyring *Keyring, ttl time.Duration) {
+	if keyring == nil {
+		s.tokens.Store(nil)
+		return
+	}
+	s.tokens.Store(&tokenKeys{keyring: keyring, ttl: ttl})
+}
+
+// IssueToken returns an access token of an enabled user.
+func (s *Service) IssueToken(ctx context.Context, name string) (Token, error) {
+	keys := s.tokens.Load()
+	if keys == nil {
+		return Token{}, ErrTokensDisabled
+	}
+	_, err := s.enabledUser(ctx, name)
+	if err != nil {
+		return Token{}, err
+	}
+	id, err := randomId(16)
+	if err != nil {
+		return Token{}, err
+	}
+	now := s.now()
+	expiresAt := now.Add(keys.ttl)
+	token, err := keys.keyring.Sign(Claims{
+		Id:        id,
+		Subject:   name,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return Token{}, err
+	}
+	return Token{AccessToken: token, ExpiresAt: time.Unix(expiresAt.Unix(), 0).UTC()}, nil
+}
+
+// RevokeToken adds an access token to the revocation list, so that it may not be used until it expires.
+func (s *Service) RevokeToken(ctx context.Context, token string) error {
+	keys := s.tokens.Load()
+	if keys == nil {
+		return ErrTokensDisabled
+	}
+	now := s.now()
+	claims, err := keys.keyring.Parse(token, now)
+	if err != nil {
+		return err
+	}
+	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		return s.revoked.Revoke(ctxTX, claims.Id, time.Unix(claims.ExpiresAt, 0).UTC(), now)
+	})
+}
+
+// CreateKey returns a new API key of a user, only its hash is stored, so the key cannot be shown again.
+func (s *Service) CreateKey(ctx context.Context, user string, name string) (APIKey, string, error) {
+	secret, err := newAPIKey()
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	key := APIKey{
+		User:      user,
+		Name:      name,
+		Prefix:    secret[:apiKeyShownLen],
+		Hash:      hashAPIKey(secret),
+		CreatedAt: s.now(),
+	}
+	err = s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		var err error
+		key, err = s.keys.Create(ctxTX, key)
+		return err
+	})
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+// ListKeys returns API keys of a user, revoked ones included.
+func (s *Service) ListKeys(ctx context.Context, user string) ([]APIKey, error) {
+	var keys []APIKey
+	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		var err error
+		keys, err = s.keys.List(ctxTX, user)
+		return err
+	})
+	return keys, err
+}
+
+// RevokeKey makes an API key unusable, only its owner or an admin may revoke it.
+// It fails with ErrNoKey when there is no such key or it belongs to another user.
+func (s *Service) RevokeKey(ctx context.Context, user string, role Role, id uint64) error {
+	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		key, err := s.keys.Get(ctxTX, id)
+		if err != nil {
+			return err
+		}
+		if key.User != user && !role.Allows(Admin) {
+			return ErrNoKey
+		}
+		if key.Revoked() {
+			return nil
+		}
+		now := s.now()
+		key.RevokedAt = &now
+		return s.keys.Update(ctxTX, key)
+	})
+}
+
+// AuthenticateToken returns the enabled user an access token or an API key was issued to.
+// It fails with ErrInvalidCredentials when the token is invalid, expired or revoked, or the user is disabled.
+func (s *Service) AuthenticateToken(ctx context.Context, token string) (User, error) {
+	var name string
+	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		if isAPIKey(token) {
+			key, err := s.keys.GetByHash(ctxTX, hashAPIKey(token))
+			if errors.Is(err, ErrNoKey) {
+				return ErrInvalidCredentials
+			}
+			if err != nil {
+				return err
+			}
+			if key.Revoked() {
+				return ErrInvalidCredentials
+			}
+			name = key.User
+			return nil
+		}
+		keys := s.tokens.Load()
+		if keys == nil {
+			return ErrInvalidCredentials
+		}
+		claims, err := keys.keyring.Parse(token, s.now())
+		if err != nil {
+			return ErrInvalidCredentials
+		}
+		revoked, err := s.revoked.Revoked(ctxTX, claims.Id)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrInvalidCredentials
+		}
+		name = claims.Subject
+		return nil
+	})
+	if err != nil {
+		return User{}, err
+	}
+	user, err := s.enabledUser(ctx, name)
+	if errors.Is(err, ErrNoUser) {
+		return User{}, ErrInvalidCredentials
+	}
+	return user, err
+}
+
+// enabledUser returns the user with the name, it fails with ErrInvalidCredentials when the user is disabled.
+func (s *Service) enabledUser(ctx context.Context, name string) (User, error) {
+	var user User
+	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		var err error
+		user, err = s.repo.Get(ctxTX, name)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+	if user.Disabled {
+		return User{}, ErrInvalidCredentials
+	}
+	if user.Role == "" {
+		user.Role = Viewer
+	}
+	return user, nil
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"github.com/stretchr/testify/suite"
+	"homework/internal/app/db"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type TokensTestSuite struct {
+	suite.Suite
+	svc *Service
+	now time.Time
+}
+
+func (s *TokensTestSuite) SetupTest() {
+	s.now = time.Date(2024, 7, 10, 12, 0, 0, 0, time.UTC)
+	s.svc = NewService(NewFileRepository(), NewFileKeyRepository(), NewFileRevocationList(), db.Dummy{})
+	s.svc.now = func() time.Time {
+		return s.now
+	}
+	key, err := NewSigningKey("1")
+	s.Require().NoError(err)
+	keyring, err := NewKeyring([]SigningKey{key})
+	s.Require().NoError(err)
+	s.svc.SetKeyring(keyring, 15*time.Minute)
+	_, err = s.svc.AddUser(context.Background(), "user", "secret", Bcrypt, Operator)
+	s.Require().NoError(err)
+	_, err = s.svc.AddUser(context.Background(), "admin", "secret", Bcrypt, Admin)
+	s.Require().NoError(err)
+}
+
+func (s *TokensTestSuite) Test_AccessToken() {
+	ctx := context.Background()
+	token, err := s.svc.IssueToken(ctx, "user")
+	s.Require().NoError(err)
+	s.Equal(s.now.Add(15*time.Minute), token.ExpiresAt)
+
+	user, err := s.svc.AuthenticateToken(ctx, token.AccessToken)
+	s.Require().NoError(err)
+	s.Equal("user", user.Name)
+	s.Equal(Operator, user.Role)
+
+	s.Require().NoError(s.svc.SetRole(ctx, "user", Viewer))
+	user, err = s.svc.AuthenticateToken(ctx, token.AccessToken)
+	s.Require().NoError(err)
+	s.Equal(Viewer, user.Role, "the current role must be used")
+
+	s.now = s.now.Add(15 * time.Minute)
+	_, err = s.svc.AuthenticateToken(ctx, token.AccessToken)
+	s.ErrorIs(err, ErrInvalidCredentials)
+}
+
+func (s *TokensTestSuite) Test_RevokeToken() {
+	ctx := context.Background()
+	token, err := s.svc.IssueToken(ctx, "user")
+	s.Require().NoError(err)
+	other, err := s.svc.IssueToken(ctx, "user")
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.svc.RevokeToken(ctx, token.AccessToken))
+	_, err = s.svc.AuthenticateToken(ctx, token.AccessToken)
+	s.ErrorIs(err, ErrInvalidCredentials)
+	_, err = s.svc.AuthenticateToken(ctx, other.AccessToken)
+	s.NoError(err)
+
+	s.ErrorIs(s.svc.RevokeToken(ctx, "token"), ErrInvalidToken)
+}
+
+func (s *TokensTestSuite) Test_DisabledUser() {
+	ctx := context.Background()
+	token, err := s.svc.IssueToken(ctx, "user")
+	s.Require().NoError(err)
+	_, key, err := s.svc.CreateKey(ctx, "user", "ci")
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.svc.DisableUser(ctx, "user"))
+	_, err = s.svc.AuthenticateToken(ctx, token.AccessToken)
+	s.ErrorIs(err, ErrInvalidCredentials)
+	_, err = s.svc.AuthenticateToken(ctx, key)
+	s.ErrorIs(err, ErrInvalidCredentials)
+	_, err = s.svc.IssueToken(ctx, "user")
+	s.ErrorIs(err, ErrInvalidCredentials)
+}
+
+func (s *TokensTestSuite) Test_RotatedKey() {
+	ctx := context.Background()
+	path := filepath.Join(s.T().TempDir(), "token_keys.json")
+	file := NewKeyringFile(path)
+	modTime := time.Now()
+	write := func(content func() error) {
+		s.Require().NoError(content())
+		// file systems may keep modification times too coarse to tell writes apart
+		modTime = modTime.Add(time.Second)
+		s.Require().NoError(os.Chtimes(path, modTime, modTime))
+	}
+	reload := func() {
+		keyring, err := file.Reload()
+		s.Require().NoError(err)
+		s.Require().NotNil(keyring)
+		s.svc.SetKeyring(keyring, 15*time.Minute)
+	}
+	oldKey, newKey := testKey(s.T(), "1"), testKey(s.T(), "2")
+
+	write(func() error { return WriteSigningKeys(path, []SigningKey{oldKey}) })
+	reload()
+	signedByOld, err := s.svc.IssueToken(ctx, "user")
+	s.Require().NoError(err)
+	keyring, err := file.Reload()
+	s.NoError(err)
+	s.Nil(keyring, "an unchanged file is not read again")
+
+	write(func() error { return WriteSigningKeys(path, []SigningKey{newKey, oldKey}) })
+	reload()
+	signedByNew, err := s.svc.IssueToken(ctx, "user")
+	s.Require().NoError(err)
+	oldKeyring, err := NewKeyring([]SigningKey{oldKey})
+	s.Require().NoError(err)
+	_, err = oldKeyring.Parse(signedByNew.AccessToken, s.now)
+	s.ErrorIs(err, ErrInvalidToken, "new tokens are signed with the new key")
+	_, err = s.svc.AuthenticateToken(ctx, signedByOld.AccessToken)
+	s.NoError(err, "tokens signed with a kept key stay valid")
+	_, err = s.svc.AuthenticateToken(ctx, signedByNew.AccessToken)
+	s.NoError(err)
+
+	write(func() error { return os.WriteFile(path, []byte("[{"), 0600) })
+	_, err = file.Reload()
+	s.Error(err)
+	_, err = s.svc.AuthenticateToken(ctx, signedByNew.AccessToken)
+	s.NoError(err, "keys loaded before are used on")
+
+	write(func() error { return WriteSigningKeys(path, []SigningKey{newKey}) })
+	reload()
+	_, err = s.svc.AuthenticateToken(ctx, signedByOld.AccessToken)
+	s.ErrorIs(err, ErrInvalidCredentials, "tokens signed with a dropped key are rejected")
+	_, err = s.svc.AuthenticateToken(ctx, signedByNew.AccessToken)
+	s.NoError(err)
+}
+
+func (s *TokensTestSuite) Test_TokensDisabled() {
+	ctx := context.Background()
+	token, err := s.svc.IssueToken(ctx, "user")
+	s.Require().NoError(err)
+
+	s.svc.SetKeyring(nil, 0)
+	_, err = s.svc.IssueToken(ctx, "user")
+	s.ErrorIs(err, ErrTokensDisabled)
+	_, err = s.svc.AuthenticateToken(ctx, token.AccessToken)
+	s.ErrorIs(err, ErrInvalidCredentials)
+}
+
+func (s *TokensTestSuite) Test_APIKey() {
+	ctx := context.Background()
+	key, secret, err := s.svc.CreateKey(ctx, "user", "ci")
+	s.Require().NoError(err)
+	s.True(strings.HasPrefix(secret, key.Prefix))
+	s.NotContains(key.Hash, secret)
+
+	user, err := s.svc.AuthenticateToken(ctx, secret)
+	s.Require().NoError(err)
+	s.Equal("user", user.Name)
+
+	_, err = s.svc.AuthenticateToken(ctx, secret+"x")
+	s.ErrorIs(err, ErrInvalidCredentials)
+
+	keys, err := s.svc.ListKeys(ctx, "user")
+	s.Require().NoError(err)
+	s.Equal([]APIKey{key}, keys)
+
+	s.ErrorIs(s.svc.RevokeKey(ctx, "other", Operator, key.Id), ErrNoKey)
+	s.ErrorIs(s.svc.RevokeKey(ctx, "user", Operator, key.Id+1), ErrNoKey)
+	s.Require().NoError(s.svc.RevokeKey(ctx, "user", Operator, key.Id))
+	_, err = s.svc.AuthenticateToken(ctx, secret)
+	s.ErrorIs(err, ErrInvalidCredentials)
+
+	keys, err = s.svc.ListKeys(ctx, "user")
+	s.Require().NoError(err)
+	s.Require().Len(keys, 1)
+	s.True(keys[0].Revoked())
+}
+
+func (s *TokensTestSuite) Test_AdminRevokesKey() {
+	ctx := context.Background()
+	key, secret, err := s.svc.CreateKey(ctx, "user", "ci")
+	s.Require().NoError(err)
+	s.Require().NoError(s.svc.RevokeKey(ctx, "admin", Admin, key.Id))
+	_, err = s.svc.AuthenticateToken(ctx, secret)
+	s.ErrorIs(err, ErrInvalidCredentials)
+}
+
+func (s *TokensTestSuite) Test_AuthenticateCertificate() {
+	ctx := context.Background()
+	s.svc.SetCertificateUsers(map[string]string{
+		"CN=billing,O=Internal":  "admin",
+		"CN=shipping,O=Internal": "user",
+		"CN=nobody,O=Internal":   "nobody",
+	})
+	tests := []struct {
+		name    string
+		subject pkix.Name
+		want    string
+		err     error
+	}{
+		{
+			name:    "not mapped",
+			subject: pkix.Name{CommonName: "user", Organization: []string{"Internal"}},
+			err:     ErrInvalidCredentials,
+		},
+		{
+			name:    "mapped to user",
+			subject: pkix.Name{CommonName: "shipping", Organization: []string{"Internal"}},
+			want:    "user",
+		},
+		{
+			name:    "mapped subject",
+			subject: pkix.Name{CommonName: "billing", Organization: []string{"Internal"}},
+			want:    "admin",
+		},
+		{
+			name:    "unknown user",
+			subject: pkix.Name{CommonName: "nobody", Organization: []string{"Internal"}},
+			err:     ErrInvalidCredentials,
+		},
+		{
+			name: "no common name",
+			err:  ErrInvalidCredentials,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			user, err := s.svc.AuthenticateCertificate(ctx, tt.subject)
+			if tt.err != nil {
+				s.ErrorIs(err, tt.err)
+				return
+			}
+			s.NoError(err)
+			s.Equal(tt.want, user.Name)
+		})
+	}
+
+	s.Require().NoError(s.svc.DisableUser(ctx, "user"))
+	_, err := s.svc.AuthenticateCertificate(ctx, pkix.Name{CommonName: "shipping", Organization: []string{"Internal"}})
+	s.ErrorIs(err, ErrInvalidCredentials)
+}
+
+func TestTokens(t *testing.T) {
+	suite.Run(t, new(TokensTestSuite))
+}
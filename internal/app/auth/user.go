@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrUserExists         = errors.New("user with such name already exists")
+	ErrNoUser             = errors.New("no such user found")
+	ErrInvalidCredentials = errors.New("invalid user name or password")
+	ErrEmptyName          = errors.New("user name must not be empty")
+	ErrEmptyPassword      = errors.New("password must not be empty")
+)
+
+// User is an account allowed to use the API, only a hash of its password is stored.
+type User struct {
+	Id           uint64    `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	PasswordHash string    `json:"password_hash" db:"password_hash"`
+	Role         Role      `json:"role" db:"role"`
+	Disabled     bool      `json:"disabled" db:"disabled"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+type Repository interface {
+	Create(ctx context.Context, user User) error
+	Get(ctx context.Context, name string) (User, error)
+	Update(ctx context.Context, user User) error
+}
+
+type TransactionManager interface {
+	RunSerializable(ctx context.Context, f func(ctxTX context.Context) error) error
+}
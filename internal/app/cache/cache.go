@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"errors"
+	"homework/internal/app/metrics"
 	"homework/internal/app/pickuppoint"
 	"sync"
 	"time"
@@ -73,7 +74,9 @@ func (c *Cache) GetPoint(id uint64) (pickuppoint.PickUpPoint, error) {
 	c.pointsMutex.RLock()
 	item, ok := c.points[id]
 	c.pointsMutex.RUnlock()
-	if !ok || item.expire.Before(time.Now()) {
+	ok = ok && !item.expire.Before(time.Now())
+	metrics.Lookup(metrics.MemoryCache, ok, nil)
+	if !ok {
 		return pickuppoint.PickUpPoint{}, errors.New("point not found")
 	}
 	return item.value, nil
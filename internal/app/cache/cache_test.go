@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"homework/internal/app/metrics"
+	"homework/internal/app/pickuppoint"
+	"testing"
+	"time"
+)
+
+func TestGetPointCountsLookups(t *testing.T) {
+	hits := metrics.CacheLookups.WithLabelValues(metrics.MemoryCache, metrics.Hit)
+	misses := metrics.CacheLookups.WithLabelValues(metrics.MemoryCache, metrics.Miss)
+	hitsBefore, missesBefore := testutil.ToFloat64(hits), testutil.ToFloat64(misses)
+
+	c := NewCache(time.Minute, time.Minute)
+	c.PutPoint(pickuppoint.PickUpPoint{Id: 1})
+	_, err := c.GetPoint(1)
+	assert.NoError(t, err)
+	_, err = c.GetPoint(2)
+	assert.Error(t, err)
+
+	expired := NewCache(-time.Minute, time.Minute)
+	expired.PutPoint(pickuppoint.PickUpPoint{Id: 1})
+	_, err = expired.GetPoint(1)
+	assert.Error(t, err)
+
+	assert.Equal(t, hitsBefore+1, testutil.ToFloat64(hits))
+	assert.Equal(t, missesBefore+2, testutil.ToFloat64(misses))
+}
@@ -1,9 +1,12 @@
 package core
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"homework/internal/app/order"
 	"homework/internal/app/packaging"
+	"homework/internal/app/pickuppoint"
 	"time"
 )
 
@@ -12,10 +15,13 @@ const dateFormat = "2006-01-02"
 type AcceptOrderRequest struct {
 	OrderId        uint64
 	CustomerId     uint64
+	PickUpPointId  uint64
 	KeepDateString string
 	PriceRub       int64
 	WeightKg       float64
 	PackagingType  string
+	// EnforceCapacity rejects orders not assigned to a pick-up point, whose capacity could not be checked.
+	EnforceCapacity bool
 }
 
 func (s *OrderCoreService) AcceptOrder(req AcceptOrderRequest) error {
@@ -25,6 +31,9 @@ func (s *OrderCoreService) AcceptOrder(req AcceptOrderRequest) error {
 	if req.CustomerId == 0 {
 		return errors.New("valid customer id is required")
 	}
+	if req.EnforceCapacity && req.PickUpPointId == 0 {
+		return errors.New("pick-up point id is required to enforce its capacity")
+	}
 
 	if req.KeepDateString == "" {
 		return errors.New("keep date is required")
@@ -56,12 +65,13 @@ func (s *OrderCoreService) AcceptOrder(req AcceptOrderRequest) error {
 	}
 
 	o := order.Order{
-		KeepDate:   keepDate,
-		AddDate:    now,
-		Id:         req.OrderId,
-		CustomerId: req.CustomerId,
-		PriceRub:   req.PriceRub,
-		WeightKg:   req.WeightKg,
+		KeepDate:      keepDate,
+		AddDate:       now,
+		Id:            req.OrderId,
+		CustomerId:    req.CustomerId,
+		PickUpPointId: req.PickUpPointId,
+		PriceRub:      req.PriceRub,
+		WeightKg:      req.WeightKg,
 	}
 	if packagingVariant != nil {
 		var err error
@@ -70,5 +80,23 @@ func (s *OrderCoreService) AcceptOrder(req AcceptOrderRequest) error {
 			return err
 		}
 	}
-	return s.orderService.AddOrder(o)
+	if o.PickUpPointId == 0 {
+		return s.orderService.AddOrder(context.Background(), o)
+	}
+	point, err := s.pointGetter.GetPoint(context.Background(), o.PickUpPointId)
+	if err != nil {
+		return err
+	}
+	return s.orderService.AddOrderAdmitted(context.Background(), o, admitInto(point, o))
+}
+
+// admitInto returns a check failing with pickuppoint.ErrFull when the order does not fit into the pick-up point.
+func admitInto(point pickuppoint.PickUpPoint, o order.Order) func(taken order.Occupancy) error {
+	return func(taken order.Occupancy) error {
+		if !point.Capacity.Admits(taken.Orders+1, taken.WeightKg+o.WeightKg) {
+			return fmt.Errorf("%w: %s, taken by %d orders of %.3f kg",
+				pickuppoint.ErrFull, point.Capacity, taken.Orders, taken.WeightKg)
+		}
+		return nil
+	}
 }
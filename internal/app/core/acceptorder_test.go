@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"homework/internal/app/db"
+	"homework/internal/app/kvstore"
+	"homework/internal/app/order"
+	"homework/internal/app/pickuppoint"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type pointGetterStub pickuppoint.PickUpPoint
+
+func (p pointGetterStub) GetPoint(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error) {
+	if id != p.Id {
+		return pickuppoint.PickUpPoint{}, pickuppoint.ErrNoItemFound
+	}
+	return pickuppoint.PickUpPoint(p), nil
+}
+
+func TestAcceptOrder_ConcurrentLastSlot(t *testing.T) {
+	const accepts = 8
+	tests := []struct {
+		name string
+		// open returns order services sharing one storage, accepts are spread between them.
+		open func(t *testing.T) []*order.Service
+	}{
+		{
+			name: "file",
+			open: func(t *testing.T) []*order.Service {
+				path := filepath.Join(t.TempDir(), "orders.json")
+				var services []*order.Service
+				for i := 0; i < 2; i++ {
+					repo, err := order.OpenFileRepository(path, 0600)
+					require.NoError(t, err)
+					t.Cleanup(func() { assert.NoError(t, repo.Close()) })
+					services = append(services, order.NewService(repo, db.Dummy{}))
+				}
+				return services
+			},
+		},
+		{
+			name: "bolt",
+			open: func(t *testing.T) []*order.Service {
+				kv, err := kvstore.Open(filepath.Join(t.TempDir(), "storage.db"), 0600)
+				require.NoError(t, err)
+				t.Cleanup(func() { assert.NoError(t, kv.Close()) })
+				repo, err := order.NewBoltRepository(kv)
+				require.NoError(t, err)
+				return []*order.Service{order.NewService(repo, kv)}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			point := pointGetterStub{Id: 1, Capacity: pickuppoint.Capacity{Slots: 2}}
+			services := tt.open(t)
+			var cores []*OrderCoreService
+			for _, svc := range services {
+				cores = append(cores, NewOrderCoreService(svc, point, nil))
+			}
+			req := AcceptOrderRequest{
+				CustomerId:     10,
+				PickUpPointId:  1,
+				KeepDateString: time.Now().AddDate(0, 0, 7).Format(dateFormat),
+				PriceRub:       100,
+				WeightKg:       1,
+			}
+			req.OrderId = 1
+			require.NoError(t, cores[0].AcceptOrder(req))
+
+			errs := make([]error, accepts)
+			var wg sync.WaitGroup
+			for i := 0; i < accepts; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					req := req
+					req.OrderId = uint64(i + 2)
+					errs[i] = cores[i%len(cores)].AcceptOrder(req)
+				}(i)
+			}
+			wg.Wait()
+
+			accepted := 0
+			for _, err := range errs {
+				if err == nil {
+					accepted++
+					continue
+				}
+				assert.ErrorIs(t, err, pickuppoint.ErrFull)
+			}
+			assert.Equal(t, 1, accepted, "only one order takes the last slot")
+			occupancy, err := services[0].GetOccupancy(context.Background(), 1)
+			require.NoError(t, err)
+			assert.Equal(t, 2, occupancy[1].Orders)
+		})
+	}
+}
+
+func TestAcceptOrder_EnforceCapacity(t *testing.T) {
+	repo, err := order.NewFileRepository(strings.NewReader(""))
+	require.NoError(t, err)
+	svc := NewOrderCoreService(order.NewService(repo, db.Dummy{}), pointGetterStub{Id: 1}, nil)
+	req := AcceptOrderRequest{
+		OrderId:         1,
+		CustomerId:      10,
+		KeepDateString:  time.Now().AddDate(0, 0, 7).Format(dateFormat),
+		PriceRub:        100,
+		WeightKg:        1,
+		EnforceCapacity: true,
+	}
+
+	assert.ErrorContains(t, svc.AcceptOrder(req), "pick-up point id is required")
+	req.EnforceCapacity = false
+	assert.NoError(t, svc.AcceptOrder(req))
+}
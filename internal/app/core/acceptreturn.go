@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 )
 
@@ -17,5 +18,5 @@ func (s *OrderCoreService) AcceptReturn(req AcceptReturnRequest) error {
 	if req.CustomerId == 0 {
 		return errors.New("valid customer id is required")
 	}
-	return s.orderService.AcceptReturn(req.OrderId, req.CustomerId)
+	return s.orderService.AcceptReturn(context.Background(), req.OrderId, req.CustomerId)
 }
@@ -1,10 +1,22 @@
 package core
 
 import (
+	"context"
 	"errors"
+	"go.opentelemetry.io/otel/attribute"
 	"homework/internal/app/pickuppoint"
+	"homework/internal/app/tracing"
 )
 
+// cachedPoint looks a pick-up point up in the in-memory cache within a span telling whether it was found.
+func (s *pickUpPointCoreService) cachedPoint(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, bool) {
+	_, span := tracing.Start(ctx, "cache get")
+	defer span.End()
+	point, err := s.cache.GetPoint(id)
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+	return point, err == nil
+}
+
 type NilCache struct {
 }
 
@@ -2,27 +2,53 @@ package core
 
 import (
 	"context"
+	"errors"
 	"homework/internal/app/pickuppoint"
 )
 
+var (
+	ErrUnexpectedId = errors.New("point id is assigned automatically and must not be provided")
+	ErrIdRequired   = errors.New("point id must be provided when importing")
+)
+
+// CreatePointRequest describes a pick-up point to create.
+// The id is assigned by the storage unless Import is set, in which case the provided id is kept.
 type CreatePointRequest struct {
-	Id      uint64 `json:"id"`
-	Name    string `json:"name"`
-	Address string `json:"address"`
-	Contact string `json:"contact"`
+	Id       uint64                `json:"id"`
+	Name     string                `json:"name"`
+	Address  pickuppoint.Address   `json:"address"`
+	Contact  pickuppoint.Contact   `json:"contact"`
+	Schedule pickuppoint.Schedule  `json:"schedule"`
+	Location *pickuppoint.Location `json:"location,omitempty"`
+	Capacity pickuppoint.Capacity  `json:"capacity"`
+	Import   bool                  `json:"-"`
 }
 
-func (s *pickUpPointCoreService) CreatePoint(ctx context.Context, req CreatePointRequest) error {
+func (s *pickUpPointCoreService) CreatePoint(ctx context.Context, req CreatePointRequest) (pickuppoint.PickUpPoint, error) {
+	if !req.Import && req.Id != 0 {
+		return pickuppoint.PickUpPoint{}, ErrUnexpectedId
+	}
+	if req.Import && req.Id == 0 {
+		return pickuppoint.PickUpPoint{}, ErrIdRequired
+	}
 	point := pickuppoint.PickUpPoint{
-		Id:      req.Id,
-		Name:    req.Name,
-		Address: req.Address,
-		Contact: req.Contact,
+		Id:       req.Id,
+		Name:     req.Name,
+		Address:  req.Address,
+		Contact:  req.Contact,
+		Schedule: req.Schedule,
+		Location: req.Location,
+		Capacity: req.Capacity,
+	}
+	err := validatePoint(point)
+	if err != nil {
+		return pickuppoint.PickUpPoint{}, err
 	}
-	err := s.pointService.CreatePoint(ctx, point)
+	point, err = s.pointService.CreatePoint(ctx, point)
 	if err != nil {
-		return err
+		return pickuppoint.PickUpPoint{}, err
 	}
 	s.cache.PutPoint(point)
-	return nil
+	s.geoIndex.PutPoint(point)
+	return point, nil
 }
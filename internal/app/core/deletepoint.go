@@ -1,12 +1,34 @@
 package core
 
-import "context"
+import (
+	"context"
+	"homework/internal/app/pickuppoint"
+	"time"
+)
 
-func (s *pickUpPointCoreService) DeletePoint(ctx context.Context, id uint64) error {
-	err := s.pointService.DeletePoint(ctx, id)
+// DeletePoint marks a pick-up point deleted, unless version is zero the stored point must have that version.
+func (s *pickUpPointCoreService) DeletePoint(ctx context.Context, id uint64, version uint64) error {
+	err := s.pointService.DeletePoint(ctx, id, version)
 	if err != nil {
 		return err
 	}
 	s.cache.DeletePoint(id)
+	s.geoIndex.DeletePoint(id)
 	return nil
 }
+
+// RestorePoint brings back a deleted pick-up point, unless version is zero the stored point must have that version.
+func (s *pickUpPointCoreService) RestorePoint(ctx context.Context, id uint64, version uint64) (pickuppoint.PickUpPoint, error) {
+	point, err := s.pointService.RestorePoint(ctx, id, version)
+	if err != nil {
+		return pickuppoint.PickUpPoint{}, err
+	}
+	s.cache.PutPoint(point)
+	s.geoIndex.PutPoint(point)
+	return point, nil
+}
+
+// PurgePoints removes pick-up points deleted before the provided time for good.
+func (s *pickUpPointCoreService) PurgePoints(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return s.pointService.PurgePoints(ctx, deletedBefore)
+}
@@ -0,0 +1,22 @@
+package core
+
+import (
+	"errors"
+	"homework/internal/app/pickuppoint"
+)
+
+type NilGeoIndex struct {
+}
+
+func (n NilGeoIndex) SetPoints(points []pickuppoint.PickUpPoint) {
+}
+
+func (n NilGeoIndex) PutPoint(point pickuppoint.PickUpPoint) {
+}
+
+func (n NilGeoIndex) DeletePoint(id uint64) {
+}
+
+func (n NilGeoIndex) Nearest(loc pickuppoint.Location, limit int, filter func(pickuppoint.PickUpPoint) bool) ([]pickuppoint.Nearby, error) {
+	return nil, errors.New("not loaded")
+}
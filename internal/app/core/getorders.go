@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"homework/internal/app/order"
 )
@@ -19,5 +20,5 @@ func (s *OrderCoreService) ListOrders(req ListOrdersRequest) ([]order.Order, err
 	if req.DisplayCount < 0 {
 		return nil, errors.New("n must not be negative")
 	}
-	return s.orderService.GetOrders(req.CustomerId, req.DisplayCount, req.FilterGiven)
+	return s.orderService.GetOrders(context.Background(), req.CustomerId, req.DisplayCount, req.FilterGiven)
 }
@@ -6,9 +6,18 @@ import (
 )
 
 func (s *pickUpPointCoreService) GetPoint(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error) {
-	point, err := s.cache.GetPoint(id)
-	if err == nil {
+	point, ok := s.cachedPoint(ctx, id)
+	if ok {
 		return point, nil
 	}
 	return s.pointService.GetPoint(ctx, id)
 }
+
+// GetPointIncludingDeleted returns a pick-up point even when it is deleted.
+func (s *pickUpPointCoreService) GetPointIncludingDeleted(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error) {
+	point, ok := s.cachedPoint(ctx, id)
+	if ok {
+		return point, nil
+	}
+	return s.pointService.GetPointIncludingDeleted(ctx, id)
+}
@@ -1,11 +1,14 @@
 package core
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 // GiveOrders marks orders represented by provided ids as given to customer.
 func (s *OrderCoreService) GiveOrders(orderIds []uint64) error {
 	if orderIds == nil {
 		return errors.New("list of valid ids is required")
 	}
-	return s.orderService.GiveOrders(orderIds)
+	return s.orderService.GiveOrders(context.Background(), orderIds)
 }
@@ -0,0 +1,17 @@
+package core
+
+import (
+	"context"
+	"homework/internal/app/pickuppoint"
+)
+
+// MaxHistoryLimit caps a page of history entries.
+const MaxHistoryLimit = 100
+
+// GetHistory returns a page of changes made to a pick-up point, newest first.
+func (s *pickUpPointCoreService) GetHistory(ctx context.Context, id uint64, opts pickuppoint.HistoryOptions) (pickuppoint.HistoryPage, error) {
+	if opts.Limit < 1 || opts.Limit > MaxHistoryLimit {
+		return pickuppoint.HistoryPage{}, ErrInvalidLimit
+	}
+	return s.pointService.History(ctx, id, opts)
+}
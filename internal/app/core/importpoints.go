@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"homework/internal/app/pickuppoint"
+)
+
+const MaxBatchRows = 1000
+
+var ErrBatchTooLarge = errors.New("batch must not have more than 1000 pick-up points")
+
+// ImportPointsRequest describes a batch of pick-up points to create.
+// Points keep provided ids, the storage assigns ids to the rest.
+// With DryRun set the batch is only checked.
+type ImportPointsRequest struct {
+	Rows   []pickuppoint.BatchRow
+	DryRun bool
+}
+
+func (s *pickUpPointCoreService) ImportPoints(ctx context.Context, req ImportPointsRequest) (pickuppoint.BatchReport, error) {
+	if len(req.Rows) > MaxBatchRows {
+		return pickuppoint.BatchReport{}, ErrBatchTooLarge
+	}
+	rows := make([]pickuppoint.BatchRow, len(req.Rows))
+	for i, row := range req.Rows {
+		if row.Err == nil {
+			row.Err = validatePoint(row.Point)
+		}
+		rows[i] = row
+	}
+	report, err := s.pointService.ImportPoints(ctx, rows, req.DryRun)
+	if err != nil {
+		return report, err
+	}
+	for _, result := range report.Rows {
+		if result.Status != pickuppoint.RowCreated {
+			continue
+		}
+		point := rows[result.Row-1].Point
+		point.Id = result.Id
+		point.Version = 1
+		point.DeletedAt = nil
+		s.cache.PutPoint(point)
+		s.geoIndex.PutPoint(point)
+	}
+	return report, nil
+}
@@ -2,22 +2,38 @@ package core
 
 import (
 	"context"
+	"homework/internal/app/logger"
 	"homework/internal/app/pickuppoint"
 )
 
-func (s *pickUpPointCoreService) ListPoints(ctx context.Context) ([]pickuppoint.PickUpPoint, error) {
-	points, err := s.redis.GetPointList(ctx)
+// MaxListLimit caps a page of pick-up points, zero limit lifts the cap for internal callers.
+const MaxListLimit = 100
+
+// ListPoints lists a page of pick-up points selected by opts.
+// Pages are cached by options, except for points deleted before some time.
+func (s *pickUpPointCoreService) ListPoints(ctx context.Context, opts pickuppoint.ListOptions) (pickuppoint.Page, error) {
+	if opts.Limit < 0 || opts.Limit > MaxListLimit {
+		return pickuppoint.Page{}, ErrInvalidLimit
+	}
+	err := opts.Validate()
+	if err != nil {
+		return pickuppoint.Page{}, err
+	}
+	if !opts.DeletedBefore.IsZero() {
+		return s.pointService.ListPoints(ctx, opts)
+	}
+	page, err := s.redis.GetPointPage(ctx, opts)
 	if err == nil {
-		return points, nil
+		return page, nil
 	}
-	s.log.Log(err.Error())
-	points, err = s.pointService.ListPoints(ctx)
+	logger.WithContext(ctx, s.log).Log("%v", err)
+	page, err = s.pointService.ListPoints(ctx, opts)
 	if err != nil {
-		return nil, err
+		return pickuppoint.Page{}, err
 	}
-	err = s.redis.SetPointList(ctx, points)
+	err = s.redis.SetPointPage(ctx, opts, page)
 	if err != nil {
-		return nil, err
+		return pickuppoint.Page{}, err
 	}
-	return points, nil
+	return page, nil
 }
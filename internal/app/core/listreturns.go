@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"homework/internal/app/order"
 )
@@ -18,5 +19,5 @@ func (s *OrderCoreService) ListReturns(req ListReturnsRequest) ([]order.Order, e
 	if req.PageNum < 0 {
 		return nil, errors.New("invalid page number")
 	}
-	return s.orderService.GetReturns(req.Count, req.PageNum)
+	return s.orderService.GetReturns(context.Background(), req.Count, req.PageNum)
 }
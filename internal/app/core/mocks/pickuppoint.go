@@ -12,8 +12,10 @@ package mocks
 import (
 	context "context"
 	core "homework/internal/app/core"
+	order "homework/internal/app/order"
 	pickuppoint "homework/internal/app/pickuppoint"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -42,11 +44,12 @@ func (m *MockPickUpPointCoreService) EXPECT() *MockPickUpPointCoreServiceMockRec
 }
 
 // CreatePoint mocks base method.
-func (m *MockPickUpPointCoreService) CreatePoint(ctx context.Context, req core.CreatePointRequest) error {
+func (m *MockPickUpPointCoreService) CreatePoint(ctx context.Context, req core.CreatePointRequest) (pickuppoint.PickUpPoint, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreatePoint", ctx, req)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreatePoint indicates an expected call of CreatePoint.
@@ -56,17 +59,47 @@ func (mr *MockPickUpPointCoreServiceMockRecorder) CreatePoint(ctx, req any) *gom
 }
 
 // DeletePoint mocks base method.
-func (m *MockPickUpPointCoreService) DeletePoint(ctx context.Context, id uint64) error {
+func (m *MockPickUpPointCoreService) DeletePoint(ctx context.Context, id, version uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePoint", ctx, id)
+	ret := m.ctrl.Call(m, "DeletePoint", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeletePoint indicates an expected call of DeletePoint.
-func (mr *MockPickUpPointCoreServiceMockRecorder) DeletePoint(ctx, id any) *gomock.Call {
+func (mr *MockPickUpPointCoreServiceMockRecorder) DeletePoint(ctx, id, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePoint", reflect.TypeOf((*MockPickUpPointCoreService)(nil).DeletePoint), ctx, id, version)
+}
+
+// GetHistory mocks base method.
+func (m *MockPickUpPointCoreService) GetHistory(ctx context.Context, id uint64, opts pickuppoint.HistoryOptions) (pickuppoint.HistoryPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, id, opts)
+	ret0, _ := ret[0].(pickuppoint.HistoryPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockPickUpPointCoreServiceMockRecorder) GetHistory(ctx, id, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockPickUpPointCoreService)(nil).GetHistory), ctx, id, opts)
+}
+
+// GetOpenStatus mocks base method.
+func (m *MockPickUpPointCoreService) GetOpenStatus(ctx context.Context, id uint64, at time.Time) (pickuppoint.OpenStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOpenStatus", ctx, id, at)
+	ret0, _ := ret[0].(pickuppoint.OpenStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOpenStatus indicates an expected call of GetOpenStatus.
+func (mr *MockPickUpPointCoreServiceMockRecorder) GetOpenStatus(ctx, id, at any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePoint", reflect.TypeOf((*MockPickUpPointCoreService)(nil).DeletePoint), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOpenStatus", reflect.TypeOf((*MockPickUpPointCoreService)(nil).GetOpenStatus), ctx, id, at)
 }
 
 // GetPoint mocks base method.
@@ -84,19 +117,124 @@ func (mr *MockPickUpPointCoreServiceMockRecorder) GetPoint(ctx, id any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPoint", reflect.TypeOf((*MockPickUpPointCoreService)(nil).GetPoint), ctx, id)
 }
 
+// GetPointIncludingDeleted mocks base method.
+func (m *MockPickUpPointCoreService) GetPointIncludingDeleted(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPointIncludingDeleted", ctx, id)
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPointIncludingDeleted indicates an expected call of GetPointIncludingDeleted.
+func (mr *MockPickUpPointCoreServiceMockRecorder) GetPointIncludingDeleted(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPointIncludingDeleted", reflect.TypeOf((*MockPickUpPointCoreService)(nil).GetPointIncludingDeleted), ctx, id)
+}
+
+// GetUtilisation mocks base method.
+func (m *MockPickUpPointCoreService) GetUtilisation(ctx context.Context, points []pickuppoint.PickUpPoint) (map[uint64]pickuppoint.Utilisation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUtilisation", ctx, points)
+	ret0, _ := ret[0].(map[uint64]pickuppoint.Utilisation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUtilisation indicates an expected call of GetUtilisation.
+func (mr *MockPickUpPointCoreServiceMockRecorder) GetUtilisation(ctx, points any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUtilisation", reflect.TypeOf((*MockPickUpPointCoreService)(nil).GetUtilisation), ctx, points)
+}
+
+// ImportPoints mocks base method.
+func (m *MockPickUpPointCoreService) ImportPoints(ctx context.Context, req core.ImportPointsRequest) (pickuppoint.BatchReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportPoints", ctx, req)
+	ret0, _ := ret[0].(pickuppoint.BatchReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportPoints indicates an expected call of ImportPoints.
+func (mr *MockPickUpPointCoreServiceMockRecorder) ImportPoints(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportPoints", reflect.TypeOf((*MockPickUpPointCoreService)(nil).ImportPoints), ctx, req)
+}
+
 // ListPoints mocks base method.
-func (m *MockPickUpPointCoreService) ListPoints(ctx context.Context) ([]pickuppoint.PickUpPoint, error) {
+func (m *MockPickUpPointCoreService) ListPoints(ctx context.Context, opts pickuppoint.ListOptions) (pickuppoint.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListPoints", ctx)
-	ret0, _ := ret[0].([]pickuppoint.PickUpPoint)
+	ret := m.ctrl.Call(m, "ListPoints", ctx, opts)
+	ret0, _ := ret[0].(pickuppoint.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListPoints indicates an expected call of ListPoints.
-func (mr *MockPickUpPointCoreServiceMockRecorder) ListPoints(ctx any) *gomock.Call {
+func (mr *MockPickUpPointCoreServiceMockRecorder) ListPoints(ctx, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPoints", reflect.TypeOf((*MockPickUpPointCoreService)(nil).ListPoints), ctx, opts)
+}
+
+// NearestPoints mocks base method.
+func (m *MockPickUpPointCoreService) NearestPoints(ctx context.Context, req core.NearestPointsRequest) ([]pickuppoint.Nearby, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NearestPoints", ctx, req)
+	ret0, _ := ret[0].([]pickuppoint.Nearby)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NearestPoints indicates an expected call of NearestPoints.
+func (mr *MockPickUpPointCoreServiceMockRecorder) NearestPoints(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NearestPoints", reflect.TypeOf((*MockPickUpPointCoreService)(nil).NearestPoints), ctx, req)
+}
+
+// PatchPoint mocks base method.
+func (m *MockPickUpPointCoreService) PatchPoint(ctx context.Context, req core.PatchPointRequest) (pickuppoint.PickUpPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchPoint", ctx, req)
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchPoint indicates an expected call of PatchPoint.
+func (mr *MockPickUpPointCoreServiceMockRecorder) PatchPoint(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchPoint", reflect.TypeOf((*MockPickUpPointCoreService)(nil).PatchPoint), ctx, req)
+}
+
+// PurgePoints mocks base method.
+func (m *MockPickUpPointCoreService) PurgePoints(ctx context.Context, deletedBefore time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgePoints", ctx, deletedBefore)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgePoints indicates an expected call of PurgePoints.
+func (mr *MockPickUpPointCoreServiceMockRecorder) PurgePoints(ctx, deletedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgePoints", reflect.TypeOf((*MockPickUpPointCoreService)(nil).PurgePoints), ctx, deletedBefore)
+}
+
+// RestorePoint mocks base method.
+func (m *MockPickUpPointCoreService) RestorePoint(ctx context.Context, id, version uint64) (pickuppoint.PickUpPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestorePoint", ctx, id, version)
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestorePoint indicates an expected call of RestorePoint.
+func (mr *MockPickUpPointCoreServiceMockRecorder) RestorePoint(ctx, id, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPoints", reflect.TypeOf((*MockPickUpPointCoreService)(nil).ListPoints), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePoint", reflect.TypeOf((*MockPickUpPointCoreService)(nil).RestorePoint), ctx, id, version)
 }
 
 // SetCache mocks base method.
@@ -111,6 +249,30 @@ func (mr *MockPickUpPointCoreServiceMockRecorder) SetCache(cache any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCache", reflect.TypeOf((*MockPickUpPointCoreService)(nil).SetCache), cache)
 }
 
+// SetGeoIndex mocks base method.
+func (m *MockPickUpPointCoreService) SetGeoIndex(geoIndex core.GeoIndex) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetGeoIndex", geoIndex)
+}
+
+// SetGeoIndex indicates an expected call of SetGeoIndex.
+func (mr *MockPickUpPointCoreServiceMockRecorder) SetGeoIndex(geoIndex any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetGeoIndex", reflect.TypeOf((*MockPickUpPointCoreService)(nil).SetGeoIndex), geoIndex)
+}
+
+// SetOccupancy mocks base method.
+func (m *MockPickUpPointCoreService) SetOccupancy(occupancy core.Occupancy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetOccupancy", occupancy)
+}
+
+// SetOccupancy indicates an expected call of SetOccupancy.
+func (mr *MockPickUpPointCoreServiceMockRecorder) SetOccupancy(occupancy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOccupancy", reflect.TypeOf((*MockPickUpPointCoreService)(nil).SetOccupancy), occupancy)
+}
+
 // SetRedis mocks base method.
 func (m *MockPickUpPointCoreService) SetRedis(redis core.Redis) {
 	m.ctrl.T.Helper()
@@ -123,12 +285,28 @@ func (mr *MockPickUpPointCoreServiceMockRecorder) SetRedis(redis any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRedis", reflect.TypeOf((*MockPickUpPointCoreService)(nil).SetRedis), redis)
 }
 
+// SetSchedule mocks base method.
+func (m *MockPickUpPointCoreService) SetSchedule(ctx context.Context, id, version uint64, schedule pickuppoint.Schedule) (pickuppoint.PickUpPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSchedule", ctx, id, version, schedule)
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetSchedule indicates an expected call of SetSchedule.
+func (mr *MockPickUpPointCoreServiceMockRecorder) SetSchedule(ctx, id, version, schedule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSchedule", reflect.TypeOf((*MockPickUpPointCoreService)(nil).SetSchedule), ctx, id, version, schedule)
+}
+
 // UpdatePoint mocks base method.
-func (m *MockPickUpPointCoreService) UpdatePoint(ctx context.Context, req core.UpdatePointRequest) error {
+func (m *MockPickUpPointCoreService) UpdatePoint(ctx context.Context, req core.UpdatePointRequest) (pickuppoint.PickUpPoint, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdatePoint", ctx, req)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // UpdatePoint indicates an expected call of UpdatePoint.
@@ -222,33 +400,150 @@ func (m *MockRedis) EXPECT() *MockRedisMockRecorder {
 	return m.recorder
 }
 
-// GetPointList mocks base method.
-func (m *MockRedis) GetPointList(ctx context.Context) ([]pickuppoint.PickUpPoint, error) {
+// GetPointPage mocks base method.
+func (m *MockRedis) GetPointPage(ctx context.Context, opts pickuppoint.ListOptions) (pickuppoint.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPointList", ctx)
-	ret0, _ := ret[0].([]pickuppoint.PickUpPoint)
+	ret := m.ctrl.Call(m, "GetPointPage", ctx, opts)
+	ret0, _ := ret[0].(pickuppoint.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPointList indicates an expected call of GetPointList.
-func (mr *MockRedisMockRecorder) GetPointList(ctx any) *gomock.Call {
+// GetPointPage indicates an expected call of GetPointPage.
+func (mr *MockRedisMockRecorder) GetPointPage(ctx, opts any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPointList", reflect.TypeOf((*MockRedis)(nil).GetPointList), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPointPage", reflect.TypeOf((*MockRedis)(nil).GetPointPage), ctx, opts)
 }
 
-// SetPointList mocks base method.
-func (m *MockRedis) SetPointList(ctx context.Context, points []pickuppoint.PickUpPoint) error {
+// SetPointPage mocks base method.
+func (m *MockRedis) SetPointPage(ctx context.Context, opts pickuppoint.ListOptions, page pickuppoint.Page) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetPointList", ctx, points)
+	ret := m.ctrl.Call(m, "SetPointPage", ctx, opts, page)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetPointList indicates an expected call of SetPointList.
-func (mr *MockRedisMockRecorder) SetPointList(ctx, points any) *gomock.Call {
+// SetPointPage indicates an expected call of SetPointPage.
+func (mr *MockRedisMockRecorder) SetPointPage(ctx, opts, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPointPage", reflect.TypeOf((*MockRedis)(nil).SetPointPage), ctx, opts, page)
+}
+
+// MockGeoIndex is a mock of GeoIndex interface.
+type MockGeoIndex struct {
+	ctrl     *gomock.Controller
+	recorder *MockGeoIndexMockRecorder
+}
+
+// MockGeoIndexMockRecorder is the mock recorder for MockGeoIndex.
+type MockGeoIndexMockRecorder struct {
+	mock *MockGeoIndex
+}
+
+// NewMockGeoIndex creates a new mock instance.
+func NewMockGeoIndex(ctrl *gomock.Controller) *MockGeoIndex {
+	mock := &MockGeoIndex{ctrl: ctrl}
+	mock.recorder = &MockGeoIndexMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGeoIndex) EXPECT() *MockGeoIndexMockRecorder {
+	return m.recorder
+}
+
+// DeletePoint mocks base method.
+func (m *MockGeoIndex) DeletePoint(id uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeletePoint", id)
+}
+
+// DeletePoint indicates an expected call of DeletePoint.
+func (mr *MockGeoIndexMockRecorder) DeletePoint(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePoint", reflect.TypeOf((*MockGeoIndex)(nil).DeletePoint), id)
+}
+
+// Nearest mocks base method.
+func (m *MockGeoIndex) Nearest(loc pickuppoint.Location, limit int, filter func(pickuppoint.PickUpPoint) bool) ([]pickuppoint.Nearby, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Nearest", loc, limit, filter)
+	ret0, _ := ret[0].([]pickuppoint.Nearby)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Nearest indicates an expected call of Nearest.
+func (mr *MockGeoIndexMockRecorder) Nearest(loc, limit, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nearest", reflect.TypeOf((*MockGeoIndex)(nil).Nearest), loc, limit, filter)
+}
+
+// PutPoint mocks base method.
+func (m *MockGeoIndex) PutPoint(point pickuppoint.PickUpPoint) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PutPoint", point)
+}
+
+// PutPoint indicates an expected call of PutPoint.
+func (mr *MockGeoIndexMockRecorder) PutPoint(point any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutPoint", reflect.TypeOf((*MockGeoIndex)(nil).PutPoint), point)
+}
+
+// SetPoints mocks base method.
+func (m *MockGeoIndex) SetPoints(points []pickuppoint.PickUpPoint) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPoints", points)
+}
+
+// SetPoints indicates an expected call of SetPoints.
+func (mr *MockGeoIndexMockRecorder) SetPoints(points any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPointList", reflect.TypeOf((*MockRedis)(nil).SetPointList), ctx, points)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPoints", reflect.TypeOf((*MockGeoIndex)(nil).SetPoints), points)
+}
+
+// MockOccupancy is a mock of Occupancy interface.
+type MockOccupancy struct {
+	ctrl     *gomock.Controller
+	recorder *MockOccupancyMockRecorder
+}
+
+// MockOccupancyMockRecorder is the mock recorder for MockOccupancy.
+type MockOccupancyMockRecorder struct {
+	mock *MockOccupancy
+}
+
+// NewMockOccupancy creates a new mock instance.
+func NewMockOccupancy(ctrl *gomock.Controller) *MockOccupancy {
+	mock := &MockOccupancy{ctrl: ctrl}
+	mock.recorder = &MockOccupancyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOccupancy) EXPECT() *MockOccupancyMockRecorder {
+	return m.recorder
+}
+
+// GetOccupancy mocks base method.
+func (m *MockOccupancy) GetOccupancy(ctx context.Context, pickUpPointIds ...uint64) (map[uint64]order.Occupancy, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range pickUpPointIds {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetOccupancy", varargs...)
+	ret0, _ := ret[0].(map[uint64]order.Occupancy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOccupancy indicates an expected call of GetOccupancy.
+func (mr *MockOccupancyMockRecorder) GetOccupancy(ctx any, pickUpPointIds ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, pickUpPointIds...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOccupancy", reflect.TypeOf((*MockOccupancy)(nil).GetOccupancy), varargs...)
 }
 
 // MockPickUpPointService is a mock of PickUpPointService interface.
@@ -275,11 +570,12 @@ func (m *MockPickUpPointService) EXPECT() *MockPickUpPointServiceMockRecorder {
 }
 
 // CreatePoint mocks base method.
-func (m *MockPickUpPointService) CreatePoint(ctx context.Context, point pickuppoint.PickUpPoint) error {
+func (m *MockPickUpPointService) CreatePoint(ctx context.Context, point pickuppoint.PickUpPoint) (pickuppoint.PickUpPoint, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreatePoint", ctx, point)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreatePoint indicates an expected call of CreatePoint.
@@ -289,17 +585,17 @@ func (mr *MockPickUpPointServiceMockRecorder) CreatePoint(ctx, point any) *gomoc
 }
 
 // DeletePoint mocks base method.
-func (m *MockPickUpPointService) DeletePoint(ctx context.Context, id uint64) error {
+func (m *MockPickUpPointService) DeletePoint(ctx context.Context, id, version uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePoint", ctx, id)
+	ret := m.ctrl.Call(m, "DeletePoint", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeletePoint indicates an expected call of DeletePoint.
-func (mr *MockPickUpPointServiceMockRecorder) DeletePoint(ctx, id any) *gomock.Call {
+func (mr *MockPickUpPointServiceMockRecorder) DeletePoint(ctx, id, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePoint", reflect.TypeOf((*MockPickUpPointService)(nil).DeletePoint), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePoint", reflect.TypeOf((*MockPickUpPointService)(nil).DeletePoint), ctx, id, version)
 }
 
 // GetPoint mocks base method.
@@ -317,27 +613,133 @@ func (mr *MockPickUpPointServiceMockRecorder) GetPoint(ctx, id any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPoint", reflect.TypeOf((*MockPickUpPointService)(nil).GetPoint), ctx, id)
 }
 
+// GetPointIncludingDeleted mocks base method.
+func (m *MockPickUpPointService) GetPointIncludingDeleted(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPointIncludingDeleted", ctx, id)
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPointIncludingDeleted indicates an expected call of GetPointIncludingDeleted.
+func (mr *MockPickUpPointServiceMockRecorder) GetPointIncludingDeleted(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPointIncludingDeleted", reflect.TypeOf((*MockPickUpPointService)(nil).GetPointIncludingDeleted), ctx, id)
+}
+
+// History mocks base method.
+func (m *MockPickUpPointService) History(ctx context.Context, id uint64, opts pickuppoint.HistoryOptions) (pickuppoint.HistoryPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", ctx, id, opts)
+	ret0, _ := ret[0].(pickuppoint.HistoryPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockPickUpPointServiceMockRecorder) History(ctx, id, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockPickUpPointService)(nil).History), ctx, id, opts)
+}
+
+// ImportPoints mocks base method.
+func (m *MockPickUpPointService) ImportPoints(ctx context.Context, rows []pickuppoint.BatchRow, dryRun bool) (pickuppoint.BatchReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportPoints", ctx, rows, dryRun)
+	ret0, _ := ret[0].(pickuppoint.BatchReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportPoints indicates an expected call of ImportPoints.
+func (mr *MockPickUpPointServiceMockRecorder) ImportPoints(ctx, rows, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportPoints", reflect.TypeOf((*MockPickUpPointService)(nil).ImportPoints), ctx, rows, dryRun)
+}
+
 // ListPoints mocks base method.
-func (m *MockPickUpPointService) ListPoints(ctx context.Context) ([]pickuppoint.PickUpPoint, error) {
+func (m *MockPickUpPointService) ListPoints(ctx context.Context, opts pickuppoint.ListOptions) (pickuppoint.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListPoints", ctx)
-	ret0, _ := ret[0].([]pickuppoint.PickUpPoint)
+	ret := m.ctrl.Call(m, "ListPoints", ctx, opts)
+	ret0, _ := ret[0].(pickuppoint.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListPoints indicates an expected call of ListPoints.
-func (mr *MockPickUpPointServiceMockRecorder) ListPoints(ctx any) *gomock.Call {
+func (mr *MockPickUpPointServiceMockRecorder) ListPoints(ctx, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPoints", reflect.TypeOf((*MockPickUpPointService)(nil).ListPoints), ctx, opts)
+}
+
+// ModifyPoint mocks base method.
+func (m *MockPickUpPointService) ModifyPoint(ctx context.Context, id, version uint64, modify func(pickuppoint.PickUpPoint) (pickuppoint.PickUpPoint, error)) (pickuppoint.PickUpPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ModifyPoint", ctx, id, version, modify)
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ModifyPoint indicates an expected call of ModifyPoint.
+func (mr *MockPickUpPointServiceMockRecorder) ModifyPoint(ctx, id, version, modify any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModifyPoint", reflect.TypeOf((*MockPickUpPointService)(nil).ModifyPoint), ctx, id, version, modify)
+}
+
+// PurgePoints mocks base method.
+func (m *MockPickUpPointService) PurgePoints(ctx context.Context, deletedBefore time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgePoints", ctx, deletedBefore)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgePoints indicates an expected call of PurgePoints.
+func (mr *MockPickUpPointServiceMockRecorder) PurgePoints(ctx, deletedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgePoints", reflect.TypeOf((*MockPickUpPointService)(nil).PurgePoints), ctx, deletedBefore)
+}
+
+// RestorePoint mocks base method.
+func (m *MockPickUpPointService) RestorePoint(ctx context.Context, id, version uint64) (pickuppoint.PickUpPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestorePoint", ctx, id, version)
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestorePoint indicates an expected call of RestorePoint.
+func (mr *MockPickUpPointServiceMockRecorder) RestorePoint(ctx, id, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPoints", reflect.TypeOf((*MockPickUpPointService)(nil).ListPoints), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePoint", reflect.TypeOf((*MockPickUpPointService)(nil).RestorePoint), ctx, id, version)
+}
+
+// SetSchedule mocks base method.
+func (m *MockPickUpPointService) SetSchedule(ctx context.Context, id, version uint64, schedule pickuppoint.Schedule) (pickuppoint.PickUpPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSchedule", ctx, id, version, schedule)
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetSchedule indicates an expected call of SetSchedule.
+func (mr *MockPickUpPointServiceMockRecorder) SetSchedule(ctx, id, version, schedule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSchedule", reflect.TypeOf((*MockPickUpPointService)(nil).SetSchedule), ctx, id, version, schedule)
 }
 
 // UpdatePoint mocks base method.
-func (m *MockPickUpPointService) UpdatePoint(ctx context.Context, point pickuppoint.PickUpPoint) error {
+func (m *MockPickUpPointService) UpdatePoint(ctx context.Context, point pickuppoint.PickUpPoint) (pickuppoint.PickUpPoint, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdatePoint", ctx, point)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(pickuppoint.PickUpPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // UpdatePoint indicates an expected call of UpdatePoint.
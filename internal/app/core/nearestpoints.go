@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"homework/internal/app/order"
+	"homework/internal/app/pickuppoint"
+	"time"
+)
+
+const MaxNearestLimit = 100
+
+var ErrInvalidLimit = errors.New("limit must be between 1 and 100")
+
+// NearestPointsRequest asks for up to Limit points closest to Location.
+// When OpenNow is set, only points open at At are returned.
+// When HasCapacity is set, full points are skipped.
+type NearestPointsRequest struct {
+	Location    pickuppoint.Location
+	Limit       int
+	OpenNow     bool
+	At          time.Time
+	HasCapacity bool
+}
+
+func (s *pickUpPointCoreService) NearestPoints(ctx context.Context, req NearestPointsRequest) ([]pickuppoint.Nearby, error) {
+	err := req.Location.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if req.Limit < 1 || req.Limit > MaxNearestLimit {
+		return nil, ErrInvalidLimit
+	}
+	filter, err := s.nearestFilter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	nearby, err := s.geoIndex.Nearest(req.Location, req.Limit, filter)
+	if err == nil {
+		return nearby, nil
+	}
+	page, err := s.pointService.ListPoints(ctx, pickuppoint.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	points := page.Points
+	s.geoIndex.SetPoints(points)
+	nearby, err = s.geoIndex.Nearest(req.Location, req.Limit, filter)
+	if err == nil {
+		return nearby, nil
+	}
+	return pickuppoint.Nearest(points, req.Location, req.Limit, filter), nil
+}
+
+// nearestFilter returns a filter accepting points matching req, or nil when all points match.
+func (s *pickUpPointCoreService) nearestFilter(ctx context.Context, req NearestPointsRequest) (func(point pickuppoint.PickUpPoint) bool, error) {
+	if !req.OpenNow && !req.HasCapacity {
+		return nil, nil
+	}
+	var occupancy map[uint64]order.Occupancy
+	if req.HasCapacity {
+		var err error
+		occupancy, err = s.occupancy.GetOccupancy(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func(point pickuppoint.PickUpPoint) bool {
+		if req.OpenNow {
+			status, err := point.Schedule.Status(req.At)
+			if err != nil || !status.Open {
+				return false
+			}
+		}
+		if req.HasCapacity {
+			taken := occupancy[point.Id]
+			if point.Capacity.Utilisation(taken.Orders, taken.WeightKg).Full {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
@@ -1,27 +1,37 @@
 package core
 
 import (
+	"context"
 	"homework/internal/app/order"
 	"homework/internal/app/packaging"
+	"homework/internal/app/pickuppoint"
 )
 
 type OrderCoreService struct {
 	orderService      OrderService
+	pointGetter       PointGetter
 	packagingVariants map[packaging.Type]packaging.Packaging
 }
 
 type OrderService interface {
-	AddOrder(o order.Order) error
-	RemoveOrder(id uint64) error
-	GiveOrders(ids []uint64) error
-	GetOrders(customerId uint64, n int, filterGiven bool) ([]order.Order, error)
-	AcceptReturn(orderId uint64, customerId uint64) error
-	GetReturns(count int, pageNum int) ([]order.Order, error)
+	AddOrder(ctx context.Context, o order.Order) error
+	AddOrderAdmitted(ctx context.Context, o order.Order, admit func(taken order.Occupancy) error) error
+	RemoveOrder(ctx context.Context, id uint64) error
+	GiveOrders(ctx context.Context, ids []uint64) error
+	GetOrders(ctx context.Context, customerId uint64, n int, filterGiven bool) ([]order.Order, error)
+	AcceptReturn(ctx context.Context, orderId uint64, customerId uint64) error
+	GetReturns(ctx context.Context, count int, pageNum int) ([]order.Order, error)
 }
 
-func NewOrderCoreService(orderService OrderService, packagingTypes map[packaging.Type]packaging.Packaging) *OrderCoreService {
+// PointGetter provides pick-up points that orders are stored at.
+type PointGetter interface {
+	GetPoint(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error)
+}
+
+func NewOrderCoreService(orderService OrderService, pointGetter PointGetter, packagingTypes map[packaging.Type]packaging.Packaging) *OrderCoreService {
 	return &OrderCoreService{
 		orderService:      orderService,
+		pointGetter:       pointGetter,
 		packagingVariants: packagingTypes,
 	}
 }
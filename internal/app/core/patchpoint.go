@@ -0,0 +1,36 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"homework/internal/app/pickuppoint"
+)
+
+var ErrIdChanged = errors.New("point id must not be changed")
+
+// PatchPointRequest describes a JSON Merge Patch of a pick-up point.
+// Unless Version is zero, the stored point must have that version.
+type PatchPointRequest struct {
+	Id      uint64
+	Version uint64
+	Patch   []byte
+}
+
+func (s *pickUpPointCoreService) PatchPoint(ctx context.Context, req PatchPointRequest) (pickuppoint.PickUpPoint, error) {
+	point, err := s.pointService.ModifyPoint(ctx, req.Id, req.Version, func(point pickuppoint.PickUpPoint) (pickuppoint.PickUpPoint, error) {
+		patched, err := point.MergePatch(req.Patch)
+		if err != nil {
+			return pickuppoint.PickUpPoint{}, err
+		}
+		if patched.Id != point.Id {
+			return pickuppoint.PickUpPoint{}, ErrIdChanged
+		}
+		return patched, validatePoint(patched)
+	})
+	if err != nil {
+		return pickuppoint.PickUpPoint{}, err
+	}
+	s.cache.PutPoint(point)
+	s.geoIndex.PutPoint(point)
+	return point, nil
+}
@@ -5,17 +5,31 @@ package core
 import (
 	"context"
 	"homework/internal/app/logger"
+	"homework/internal/app/order"
 	"homework/internal/app/pickuppoint"
+	"time"
 )
 
 type PickUpPointCoreService interface {
-	CreatePoint(ctx context.Context, req CreatePointRequest) error
-	ListPoints(ctx context.Context) ([]pickuppoint.PickUpPoint, error)
+	CreatePoint(ctx context.Context, req CreatePointRequest) (pickuppoint.PickUpPoint, error)
+	ImportPoints(ctx context.Context, req ImportPointsRequest) (pickuppoint.BatchReport, error)
+	ListPoints(ctx context.Context, opts pickuppoint.ListOptions) (pickuppoint.Page, error)
 	GetPoint(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error)
-	UpdatePoint(ctx context.Context, req UpdatePointRequest) error
-	DeletePoint(ctx context.Context, id uint64) error
+	GetPointIncludingDeleted(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error)
+	UpdatePoint(ctx context.Context, req UpdatePointRequest) (pickuppoint.PickUpPoint, error)
+	PatchPoint(ctx context.Context, req PatchPointRequest) (pickuppoint.PickUpPoint, error)
+	DeletePoint(ctx context.Context, id uint64, version uint64) error
+	RestorePoint(ctx context.Context, id uint64, version uint64) (pickuppoint.PickUpPoint, error)
+	PurgePoints(ctx context.Context, deletedBefore time.Time) (int, error)
+	SetSchedule(ctx context.Context, id uint64, version uint64, schedule pickuppoint.Schedule) (pickuppoint.PickUpPoint, error)
+	GetOpenStatus(ctx context.Context, id uint64, at time.Time) (pickuppoint.OpenStatus, error)
+	GetHistory(ctx context.Context, id uint64, opts pickuppoint.HistoryOptions) (pickuppoint.HistoryPage, error)
+	NearestPoints(ctx context.Context, req NearestPointsRequest) ([]pickuppoint.Nearby, error)
+	GetUtilisation(ctx context.Context, points []pickuppoint.PickUpPoint) (map[uint64]pickuppoint.Utilisation, error)
 	SetCache(cache Cache)
 	SetRedis(redis Redis)
+	SetGeoIndex(geoIndex GeoIndex)
+	SetOccupancy(occupancy Occupancy)
 }
 
 type Cache interface {
@@ -25,8 +39,19 @@ type Cache interface {
 }
 
 type Redis interface {
-	GetPointList(ctx context.Context) ([]pickuppoint.PickUpPoint, error)
-	SetPointList(ctx context.Context, points []pickuppoint.PickUpPoint) error
+	GetPointPage(ctx context.Context, opts pickuppoint.ListOptions) (pickuppoint.Page, error)
+	SetPointPage(ctx context.Context, opts pickuppoint.ListOptions, page pickuppoint.Page) error
+}
+
+type GeoIndex interface {
+	SetPoints(points []pickuppoint.PickUpPoint)
+	PutPoint(point pickuppoint.PickUpPoint)
+	DeletePoint(id uint64)
+	Nearest(loc pickuppoint.Location, limit int, filter func(pickuppoint.PickUpPoint) bool) ([]pickuppoint.Nearby, error)
+}
+
+type Occupancy interface {
+	GetOccupancy(ctx context.Context, pickUpPointIds ...uint64) (map[uint64]order.Occupancy, error)
 }
 
 type pickUpPointCoreService struct {
@@ -34,18 +59,27 @@ type pickUpPointCoreService struct {
 	log          logger.Logger
 	cache        Cache
 	redis        Redis
+	geoIndex     GeoIndex
+	occupancy    Occupancy
 }
 
 type PickUpPointService interface {
-	CreatePoint(ctx context.Context, point pickuppoint.PickUpPoint) error
-	ListPoints(ctx context.Context) ([]pickuppoint.PickUpPoint, error)
+	CreatePoint(ctx context.Context, point pickuppoint.PickUpPoint) (pickuppoint.PickUpPoint, error)
+	ImportPoints(ctx context.Context, rows []pickuppoint.BatchRow, dryRun bool) (pickuppoint.BatchReport, error)
+	ListPoints(ctx context.Context, opts pickuppoint.ListOptions) (pickuppoint.Page, error)
 	GetPoint(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error)
-	UpdatePoint(ctx context.Context, point pickuppoint.PickUpPoint) error
-	DeletePoint(ctx context.Context, id uint64) error
+	GetPointIncludingDeleted(ctx context.Context, id uint64) (pickuppoint.PickUpPoint, error)
+	UpdatePoint(ctx context.Context, point pickuppoint.PickUpPoint) (pickuppoint.PickUpPoint, error)
+	ModifyPoint(ctx context.Context, id uint64, version uint64, modify func(point pickuppoint.PickUpPoint) (pickuppoint.PickUpPoint, error)) (pickuppoint.PickUpPoint, error)
+	DeletePoint(ctx context.Context, id uint64, version uint64) error
+	RestorePoint(ctx context.Context, id uint64, version uint64) (pickuppoint.PickUpPoint, error)
+	PurgePoints(ctx context.Context, deletedBefore time.Time) (int, error)
+	SetSchedule(ctx context.Context, id uint64, version uint64, schedule pickuppoint.Schedule) (pickuppoint.PickUpPoint, error)
+	History(ctx context.Context, id uint64, opts pickuppoint.HistoryOptions) (pickuppoint.HistoryPage, error)
 }
 
 func NewPickUpPointCoreService(pointService PickUpPointService, log logger.Logger) PickUpPointCoreService {
-	return &pickUpPointCoreService{pointService: pointService, log: log, cache: NilCache{}, redis: NilRedis{}}
+	return &pickUpPointCoreService{pointService: pointService, log: log, cache: NilCache{}, redis: NilRedis{}, geoIndex: NilGeoIndex{}, occupancy: NilOccupancy{}}
 }
 
 func (s *pickUpPointCoreService) SetCache(cache Cache) {
@@ -55,3 +89,11 @@ func (s *pickUpPointCoreService) SetCache(cache Cache) {
 func (s *pickUpPointCoreService) SetRedis(redis Redis) {
 	s.redis = redis
 }
+
+func (s *pickUpPointCoreService) SetGeoIndex(geoIndex GeoIndex) {
+	s.geoIndex = geoIndex
+}
+
+func (s *pickUpPointCoreService) SetOccupancy(occupancy Occupancy) {
+	s.occupancy = occupancy
+}
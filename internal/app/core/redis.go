@@ -9,10 +9,10 @@ import (
 type NilRedis struct {
 }
 
-func (n NilRedis) GetPointList(ctx context.Context) ([]pickuppoint.PickUpPoint, error) {
-	return nil, errors.New("not found")
+func (n NilRedis) GetPointPage(ctx context.Context, opts pickuppoint.ListOptions) (pickuppoint.Page, error) {
+	return pickuppoint.Page{}, errors.New("not found")
 }
 
-func (n NilRedis) SetPointList(ctx context.Context, points []pickuppoint.PickUpPoint) error {
+func (n NilRedis) SetPointPage(ctx context.Context, opts pickuppoint.ListOptions, page pickuppoint.Page) error {
 	return nil
 }
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 )
 
@@ -9,5 +10,5 @@ func (s *OrderCoreService) ReturnOrder(orderId uint64) error {
 	if orderId == 0 {
 		return errors.New("valid order id is required")
 	}
-	return s.orderService.RemoveOrder(orderId)
+	return s.orderService.RemoveOrder(context.Background(), orderId)
 }
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"context"
+	"homework/internal/app/pickuppoint"
+	"time"
+)
+
+func (s *pickUpPointCoreService) SetSchedule(ctx context.Context, id uint64, version uint64, schedule pickuppoint.Schedule) (pickuppoint.PickUpPoint, error) {
+	err := schedule.Validate()
+	if err != nil {
+		return pickuppoint.PickUpPoint{}, err
+	}
+	point, err := s.pointService.SetSchedule(ctx, id, version, schedule)
+	if err != nil {
+		return pickuppoint.PickUpPoint{}, err
+	}
+	s.cache.PutPoint(point)
+	s.geoIndex.PutPoint(point)
+	return point, nil
+}
+
+func (s *pickUpPointCoreService) GetOpenStatus(ctx context.Context, id uint64, at time.Time) (pickuppoint.OpenStatus, error) {
+	point, err := s.GetPoint(ctx, id)
+	if err != nil {
+		return pickuppoint.OpenStatus{}, err
+	}
+	return point.Schedule.Status(at)
+}
@@ -5,24 +5,58 @@ import (
 	"homework/internal/app/pickuppoint"
 )
 
+// UpdatePointRequest describes a pick-up point replacing the stored one.
+// Unless Version is zero, the stored point must have that version.
 type UpdatePointRequest struct {
-	Id      uint64 `json:"id"`
-	Name    string `json:"name"`
-	Address string `json:"address"`
-	Contact string `json:"contact"`
+	Id       uint64                `json:"id"`
+	Name     string                `json:"name"`
+	Address  pickuppoint.Address   `json:"address"`
+	Contact  pickuppoint.Contact   `json:"contact"`
+	Schedule pickuppoint.Schedule  `json:"schedule"`
+	Location *pickuppoint.Location `json:"location,omitempty"`
+	Capacity pickuppoint.Capacity  `json:"capacity"`
+	Version  uint64                `json:"-"`
 }
 
-func (s *pickUpPointCoreService) UpdatePoint(ctx context.Context, req UpdatePointRequest) error {
+func (s *pickUpPointCoreService) UpdatePoint(ctx context.Context, req UpdatePointRequest) (pickuppoint.PickUpPoint, error) {
 	point := pickuppoint.PickUpPoint{
-		Id:      req.Id,
-		Name:    req.Name,
-		Address: req.Address,
-		Contact: req.Contact,
+		Id:       req.Id,
+		Name:     req.Name,
+		Address:  req.Address,
+		Contact:  req.Contact,
+		Schedule: req.Schedule,
+		Location: req.Location,
+		Capacity: req.Capacity,
+		Version:  req.Version,
 	}
-	err := s.pointService.UpdatePoint(ctx, point)
+	err := validatePoint(point)
 	if err != nil {
-		return err
+		return pickuppoint.PickUpPoint{}, err
+	}
+	point, err = s.pointService.UpdatePoint(ctx, point)
+	if err != nil {
+		return pickuppoint.PickUpPoint{}, err
 	}
 	s.cache.PutPoint(point)
-	return nil
+	s.geoIndex.PutPoint(point)
+	return point, nil
+}
+
+// validatePoint checks every field of a point provided by a user.
+func validatePoint(point pickuppoint.PickUpPoint) error {
+	err := pickuppoint.ValidateDetails(point.Name, point.Address, point.Contact)
+	if err != nil {
+		return err
+	}
+	err = point.Schedule.Validate()
+	if err != nil {
+		return err
+	}
+	if point.Location != nil {
+		err = point.Location.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	return point.Capacity.Validate()
 }
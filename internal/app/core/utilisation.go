@@ -0,0 +1,27 @@
+package core
+
+import (
+	"context"
+	"homework/internal/app/pickuppoint"
+)
+
+// GetUtilisation returns the capacity utilisation of each of the points by stored orders.
+func (s *pickUpPointCoreService) GetUtilisation(ctx context.Context, points []pickuppoint.PickUpPoint) (map[uint64]pickuppoint.Utilisation, error) {
+	ids := make([]uint64, len(points))
+	for i, point := range points {
+		ids[i] = point.Id
+	}
+	utilisation := make(map[uint64]pickuppoint.Utilisation, len(points))
+	if len(points) == 0 {
+		return utilisation, nil
+	}
+	occupancy, err := s.occupancy.GetOccupancy(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+	for _, point := range points {
+		taken := occupancy[point.Id]
+		utilisation[point.Id] = point.Capacity.Utilisation(taken.Orders, taken.WeightKg)
+	}
+	return utilisation, nil
+}
@@ -6,6 +6,10 @@ import (
 	"context"
 	"github.com/georgysavva/scany/pgxscan"
 	"github.com/jackc/pgx/v4"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"homework/internal/app/tracing"
+	"strings"
 )
 
 type CommandTag interface {
@@ -27,18 +31,45 @@ func NewDatabase(provider QueryEngineProvider) *PostgresDatabase {
 	return &PostgresDatabase{provider: provider}
 }
 
+// startStatement starts a span of a statement named by its first keyword, the arguments are not recorded.
+func startStatement(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := "statement"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		name = strings.ToUpper(fields[0])
+	}
+	return tracing.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBStatement(query)))
+}
+
 func (db PostgresDatabase) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	return pgxscan.Get(ctx, db.provider.GetQueryEngine(ctx), dest, query, args...)
+	ctx, span := startStatement(ctx, query)
+	err := pgxscan.Get(ctx, db.provider.GetQueryEngine(ctx), dest, query, args...)
+	// no rows is an answer rather than a failure
+	if pgxscan.NotFound(err) {
+		tracing.End(span, nil)
+	} else {
+		tracing.End(span, err)
+	}
+	return err
 }
 
 func (db PostgresDatabase) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	return pgxscan.Select(ctx, db.provider.GetQueryEngine(ctx), dest, query, args...)
+	ctx, span := startStatement(ctx, query)
+	err := pgxscan.Select(ctx, db.provider.GetQueryEngine(ctx), dest, query, args...)
+	tracing.End(span, err)
+	return err
 }
 
 func (db PostgresDatabase) Exec(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
-	return db.provider.GetQueryEngine(ctx).Exec(ctx, query, args...)
+	ctx, span := startStatement(ctx, query)
+	tag, err := db.provider.GetQueryEngine(ctx).Exec(ctx, query, args...)
+	tracing.End(span, err)
+	return tag, err
 }
 
+// ExecQueryRow traces sending the statement, errors are only known when the row is scanned.
 func (db PostgresDatabase) ExecQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	ctx, span := startStatement(ctx, query)
+	defer span.End()
 	return db.provider.GetQueryEngine(ctx).QueryRow(ctx, query, args...)
 }
@@ -3,6 +3,11 @@ package db
 import (
 	"context"
 	"github.com/jackc/pgconn"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"homework/internal/app/metrics"
+	"homework/internal/app/requestid"
+	"homework/internal/app/tracing"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -11,6 +16,9 @@ import (
 
 const key = "transaction"
 
+// applicationName is reported to the server along with the id of the request a transaction is made for.
+const applicationName = "pickup-points"
+
 type QueryEngine interface {
 	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
@@ -31,7 +39,13 @@ func newTransactionManager(pool *pgxpool.Pool) *TransactionManager {
 	}
 }
 
-func (t *TransactionManager) RunSerializable(ctx context.Context, f func(ctxTX context.Context) error) error {
+// RunSerializable runs f within a serializable transaction traced as a span, statements of f are its children.
+func (t *TransactionManager) RunSerializable(ctx context.Context, f func(ctxTX context.Context) error) (err error) {
+	ctx, span := tracing.Start(ctx, "transaction", trace.WithAttributes(semconv.DBSystemPostgreSQL))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel:   pgx.Serializable,
 		AccessMode: pgx.ReadWrite,
@@ -40,18 +54,36 @@ func (t *TransactionManager) RunSerializable(ctx context.Context, f func(ctxTX c
 		return err
 	}
 
+	if id := requestid.FromContext(ctx); id != "" {
+		// the request is seen in pg_stat_activity and in server logs with %a in log_line_prefix until the transaction ends
+		_, err = tx.Exec(ctx, "SELECT set_config('application_name', $1, true);", applicationName+" "+id)
+		if err != nil {
+			return rollback(ctx, tx, err)
+		}
+	}
+
 	if err := f(context.WithValue(ctx, key, tx)); err != nil { // unknown error
-		errRollback := tx.Rollback(ctx)           // one more error
-		return multierr.Combine(err, errRollback) // unknown error + one more error
+		return rollback(ctx, tx, err) // unknown error + one more error
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return multierr.Combine(err, tx.Rollback(ctx))
+		return rollback(ctx, tx, err)
 	}
 
 	return nil
 }
 
+// rollback rolls tx back after err and returns both errors.
+func rollback(ctx context.Context, tx pgx.Tx, err error) error {
+	metrics.TransactionRollbacks.Inc()
+	return multierr.Combine(err, tx.Rollback(ctx))
+}
+
+func (t *TransactionManager) Close() error {
+	t.pool.Close()
+	return nil
+}
+
 func (t *TransactionManager) GetQueryEngine(ctx context.Context) QueryEngine {
 	tx, ok := ctx.Value(key).(QueryEngine)
 	if ok {
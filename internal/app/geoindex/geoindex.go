@@ -0,0 +1,200 @@
+package geoindex
+
+import (
+	"container/heap"
+	"errors"
+	"homework/internal/app/pickuppoint"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrNotLoaded = errors.New("index is not loaded or has expired")
+
+type vector [3]float64
+
+// toVector returns the unit vector pointing to loc from the centre of the Earth.
+func toVector(loc pickuppoint.Location) vector {
+	lat := loc.Latitude * math.Pi / 180
+	lon := loc.Longitude * math.Pi / 180
+	return vector{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
+
+func (v vector) dist2(other vector) float64 {
+	var sum float64
+	for i := range v {
+		d := v[i] - other[i]
+		sum += d * d
+	}
+	return sum
+}
+
+type node struct {
+	point       pickuppoint.PickUpPoint
+	v           vector
+	axis        int
+	left, right *node
+}
+
+type entry struct {
+	point pickuppoint.PickUpPoint
+	v     vector
+}
+
+// build returns a k-d tree of entries, which are reordered in the process.
+func build(entries []entry, depth int) *node {
+	if len(entries) == 0 {
+		return nil
+	}
+	axis := depth % 3
+	slices.SortFunc(entries, func(a, b entry) int {
+		switch {
+		case a.v[axis] < b.v[axis]:
+			return -1
+		case a.v[axis] > b.v[axis]:
+			return 1
+		}
+		return 0
+	})
+	mid := len(entries) / 2
+	return &node{
+		point: entries[mid].point,
+		v:     entries[mid].v,
+		axis:  axis,
+		left:  build(entries[:mid], depth+1),
+		right: build(entries[mid+1:], depth+1),
+	}
+}
+
+type candidate struct {
+	point pickuppoint.PickUpPoint
+	dist2 float64
+}
+
+// candidates is a max-heap keeping the farthest candidate on top.
+type candidates []candidate
+
+func (c candidates) Len() int { return len(c) }
+
+func (c candidates) Less(i, j int) bool {
+	if c[i].dist2 != c[j].dist2 {
+		return c[i].dist2 > c[j].dist2
+	}
+	return c[i].point.Id > c[j].point.Id
+}
+
+func (c candidates) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+
+func (c *candidates) Push(x any) { *c = append(*c, x.(candidate)) }
+
+func (c *candidates) Pop() any {
+	old := *c
+	x := old[len(old)-1]
+	*c = old[:len(old)-1]
+	return x
+}
+
+func (n *node) search(q vector, limit int, filter func(pickuppoint.PickUpPoint) bool, best *candidates) {
+	if n == nil {
+		return
+	}
+	if filter == nil || filter(n.point) {
+		heap.Push(best, candidate{point: n.point, dist2: q.dist2(n.v)})
+		if best.Len() > limit {
+			heap.Pop(best)
+		}
+	}
+	diff := q[n.axis] - n.v[n.axis]
+	near, far := n.left, n.right
+	if diff > 0 {
+		near, far = n.right, n.left
+	}
+	near.search(q, limit, filter, best)
+	if best.Len() < limit || diff*diff <= (*best)[0].dist2 {
+		far.search(q, limit, filter, best)
+	}
+}
+
+// Index finds pick-up points nearest to a location.
+// Locations are kept as unit vectors in a k-d tree: the straight-line distance between
+// two vectors grows with the great-circle distance, so the nearest vectors are the nearest points.
+//
+// The index expires after ttl since it was loaded with SetPoints, so that changes made
+// by other processes are picked up. The tree is rebuilt on the first search after a change.
+type Index struct {
+	mutex  sync.Mutex
+	points map[uint64]pickuppoint.PickUpPoint
+	root   *node
+	dirty  bool
+	ttl    time.Duration
+	expire time.Time
+}
+
+// NewIndex returns an empty Index, which must be loaded with SetPoints before searching.
+func NewIndex(ttl time.Duration) *Index {
+	return &Index{ttl: ttl}
+}
+
+// SetPoints replaces indexed points with provided ones.
+func (i *Index) SetPoints(points []pickuppoint.PickUpPoint) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.points = make(map[uint64]pickuppoint.PickUpPoint, len(points))
+	for _, point := range points {
+		i.points[point.Id] = point
+	}
+	i.dirty = true
+	i.expire = time.Now().Add(i.ttl)
+}
+
+// PutPoint adds or replaces a point in a loaded index.
+func (i *Index) PutPoint(point pickuppoint.PickUpPoint) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if i.points == nil {
+		return
+	}
+	i.points[point.Id] = point
+	i.dirty = true
+}
+
+// DeletePoint removes a point from the index.
+func (i *Index) DeletePoint(id uint64) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if _, ok := i.points[id]; ok {
+		delete(i.points, id)
+		i.dirty = true
+	}
+}
+
+// Nearest returns up to limit points located closest to loc, nearest first,
+// skipping points rejected by filter when it is not nil.
+func (i *Index) Nearest(loc pickuppoint.Location, limit int, filter func(pickuppoint.PickUpPoint) bool) ([]pickuppoint.Nearby, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if i.points == nil || i.expire.Before(time.Now()) {
+		return nil, ErrNotLoaded
+	}
+	if i.dirty {
+		entries := make([]entry, 0, len(i.points))
+		for _, point := range i.points {
+			if point.Location != nil {
+				entries = append(entries, entry{point: point, v: toVector(*point.Location)})
+			}
+		}
+		i.root = build(entries, 0)
+		i.dirty = false
+	}
+	best := make(candidates, 0, limit+1)
+	if limit > 0 {
+		i.root.search(toVector(loc), limit, filter, &best)
+	}
+	result := make([]pickuppoint.Nearby, len(best))
+	for j, c := range best {
+		result[j] = pickuppoint.Nearby{PickUpPoint: c.point, DistanceKm: loc.DistanceKm(*c.point.Location)}
+	}
+	pickuppoint.SortNearby(result)
+	return result, nil
+}
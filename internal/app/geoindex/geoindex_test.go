@@ -0,0 +1,110 @@
+package geoindex
+
+import (
+	"github.com/stretchr/testify/suite"
+	"homework/internal/app/pickuppoint"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func randomPoints(r *rand.Rand, n int) []pickuppoint.PickUpPoint {
+	points := make([]pickuppoint.PickUpPoint, n)
+	for i := range points {
+		points[i] = pickuppoint.PickUpPoint{Id: uint64(i + 1)}
+		if i%10 != 0 {
+			points[i].Location = &pickuppoint.Location{
+				Latitude:  r.Float64()*180 - 90,
+				Longitude: r.Float64()*360 - 180,
+			}
+		}
+	}
+	return points
+}
+
+type IndexTestSuite struct {
+	suite.Suite
+}
+
+func (s *IndexTestSuite) Test_NotLoaded() {
+	index := NewIndex(time.Minute)
+	_, err := index.Nearest(pickuppoint.Location{}, 1, nil)
+	s.ErrorIs(err, ErrNotLoaded)
+
+	index = NewIndex(-time.Second)
+	index.SetPoints(nil)
+	_, err = index.Nearest(pickuppoint.Location{}, 1, nil)
+	s.ErrorIs(err, ErrNotLoaded)
+}
+
+func (s *IndexTestSuite) Test_Nearest() {
+	r := rand.New(rand.NewSource(1))
+	points := randomPoints(r, 1000)
+	index := NewIndex(time.Minute)
+	index.SetPoints(points)
+	even := func(point pickuppoint.PickUpPoint) bool {
+		return point.Id%2 == 0
+	}
+	for i := 0; i < 100; i++ {
+		loc := pickuppoint.Location{Latitude: r.Float64()*180 - 90, Longitude: r.Float64()*360 - 180}
+		for _, limit := range []int{1, 5, 20} {
+			nearby, err := index.Nearest(loc, limit, nil)
+			s.Require().NoError(err)
+			s.Equal(pickuppoint.Nearest(points, loc, limit, nil), nearby)
+
+			nearby, err = index.Nearest(loc, limit, even)
+			s.Require().NoError(err)
+			s.Equal(pickuppoint.Nearest(points, loc, limit, even), nearby)
+		}
+	}
+}
+
+func (s *IndexTestSuite) Test_Changes() {
+	moscow := pickuppoint.Location{Latitude: 55.7558, Longitude: 37.6173}
+	index := NewIndex(time.Minute)
+	index.PutPoint(pickuppoint.PickUpPoint{Id: 1, Location: &moscow})
+	_, err := index.Nearest(moscow, 1, nil)
+	s.ErrorIs(err, ErrNotLoaded)
+
+	index.SetPoints([]pickuppoint.PickUpPoint{{Id: 1, Location: &pickuppoint.Location{Latitude: 59.9386, Longitude: 30.3141}}})
+	index.PutPoint(pickuppoint.PickUpPoint{Id: 2, Location: &moscow})
+	nearby, err := index.Nearest(moscow, 2, nil)
+	s.NoError(err)
+	s.Require().Len(nearby, 2)
+	s.Equal(uint64(2), nearby[0].Id)
+	s.Zero(nearby[0].DistanceKm)
+	s.InDelta(634, nearby[1].DistanceKm, 1)
+
+	index.DeletePoint(2)
+	nearby, err = index.Nearest(moscow, 2, nil)
+	s.NoError(err)
+	s.Require().Len(nearby, 1)
+	s.Equal(uint64(1), nearby[0].Id)
+}
+
+func TestIndex(t *testing.T) {
+	suite.Run(t, new(IndexTestSuite))
+}
+
+func BenchmarkNearest(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	points := randomPoints(r, 100000)
+	loc := pickuppoint.Location{Latitude: 55.7558, Longitude: 37.6173}
+	b.Run("indexed", func(b *testing.B) {
+		index := NewIndex(time.Hour)
+		index.SetPoints(points)
+		_, err := index.Nearest(loc, 10, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = index.Nearest(loc, 10, nil)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pickuppoint.Nearest(points, loc, 10, nil)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+//go:generate mockgen -source=./idempotency.go -destination=./mocks/idempotency.go -package=mocks
+
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var ErrNoRecord = errors.New("no request with such idempotency key")
+
+// Record keeps the response to a request made with an idempotency key, so that retries of the request get it again.
+type Record struct {
+	Key string `db:"key" json:"-"`
+	// Hash tells the request apart from other requests made with the same key.
+	Hash string `db:"request_hash" json:"hash"`
+	// Completed tells whether the response is stored, until then the request is being handled.
+	Completed bool        `db:"completed" json:"completed"`
+	Status    int         `db:"status" json:"status,omitempty"`
+	Header    http.Header `db:"header" json:"header,omitempty"`
+	Body      []byte      `db:"body" json:"body,omitempty"`
+	ExpiresAt time.Time   `db:"expires_at" json:"expires_at"`
+}
+
+type Store interface {
+	// Start records that the request is being handled until rec.ExpiresAt unless its key is already used
+	// by an unexpired record, it returns the record of the key and whether it was just made.
+	Start(ctx context.Context, rec Record, now time.Time) (Record, bool, error)
+	// Finish stores the response to the request handled under the key of the record until rec.ExpiresAt.
+	Finish(ctx context.Context, rec Record) error
+	// Abort forgets the key so that the request may be made again.
+	Abort(ctx context.Context, key string) error
+}
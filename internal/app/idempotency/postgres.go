@@ -0,0 +1,71 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"homework/internal/app/db"
+	"time"
+)
+
+// PostgresStore provides a Store with a PostgreSQL database as a backend.
+type PostgresStore struct {
+	db db.Database
+}
+
+// NewPostgresStore returns a new PostgresStore with provided database.
+func NewPostgresStore(db db.Database) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Start drops expired records and makes a new one unless its key is used.
+func (s *PostgresStore) Start(ctx context.Context, rec Record, now time.Time) (Record, bool, error) {
+	_, err := s.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1;", now)
+	if err != nil {
+		return Record{}, false, err
+	}
+	tag, err := s.db.Exec(ctx, "INSERT INTO idempotency_keys (key, request_hash, expires_at) VALUES ($1, $2, $3) ON CONFLICT (key) DO NOTHING;", rec.Key, rec.Hash, rec.ExpiresAt)
+	if err != nil {
+		return Record{}, false, err
+	}
+	if tag.RowsAffected() > 0 {
+		return rec, true, nil
+	}
+	var stored Record
+	err = s.db.Get(ctx, &stored, "SELECT key, request_hash, completed, status, header, body, expires_at FROM idempotency_keys WHERE key = $1;", rec.Key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Record{}, false, ErrNoRecord
+	}
+	if err != nil {
+		return Record{}, false, err
+	}
+	return stored, false, nil
+}
+
+// Finish stores the response of the record along with its new expiration.
+func (s *PostgresStore) Finish(ctx context.Context, rec Record) error {
+	header, err := json.Marshal(rec.Header)
+	if err != nil {
+		return err
+	}
+	body := rec.Body
+	if body == nil {
+		// a nil slice would be stored as NULL
+		body = []byte{}
+	}
+	tag, err := s.db.Exec(ctx, "UPDATE idempotency_keys SET completed = true, status = $2, header = $3, body = $4, expires_at = $5 WHERE key = $1;", rec.Key, rec.Status, header, body, rec.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+	return nil
+}
+
+// Abort deletes the record of the key.
+func (s *PostgresStore) Abort(ctx context.Context, key string) error {
+	_, err := s.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE key = $1;", key)
+	return err
+}
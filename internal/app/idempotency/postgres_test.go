@@ -0,0 +1,148 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/db/mocks"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var (
+	now    = time.Date(2024, 7, 17, 12, 0, 0, 0, time.UTC)
+	sample = Record{
+		Key:       "user POST /pickup-point key",
+		Hash:      "hash",
+		ExpiresAt: now.Add(24 * time.Hour),
+	}
+)
+
+type PostgresTestSuite struct {
+	suite.Suite
+}
+
+func (s *PostgresTestSuite) Test_Start() {
+	completed := sample
+	completed.Completed = true
+	completed.Status = http.StatusCreated
+	completed.Body = []byte(`{"id":1}`)
+	tests := []struct {
+		name     string
+		inserted int64
+		stored   Record
+		getErr   error
+		want     Record
+		started  bool
+		err      error
+	}{
+		{
+			name:     "new key",
+			inserted: 1,
+			want:     sample,
+			started:  true,
+		},
+		{
+			name:   "used key",
+			stored: completed,
+			want:   completed,
+		},
+		{
+			name:   "expired meanwhile",
+			getErr: pgx.ErrNoRows,
+			err:    ErrNoRecord,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			store := NewPostgresStore(db)
+			db.EXPECT().Exec(gomock.Any(), "DELETE FROM idempotency_keys WHERE expires_at <= $1;", now).
+				Return(mocks.NewMockCommandTag(ctrl), nil)
+			tag := mocks.NewMockCommandTag(ctrl)
+			tag.EXPECT().RowsAffected().Return(tt.inserted)
+			db.EXPECT().Exec(gomock.Any(), "INSERT INTO idempotency_keys (key, request_hash, expires_at) VALUES ($1, $2, $3) ON CONFLICT (key) DO NOTHING;", sample.Key, sample.Hash, sample.ExpiresAt).
+				Return(tag, nil)
+			if tt.inserted == 0 {
+				db.EXPECT().Get(gomock.Any(), gomock.Any(), "SELECT key, request_hash, completed, status, header, body, expires_at FROM idempotency_keys WHERE key = $1;", sample.Key).
+					SetArg(1, tt.stored).
+					Return(tt.getErr)
+			}
+			rec, started, err := store.Start(context.Background(), sample, now)
+			if tt.err != nil {
+				s.ErrorIs(err, tt.err)
+				return
+			}
+			s.NoError(err)
+			s.Equal(tt.started, started)
+			s.Equal(tt.want, rec)
+		})
+	}
+}
+
+func (s *PostgresTestSuite) Test_Finish() {
+	tests := []struct {
+		name     string
+		body     []byte
+		stored   []byte
+		affected int64
+		err      error
+	}{
+		{
+			name:     "with body",
+			body:     []byte(`{"id":1}`),
+			stored:   []byte(`{"id":1}`),
+			affected: 1,
+		},
+		{
+			name:     "without body",
+			stored:   []byte{},
+			affected: 1,
+		},
+		{
+			name:   "no record",
+			body:   []byte(`{"id":1}`),
+			stored: []byte(`{"id":1}`),
+			err:    ErrNoRecord,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			store := NewPostgresStore(db)
+			rec := sample
+			rec.Completed = true
+			rec.Status = http.StatusCreated
+			rec.Header = http.Header{"Content-Type": {"application/json"}}
+			rec.Body = tt.body
+			tag := mocks.NewMockCommandTag(ctrl)
+			tag.EXPECT().RowsAffected().Return(tt.affected)
+			db.EXPECT().Exec(gomock.Any(), "UPDATE idempotency_keys SET completed = true, status = $2, header = $3, body = $4, expires_at = $5 WHERE key = $1;",
+				rec.Key, http.StatusCreated, []byte(`{"Content-Type":["application/json"]}`), tt.stored, rec.ExpiresAt).
+				Return(tag, nil)
+			err := store.Finish(context.Background(), rec)
+			if tt.err != nil {
+				s.ErrorIs(err, tt.err)
+				return
+			}
+			s.NoError(err)
+		})
+	}
+}
+
+func (s *PostgresTestSuite) Test_Abort() {
+	ctrl := gomock.NewController(s.T())
+	db := mocks.NewMockDatabase(ctrl)
+	store := NewPostgresStore(db)
+	db.EXPECT().Exec(gomock.Any(), "DELETE FROM idempotency_keys WHERE key = $1;", sample.Key).
+		Return(mocks.NewMockCommandTag(ctrl), nil)
+	s.NoError(store.Abort(context.Background(), sample.Key))
+}
+
+func TestPostgres(t *testing.T) {
+	suite.Run(t, new(PostgresTestSuite))
+}
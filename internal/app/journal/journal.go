@@ -0,0 +1,437 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/multierr"
+	"hash/crc32"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+const (
+	walSuffix           = ".wal"
+	lockSuffix          = ".lock"
+	DefaultCompactEvery = 1000
+)
+
+var ErrCorrupted = errors.New("journal is corrupted")
+
+type op string
+
+const (
+	opPut      op = "put"
+	opDelete   op = "delete"
+	opSequence op = "sequence"
+)
+
+// walFile is the write-ahead log file.
+type walFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Sync() error
+	Truncate(size int64) error
+	Stat() (os.FileInfo, error)
+}
+
+// record is a change of an item, or of the sequence, which is then kept in Id.
+type record[T any] struct {
+	Op    op     `json:"op"`
+	Id    uint64 `json:"id"`
+	Value *T     `json:"value,omitempty"`
+}
+
+// snapshot is the snapshot of a journal with a sequence,
+// journals without one keep only the items for files written before sequences to stay readable.
+type snapshot[T any] struct {
+	Sequence uint64       `json:"sequence"`
+	Items    map[uint64]T `json:"items"`
+}
+
+// Journal persists a map of items as a snapshot file and an append-only write-ahead log next to it.
+// Every change is appended to the log and synced before it is applied,
+// and the log is compacted into a new snapshot every CompactEvery records.
+//
+// Several processes may share a journal: changes are recorded under an advisory file lock,
+// and Lock brings items up to date with changes made by others before a new change is recorded.
+//
+// Along with the items, a journal keeps a sequence, such as the largest id ever assigned to them.
+type Journal[T any] struct {
+	path         string
+	perm         os.FileMode
+	wal          walFile
+	lockFile     *os.File
+	snapshot     os.FileInfo
+	offset       int64
+	records      int
+	sequence     uint64
+	CompactEvery int
+}
+
+// Open recovers items stored in the snapshot at path and its write-ahead log
+// and returns a Journal ready to record further changes.
+func Open[T any](path string, perm os.FileMode) (*Journal[T], map[uint64]T, error) {
+	lockFile, err := os.OpenFile(path+lockSuffix, os.O_CREATE|os.O_RDWR, perm)
+	if err != nil {
+		return nil, nil, err
+	}
+	wal, err := os.OpenFile(path+walSuffix, os.O_CREATE|os.O_RDWR, perm)
+	if err != nil {
+		lockFile.Close()
+		return nil, nil, err
+	}
+	j := &Journal[T]{
+		path:         path,
+		perm:         perm,
+		wal:          wal,
+		lockFile:     lockFile,
+		CompactEvery: DefaultCompactEvery,
+	}
+	items := make(map[uint64]T)
+	err = lock(lockFile)
+	if err == nil {
+		err = j.load(items)
+		err = multierr.Combine(err, unlock(lockFile))
+	}
+	if err != nil {
+		return nil, nil, multierr.Combine(err, wal.Close(), lockFile.Close())
+	}
+	return j, items, nil
+}
+
+// load replaces items with the contents of the snapshot and the log.
+func (j *Journal[T]) load(items map[uint64]T) error {
+	info, err := os.Stat(j.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	loaded, err := readSnapshot[T](j.path)
+	if err != nil {
+		return err
+	}
+	clear(items)
+	for id, item := range loaded.Items {
+		items[id] = item
+	}
+	j.snapshot = info
+	j.sequence = loaded.Sequence
+	j.offset = 0
+	j.records = 0
+	return j.replay(items, nil)
+}
+
+func readSnapshot[T any](path string) (snapshot[T], error) {
+	loaded := snapshot[T]{Items: make(map[uint64]T)}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return loaded, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return loaded, nil
+	}
+	// items are keyed by numbers, so a snapshot with a sequence is told apart by its items key
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	if err == nil {
+		if _, ok := fields["items"]; ok {
+			err = json.Unmarshal(data, &loaded)
+		} else {
+			err = json.Unmarshal(data, &loaded.Items)
+		}
+	}
+	if err != nil {
+		return loaded, fmt.Errorf("%w: snapshot %s: %v", ErrCorrupted, path, err)
+	}
+	return loaded, nil
+}
+
+// replay applies records of the log following the current offset to items
+// and adds ids of changed items to changed when it is not nil.
+// A damaged last record is considered an interrupted write and is cut off,
+// while damage anywhere else is reported as ErrCorrupted.
+func (j *Journal[T]) replay(items map[uint64]T, changed map[uint64]struct{}) error {
+	_, err := j.wal.Seek(j.offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReader(j.wal)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				return truncate(j.wal, j.offset)
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		rec, decodeErr := decode[T](line)
+		if decodeErr != nil {
+			if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+				return truncate(j.wal, j.offset)
+			}
+			return fmt.Errorf("%w: record at offset %d: %v", ErrCorrupted, j.offset, decodeErr)
+		}
+		if rec.Op == opSequence {
+			j.sequence = rec.Id
+		} else {
+			apply(items, rec)
+			if changed != nil {
+				changed[rec.Id] = struct{}{}
+			}
+		}
+		j.offset += int64(len(line))
+		j.records++
+	}
+	_, err = j.wal.Seek(j.offset, io.SeekStart)
+	return err
+}
+
+// Lock acquires an exclusive lock on the journal and brings items up to date
+// with changes recorded by other processes since the journal was last read.
+// It returns ids of the items which were changed.
+func (j *Journal[T]) Lock(items map[uint64]T) (map[uint64]struct{}, error) {
+	err := lock(j.lockFile)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := j.refresh(items)
+	if err != nil {
+		return nil, multierr.Combine(err, unlock(j.lockFile))
+	}
+	return changed, nil
+}
+
+// Unlock releases the lock acquired by Lock.
+func (j *Journal[T]) Unlock() error {
+	return unlock(j.lockFile)
+}
+
+func (j *Journal[T]) refresh(items map[uint64]T) (map[uint64]struct{}, error) {
+	info, err := os.Stat(j.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	wal, err := j.wal.Stat()
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[uint64]struct{})
+	if sameFile(j.snapshot, info) && wal.Size() >= j.offset {
+		err = j.replay(items, changed)
+		return changed, err
+	}
+	// the journal was compacted by another process
+	old := maps.Clone(items)
+	err = j.load(items)
+	if err != nil {
+		return nil, err
+	}
+	for id, item := range items {
+		if oldItem, ok := old[id]; !ok || !reflect.DeepEqual(oldItem, item) {
+			changed[id] = struct{}{}
+		}
+	}
+	for id := range old {
+		if _, ok := items[id]; !ok {
+			changed[id] = struct{}{}
+		}
+	}
+	return changed, nil
+}
+
+func sameFile(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+func truncate(wal walFile, offset int64) error {
+	err := wal.Truncate(offset)
+	if err != nil {
+		return err
+	}
+	_, err = wal.Seek(offset, io.SeekStart)
+	return err
+}
+
+func apply[T any](items map[uint64]T, rec record[T]) {
+	switch rec.Op {
+	case opPut:
+		items[rec.Id] = *rec.Value
+	case opDelete:
+		delete(items, rec.Id)
+	}
+}
+
+func encode[T any](rec record[T]) ([]byte, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, "%08x %s\n", crc32.ChecksumIEEE(data), data), nil
+}
+
+func decode[T any](line []byte) (record[T], error) {
+	var rec record[T]
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	sum, data, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return rec, errors.New("malformed record")
+	}
+	var want uint32
+	_, err := fmt.Sscanf(string(sum), "%08x", &want)
+	if err != nil {
+		return rec, err
+	}
+	if crc32.ChecksumIEEE(data) != want {
+		return rec, errors.New("checksum mismatch")
+	}
+	err = json.Unmarshal(data, &rec)
+	if err != nil {
+		return rec, err
+	}
+	if rec.Op != opDelete && rec.Op != opSequence && (rec.Op != opPut || rec.Value == nil) {
+		return rec, errors.New("unknown operation")
+	}
+	return rec, nil
+}
+
+// Put records that the item with provided id was created or changed.
+// When the journal is shared, it must be locked with Lock.
+func (j *Journal[T]) Put(id uint64, value T) error {
+	return j.append(record[T]{Op: opPut, Id: id, Value: &value})
+}
+
+// Delete records that the item with provided id was deleted.
+// When the journal is shared, it must be locked with Lock.
+func (j *Journal[T]) Delete(id uint64) error {
+	return j.append(record[T]{Op: opDelete, Id: id})
+}
+
+func (j *Journal[T]) append(rec record[T]) error {
+	data, err := encode(rec)
+	if err != nil {
+		return err
+	}
+	_, err = j.wal.Write(data)
+	if err == nil {
+		err = j.wal.Sync()
+	}
+	if err != nil {
+		// a partly written record followed by the next one would be taken for corruption
+		return multierr.Combine(err, truncate(j.wal, j.offset))
+	}
+	j.offset += int64(len(data))
+	j.records++
+	return nil
+}
+
+// Sequence returns the sequence recorded with SetSequence, zero when none was.
+func (j *Journal[T]) Sequence() uint64 {
+	return j.sequence
+}
+
+// SetSequence records the sequence.
+// When the journal is shared, it must be locked with Lock.
+func (j *Journal[T]) SetSequence(sequence uint64) error {
+	err := j.append(record[T]{Op: opSequence, Id: sequence})
+	if err != nil {
+		return err
+	}
+	j.sequence = sequence
+	return nil
+}
+
+// NeedsCompaction reports whether the log has grown enough to be compacted.
+func (j *Journal[T]) NeedsCompaction() bool {
+	return j.CompactEvery > 0 && j.records >= j.CompactEvery
+}
+
+// Compact atomically replaces the snapshot with provided items and empties the log.
+// Records are idempotent, so a crash between the two steps only replays them once more.
+func (j *Journal[T]) Compact(items map[uint64]T) error {
+	var data []byte
+	var err error
+	if j.sequence > 0 {
+		data, err = json.Marshal(snapshot[T]{Sequence: j.sequence, Items: items})
+	} else {
+		data, err = json.Marshal(items)
+	}
+	if err != nil {
+		return err
+	}
+	err = writeFileAtomic(j.path, data, j.perm)
+	if err != nil {
+		return err
+	}
+	err = truncate(j.wal, 0)
+	if err != nil {
+		return err
+	}
+	err = j.wal.Sync()
+	if err != nil {
+		return err
+	}
+	j.snapshot, err = os.Stat(j.path)
+	if err != nil {
+		return err
+	}
+	j.offset = 0
+	j.records = 0
+	return nil
+}
+
+// Close compacts the journal when the log is not empty and closes its files.
+func (j *Journal[T]) Close(items map[uint64]T) error {
+	_, err := j.Lock(items)
+	if err == nil {
+		if j.records > 0 {
+			err = j.Compact(items)
+		}
+		err = multierr.Combine(err, j.Unlock())
+	}
+	return multierr.Combine(err, j.wal.Close(), j.lockFile.Close())
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	err = multierr.Combine(err, tmp.Close())
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	return multierr.Combine(err, d.Close())
+}
@@ -0,0 +1,189 @@
+package journal
+
+import (
+	"errors"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+type JournalTestSuite struct {
+	suite.Suite
+	path string
+}
+
+func (s *JournalTestSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "items.json")
+}
+
+func (s *JournalTestSuite) open() (*Journal[item], map[uint64]item) {
+	j, items, err := Open[item](s.path, 0600)
+	s.Require().NoError(err)
+	return j, items
+}
+
+func (s *JournalTestSuite) Test_Recover() {
+	j, items := s.open()
+	s.Empty(items)
+	s.NoError(j.Put(1, item{Name: "one"}))
+	s.NoError(j.Put(2, item{Name: "two"}))
+	s.NoError(j.Put(1, item{Name: "uno"}))
+	s.NoError(j.Delete(2))
+	// simulate a crash: the log is left as is
+	s.NoError(j.wal.Close())
+
+	j, items = s.open()
+	s.Equal(map[uint64]item{1: {Name: "uno"}}, items)
+	s.Equal(4, j.records)
+	s.NoError(j.Close(items))
+
+	data, err := os.ReadFile(s.path)
+	s.NoError(err)
+	s.JSONEq(`{"1":{"name":"uno"}}`, string(data))
+	info, err := os.Stat(s.path + walSuffix)
+	s.NoError(err)
+	s.Zero(info.Size())
+}
+
+func (s *JournalTestSuite) Test_Compact() {
+	j, items := s.open()
+	j.CompactEvery = 2
+	s.NoError(j.Put(1, item{Name: "one"}))
+	s.False(j.NeedsCompaction())
+	s.NoError(j.Put(2, item{Name: "two"}))
+	s.True(j.NeedsCompaction())
+	items[1] = item{Name: "one"}
+	items[2] = item{Name: "two"}
+	s.NoError(j.Compact(items))
+	s.False(j.NeedsCompaction())
+	s.NoError(j.Delete(1))
+	s.NoError(j.wal.Close())
+
+	_, items = s.open()
+	s.Equal(map[uint64]item{2: {Name: "two"}}, items)
+}
+
+func (s *JournalTestSuite) Test_TornTail() {
+	j, _ := s.open()
+	s.NoError(j.Put(1, item{Name: "one"}))
+	s.NoError(j.wal.Close())
+	f, err := os.OpenFile(s.path+walSuffix, os.O_APPEND|os.O_WRONLY, 0600)
+	s.Require().NoError(err)
+	_, err = f.WriteString(`0badc0de {"op":"put","id":2,"val`)
+	s.NoError(err)
+	s.NoError(f.Close())
+
+	j, items := s.open()
+	s.Equal(map[uint64]item{1: {Name: "one"}}, items)
+	s.NoError(j.Put(3, item{Name: "three"}))
+	s.NoError(j.wal.Close())
+
+	_, items = s.open()
+	s.Equal(map[uint64]item{1: {Name: "one"}, 3: {Name: "three"}}, items)
+}
+
+func (s *JournalTestSuite) Test_Sequence() {
+	j, items := s.open()
+	s.Zero(j.Sequence())
+	s.NoError(j.Put(1, item{Name: "one"}))
+	s.NoError(j.SetSequence(1))
+	s.NoError(j.Delete(1))
+	s.NoError(j.wal.Close())
+
+	j, items = s.open()
+	s.Empty(items)
+	s.Equal(uint64(1), j.Sequence(), "the sequence is replayed from the log")
+	s.NoError(j.Close(items))
+
+	j, items = s.open()
+	s.Equal(uint64(1), j.Sequence(), "the sequence is kept in the snapshot")
+	s.NoError(j.Close(items))
+}
+
+// tornWAL writes only the first half of the data and fails, like a write to a full disk.
+type tornWAL struct {
+	walFile
+}
+
+func (w tornWAL) Write(data []byte) (int, error) {
+	n, _ := w.walFile.Write(data[:len(data)/2])
+	return n, errors.New("no space left on device")
+}
+
+func (s *JournalTestSuite) Test_FailedWrite() {
+	j, _ := s.open()
+	s.NoError(j.Put(1, item{Name: "one"}))
+	wal := j.wal
+	j.wal = tornWAL{wal}
+	s.Error(j.Put(2, item{Name: "two"}))
+	j.wal = wal
+	s.NoError(j.Put(3, item{Name: "three"}))
+	s.NoError(j.wal.Close())
+
+	_, items := s.open()
+	s.Equal(map[uint64]item{1: {Name: "one"}, 3: {Name: "three"}}, items)
+}
+
+func (s *JournalTestSuite) Test_Corrupted() {
+	tests := []struct {
+		name     string
+		snapshot string
+		wal      string
+	}{
+		{
+			name:     "snapshot",
+			snapshot: `{"1":{"name":"one"}}garbage`,
+		},
+		{
+			name: "checksum mismatch in the middle",
+			wal:  "00000000 {\"op\":\"delete\",\"id\":1}\n00000000 {\"op\":\"delete\",\"id\":2}\n",
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.SetupTest()
+			s.NoError(os.WriteFile(s.path, []byte(tt.snapshot), 0600))
+			s.NoError(os.WriteFile(s.path+walSuffix, []byte(tt.wal), 0600))
+			_, _, err := Open[item](s.path, 0600)
+			s.ErrorIs(err, ErrCorrupted)
+		})
+	}
+}
+
+func (s *JournalTestSuite) Test_Shared() {
+	first, firstItems := s.open()
+	second, secondItems := s.open()
+
+	_, err := first.Lock(firstItems)
+	s.NoError(err)
+	firstItems[1] = item{Name: "one"}
+	s.NoError(first.Put(1, firstItems[1]))
+	s.NoError(first.Unlock())
+
+	changed, err := second.Lock(secondItems)
+	s.NoError(err)
+	s.Equal(map[uint64]struct{}{1: {}}, changed)
+	s.Equal(map[uint64]item{1: {Name: "one"}}, secondItems)
+	secondItems[2] = item{Name: "two"}
+	s.NoError(second.Put(2, secondItems[2]))
+	s.NoError(second.Compact(secondItems))
+	s.NoError(second.Unlock())
+
+	changed, err = first.Lock(firstItems)
+	s.NoError(err)
+	s.Equal(map[uint64]struct{}{2: {}}, changed)
+	s.Equal(map[uint64]item{1: {Name: "one"}, 2: {Name: "two"}}, firstItems)
+	s.NoError(first.Unlock())
+
+	s.NoError(first.Close(firstItems))
+	s.NoError(second.Close(secondItems))
+}
+
+func TestJournal(t *testing.T) {
+	suite.Run(t, new(JournalTestSuite))
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package journal
+
+import "os"
+
+// Advisory file locks are not supported, so the journal must not be shared by processes.
+
+func lock(f *os.File) error {
+	return nil
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
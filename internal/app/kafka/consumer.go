@@ -3,11 +3,17 @@ package kafka
 import (
 	"context"
 	"github.com/IBM/sarama"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
+	"homework/internal/app/metrics"
+	"homework/internal/app/tracing"
+	"strconv"
 	"time"
 )
 
-type MessageHandler func(msg []byte)
+// MessageHandler handles a message with the request id and the trace context of its headers in ctx.
+type MessageHandler func(ctx context.Context, msg []byte)
 
 type Consumer struct {
 	client         sarama.Consumer
@@ -61,7 +67,9 @@ func (c *Consumer) Run(ctx context.Context) error {
 					if !ok {
 						return nil
 					}
-					c.messageHandler(msg.Value)
+					c.handle(ctx, msg)
+					// the high water mark is the offset of the next message to be produced
+					metrics.KafkaLag.WithLabelValues(c.topic, strconv.Itoa(int(msg.Partition))).Set(float64(pc.HighWaterMarkOffset() - msg.Offset - 1))
 				}
 			}
 		})
@@ -71,6 +79,17 @@ func (c *Consumer) Run(ctx context.Context) error {
 	return eg.Wait()
 }
 
+// handle passes a message to the handler within a consumer span continuing the trace of the producer.
+func (c *Consumer) handle(ctx context.Context, msg *sarama.ConsumerMessage) {
+	ctx, span := tracing.Start(withRemoteParent(ctx, msg.Headers), "receive "+c.topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(semconv.MessagingSystemKafka, semconv.MessagingOperationReceive, semconv.MessagingDestinationName(c.topic)),
+	)
+	defer span.End()
+	metrics.KafkaConsumed.WithLabelValues(c.topic).Inc()
+	c.messageHandler(contextWithHeaders(ctx, msg.Headers), msg.Value)
+}
+
 func (c *Consumer) Ready() <-chan bool {
 	return c.ready
 }
@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"homework/internal/app/requestid"
+	"homework/internal/app/tracing"
+)
+
+// headers returns message headers carrying the request id and the trace context of ctx.
+// The trace context is that of the span of ctx when it is recorded.
+func headers(ctx context.Context) []sarama.RecordHeader {
+	traceparent, ok := tracing.Traceparent(ctx)
+	if !ok {
+		traceparent, ok = requestid.TraceparentFromContext(ctx)
+	}
+	if !ok {
+		return nil
+	}
+	return []sarama.RecordHeader{
+		{Key: []byte(requestid.Header), Value: []byte(requestid.FromContext(ctx))},
+		{Key: []byte(requestid.TraceparentHeader), Value: []byte(traceparent.String())},
+	}
+}
+
+// header returns the value of the header named key, empty when there is none.
+func header(headers []*sarama.RecordHeader, key string) string {
+	for _, header := range headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// withRemoteParent returns ctx in which spans continue the trace of message headers, if they carry a valid one.
+func withRemoteParent(ctx context.Context, headers []*sarama.RecordHeader) context.Context {
+	traceparent, err := requestid.ParseTraceparent(header(headers, requestid.TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	return tracing.WithRemoteParent(ctx, traceparent)
+}
+
+// contextWithHeaders returns ctx with the request id and the trace context of message headers.
+// The consumer continues the trace of the producer, a message without a valid trace context starts a new one.
+// A message handled within a recorded span takes the trace context of the span.
+func contextWithHeaders(ctx context.Context, headers []*sarama.RecordHeader) context.Context {
+	traceparent, ok := tracing.Traceparent(ctx)
+	if !ok {
+		var err error
+		traceparent, err = requestid.ParseTraceparent(header(headers, requestid.TraceparentHeader))
+		if err != nil {
+			traceparent = requestid.NewTraceparent()
+		} else {
+			traceparent = traceparent.Child()
+		}
+	}
+	id := header(headers, requestid.Header)
+	if !requestid.Valid(id) {
+		id = traceparent.TraceIdString()
+	}
+	return requestid.With(ctx, id, traceparent)
+}
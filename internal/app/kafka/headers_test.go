@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"homework/internal/app/requestid"
+	"homework/internal/app/tracing"
+	"testing"
+)
+
+func TestHeaders(t *testing.T) {
+	assert.Empty(t, headers(context.Background()))
+
+	traceparent := requestid.NewTraceparent()
+	ctx := requestid.With(context.Background(), "42", traceparent)
+	sent := headers(ctx)
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("X-Request-Id"), Value: []byte("42")},
+		{Key: []byte("traceparent"), Value: []byte(traceparent.String())},
+	}, sent)
+
+	received := make([]*sarama.RecordHeader, len(sent))
+	for i := range sent {
+		received[i] = &sent[i]
+	}
+	ctx = contextWithHeaders(context.Background(), received)
+	assert.Equal(t, "42", requestid.FromContext(ctx))
+	got, ok := requestid.TraceparentFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, traceparent.TraceId, got.TraceId, "the consumer continues the trace")
+	assert.NotEqual(t, traceparent.ParentId, got.ParentId)
+}
+
+func TestContextWithoutHeaders(t *testing.T) {
+	ctx := contextWithHeaders(context.Background(), nil)
+	traceparent, ok := requestid.TraceparentFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, traceparent.TraceIdString(), requestid.FromContext(ctx))
+}
+
+func TestHandleContinuesTrace(t *testing.T) {
+	exporter := tracing.InMemory()
+
+	ctx, producerSpan := tracing.Start(requestid.With(context.Background(), "42", requestid.NewTraceparent()), "publish requests")
+	sent := headers(ctx)
+	producerSpan.End()
+	msg := &sarama.ConsumerMessage{Value: []byte("{}")}
+	for i := range sent {
+		msg.Headers = append(msg.Headers, &sent[i])
+	}
+
+	var handled requestid.Traceparent
+	var handledId string
+	c := &Consumer{topic: "requests", messageHandler: func(ctx context.Context, msg []byte) {
+		handled, _ = requestid.TraceparentFromContext(ctx)
+		handledId = requestid.FromContext(ctx)
+	}}
+	c.handle(context.Background(), msg)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	producer, consumer := spans[0], spans[1]
+	assert.Equal(t, "receive requests", consumer.Name)
+	assert.Equal(t, producer.SpanContext.TraceID(), consumer.SpanContext.TraceID())
+	assert.Equal(t, producer.SpanContext.SpanID(), consumer.Parent.SpanID(), "the consumer span is a child of the producer span")
+	assert.Equal(t, [8]byte(consumer.SpanContext.SpanID()), handled.ParentId)
+	assert.Equal(t, "42", handledId)
+}
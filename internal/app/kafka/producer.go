@@ -1,8 +1,13 @@
 package kafka
 
 import (
+	"context"
 	"github.com/IBM/sarama"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 	"homework/internal/app/logger"
+	"homework/internal/app/metrics"
+	"homework/internal/app/tracing"
 )
 
 type Producer struct {
@@ -26,18 +31,29 @@ func NewProducer(brokers []string, log logger.Logger, topic string) (*Producer,
 	return &Producer{producer: producer, topic: topic, log: log}, nil
 }
 
-func (p *Producer) SendMessage(msg []byte) {
+// SendMessage sends a message with the request id and the trace context of ctx in its headers.
+// The message continues the trace from a producer span.
+func (p *Producer) SendMessage(ctx context.Context, msg []byte) {
+	ctx, span := tracing.Start(ctx, "publish "+p.topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(semconv.MessagingSystemKafka, semconv.MessagingOperationPublish, semconv.MessagingDestinationName(p.topic)),
+	)
 	prodMsg := &sarama.ProducerMessage{
 		Topic:     p.topic,
 		Value:     sarama.ByteEncoder(msg),
+		Headers:   headers(ctx),
 		Partition: -1,
 	}
-	p.log.Log("Sending message...")
+	log := logger.WithContext(ctx, p.log)
+	log.Log("Sending message...")
 	_, _, err := p.producer.SendMessage(prodMsg)
+	tracing.End(span, err)
 	if err != nil {
-		p.log.Log("%v", err)
+		metrics.KafkaProduced.WithLabelValues(p.topic, "error").Inc()
+		log.Log("%v", err)
 	} else {
-		p.log.Log("Sent message!")
+		metrics.KafkaProduced.WithLabelValues(p.topic, "ok").Inc()
+		log.Log("Sent message!")
 	}
 }
 
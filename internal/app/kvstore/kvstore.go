@@ -0,0 +1,86 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"go.etcd.io/bbolt"
+	"os"
+	"time"
+)
+
+const (
+	key         = "kvstore_transaction"
+	openTimeout = time.Second
+)
+
+var ErrReadOnly = errors.New("transaction is read-only")
+
+// DB provides an embedded single-file key-value store.
+type DB struct {
+	db *bbolt.DB
+}
+
+// Open opens the store kept in the file stored in the provided path, creating it when needed.
+// The file is locked while it is open, so another process trying to open it gets an error.
+func Open(path string, perm os.FileMode) (*DB, error) {
+	db, err := bbolt.Open(path, perm, &bbolt.Options{Timeout: openTimeout})
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return nil, errors.New("storage file is used by another process")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &DB{db: db}, nil
+}
+
+// Close releases the store file.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// RunSerializable runs f within a read-write transaction available to Update and View through ctxTX.
+// Write transactions of the store never run concurrently, so they are serializable.
+func (d *DB) RunSerializable(ctx context.Context, f func(ctxTX context.Context) error) error {
+	if _, ok := ctx.Value(key).(*bbolt.Tx); ok {
+		return f(ctx)
+	}
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return f(context.WithValue(ctx, key, tx))
+	})
+}
+
+// Update runs f within the transaction of ctx, or within a new read-write transaction if there is none.
+func (d *DB) Update(ctx context.Context, f func(tx *bbolt.Tx) error) error {
+	tx, ok := ctx.Value(key).(*bbolt.Tx)
+	if !ok {
+		return d.db.Update(f)
+	}
+	if !tx.Writable() {
+		return ErrReadOnly
+	}
+	return f(tx)
+}
+
+// View runs f within the transaction of ctx, or within a new read-only transaction if there is none.
+func (d *DB) View(ctx context.Context, f func(tx *bbolt.Tx) error) error {
+	tx, ok := ctx.Value(key).(*bbolt.Tx)
+	if !ok {
+		return d.db.View(f)
+	}
+	return f(tx)
+}
+
+// Key encodes n so that keys are ordered the same way as numbers.
+func Key(n ...uint64) []byte {
+	b := make([]byte, 0, 8*len(n))
+	for _, v := range n {
+		b = binary.BigEndian.AppendUint64(b, v)
+	}
+	return b
+}
+
+// Uint64 decodes the i-th number of a key made by Key.
+func Uint64(k []byte, i int) uint64 {
+	return binary.BigEndian.Uint64(k[8*i:])
+}
@@ -0,0 +1,107 @@
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/suite"
+	"go.etcd.io/bbolt"
+	"path/filepath"
+	"testing"
+)
+
+var bucket = []byte("items")
+
+type DBTestSuite struct {
+	suite.Suite
+	db *DB
+}
+
+func (s *DBTestSuite) SetupTest() {
+	var err error
+	s.db, err = Open(filepath.Join(s.T().TempDir(), "storage.db"), 0600)
+	s.Require().NoError(err)
+	s.Require().NoError(s.db.Update(context.Background(), func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucket(bucket)
+		return err
+	}))
+}
+
+func (s *DBTestSuite) TearDownTest() {
+	s.NoError(s.db.Close())
+}
+
+func (s *DBTestSuite) put(ctx context.Context, id uint64, value string) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).Put(Key(id), []byte(value))
+	})
+}
+
+func (s *DBTestSuite) get(ctx context.Context, id uint64) string {
+	var value string
+	s.NoError(s.db.View(ctx, func(tx *bbolt.Tx) error {
+		value = string(tx.Bucket(bucket).Get(Key(id)))
+		return nil
+	}))
+	return value
+}
+
+func (s *DBTestSuite) Test_RunSerializable_Commit() {
+	ctx := context.Background()
+	err := s.db.RunSerializable(ctx, func(ctxTX context.Context) error {
+		s.NoError(s.put(ctxTX, 1, "one"))
+		s.Equal("one", s.get(ctxTX, 1), "changes are seen within the transaction")
+		return s.put(ctxTX, 2, "two")
+	})
+	s.NoError(err)
+	s.Equal("one", s.get(ctx, 1))
+	s.Equal("two", s.get(ctx, 2))
+}
+
+func (s *DBTestSuite) Test_RunSerializable_Rollback() {
+	ctx := context.Background()
+	s.NoError(s.put(ctx, 1, "one"))
+	failure := errors.New("failure")
+	err := s.db.RunSerializable(ctx, func(ctxTX context.Context) error {
+		s.NoError(s.put(ctxTX, 1, "uno"))
+		s.NoError(s.put(ctxTX, 2, "two"))
+		return failure
+	})
+	s.ErrorIs(err, failure)
+	s.Equal("one", s.get(ctx, 1))
+	s.Empty(s.get(ctx, 2))
+}
+
+func (s *DBTestSuite) Test_RunSerializable_Nested() {
+	ctx := context.Background()
+	failure := errors.New("failure")
+	err := s.db.RunSerializable(ctx, func(outer context.Context) error {
+		err := s.db.RunSerializable(outer, func(inner context.Context) error {
+			s.Equal(outer.Value(key), inner.Value(key), "the outer transaction is reused")
+			return s.put(inner, 1, "one")
+		})
+		s.NoError(err)
+		s.Equal("one", s.get(outer, 1))
+		return failure
+	})
+	s.ErrorIs(err, failure)
+	s.Empty(s.get(ctx, 1), "the nested changes are rolled back with the outer transaction")
+}
+
+func (s *DBTestSuite) Test_Update_ReadOnly() {
+	ctx := context.Background()
+	err := s.db.db.View(func(tx *bbolt.Tx) error {
+		return s.put(context.WithValue(ctx, key, tx), 1, "one")
+	})
+	s.ErrorIs(err, ErrReadOnly)
+}
+
+func (s *DBTestSuite) Test_Key() {
+	s.Less(string(Key(1, 255)), string(Key(256, 0)), "keys are ordered as numbers")
+	k := Key(7, 1<<40)
+	s.Equal(uint64(7), Uint64(k, 0))
+	s.Equal(uint64(1<<40), Uint64(k, 1))
+}
+
+func TestDB(t *testing.T) {
+	suite.Run(t, new(DBTestSuite))
+}
@@ -0,0 +1,25 @@
+package logger
+
+import (
+	"context"
+	"homework/internal/app/requestid"
+)
+
+// contextLogger prefixes lines with the id of a request so that they can be told apart from lines of other requests.
+type contextLogger struct {
+	log Logger
+	id  string
+}
+
+func (l contextLogger) Log(format string, a ...any) {
+	l.log.Log("[request %s] "+format, append([]any{l.id}, a...)...)
+}
+
+// WithContext returns a logger marking lines with the request id of ctx, or log itself when ctx has no request.
+func WithContext(ctx context.Context, log Logger) Logger {
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		return log
+	}
+	return contextLogger{log: log, id: id}
+}
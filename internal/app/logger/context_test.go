@@ -0,0 +1,21 @@
+package logger
+
+import (
+	"context"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/logger/mocks"
+	"homework/internal/app/requestid"
+	"testing"
+)
+
+func TestWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	log := mocks.NewMockLogger(ctrl)
+
+	log.EXPECT().Log("purged %d points", 3)
+	WithContext(context.Background(), log).Log("purged %d points", 3)
+
+	ctx := requestid.With(context.Background(), "42", requestid.NewTraceparent())
+	log.EXPECT().Log("[request %s] purged %d points", "42", 3)
+	WithContext(ctx, log).Log("purged %d points", 3)
+}
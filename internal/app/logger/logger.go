@@ -16,11 +16,10 @@ type ThreadLogger struct {
 }
 
 func NewLogger() *ThreadLogger {
-	return &ThreadLogger{}
+	return &ThreadLogger{out: make(chan string, 128)}
 }
 
 func (l *ThreadLogger) Run(ctx context.Context) error {
-	l.out = make(chan string, 128)
 	defer close(l.out)
 	for {
 		select {
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "pickup_points"
+
+// Results of cache lookups.
+const (
+	Hit   = "hit"
+	Miss  = "miss"
+	Error = "error"
+)
+
+// Caches looked up.
+const (
+	MemoryCache = "memory"
+	RedisCache  = "redis"
+)
+
+// registry keeps the metrics of the service along with the Go runtime and process stats.
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	Requests = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served by route, method and response status.",
+	}, []string{"route", "method", "status"})
+
+	RequestDuration = promauto.With(registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	CacheLookups = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Cache lookups by cache and result: hit, miss or error.",
+	}, []string{"cache", "result"})
+
+	TransactionRollbacks = promauto.With(registry).NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_transaction_rollbacks_total",
+		Help:      "Transactions rolled back after an error.",
+	})
+
+	KafkaProduced = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_produced_total",
+		Help:      "Messages sent to Kafka by topic and result: ok or error.",
+	}, []string{"topic", "result"})
+
+	KafkaConsumed = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_consumed_total",
+		Help:      "Messages received from Kafka by topic.",
+	}, []string{"topic"})
+
+	KafkaLag = promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_lag",
+		Help:      "Messages of a partition not yet received, as of the last message received.",
+	}, []string{"topic", "partition"})
+)
+
+// Lookup counts a cache lookup, an error that is not a miss is counted as an error.
+func Lookup(cache string, found bool, err error) {
+	switch {
+	case found:
+		CacheLookups.WithLabelValues(cache, Hit).Inc()
+	case err != nil:
+		CacheLookups.WithLabelValues(cache, Error).Inc()
+	default:
+		CacheLookups.WithLabelValues(cache, Miss).Inc()
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	hits := CacheLookups.WithLabelValues(RedisCache, Hit)
+	misses := CacheLookups.WithLabelValues(RedisCache, Miss)
+	errs := CacheLookups.WithLabelValues(RedisCache, Error)
+	hitsBefore, missesBefore, errsBefore := testutil.ToFloat64(hits), testutil.ToFloat64(misses), testutil.ToFloat64(errs)
+
+	Lookup(RedisCache, true, nil)
+	Lookup(RedisCache, false, nil)
+	Lookup(RedisCache, false, nil)
+	Lookup(RedisCache, false, errors.New("connection refused"))
+
+	assert.Equal(t, hitsBefore+1, testutil.ToFloat64(hits))
+	assert.Equal(t, missesBefore+2, testutil.ToFloat64(misses))
+	assert.Equal(t, errsBefore+1, testutil.ToFloat64(errs))
+}
+
+func TestHandler(t *testing.T) {
+	TransactionRollbacks.Inc()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	assert.Contains(t, string(body), "pickup_points_db_transaction_rollbacks_total")
+	assert.Contains(t, string(body), "go_goroutines", "runtime stats are served")
+	assert.Contains(t, string(body), "process_cpu_seconds_total")
+}
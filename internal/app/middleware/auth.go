@@ -1,21 +1,65 @@
+//go:generate mockgen -source=./auth.go -destination=./mocks/auth.go -package=mocks
+
 package middleware
 
 import (
+	"context"
+	"crypto/x509/pkix"
+	"errors"
 	"github.com/gorilla/mux"
+	"homework/internal/app/auth"
+	"homework/internal/app/problem"
 	"net/http"
+	"strings"
 )
 
-func AuthMiddleware(username string, password string) mux.MiddlewareFunc {
+type Authenticator interface {
+	Authenticate(ctx context.Context, name string, password string) (auth.User, error)
+	AuthenticateToken(ctx context.Context, token string) (auth.User, error)
+	AuthenticateCertificate(ctx context.Context, subject pkix.Name) (auth.User, error)
+}
+
+// BearerToken returns the token of a request authorized with the Bearer scheme.
+func BearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// AuthMiddleware lets through requests of enabled users authenticated with a client certificate verified by the server,
+// or authorized either with a name and a password by the Basic scheme, or with an access token or an API key by the Bearer one.
+func AuthMiddleware(users Authenticator) mux.MiddlewareFunc {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			reqUsername, reqPassword, ok := req.BasicAuth()
-			if !ok || reqUsername != username || reqPassword != password {
-				w.Header().Set("WWW-Authenticate", "Basic realm=\"pickup-point\"")
-				w.WriteHeader(http.StatusUnauthorized)
+			var user auth.User
+			var err error
+			if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+				user, err = users.AuthenticateCertificate(req.Context(), req.TLS.VerifiedChains[0][0].Subject)
+			} else if reqUsername, reqPassword, ok := req.BasicAuth(); ok {
+				user, err = users.Authenticate(req.Context(), reqUsername, reqPassword)
+			} else if token, ok := BearerToken(req); ok {
+				user, err = users.AuthenticateToken(req.Context(), token)
+			} else {
+				err = auth.ErrInvalidCredentials
+			}
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				w.Header().Add("WWW-Authenticate", "Basic realm=\"pickup-point\"")
+				w.Header().Add("WWW-Authenticate", "Bearer realm=\"pickup-point\"")
+				problem.Write(w, req, http.StatusUnauthorized, problem.Problem{
+					Code:    "invalid_credentials",
+					Message: "valid credentials are required",
+				})
+				return
+			}
+			if err != nil {
+				problem.Write(w, req, http.StatusInternalServerError, problem.Problem{Code: "internal_error", Message: "internal server error"})
 				return
 			}
 
-			h.ServeHTTP(w, req)
+			ctx := auth.WithUser(req.Context(), user.Name)
+			h.ServeHTTP(w, req.WithContext(auth.WithRole(ctx, user.Role)))
 		})
 	}
 }
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/auth"
+	"homework/internal/app/middleware/mocks"
+	"homework/internal/app/problem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// assertProblem asserts that the response is a problem with the code.
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, code string) {
+	t.Helper()
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	var p problem.Problem
+	if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p)) {
+		assert.Equal(t, code, p.Code)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		noAuth   bool
+		token    string
+		cert     bool
+		user     auth.User
+		err      error
+		status   int
+		wantUser string
+		wantRole auth.Role
+	}{
+		{
+			name:     "valid",
+			user:     auth.User{Name: "user", Role: auth.Operator},
+			status:   http.StatusOK,
+			wantUser: "user",
+			wantRole: auth.Operator,
+		},
+		{
+			name:     "bearer token",
+			token:    "token",
+			user:     auth.User{Name: "user", Role: auth.Viewer},
+			status:   http.StatusOK,
+			wantUser: "user",
+			wantRole: auth.Viewer,
+		},
+		{
+			name:   "invalid bearer token",
+			token:  "token",
+			err:    auth.ErrInvalidCredentials,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:     "client certificate",
+			cert:     true,
+			user:     auth.User{Name: "svc", Role: auth.Operator},
+			status:   http.StatusOK,
+			wantUser: "svc",
+			wantRole: auth.Operator,
+		},
+		{
+			name:   "unknown client certificate",
+			cert:   true,
+			err:    auth.ErrInvalidCredentials,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "no credentials",
+			noAuth: true,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "invalid credentials",
+			err:    auth.ErrInvalidCredentials,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "error",
+			err:    assert.AnError,
+			status: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			users := mocks.NewMockAuthenticator(ctrl)
+			subject := pkix.Name{CommonName: "svc"}
+			switch {
+			case tt.cert:
+				users.EXPECT().AuthenticateCertificate(gomock.Any(), subject).Return(tt.user, tt.err)
+			case tt.token != "":
+				users.EXPECT().AuthenticateToken(gomock.Any(), tt.token).Return(tt.user, tt.err)
+			case !tt.noAuth:
+				users.EXPECT().Authenticate(gomock.Any(), "user", "password").Return(tt.user, tt.err)
+			}
+			var gotUser string
+			var gotRole auth.Role
+			h := AuthMiddleware(users)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser = auth.UserName(r.Context())
+				gotRole = auth.UserRole(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			switch {
+			case tt.cert:
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: subject}}}}
+			case tt.token != "":
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			case !tt.noAuth:
+				req.SetBasicAuth("user", "password")
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.wantUser, gotUser)
+			assert.Equal(t, tt.wantRole, gotRole)
+			switch tt.status {
+			case http.StatusUnauthorized:
+				assert.Len(t, w.Header().Values("WWW-Authenticate"), 2)
+				assertProblem(t, w, "invalid_credentials")
+			case http.StatusInternalServerError:
+				assertProblem(t, w, "internal_error")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gorilla/mux"
+	"homework/internal/app/auth"
+	"homework/internal/app/idempotency"
+	"homework/internal/app/logger"
+	"homework/internal/app/problem"
+	"homework/internal/app/requestid"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+	// pendingTTL bounds how long retries are rejected as in progress when the first request is never finished.
+	pendingTTL = time.Minute
+)
+
+// responseRecorder passes a response on keeping its status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// requestHash tells apart requests made with the same idempotency key.
+func requestHash(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// IdempotencyMiddleware makes POST requests to the route with an Idempotency-Key header take effect once for ttl:
+// the response to the first request is stored and returned again to requests of the same user with the same key.
+// A request reusing a key with another path or body is rejected with 422 Unprocessable Entity,
+// one made while the first request is still handled is rejected with 409 Conflict.
+// Server errors and panics of the handler are not stored, so that the request may be retried.
+// A request is known to be in progress for at most a minute, after that a retry is handled again.
+func IdempotencyMiddleware(store idempotency.Store, route string, ttl time.Duration, log logger.Logger) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			key := req.Header.Get(IdempotencyKeyHeader)
+			if req.Method != http.MethodPost || key == "" {
+				h.ServeHTTP(w, req)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				problem.Write(w, req, http.StatusBadRequest, problem.Problem{
+					Code:    "invalid_idempotency_key",
+					Message: IdempotencyKeyHeader + " must be at most " + strconv.Itoa(maxIdempotencyKeyLen) + " bytes long",
+				})
+				return
+			}
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				problem.Write(w, req, http.StatusBadRequest, problem.Problem{Code: "invalid_body", Message: "request body could not be read"})
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := time.Now()
+			rec := idempotency.Record{
+				Key:       auth.UserName(req.Context()) + " " + route + " " + key,
+				Hash:      requestHash(req, body),
+				ExpiresAt: now.Add(min(ttl, pendingTTL)),
+			}
+			stored, started, err := store.Start(req.Context(), rec, now)
+			if err != nil {
+				logger.WithContext(req.Context(), log).Log("idempotency key: %v", err)
+				problem.Write(w, req, http.StatusInternalServerError, problem.Problem{Code: "internal_error", Message: "internal server error"})
+				return
+			}
+			if !started {
+				switch {
+				case stored.Hash != rec.Hash:
+					problem.Write(w, req, http.StatusUnprocessableEntity, problem.Problem{
+						Code:    "idempotency_key_reused",
+						Message: IdempotencyKeyHeader + " was used with another request",
+					})
+				case !stored.Completed:
+					w.Header().Set("Retry-After", "1")
+					problem.Write(w, req, http.StatusConflict, problem.Problem{
+						Code:    "request_in_progress",
+						Message: "request with the same " + IdempotencyKeyHeader + " is still handled, retry after the time in Retry-After",
+					})
+				default:
+					for name, values := range stored.Header {
+						w.Header()[name] = values
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(stored.Status)
+					w.Write(stored.Body)
+				}
+				return
+			}
+
+			// the response is stored even when the client is gone, as it will likely retry
+			ctx := context.WithoutCancel(req.Context())
+			// the key is forgotten when the handler fails or panics
+			defer func() {
+				if rec.Completed {
+					return
+				}
+				err := store.Abort(ctx, rec.Key)
+				if err != nil {
+					logger.WithContext(req.Context(), log).Log("idempotency key: %v", err)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			h.ServeHTTP(recorder, req)
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+			rec.Completed = true
+			rec.Status = recorder.status
+			rec.Header = w.Header().Clone()
+			// replays are other requests with ids of their own
+			rec.Header.Del(requestid.Header)
+			rec.Header.Del(requestid.TraceparentHeader)
+			rec.Body = recorder.body.Bytes()
+			rec.ExpiresAt = time.Now().Add(ttl)
+			err = store.Finish(ctx, rec)
+			if err != nil {
+				logger.WithContext(req.Context(), log).Log("idempotency key: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/auth"
+	"homework/internal/app/idempotency"
+	idempotencymocks "homework/internal/app/idempotency/mocks"
+	loggermocks "homework/internal/app/logger/mocks"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	const (
+		key  = "alice /pickup-point 42"
+		body = `{"name":"SomePVZ"}`
+	)
+	hash := requestHash(httptest.NewRequest(http.MethodPost, "/pickup-point", nil), []byte(body))
+	completed := idempotency.Record{
+		Key:       key,
+		Hash:      hash,
+		Completed: true,
+		Status:    http.StatusCreated,
+		Header:    http.Header{"Content-Type": {"application/json"}, "Location": {"/pickup-point/1"}},
+		Body:      []byte(`{"id":1}`),
+	}
+	tests := []struct {
+		name       string
+		method     string
+		key        string
+		body       string
+		stored     *idempotency.Record
+		status     int
+		handled    bool
+		finished   bool
+		aborted    bool
+		wantBody   string
+		wantHeader http.Header
+		wantCode   string
+	}{
+		{
+			name:     "first request",
+			method:   http.MethodPost,
+			key:      "42",
+			body:     body,
+			status:   http.StatusCreated,
+			handled:  true,
+			finished: true,
+			wantBody: `{"id":1}`,
+		},
+		{
+			name:     "replayed request",
+			method:   http.MethodPost,
+			key:      "42",
+			body:     body,
+			stored:   &completed,
+			status:   http.StatusCreated,
+			wantBody: `{"id":1}`,
+			wantHeader: http.Header{
+				"Content-Type":        {"application/json"},
+				"Location":            {"/pickup-point/1"},
+				"Idempotent-Replayed": {"true"},
+			},
+		},
+		{
+			name:     "reused key",
+			method:   http.MethodPost,
+			key:      "42",
+			body:     `{"name":"OtherPVZ"}`,
+			stored:   &completed,
+			status:   http.StatusUnprocessableEntity,
+			wantCode: "idempotency_key_reused",
+		},
+		{
+			name:     "request in progress",
+			method:   http.MethodPost,
+			key:      "42",
+			body:     body,
+			stored:   &idempotency.Record{Key: key, Hash: hash},
+			status:   http.StatusConflict,
+			wantCode: "request_in_progress",
+		},
+		{
+			name:    "failed request",
+			method:  http.MethodPost,
+			key:     "42",
+			body:    `fail`,
+			status:  http.StatusInternalServerError,
+			handled: true,
+			aborted: true,
+		},
+		{
+			name:     "without key",
+			method:   http.MethodPost,
+			body:     body,
+			status:   http.StatusCreated,
+			handled:  true,
+			wantBody: `{"id":1}`,
+		},
+		{
+			name:    "not a post",
+			method:  http.MethodPut,
+			key:     "42",
+			body:    body,
+			status:  http.StatusOK,
+			handled: true,
+		},
+		{
+			name:     "too long key",
+			method:   http.MethodPost,
+			key:      strings.Repeat("k", 256),
+			body:     body,
+			status:   http.StatusBadRequest,
+			wantCode: "invalid_idempotency_key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := idempotencymocks.NewMockStore(ctrl)
+			log := loggermocks.NewMockLogger(ctrl)
+			if tt.method == http.MethodPost && tt.key != "" && len(tt.key) <= maxIdempotencyKeyLen {
+				store.EXPECT().Start(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, rec idempotency.Record, now time.Time) (idempotency.Record, bool, error) {
+						assert.Equal(t, key, rec.Key)
+						assert.Equal(t, now.Add(pendingTTL), rec.ExpiresAt, "a pending request expires soon")
+						if tt.stored != nil {
+							return *tt.stored, false, nil
+						}
+						return rec, true, nil
+					})
+			}
+			if tt.finished {
+				store.EXPECT().Finish(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, rec idempotency.Record) error {
+						assert.WithinDuration(t, time.Now().Add(time.Hour), rec.ExpiresAt, time.Second, "a response is kept for ttl")
+						rec.ExpiresAt = time.Time{}
+						assert.Equal(t, completed, rec)
+						return nil
+					})
+			}
+			if tt.aborted {
+				store.EXPECT().Abort(gomock.Any(), key)
+			}
+			handled := false
+			h := IdempotencyMiddleware(store, "/pickup-point", time.Hour, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handled = true
+				b, _ := io.ReadAll(r.Body)
+				assert.Equal(t, tt.body, string(b))
+				switch {
+				case string(b) == "fail":
+					w.WriteHeader(http.StatusInternalServerError)
+				case r.Method == http.MethodPost:
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set("Location", "/pickup-point/1")
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{"id":1}`))
+				}
+			}))
+			req := httptest.NewRequest(tt.method, "/pickup-point", strings.NewReader(tt.body))
+			req = req.WithContext(auth.WithUser(req.Context(), "alice"))
+			if tt.key != "" {
+				req.Header.Set(IdempotencyKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.handled, handled)
+			if tt.wantCode != "" {
+				assertProblem(t, w, tt.wantCode)
+			} else {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+			if tt.wantHeader != nil {
+				assert.Equal(t, tt.wantHeader, w.Header())
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddleware_Panic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := idempotencymocks.NewMockStore(ctrl)
+	store.EXPECT().Start(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, rec idempotency.Record, now time.Time) (idempotency.Record, bool, error) {
+			return rec, true, nil
+		})
+	store.EXPECT().Abort(gomock.Any(), "alice /pickup-point 42")
+	h := IdempotencyMiddleware(store, "/pickup-point", time.Hour, loggermocks.NewMockLogger(ctrl))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/pickup-point", strings.NewReader(`{}`))
+	req = req.WithContext(auth.WithUser(req.Context(), "alice"))
+	req.Header.Set(IdempotencyKeyHeader, "42")
+
+	assert.Panics(t, func() {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}, "the panic is left to the server")
+}
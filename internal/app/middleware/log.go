@@ -4,15 +4,17 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"github.com/gorilla/mux"
 	"homework/internal/app/reqlog"
+	"homework/internal/app/requestid"
 	"io"
 	"net/http"
 	"time"
 )
 
 type RequestLogger interface {
-	Log(msg reqlog.Message)
+	Log(ctx context.Context, msg reqlog.Message)
 }
 
 func LogMiddleware(log RequestLogger) mux.MiddlewareFunc {
@@ -29,8 +31,12 @@ func LogMiddleware(log RequestLogger) mux.MiddlewareFunc {
 				Headers:   req.Header,
 				Params:    mux.Vars(req),
 				Body:      buf.String(),
+				RequestId: requestid.FromContext(req.Context()),
 			}
-			log.Log(msg)
+			if traceparent, ok := requestid.TraceparentFromContext(req.Context()); ok {
+				msg.Traceparent = traceparent.String()
+			}
+			log.Log(req.Context(), msg)
 		})
 	}
 }
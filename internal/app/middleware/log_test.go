@@ -1,11 +1,13 @@
 package middleware
 
 import (
+	"context"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"homework/internal/app/middleware/mocks"
 	"homework/internal/app/reqlog"
+	"homework/internal/app/requestid"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -26,6 +28,7 @@ func TestLogMiddleware(t *testing.T) {
 		headers http.Header
 		params  map[string]string
 		body    string
+		id      string
 	}{
 		{
 			name:    "basic",
@@ -63,23 +66,40 @@ func TestLogMiddleware(t *testing.T) {
 			headers: map[string][]string{},
 			body:    "somebodyuwu",
 		},
+		{
+			name:    "request id",
+			method:  http.MethodGet,
+			path:    "/",
+			headers: map[string][]string{},
+			id:      "42",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			log := mocks.NewMockRequestLogger(ctrl)
-			log.EXPECT().Log(gomock.Any()).Do(func(msg reqlog.Message) {
+			traceparent := requestid.NewTraceparent()
+			log.EXPECT().Log(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, msg reqlog.Message) {
 				assert.Equal(t, tt.method, msg.Method)
 				assert.Equal(t, tt.path, msg.Path)
 				assert.Equal(t, tt.headers, msg.Headers)
 				assert.Equal(t, tt.params, msg.Params)
 				assert.Equal(t, tt.body, msg.Body)
+				assert.Equal(t, tt.id, msg.RequestId)
+				if tt.id != "" {
+					assert.Equal(t, traceparent.String(), msg.Traceparent)
+				} else {
+					assert.Empty(t, msg.Traceparent)
+				}
 			})
 			m := LogMiddleware(log)
 			h := m(h)
 			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
 			req = mux.SetURLVars(req, tt.params)
 			req.Header = tt.headers
+			if tt.id != "" {
+				req = req.WithContext(requestid.With(req.Context(), tt.id, traceparent))
+			}
 			w := httptest.NewRecorder()
 			h.ServeHTTP(w, req)
 		})
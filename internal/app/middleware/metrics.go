@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"homework/internal/app/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MetricsMiddleware counts requests by route, method and response status and observes how long they take.
+func MetricsMiddleware() mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w}
+			h.ServeHTTP(recorder, req)
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			route := route(req)
+			metrics.RequestDuration.WithLabelValues(route, req.Method).Observe(time.Since(start).Seconds())
+			metrics.Requests.WithLabelValues(route, req.Method, strconv.Itoa(recorder.status)).Inc()
+		})
+	}
+}
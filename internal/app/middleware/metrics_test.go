@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"homework/internal/app/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	const route = "/pickup-point/{id:[0-9]+}"
+	router := mux.NewRouter()
+	router.HandleFunc(route, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+	router.Use(MetricsMiddleware())
+
+	ok := metrics.Requests.WithLabelValues(route, http.MethodGet, "200")
+	forbidden := metrics.Requests.WithLabelValues(route, http.MethodDelete, "403")
+	okBefore, forbiddenBefore := testutil.ToFloat64(ok), testutil.ToFloat64(forbidden)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/pickup-point/1", nil),
+		httptest.NewRequest(http.MethodGet, "/pickup-point/2", nil),
+		httptest.NewRequest(http.MethodDelete, "/pickup-point/1", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, okBefore+2, testutil.ToFloat64(ok), "requests are counted by route rather than path")
+	assert.Equal(t, forbiddenBefore+1, testutil.ToFloat64(forbidden))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.RequestDuration), "durations are observed by route and method")
+}
@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	reqlog "homework/internal/app/reqlog"
 	reflect "reflect"
 
@@ -40,13 +41,13 @@ func (m *MockRequestLogger) EXPECT() *MockRequestLoggerMockRecorder {
 }
 
 // Log mocks base method.
-func (m *MockRequestLogger) Log(msg reqlog.Message) {
+func (m *MockRequestLogger) Log(ctx context.Context, msg reqlog.Message) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Log", msg)
+	m.ctrl.Call(m, "Log", ctx, msg)
 }
 
 // Log indicates an expected call of Log.
-func (mr *MockRequestLoggerMockRecorder) Log(msg any) *gomock.Call {
+func (mr *MockRequestLoggerMockRecorder) Log(ctx, msg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Log", reflect.TypeOf((*MockRequestLogger)(nil).Log), msg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Log", reflect.TypeOf((*MockRequestLogger)(nil).Log), ctx, msg)
 }
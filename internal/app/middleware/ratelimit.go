@@ -0,0 +1,83 @@
+//go:generate mockgen -source=./ratelimit.go -destination=./mocks/ratelimit.go -package=mocks
+
+package middleware
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"homework/internal/app/auth"
+	"homework/internal/app/logger"
+	"homework/internal/app/problem"
+	"homework/internal/app/ratelimit"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error)
+}
+
+// clientKey identifies the client of a request by its user, or by its address when it is not authenticated.
+func clientKey(req *http.Request) string {
+	if name := auth.UserName(req.Context()); name != "" {
+		return "user:" + name
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// rateLimitKey makes the budget of a request to the route shared by every request of its user with the same method,
+// requests of unauthenticated clients share the budget of their address.
+func rateLimitKey(route string, req *http.Request) string {
+	return route + " " + req.Method + " " + clientKey(req)
+}
+
+// RateLimitMiddleware rejects requests to the route made over the limit of their method with 429 Too Many Requests,
+// telling in Retry-After how many seconds to wait. Methods missing from limits are not limited.
+// Requests are let through when the limiter fails, so that an unavailable shared limiter does not stop the service.
+func RateLimitMiddleware(limiter RateLimiter, route string, limits map[string]ratelimit.Limit, log logger.Logger) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limit, ok := limits[req.Method]
+			if !ok || limit.Unlimited() || allow(w, req, limiter, rateLimitKey(route, req), limit, log) {
+				h.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+// ClientRateLimitMiddleware rejects requests made over the limit by a client address with 429 Too Many Requests,
+// whatever their route and method. Used ahead of AuthMiddleware, it also limits requests failing to authenticate,
+// so that credentials cannot be guessed at the rate the server checks them.
+func ClientRateLimitMiddleware(limiter RateLimiter, limit ratelimit.Limit, log logger.Logger) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if limit.Unlimited() || allow(w, req, limiter, "client "+clientKey(req), limit, log) {
+				h.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+// allow reports whether the request is within the limit of the key, otherwise it responds with 429 Too Many Requests.
+func allow(w http.ResponseWriter, req *http.Request, limiter RateLimiter, key string, limit ratelimit.Limit, log logger.Logger) bool {
+	allowed, retryAfter, err := limiter.Allow(req.Context(), key, limit)
+	if err != nil {
+		logger.WithContext(req.Context(), log).Log("rate limiter: %v", err)
+		return true
+	}
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		problem.Write(w, req, http.StatusTooManyRequests, problem.Problem{
+			Code:    "rate_limited",
+			Message: "too many requests, retry after the time in Retry-After",
+		})
+	}
+	return allowed
+}
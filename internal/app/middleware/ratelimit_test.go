@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/auth"
+	loggermocks "homework/internal/app/logger/mocks"
+	"homework/internal/app/middleware/mocks"
+	"homework/internal/app/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 1, Period: time.Second, Burst: 1}
+	limits := map[string]ratelimit.Limit{
+		http.MethodGet:  limit,
+		http.MethodPost: {},
+	}
+	tests := []struct {
+		name       string
+		method     string
+		user       string
+		key        string
+		allowed    bool
+		retryAfter time.Duration
+		err        error
+		status     int
+		header     string
+	}{
+		{
+			name:    "user allowed",
+			method:  http.MethodGet,
+			user:    "alice",
+			key:     "/pickup-points GET user:alice",
+			allowed: true,
+			status:  http.StatusOK,
+		},
+		{
+			name:       "user limited",
+			method:     http.MethodGet,
+			user:       "alice",
+			key:        "/pickup-points GET user:alice",
+			retryAfter: 1500 * time.Millisecond,
+			status:     http.StatusTooManyRequests,
+			header:     "2",
+		},
+		{
+			name:       "address limited",
+			method:     http.MethodGet,
+			key:        "/pickup-points GET ip:192.0.2.1",
+			retryAfter: 100 * time.Millisecond,
+			status:     http.StatusTooManyRequests,
+			header:     "1",
+		},
+		{
+			name:   "limiter fails",
+			method: http.MethodGet,
+			user:   "alice",
+			key:    "/pickup-points GET user:alice",
+			err:    errors.New("connection refused"),
+			status: http.StatusOK,
+		},
+		{
+			name:   "unlimited method",
+			method: http.MethodPost,
+			user:   "alice",
+			status: http.StatusOK,
+		},
+		{
+			name:   "method without limit",
+			method: http.MethodDelete,
+			user:   "alice",
+			status: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			limiter := mocks.NewMockRateLimiter(ctrl)
+			log := loggermocks.NewMockLogger(ctrl)
+			if tt.key != "" {
+				limiter.EXPECT().Allow(gomock.Any(), tt.key, limit).Return(tt.allowed, tt.retryAfter, tt.err)
+			}
+			if tt.err != nil {
+				log.EXPECT().Log(gomock.Any(), gomock.Any())
+			}
+			h := RateLimitMiddleware(limiter, "/pickup-points", limits, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(tt.method, "/pickup-points", nil)
+			if tt.user != "" {
+				req = req.WithContext(auth.WithUser(req.Context(), tt.user))
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.header, w.Header().Get("Retry-After"))
+			if tt.status == http.StatusTooManyRequests {
+				assertProblem(t, w, "rate_limited")
+			}
+		})
+	}
+}
+
+func TestClientRateLimitMiddleware_BadCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	users := mocks.NewMockAuthenticator(ctrl)
+	users.EXPECT().Authenticate(gomock.Any(), "alice", "guess").Return(auth.User{}, auth.ErrInvalidCredentials).Times(3)
+	limit := ratelimit.Limit{Requests: 1, Period: time.Minute, Burst: 2}
+	h := ClientRateLimitMiddleware(ratelimit.NewLocal(time.Minute), limit, loggermocks.NewMockLogger(ctrl))(
+		AuthMiddleware(users)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			t.Error("request with bad credentials let through")
+		})))
+	login := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/token", nil)
+		req.RemoteAddr = remoteAddr
+		req.SetBasicAuth("alice", "guess")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("192.0.2.1:1000").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("192.0.2.1:1001").Code)
+	w := login("192.0.2.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "credentials are not checked over the limit")
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assertProblem(t, w, "rate_limited")
+	assert.Equal(t, http.StatusUnauthorized, login("192.0.2.2:1000").Code, "other addresses keep their budget")
+}
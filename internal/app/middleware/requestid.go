@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"homework/internal/app/requestid"
+	"homework/internal/app/tracing"
+	"net/http"
+)
+
+// RequestIdMiddleware puts the id and the trace context of a request into its context and echoes them in the response.
+// The id is taken from X-Request-Id when it is valid, otherwise it is the id of the trace.
+// A request with a valid traceparent continues its trace, others start new ones.
+// A request traced by TracingMiddleware takes the trace context of its span.
+func RequestIdMiddleware() mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			traceparent, ok := tracing.Traceparent(req.Context())
+			if !ok {
+				var err error
+				traceparent, err = requestid.ParseTraceparent(req.Header.Get(requestid.TraceparentHeader))
+				if err != nil {
+					traceparent = requestid.NewTraceparent()
+				} else {
+					traceparent = traceparent.Child()
+				}
+			}
+			id := req.Header.Get(requestid.Header)
+			if !requestid.Valid(id) {
+				id = traceparent.TraceIdString()
+			}
+
+			w.Header().Set(requestid.Header, id)
+			w.Header().Set(requestid.TraceparentHeader, traceparent.String())
+			h.ServeHTTP(w, req.WithContext(requestid.With(req.Context(), id, traceparent)))
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"homework/internal/app/requestid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIdMiddleware(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name        string
+		id          string
+		traceparent string
+		wantId      string
+		wantTraceId string
+	}{
+		{
+			name:        "given id and trace",
+			id:          "6f1d2c1e",
+			traceparent: traceparent,
+			wantId:      "6f1d2c1e",
+			wantTraceId: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:        "given trace",
+			traceparent: traceparent,
+			wantId:      "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantTraceId: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:        "invalid id and trace",
+			id:          "with space",
+			traceparent: "00-zz",
+		},
+		{
+			name: "nothing given",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxId string
+			var ctxTraceparent requestid.Traceparent
+			h := RequestIdMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxId = requestid.FromContext(r.Context())
+				ctxTraceparent, _ = requestid.TraceparentFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/pickup-point", nil)
+			if tt.id != "" {
+				req.Header.Set(requestid.Header, tt.id)
+			}
+			if tt.traceparent != "" {
+				req.Header.Set(requestid.TraceparentHeader, tt.traceparent)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if tt.wantTraceId != "" {
+				assert.Equal(t, tt.wantTraceId, ctxTraceparent.TraceIdString())
+			}
+			if tt.wantId != "" {
+				assert.Equal(t, tt.wantId, ctxId)
+			} else {
+				assert.Equal(t, ctxTraceparent.TraceIdString(), ctxId, "a new trace id is the request id")
+			}
+			assert.NotEqual(t, traceparent, ctxTraceparent.String(), "the server makes a span of its own")
+			assert.Equal(t, ctxId, w.Header().Get(requestid.Header))
+			assert.Equal(t, ctxTraceparent.String(), w.Header().Get(requestid.TraceparentHeader))
+		})
+	}
+}
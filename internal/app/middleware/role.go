@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"homework/internal/app/auth"
+	"homework/internal/app/problem"
+	"net/http"
+)
+
+// RoleMiddleware lets through only requests of users whose role allows the one required for the request method.
+// Methods missing from roles are let through to be rejected by the handler.
+func RoleMiddleware(roles map[string]auth.Role) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			required, ok := roles[req.Method]
+			if ok && !auth.UserRole(req.Context()).Allows(required) {
+				problem.Write(w, req, http.StatusForbidden, problem.Problem{
+					Code:    "forbidden",
+					Message: "role " + string(required) + " is required",
+				})
+				return
+			}
+
+			h.ServeHTTP(w, req)
+		})
+	}
+}
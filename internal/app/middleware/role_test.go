@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"homework/internal/app/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoleMiddleware(t *testing.T) {
+	roles := map[string]auth.Role{
+		http.MethodGet:    auth.Viewer,
+		http.MethodPost:   auth.Operator,
+		http.MethodDelete: auth.Admin,
+	}
+	tests := []struct {
+		name   string
+		method string
+		role   auth.Role
+		status int
+	}{
+		{
+			name:   "viewer reads",
+			method: http.MethodGet,
+			role:   auth.Viewer,
+			status: http.StatusOK,
+		},
+		{
+			name:   "viewer creates",
+			method: http.MethodPost,
+			role:   auth.Viewer,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "operator creates",
+			method: http.MethodPost,
+			role:   auth.Operator,
+			status: http.StatusOK,
+		},
+		{
+			name:   "operator deletes",
+			method: http.MethodDelete,
+			role:   auth.Operator,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "admin deletes",
+			method: http.MethodDelete,
+			role:   auth.Admin,
+			status: http.StatusOK,
+		},
+		{
+			name:   "no role",
+			method: http.MethodGet,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "unknown method",
+			method: http.MethodPut,
+			status: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := RoleMiddleware(roles)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.role != "" {
+				req = req.WithContext(auth.WithRole(req.Context(), tt.role))
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusForbidden {
+				assertProblem(t, w, "forbidden")
+			}
+		})
+	}
+}
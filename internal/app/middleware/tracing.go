@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"homework/internal/app/requestid"
+	"homework/internal/app/tracing"
+	"net/http"
+)
+
+// statusRecorder passes a response on keeping its status.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// route returns the path template of the route matched by req, or its path when none matched.
+func route(req *http.Request) string {
+	if r := mux.CurrentRoute(req); r != nil {
+		if tmpl, err := r.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return req.URL.Path
+}
+
+// TracingMiddleware serves a request within a server span continuing the trace of its traceparent.
+// It goes before RequestIdMiddleware, so that the trace context echoed is that of the span.
+func TracingMiddleware() mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			if traceparent, err := requestid.ParseTraceparent(req.Header.Get(requestid.TraceparentHeader)); err == nil {
+				ctx = tracing.WithRemoteParent(ctx, traceparent)
+			}
+			route := route(req)
+			ctx, span := tracing.Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPRequestMethodKey.String(req.Method), semconv.HTTPRoute(route)),
+			)
+			defer span.End()
+
+			recorder := &statusRecorder{ResponseWriter: w}
+			h.ServeHTTP(recorder, req.WithContext(ctx))
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+			// client errors are the client's, not the server's
+			if recorder.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(recorder.status))
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"homework/internal/app/requestid"
+	"homework/internal/app/tracing"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		status      int
+		wantName    string
+		wantCode    codes.Code
+		wantRemote  bool
+	}{
+		{
+			name:        "continues trace",
+			path:        "/pickup-point/42",
+			traceparent: traceparent,
+			status:      http.StatusOK,
+			wantName:    "GET /pickup-point/{id:[0-9]+}",
+			wantCode:    codes.Unset,
+			wantRemote:  true,
+		},
+		{
+			name:     "new trace",
+			path:     "/pickup-point/42",
+			status:   http.StatusNotFound,
+			wantName: "GET /pickup-point/{id:[0-9]+}",
+			wantCode: codes.Unset,
+		},
+		{
+			name:     "server error",
+			path:     "/pickup-point/42",
+			status:   http.StatusInternalServerError,
+			wantName: "GET /pickup-point/{id:[0-9]+}",
+			wantCode: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracing.InMemory()
+			router := mux.NewRouter()
+			router.HandleFunc("/pickup-point/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			router.Use(TracingMiddleware(), RequestIdMiddleware())
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set(requestid.TraceparentHeader, tt.traceparent)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			spans := exporter.GetSpans()
+			assert.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, tt.wantName, span.Name)
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+			assert.Equal(t, tt.wantCode, span.Status.Code)
+			assert.Contains(t, span.Attributes, attribute.Int("http.response.status_code", tt.status))
+			assert.Equal(t, tt.wantRemote, span.Parent.IsRemote())
+			if tt.wantRemote {
+				assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+			}
+			got, err := requestid.ParseTraceparent(w.Header().Get(requestid.TraceparentHeader))
+			assert.NoError(t, err)
+			assert.Equal(t, [16]byte(span.SpanContext.TraceID()), got.TraceId, "the trace context of the span is echoed")
+			assert.Equal(t, [8]byte(span.SpanContext.SpanID()), got.ParentId)
+			assert.Equal(t, got.TraceIdString(), w.Header().Get(requestid.Header))
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"homework/internal/app/kvstore"
+	"slices"
+	"time"
+)
+
+var (
+	ordersBucket     = []byte("orders")
+	byCustomerBucket = []byte("orders_by_customer")
+	byReturnBucket   = []byte("orders_by_return")
+)
+
+// BoltRepository provides an order Repository with an embedded key-value store as a backend.
+type BoltRepository struct {
+	db *kvstore.DB
+}
+
+// NewBoltRepository returns a new BoltRepository with provided store.
+func NewBoltRepository(db *kvstore.DB) (*BoltRepository, error) {
+	err := db.Update(context.Background(), func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{ordersBucket, byCustomerBucket, byReturnBucket} {
+			_, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+		}
+		// the sequence of the returned index counts its keys, it is set once for stores made without it
+		b := tx.Bucket(byReturnBucket)
+		if k, _ := b.Cursor().First(); b.Sequence() == 0 && k != nil {
+			return b.SetSequence(uint64(b.Stats().KeyN))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltRepository{db: db}, nil
+}
+
+// newestFirst encodes t so that later dates are ordered first.
+func newestFirst(t time.Time) uint64 {
+	return ^uint64(max(t.UnixNano(), 0))
+}
+
+func customerKey(o Order) []byte {
+	return kvstore.Key(o.CustomerId, newestFirst(o.AddDate), o.Id)
+}
+
+func returnKey(o Order) []byte {
+	return kvstore.Key(newestFirst(o.ReturnDate), o.Id)
+}
+
+func getOrder(tx *bbolt.Tx, id uint64) (Order, error) {
+	var order Order
+	v := tx.Bucket(ordersBucket).Get(kvstore.Key(id))
+	if v == nil {
+		return order, ErrNoItemFound
+	}
+	err := json.Unmarshal(v, &order)
+	return order, err
+}
+
+func putOrder(tx *bbolt.Tx, order Order) error {
+	v, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(ordersBucket).Put(kvstore.Key(order.Id), v)
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(byCustomerBucket).Put(customerKey(order), nil)
+	if err != nil {
+		return err
+	}
+	if order.IsReturned {
+		b := tx.Bucket(byReturnBucket)
+		err = b.Put(returnKey(order), nil)
+		if err != nil {
+			return err
+		}
+		return b.SetSequence(b.Sequence() + 1)
+	}
+	return nil
+}
+
+func deleteOrder(tx *bbolt.Tx, order Order) error {
+	err := tx.Bucket(ordersBucket).Delete(kvstore.Key(order.Id))
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(byCustomerBucket).Delete(customerKey(order))
+	if err != nil {
+		return err
+	}
+	if order.IsReturned {
+		b := tx.Bucket(byReturnBucket)
+		err = b.Delete(returnKey(order))
+		if err != nil {
+			return err
+		}
+		return b.SetSequence(b.Sequence() - 1)
+	}
+	return nil
+}
+
+// Create creates a new order.
+func (s *BoltRepository) Create(ctx context.Context, order Order) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		if tx.Bucket(ordersBucket).Get(kvstore.Key(order.Id)) != nil {
+			return ErrIdAlreadyExists
+		}
+		return putOrder(tx, order)
+	})
+}
+
+// CreateAdmitted creates a new order unless admit rejects the space already taken at its pick-up point.
+// The check and the creation are done in one transaction.
+func (s *BoltRepository) CreateAdmitted(ctx context.Context, order Order, admit func(taken Occupancy) error) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		occupancy, err := occupancy(tx, order.PickUpPointId)
+		if err != nil {
+			return err
+		}
+		err = admit(occupancy[order.PickUpPointId])
+		if err != nil {
+			return err
+		}
+		if tx.Bucket(ordersBucket).Get(kvstore.Key(order.Id)) != nil {
+			return ErrIdAlreadyExists
+		}
+		return putOrder(tx, order)
+	})
+}
+
+// List returns a slice of all orders stored.
+func (s *BoltRepository) List(ctx context.Context) ([]Order, error) {
+	slice := make([]Order, 0)
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		return tx.Bucket(ordersBucket).ForEach(func(k, v []byte) error {
+			var order Order
+			err := json.Unmarshal(v, &order)
+			if err != nil {
+				return err
+			}
+			slice = append(slice, order)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slice, nil
+}
+
+// ListByCustomer returns orders of the customer, most recently added first.
+// When statuses are provided, only orders in one of them are returned.
+func (s *BoltRepository) ListByCustomer(ctx context.Context, customerId uint64, statuses ...Status) ([]Order, error) {
+	slice := make([]Order, 0)
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		prefix := kvstore.Key(customerId)
+		c := tx.Bucket(byCustomerBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			order, err := getOrder(tx, kvstore.Uint64(k, 2))
+			if err != nil {
+				return err
+			}
+			if len(statuses) > 0 && !slices.Contains(statuses, order.Status()) {
+				continue
+			}
+			slice = append(slice, order)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slice, nil
+}
+
+// ListReturned returns up to count returned orders starting from offset,
+// most recently returned first, along with the total number of returned orders.
+func (s *BoltRepository) ListReturned(ctx context.Context, offset int, count int) ([]Order, int, error) {
+	slice := make([]Order, 0)
+	var total int
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(byReturnBucket)
+		total = int(b.Sequence())
+		c := b.Cursor()
+		i := 0
+		for k, _ := c.First(); k != nil && i < offset+count; k, _ = c.Next() {
+			if i >= offset {
+				order, err := getOrder(tx, kvstore.Uint64(k, 1))
+				if err != nil {
+					return err
+				}
+				slice = append(slice, order)
+			}
+			i++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return slice, total, nil
+}
+
+// Occupancy returns the space taken by orders stored at each pick-up point.
+// When pickUpPointIds are provided, only those points are counted.
+func (s *BoltRepository) Occupancy(ctx context.Context, pickUpPointIds ...uint64) (map[uint64]Occupancy, error) {
+	var result map[uint64]Occupancy
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		var err error
+		result, err = occupancy(tx, pickUpPointIds...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func occupancy(tx *bbolt.Tx, pickUpPointIds ...uint64) (map[uint64]Occupancy, error) {
+	occupancy := make(map[uint64]Occupancy)
+	err := tx.Bucket(ordersBucket).ForEach(func(k, v []byte) error {
+		var order Order
+		err := json.Unmarshal(v, &order)
+		if err != nil {
+			return err
+		}
+		if !order.occupies() || len(pickUpPointIds) > 0 && !slices.Contains(pickUpPointIds, order.PickUpPointId) {
+			return nil
+		}
+		occupancy[order.PickUpPointId] = occupancy[order.PickUpPointId].add(order)
+		return nil
+	})
+	return occupancy, err
+}
+
+// Get returns the order represented by id.
+func (s *BoltRepository) Get(ctx context.Context, id uint64) (Order, error) {
+	var order Order
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		var err error
+		order, err = getOrder(tx, id)
+		return err
+	})
+	return order, err
+}
+
+// Update sets the parameters of an order to those provided.
+func (s *BoltRepository) Update(ctx context.Context, order Order) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		old, err := getOrder(tx, order.Id)
+		if err != nil {
+			return err
+		}
+		err = deleteOrder(tx, old)
+		if err != nil {
+			return err
+		}
+		return putOrder(tx, order)
+	})
+}
+
+// Delete deletes an order.
+func (s *BoltRepository) Delete(ctx context.Context, id uint64) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		order, err := getOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		return deleteOrder(tx, order)
+	})
+}
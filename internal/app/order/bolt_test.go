@@ -0,0 +1,112 @@
+package order
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"go.etcd.io/bbolt"
+	"homework/internal/app/kvstore"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type BoltRepositoryTestSuite struct {
+	suite.Suite
+	db   *kvstore.DB
+	repo *BoltRepository
+}
+
+func (s *BoltRepositoryTestSuite) SetupTest() {
+	ctx := context.Background()
+	var err error
+	s.db, err = kvstore.Open(filepath.Join(s.T().TempDir(), "storage.db"), 0600)
+	s.Require().NoError(err)
+	s.repo, err = NewBoltRepository(s.db)
+	s.Require().NoError(err)
+	for _, o := range sampleOrders() {
+		s.Require().NoError(s.repo.Create(ctx, o))
+	}
+}
+
+func (s *BoltRepositoryTestSuite) TearDownTest() {
+	s.NoError(s.db.Close())
+}
+
+func (s *BoltRepositoryTestSuite) Test_ListByCustomer() {
+	ctx := context.Background()
+	orders, err := s.repo.ListByCustomer(ctx, 10)
+	s.NoError(err)
+	s.Equal([]uint64{3, 2, 1}, ids(orders))
+	orders, err = s.repo.ListByCustomer(ctx, 10, StatusStored, StatusReturned)
+	s.NoError(err)
+	s.Equal([]uint64{3, 1}, ids(orders))
+	orders, err = s.repo.ListByCustomer(ctx, 30)
+	s.NoError(err)
+	s.Empty(orders)
+}
+
+func (s *BoltRepositoryTestSuite) Test_ListReturned() {
+	ctx := context.Background()
+	orders, total, err := s.repo.ListReturned(ctx, 0, 1)
+	s.NoError(err)
+	s.Equal([]uint64{4}, ids(orders))
+	s.Equal(2, total)
+	orders, total, err = s.repo.ListReturned(ctx, 1, 10)
+	s.NoError(err)
+	s.Equal([]uint64{3}, ids(orders))
+	s.Equal(2, total)
+}
+
+func (s *BoltRepositoryTestSuite) Test_ListReturned_UncountedStore() {
+	ctx := context.Background()
+	s.NoError(s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		return tx.Bucket(byReturnBucket).SetSequence(0)
+	}))
+	repo, err := NewBoltRepository(s.db)
+	s.Require().NoError(err)
+	_, total, err := repo.ListReturned(ctx, 0, 1)
+	s.NoError(err)
+	s.Equal(2, total)
+}
+
+func (s *BoltRepositoryTestSuite) Test_Occupancy() {
+	ctx := context.Background()
+	occupancy, err := s.repo.Occupancy(ctx)
+	s.NoError(err)
+	s.Equal(map[uint64]Occupancy{1: {Orders: 2, WeightKg: 6}, 2: {Orders: 1, WeightKg: 5}}, occupancy)
+	occupancy, err = s.repo.Occupancy(ctx, 2, 3)
+	s.NoError(err)
+	s.Equal(map[uint64]Occupancy{2: {Orders: 1, WeightKg: 5}}, occupancy)
+}
+
+func (s *BoltRepositoryTestSuite) Test_IndexesFollowChanges() {
+	ctx := context.Background()
+	s.ErrorIs(s.repo.Create(ctx, Order{Id: 1}), ErrIdAlreadyExists)
+
+	o, err := s.repo.Get(ctx, 2)
+	s.NoError(err)
+	o.IsReturned = true
+	o.ReturnDate = baseDate.Add(6 * time.Hour)
+	s.NoError(s.repo.Update(ctx, o))
+	returned, total, err := s.repo.ListReturned(ctx, 0, 10)
+	s.NoError(err)
+	s.Equal([]uint64{2, 4, 3}, ids(returned))
+	s.Equal(3, total)
+
+	s.NoError(s.repo.Delete(ctx, 3))
+	s.ErrorIs(s.repo.Delete(ctx, 3), ErrNoItemFound)
+	returned, total, err = s.repo.ListReturned(ctx, 0, 10)
+	s.NoError(err)
+	s.Equal([]uint64{2, 4}, ids(returned))
+	s.Equal(2, total)
+	orders, err := s.repo.ListByCustomer(ctx, 10)
+	s.NoError(err)
+	s.Equal([]uint64{2, 1}, ids(orders))
+	all, err := s.repo.List(ctx)
+	s.NoError(err)
+	s.Len(all, 3)
+}
+
+func TestBoltRepository(t *testing.T) {
+	suite.Run(t, new(BoltRepositoryTestSuite))
+}
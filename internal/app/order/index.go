@@ -0,0 +1,104 @@
+package order
+
+import (
+	"slices"
+	"time"
+)
+
+// Status represents a stage of the order lifecycle.
+type Status int
+
+const (
+	StatusStored Status = iota
+	StatusGiven
+	StatusReturned
+)
+
+// Status returns the current status of the order.
+func (o Order) Status() Status {
+	if o.IsReturned {
+		return StatusReturned
+	}
+	if o.IsGiven {
+		return StatusGiven
+	}
+	return StatusStored
+}
+
+type indexEntry struct {
+	date time.Time
+	id   uint64
+}
+
+// dateIndex keeps order ids sorted by date, newest first.
+type dateIndex []indexEntry
+
+func compareEntries(a, b indexEntry) int {
+	if c := b.date.Compare(a.date); c != 0 {
+		return c
+	}
+	if a.id < b.id {
+		return -1
+	}
+	if a.id > b.id {
+		return 1
+	}
+	return 0
+}
+
+func (idx *dateIndex) insert(date time.Time, id uint64) {
+	e := indexEntry{date: date, id: id}
+	i, _ := slices.BinarySearchFunc(*idx, e, compareEntries)
+	*idx = slices.Insert(*idx, i, e)
+}
+
+func (idx *dateIndex) remove(date time.Time, id uint64) {
+	i, found := slices.BinarySearchFunc(*idx, indexEntry{date: date, id: id}, compareEntries)
+	if found {
+		*idx = slices.Delete(*idx, i, i+1)
+	}
+}
+
+// indexes holds secondary indexes over a set of orders.
+type indexes struct {
+	byCustomer map[uint64]*dateIndex
+	byStatus   map[Status]map[uint64]struct{}
+	byReturn   dateIndex
+}
+
+func newIndexes() *indexes {
+	return &indexes{
+		byCustomer: make(map[uint64]*dateIndex),
+		byStatus: map[Status]map[uint64]struct{}{
+			StatusStored:   {},
+			StatusGiven:    {},
+			StatusReturned: {},
+		},
+	}
+}
+
+func (x *indexes) add(o Order) {
+	idx, ok := x.byCustomer[o.CustomerId]
+	if !ok {
+		idx = &dateIndex{}
+		x.byCustomer[o.CustomerId] = idx
+	}
+	idx.insert(o.AddDate, o.Id)
+	x.byStatus[o.Status()][o.Id] = struct{}{}
+	if o.IsReturned {
+		x.byReturn.insert(o.ReturnDate, o.Id)
+	}
+}
+
+func (x *indexes) remove(o Order) {
+	if idx, ok := x.byCustomer[o.CustomerId]; ok {
+		idx.remove(o.AddDate, o.Id)
+		if len(*idx) == 0 {
+			delete(x.byCustomer, o.CustomerId)
+		}
+	}
+	delete(x.byStatus[o.Status()], o.Id)
+	if o.IsReturned {
+		x.byReturn.remove(o.ReturnDate, o.Id)
+	}
+}
@@ -6,17 +6,19 @@ import (
 )
 
 // Order contains fields relevant to an order.
+// PickUpPointId is the pick-up point storing the order, zero when it is not tracked.
 type Order struct {
-	GiveDate   time.Time `json:"give_date"`
-	ReturnDate time.Time `json:"return_date"`
-	KeepDate   time.Time `json:"keep_date"`
-	AddDate    time.Time `json:"add_date"`
-	Id         uint64    `json:"id"`
-	CustomerId uint64    `json:"customer_id"`
-	PriceRub   int64     `json:"price"`
-	WeightKg   float64   `json:"weight_kg"`
-	IsGiven    bool      `json:"is_given"`
-	IsReturned bool      `json:"is_returned"`
+	GiveDate      time.Time `json:"give_date" db:"give_date"`
+	ReturnDate    time.Time `json:"return_date" db:"return_date"`
+	KeepDate      time.Time `json:"keep_date" db:"keep_date"`
+	AddDate       time.Time `json:"add_date" db:"add_date"`
+	Id            uint64    `json:"id" db:"id"`
+	CustomerId    uint64    `json:"customer_id" db:"customer_id"`
+	PickUpPointId uint64    `json:"pickup_point_id" db:"pickup_point_id"`
+	PriceRub      int64     `json:"price" db:"price"`
+	WeightKg      float64   `json:"weight_kg" db:"weight_kg"`
+	IsGiven       bool      `json:"is_given" db:"is_given"`
+	IsReturned    bool      `json:"is_returned" db:"is_returned"`
 }
 
 const dateFormat = "2006-01-02"
@@ -30,10 +32,15 @@ func (o Order) String() string {
 	if o.IsReturned {
 		displayedReturnDate = o.ReturnDate.Format(dateFormat)
 	}
+	displayedPickUpPointId := "-"
+	if o.PickUpPointId != 0 {
+		displayedPickUpPointId = fmt.Sprint(o.PickUpPointId)
+	}
 	return fmt.Sprintf(
-		"%d\t%d\t%d\t%.3f\t%s\t%s\t%t\t%s\t%t\t%s\n",
+		"%d\t%d\t%s\t%d\t%.3f\t%s\t%s\t%t\t%s\t%t\t%s\n",
 		o.Id,
 		o.CustomerId,
+		displayedPickUpPointId,
 		o.PriceRub,
 		o.WeightKg,
 		o.AddDate.Format(dateFormat),
@@ -43,3 +50,19 @@ func (o Order) String() string {
 		o.IsReturned,
 		displayedReturnDate)
 }
+
+// Occupancy is the space taken by orders stored at a pick-up point:
+// those not given to customers yet and those returned by them.
+type Occupancy struct {
+	Orders   int     `db:"orders"`
+	WeightKg float64 `db:"weight_kg"`
+}
+
+func (o Occupancy) add(order Order) Occupancy {
+	return Occupancy{Orders: o.Orders + 1, WeightKg: o.WeightKg + order.WeightKg}
+}
+
+// occupies reports whether the order takes space at its pick-up point.
+func (o Order) occupies() bool {
+	return o.PickUpPointId != 0 && o.Status() != StatusGiven
+}
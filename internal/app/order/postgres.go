@@ -0,0 +1,160 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"homework/internal/app/db"
+	"slices"
+)
+
+const orderColumns = "id, customer_id, price, weight_kg, add_date, keep_date, is_given, give_date, is_returned, return_date, pickup_point_id"
+
+// PostgresRepository provides an order Repository with a PostgreSQL database as a backend.
+type PostgresRepository struct {
+	db db.Database
+}
+
+// NewPostgresRepository returns a new PostgresRepository with provided database.
+func NewPostgresRepository(db db.Database) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// Create creates a new order.
+func (s *PostgresRepository) Create(ctx context.Context, order Order) error {
+	_, err := s.db.Exec(ctx,
+		"INSERT INTO orders ("+orderColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);",
+		order.Id, order.CustomerId, order.PriceRub, order.WeightKg, order.AddDate, order.KeepDate,
+		order.IsGiven, order.GiveDate, order.IsReturned, order.ReturnDate, order.PickUpPointId)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName != "" {
+		return ErrIdAlreadyExists
+	}
+	return err
+}
+
+// CreateAdmitted creates a new order unless admit rejects the space already taken at its pick-up point.
+// Run within a serializable transaction, it fails with a serialization error rather than let
+// a concurrent order take the space counted.
+func (s *PostgresRepository) CreateAdmitted(ctx context.Context, order Order, admit func(taken Occupancy) error) error {
+	occupancy, err := s.Occupancy(ctx, order.PickUpPointId)
+	if err != nil {
+		return err
+	}
+	err = admit(occupancy[order.PickUpPointId])
+	if err != nil {
+		return err
+	}
+	return s.Create(ctx, order)
+}
+
+// List returns a slice of all orders stored.
+func (s *PostgresRepository) List(ctx context.Context) ([]Order, error) {
+	slice := make([]Order, 0)
+	err := s.db.Select(ctx, &slice, "SELECT "+orderColumns+" FROM orders;")
+	if err != nil {
+		return nil, err
+	}
+	return slice, nil
+}
+
+// ListByCustomer returns orders of the customer, most recently added first.
+// When statuses are provided, only orders in one of them are returned.
+func (s *PostgresRepository) ListByCustomer(ctx context.Context, customerId uint64, statuses ...Status) ([]Order, error) {
+	var all []Order
+	err := s.db.Select(ctx, &all,
+		"SELECT "+orderColumns+" FROM orders WHERE customer_id = $1 ORDER BY add_date DESC, id;", customerId)
+	if err != nil {
+		return nil, err
+	}
+	slice := make([]Order, 0, len(all))
+	for _, order := range all {
+		if len(statuses) > 0 && !slices.Contains(statuses, order.Status()) {
+			continue
+		}
+		slice = append(slice, order)
+	}
+	return slice, nil
+}
+
+// ListReturned returns up to count returned orders starting from offset,
+// most recently returned first, along with the total number of returned orders.
+func (s *PostgresRepository) ListReturned(ctx context.Context, offset int, count int) ([]Order, int, error) {
+	var total int
+	err := s.db.Get(ctx, &total, "SELECT count(*) FROM orders WHERE is_returned;")
+	if err != nil {
+		return nil, 0, err
+	}
+	slice := make([]Order, 0)
+	err = s.db.Select(ctx, &slice,
+		"SELECT "+orderColumns+" FROM orders WHERE is_returned ORDER BY return_date DESC, id LIMIT $1 OFFSET $2;",
+		count, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return slice, total, nil
+}
+
+type pointOccupancy struct {
+	PickUpPointId uint64 `db:"pickup_point_id"`
+	Occupancy
+}
+
+// Occupancy returns the space taken by orders stored at each pick-up point.
+// When pickUpPointIds are provided, only those points are counted.
+func (s *PostgresRepository) Occupancy(ctx context.Context, pickUpPointIds ...uint64) (map[uint64]Occupancy, error) {
+	query := "SELECT pickup_point_id, count(*) AS orders, coalesce(sum(weight_kg), 0) AS weight_kg FROM orders WHERE pickup_point_id <> 0 AND (NOT is_given OR is_returned)"
+	var args []interface{}
+	if len(pickUpPointIds) > 0 {
+		query += " AND pickup_point_id = ANY($1)"
+		args = append(args, pickUpPointIds)
+	}
+	var rows []pointOccupancy
+	err := s.db.Select(ctx, &rows, query+" GROUP BY pickup_point_id;", args...)
+	if err != nil {
+		return nil, err
+	}
+	occupancy := make(map[uint64]Occupancy, len(rows))
+	for _, row := range rows {
+		occupancy[row.PickUpPointId] = row.Occupancy
+	}
+	return occupancy, nil
+}
+
+// Get returns the order represented by id.
+func (s *PostgresRepository) Get(ctx context.Context, id uint64) (Order, error) {
+	var order Order
+	err := s.db.Get(ctx, &order, "SELECT "+orderColumns+" FROM orders WHERE id = $1;", id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return order, ErrNoItemFound
+	}
+	return order, err
+}
+
+// Update sets the parameters of an order to those provided.
+func (s *PostgresRepository) Update(ctx context.Context, order Order) error {
+	tag, err := s.db.Exec(ctx,
+		"UPDATE orders SET customer_id = $2, price = $3, weight_kg = $4, add_date = $5, keep_date = $6, is_given = $7, give_date = $8, is_returned = $9, return_date = $10, pickup_point_id = $11 WHERE id = $1;",
+		order.Id, order.CustomerId, order.PriceRub, order.WeightKg, order.AddDate, order.KeepDate,
+		order.IsGiven, order.GiveDate, order.IsReturned, order.ReturnDate, order.PickUpPointId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoItemFound
+	}
+	return nil
+}
+
+// Delete deletes an order.
+func (s *PostgresRepository) Delete(ctx context.Context, id uint64) error {
+	tag, err := s.db.Exec(ctx, "DELETE FROM orders WHERE id = $1;", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoItemFound
+	}
+	return nil
+}
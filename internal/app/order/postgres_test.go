@@ -0,0 +1,282 @@
+package order
+
+import (
+	"context"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/db/mocks"
+	"testing"
+)
+
+type PostgresRepositoryTestSuite struct {
+	suite.Suite
+}
+
+func (s *PostgresRepositoryTestSuite) Test_Create() {
+	ctx := context.Background()
+	o := sampleOrders()[3]
+	tests := []struct {
+		name    string
+		dbErr   error
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:    "existing id",
+			dbErr:   &pgconn.PgError{ConstraintName: "orders_pkey"},
+			wantErr: true,
+			err:     ErrIdAlreadyExists,
+		},
+		{
+			name:    "error",
+			dbErr:   assert.AnError,
+			wantErr: true,
+			err:     assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresRepository(db)
+			db.EXPECT().
+				Exec(gomock.Any(),
+					"INSERT INTO orders (id, customer_id, price, weight_kg, add_date, keep_date, is_given, give_date, is_returned, return_date, pickup_point_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);",
+					o.Id, o.CustomerId, o.PriceRub, o.WeightKg, o.AddDate, o.KeepDate, o.IsGiven, o.GiveDate, o.IsReturned, o.ReturnDate, o.PickUpPointId).
+				Return(nil, tt.dbErr)
+			err := repo.Create(ctx, o)
+			if tt.wantErr {
+				s.Error(err)
+				s.ErrorIs(err, tt.err)
+			} else {
+				s.NoError(err)
+			}
+		})
+	}
+}
+
+func (s *PostgresRepositoryTestSuite) Test_CreateAdmitted() {
+	ctx := context.Background()
+	o := sampleOrders()[4]
+	tests := []struct {
+		name     string
+		admitErr error
+	}{
+		{
+			name: "admitted",
+		},
+		{
+			name:     "rejected",
+			admitErr: assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresRepository(db)
+			db.EXPECT().
+				Select(gomock.Any(), gomock.Any(),
+					"SELECT pickup_point_id, count(*) AS orders, coalesce(sum(weight_kg), 0) AS weight_kg FROM orders WHERE pickup_point_id <> 0 AND (NOT is_given OR is_returned) AND pickup_point_id = ANY($1) GROUP BY pickup_point_id;",
+					[]uint64{2}).
+				DoAndReturn(func(ctx context.Context, dest *[]pointOccupancy, query string, args ...interface{}) error {
+					*dest = []pointOccupancy{{PickUpPointId: 2, Occupancy: Occupancy{Orders: 1, WeightKg: 5}}}
+					return nil
+				})
+			if tt.admitErr == nil {
+				db.EXPECT().
+					Exec(gomock.Any(), gomock.Any(),
+						o.Id, o.CustomerId, o.PriceRub, o.WeightKg, o.AddDate, o.KeepDate, o.IsGiven, o.GiveDate, o.IsReturned, o.ReturnDate, o.PickUpPointId).
+					Return(nil, nil)
+			}
+			var taken Occupancy
+			err := repo.CreateAdmitted(ctx, o, func(t Occupancy) error {
+				taken = t
+				return tt.admitErr
+			})
+			s.Equal(Occupancy{Orders: 1, WeightKg: 5}, taken)
+			s.ErrorIs(err, tt.admitErr)
+		})
+	}
+}
+
+func (s *PostgresRepositoryTestSuite) Test_ListByCustomer() {
+	ctx := context.Background()
+	ctrl := gomock.NewController(s.T())
+	db := mocks.NewMockDatabase(ctrl)
+	repo := NewPostgresRepository(db)
+	all := sampleOrders()
+	db.EXPECT().
+		Select(gomock.Any(), gomock.Any(),
+			"SELECT id, customer_id, price, weight_kg, add_date, keep_date, is_given, give_date, is_returned, return_date, pickup_point_id FROM orders WHERE customer_id = $1 ORDER BY add_date DESC, id;",
+			uint64(10)).
+		DoAndReturn(func(ctx context.Context, dest *[]Order, query string, args ...interface{}) error {
+			*dest = []Order{all[3], all[2], all[1]}
+			return nil
+		})
+	orders, err := repo.ListByCustomer(ctx, 10, StatusStored, StatusReturned)
+	s.NoError(err)
+	s.Equal([]uint64{3, 1}, ids(orders))
+}
+
+func (s *PostgresRepositoryTestSuite) Test_Occupancy() {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		ids     []uint64
+		query   string
+		args    []interface{}
+		dbErr   error
+		want    map[uint64]Occupancy
+		wantErr bool
+	}{
+		{
+			name:  "all points",
+			query: "SELECT pickup_point_id, count(*) AS orders, coalesce(sum(weight_kg), 0) AS weight_kg FROM orders WHERE pickup_point_id <> 0 AND (NOT is_given OR is_returned) GROUP BY pickup_point_id;",
+			want:  map[uint64]Occupancy{1: {Orders: 2, WeightKg: 6}, 2: {Orders: 1, WeightKg: 5}},
+		},
+		{
+			name:  "selected points",
+			ids:   []uint64{1, 2},
+			query: "SELECT pickup_point_id, count(*) AS orders, coalesce(sum(weight_kg), 0) AS weight_kg FROM orders WHERE pickup_point_id <> 0 AND (NOT is_given OR is_returned) AND pickup_point_id = ANY($1) GROUP BY pickup_point_id;",
+			args:  []interface{}{[]uint64{1, 2}},
+			want:  map[uint64]Occupancy{1: {Orders: 2, WeightKg: 6}, 2: {Orders: 1, WeightKg: 5}},
+		},
+		{
+			name:    "error",
+			query:   "SELECT pickup_point_id, count(*) AS orders, coalesce(sum(weight_kg), 0) AS weight_kg FROM orders WHERE pickup_point_id <> 0 AND (NOT is_given OR is_returned) GROUP BY pickup_point_id;",
+			dbErr:   assert.AnError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresRepository(db)
+			db.EXPECT().
+				Select(gomock.Any(), gomock.Any(), tt.query, tt.args...).
+				DoAndReturn(func(ctx context.Context, dest *[]pointOccupancy, query string, args ...interface{}) error {
+					*dest = []pointOccupancy{
+						{PickUpPointId: 1, Occupancy: Occupancy{Orders: 2, WeightKg: 6}},
+						{PickUpPointId: 2, Occupancy: Occupancy{Orders: 1, WeightKg: 5}},
+					}
+					return tt.dbErr
+				})
+			occupancy, err := repo.Occupancy(ctx, tt.ids...)
+			if tt.wantErr {
+				s.ErrorIs(err, assert.AnError)
+			} else {
+				s.NoError(err)
+				s.Equal(tt.want, occupancy)
+			}
+		})
+	}
+}
+
+func (s *PostgresRepositoryTestSuite) Test_Get() {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		dbErr   error
+		want    Order
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "ok",
+			want: sampleOrders()[1],
+		},
+		{
+			name:    "not found",
+			dbErr:   pgx.ErrNoRows,
+			wantErr: true,
+			err:     ErrNoItemFound,
+		},
+		{
+			name:    "error",
+			dbErr:   assert.AnError,
+			wantErr: true,
+			err:     assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresRepository(db)
+			db.EXPECT().
+				Get(gomock.Any(), gomock.Any(),
+					"SELECT id, customer_id, price, weight_kg, add_date, keep_date, is_given, give_date, is_returned, return_date, pickup_point_id FROM orders WHERE id = $1;",
+					uint64(1)).
+				DoAndReturn(func(ctx context.Context, dest *Order, query string, args ...interface{}) error {
+					*dest = tt.want
+					return tt.dbErr
+				})
+			o, err := repo.Get(ctx, 1)
+			if tt.wantErr {
+				s.Error(err)
+				s.ErrorIs(err, tt.err)
+			} else {
+				s.NoError(err)
+				s.Equal(tt.want, o)
+			}
+		})
+	}
+}
+
+func (s *PostgresRepositoryTestSuite) Test_Delete() {
+	ctx := context.Background()
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		dbErr        error
+		wantErr      bool
+		err          error
+	}{
+		{
+			name:         "ok",
+			rowsAffected: 1,
+		},
+		{
+			name:    "not found",
+			wantErr: true,
+			err:     ErrNoItemFound,
+		},
+		{
+			name:    "error",
+			dbErr:   assert.AnError,
+			wantErr: true,
+			err:     assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresRepository(db)
+			tag := mocks.NewMockCommandTag(ctrl)
+			tag.EXPECT().RowsAffected().AnyTimes().Return(tt.rowsAffected)
+			db.EXPECT().
+				Exec(gomock.Any(), "DELETE FROM orders WHERE id = $1;", uint64(1)).
+				Return(tag, tt.dbErr)
+			err := repo.Delete(ctx, 1)
+			if tt.wantErr {
+				s.Error(err)
+				s.ErrorIs(err, tt.err)
+			} else {
+				s.NoError(err)
+			}
+		})
+	}
+}
+
+func TestPostgresRepository(t *testing.T) {
+	suite.Run(t, new(PostgresRepositoryTestSuite))
+}
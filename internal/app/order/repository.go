@@ -1,17 +1,25 @@
 package order
 
 import (
+	"context"
 	"encoding/json"
+	"go.uber.org/multierr"
+	"homework/internal/app/journal"
 	"io"
+	"os"
+	"slices"
+	"sync"
 )
 
 // FileRepository provides an order Repository with a JSON file as a backend.
 type FileRepository struct {
 	orders  map[uint64]Order
-	changed bool
+	indexes *indexes
+	journal *journal.Journal[Order]
+	mutex   sync.RWMutex
 }
 
-// NewFileRepository returns a new FileRepository with file stored in the provided path.
+// NewFileRepository returns a new in-memory FileRepository filled with orders read from r.
 func NewFileRepository(r io.Reader) (*FileRepository, error) {
 	bytes, err := io.ReadAll(r)
 	if err != nil {
@@ -26,72 +34,304 @@ func NewFileRepository(r io.Reader) (*FileRepository, error) {
 			return nil, err
 		}
 	}
-	return &FileRepository{orders: orders}, nil
+	return newFileRepository(orders), nil
 }
 
-// Close saves cached orders information into file when needed.
-func (s *FileRepository) Close(w io.Writer) error {
-	if !s.changed {
+// OpenFileRepository returns a new FileRepository persisted to the file stored in the provided path.
+func OpenFileRepository(path string, perm os.FileMode) (*FileRepository, error) {
+	j, orders, err := journal.Open[Order](path, perm)
+	if err != nil {
+		return nil, err
+	}
+	repo := newFileRepository(orders)
+	repo.journal = j
+	return repo, nil
+}
+
+func newFileRepository(orders map[uint64]Order) *FileRepository {
+	repo := &FileRepository{orders: orders}
+	repo.rebuildIndexes()
+	return repo
+}
+
+// Close writes a snapshot of orders into file when needed and releases it.
+func (s *FileRepository) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
 		return nil
 	}
+	return s.journal.Close(s.orders)
+}
+
+// Export writes all orders into w as JSON.
+func (s *FileRepository) Export(w io.Writer) error {
+	s.mutex.RLock()
 	bytes, err := json.Marshal(s.orders)
+	s.mutex.RUnlock()
 	if err != nil {
 		return err
 	}
 	_, err = w.Write(bytes)
+	return err
+}
+
+func (s *FileRepository) logPut(order Order) error {
+	if s.journal == nil {
+		return nil
+	}
+	err := s.compact()
 	if err != nil {
 		return err
 	}
-	s.changed = false
-	return nil
+	return s.journal.Put(order.Id, order)
+}
+
+func (s *FileRepository) logDelete(id uint64) error {
+	if s.journal == nil {
+		return nil
+	}
+	err := s.compact()
+	if err != nil {
+		return err
+	}
+	return s.journal.Delete(id)
+}
+
+// sync runs f with orders brought up to date with changes made by other processes,
+// keeping them from making new changes until f returns.
+// The ids of orders changed by other processes are passed to f.
+func (s *FileRepository) sync(f func(changed map[uint64]struct{}) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
+		return f(nil)
+	}
+	changed, err := s.journal.Lock(s.orders)
+	if err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		s.rebuildIndexes()
+	}
+	err = f(changed)
+	return multierr.Combine(err, s.journal.Unlock())
+}
+
+func (s *FileRepository) rebuildIndexes() {
+	s.indexes = newIndexes()
+	for _, order := range s.orders {
+		s.indexes.add(order)
+	}
+}
+
+func (s *FileRepository) compact() error {
+	if !s.journal.NeedsCompaction() {
+		return nil
+	}
+	return s.journal.Compact(s.orders)
 }
 
 // Create creates a new order.
-func (s *FileRepository) Create(order Order) error {
+func (s *FileRepository) Create(ctx context.Context, order Order) error {
+	return s.sync(func(changed map[uint64]struct{}) error {
+		return s.create(order)
+	})
+}
+
+// CreateAdmitted creates a new order unless admit rejects the space already taken at its pick-up point.
+// No other order is added or changed between the check and the creation.
+func (s *FileRepository) CreateAdmitted(ctx context.Context, order Order, admit func(taken Occupancy) error) error {
+	return s.sync(func(changed map[uint64]struct{}) error {
+		err := admit(s.occupancy(order.PickUpPointId)[order.PickUpPointId])
+		if err != nil {
+			return err
+		}
+		return s.create(order)
+	})
+}
+
+func (s *FileRepository) create(order Order) error {
 	_, exists := s.orders[order.Id]
 	if exists {
 		return ErrIdAlreadyExists
 	}
+	err := s.logPut(order)
+	if err != nil {
+		return err
+	}
 	s.orders[order.Id] = order
-	s.changed = true
+	s.indexes.add(order)
 	return nil
 }
 
 // List returns a slice of all orders stored.
-func (s *FileRepository) List() []Order {
+func (s *FileRepository) List(ctx context.Context) ([]Order, error) {
 	slice := make([]Order, 0)
-	for _, order := range s.orders {
-		slice = append(slice, order)
+	err := s.sync(func(changed map[uint64]struct{}) error {
+		for _, order := range s.orders {
+			slice = append(slice, order)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return slice
+	return slice, nil
 }
 
-// Get returns the order represented by id.
-func (s *FileRepository) Get(id uint64) (Order, error) {
-	if order, found := s.orders[id]; found {
-		return order, nil
+// ListByCustomer returns orders of the customer, most recently added first.
+// When statuses are provided, only orders in one of them are returned.
+func (s *FileRepository) ListByCustomer(ctx context.Context, customerId uint64, statuses ...Status) ([]Order, error) {
+	slice := make([]Order, 0)
+	err := s.sync(func(changed map[uint64]struct{}) error {
+		idx, ok := s.indexes.byCustomer[customerId]
+		if !ok {
+			return nil
+		}
+		for _, e := range *idx {
+			if len(statuses) > 0 && !s.hasStatus(e.id, statuses) {
+				continue
+			}
+			slice = append(slice, s.orders[e.id])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return Order{}, ErrNoItemFound
+	return slice, nil
 }
 
-// Update sets the parameters of an order to those provided.
-func (s *FileRepository) Update(order Order) error {
-	_, found := s.orders[order.Id]
-	if !found {
-		return ErrNoItemFound
+func (s *FileRepository) hasStatus(id uint64, statuses []Status) bool {
+	for _, status := range statuses {
+		if _, ok := s.indexes.byStatus[status][id]; ok {
+			return true
+		}
 	}
-	s.orders[order.Id] = order
-	s.changed = true
-	return nil
+	return false
 }
 
-// Delete deletes an order.
-func (s *FileRepository) Delete(id uint64) error {
-	_, found := s.orders[id]
-	if !found {
-		return ErrNoItemFound
+// ListByStatus returns all orders with the provided status.
+func (s *FileRepository) ListByStatus(status Status) ([]Order, error) {
+	var slice []Order
+	err := s.sync(func(changed map[uint64]struct{}) error {
+		ids := s.indexes.byStatus[status]
+		slice = make([]Order, 0, len(ids))
+		for id := range ids {
+			slice = append(slice, s.orders[id])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	delete(s.orders, id)
-	s.changed = true
-	return nil
+	return slice, nil
+}
+
+// ListReturned returns up to count returned orders starting from offset,
+// most recently returned first, along with the total number of returned orders.
+func (s *FileRepository) ListReturned(ctx context.Context, offset int, count int) ([]Order, int, error) {
+	slice := make([]Order, 0)
+	var total int
+	err := s.sync(func(changed map[uint64]struct{}) error {
+		total = len(s.indexes.byReturn)
+		if offset >= total {
+			return nil
+		}
+		end := min(offset+count, total)
+		for _, e := range s.indexes.byReturn[offset:end] {
+			slice = append(slice, s.orders[e.id])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return slice, total, nil
+}
+
+// Occupancy returns the space taken by orders stored at each pick-up point.
+// When pickUpPointIds are provided, only those points are counted.
+func (s *FileRepository) Occupancy(ctx context.Context, pickUpPointIds ...uint64) (map[uint64]Occupancy, error) {
+	var occupancy map[uint64]Occupancy
+	err := s.sync(func(changed map[uint64]struct{}) error {
+		occupancy = s.occupancy(pickUpPointIds...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return occupancy, nil
+}
+
+func (s *FileRepository) occupancy(pickUpPointIds ...uint64) map[uint64]Occupancy {
+	occupancy := make(map[uint64]Occupancy)
+	for _, status := range []Status{StatusStored, StatusReturned} {
+		for id := range s.indexes.byStatus[status] {
+			order := s.orders[id]
+			if !order.occupies() || len(pickUpPointIds) > 0 && !slices.Contains(pickUpPointIds, order.PickUpPointId) {
+				continue
+			}
+			occupancy[order.PickUpPointId] = occupancy[order.PickUpPointId].add(order)
+		}
+	}
+	return occupancy
+}
+
+// Get returns the order represented by id.
+func (s *FileRepository) Get(ctx context.Context, id uint64) (Order, error) {
+	var order Order
+	err := s.sync(func(changed map[uint64]struct{}) error {
+		var found bool
+		order, found = s.orders[id]
+		if !found {
+			return ErrNoItemFound
+		}
+		return nil
+	})
+	return order, err
+}
+
+// Update sets the parameters of an order to those provided.
+// It fails with ErrChanged when the order was changed by another process since it was read.
+func (s *FileRepository) Update(ctx context.Context, order Order) error {
+	return s.sync(func(changed map[uint64]struct{}) error {
+		old, found := s.orders[order.Id]
+		if !found {
+			return ErrNoItemFound
+		}
+		if _, ok := changed[order.Id]; ok {
+			return ErrChanged
+		}
+		err := s.logPut(order)
+		if err != nil {
+			return err
+		}
+		s.indexes.remove(old)
+		s.orders[order.Id] = order
+		s.indexes.add(order)
+		return nil
+	})
+}
+
+// Delete deletes an order.
+// It fails with ErrChanged when the order was changed by another process since it was read.
+func (s *FileRepository) Delete(ctx context.Context, id uint64) error {
+	return s.sync(func(changed map[uint64]struct{}) error {
+		order, found := s.orders[id]
+		if !found {
+			return ErrNoItemFound
+		}
+		if _, ok := changed[id]; ok {
+			return ErrChanged
+		}
+		err := s.logDelete(id)
+		if err != nil {
+			return err
+		}
+		s.indexes.remove(order)
+		delete(s.orders, id)
+		return nil
+	})
 }
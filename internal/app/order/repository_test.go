@@ -0,0 +1,318 @@
+package order
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var baseDate = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func sampleOrders() map[uint64]Order {
+	return map[uint64]Order{
+		1: {Id: 1, CustomerId: 10, PickUpPointId: 1, WeightKg: 2, AddDate: baseDate},
+		2: {Id: 2, CustomerId: 10, PickUpPointId: 1, WeightKg: 3, AddDate: baseDate.Add(time.Hour), IsGiven: true, GiveDate: baseDate.Add(2 * time.Hour)},
+		3: {Id: 3, CustomerId: 10, PickUpPointId: 1, WeightKg: 4, AddDate: baseDate.Add(2 * time.Hour), IsGiven: true, GiveDate: baseDate.Add(3 * time.Hour), IsReturned: true, ReturnDate: baseDate.Add(4 * time.Hour)},
+		4: {Id: 4, CustomerId: 20, PickUpPointId: 2, WeightKg: 5, AddDate: baseDate.Add(3 * time.Hour), IsGiven: true, GiveDate: baseDate.Add(3 * time.Hour), IsReturned: true, ReturnDate: baseDate.Add(5 * time.Hour)},
+	}
+}
+
+func ids(orders []Order) []uint64 {
+	res := make([]uint64, len(orders))
+	for i, o := range orders {
+		res[i] = o.Id
+	}
+	return res
+}
+
+type FileRepositoryTestSuite struct {
+	suite.Suite
+}
+
+func (s *FileRepositoryTestSuite) Test_ListByCustomer() {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		customerId uint64
+		statuses   []Status
+		want       []uint64
+	}{
+		{
+			name:       "all",
+			customerId: 10,
+			want:       []uint64{3, 2, 1},
+		},
+		{
+			name:       "stored and returned, given excluded",
+			customerId: 10,
+			statuses:   []Status{StatusStored, StatusReturned},
+			want:       []uint64{3, 1},
+		},
+		{
+			name:       "unknown customer",
+			customerId: 30,
+			want:       []uint64{},
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			repo := newFileRepository(sampleOrders())
+			orders, err := repo.ListByCustomer(ctx, tt.customerId, tt.statuses...)
+			s.NoError(err)
+			s.Equal(tt.want, ids(orders))
+		})
+	}
+}
+
+func (s *FileRepositoryTestSuite) Test_ListReturned() {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		offset    int
+		count     int
+		want      []uint64
+		wantTotal int
+	}{
+		{
+			name:      "first page",
+			offset:    0,
+			count:     1,
+			want:      []uint64{4},
+			wantTotal: 2,
+		},
+		{
+			name:      "whole list",
+			offset:    0,
+			count:     10,
+			want:      []uint64{4, 3},
+			wantTotal: 2,
+		},
+		{
+			name:      "out of range",
+			offset:    2,
+			count:     1,
+			want:      []uint64{},
+			wantTotal: 2,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			repo := newFileRepository(sampleOrders())
+			orders, total, err := repo.ListReturned(ctx, tt.offset, tt.count)
+			s.NoError(err)
+			s.Equal(tt.want, ids(orders))
+			s.Equal(tt.wantTotal, total)
+		})
+	}
+}
+
+func (s *FileRepositoryTestSuite) Test_Occupancy() {
+	ctx := context.Background()
+	repo := newFileRepository(sampleOrders())
+	occupancy, err := repo.Occupancy(ctx)
+	s.NoError(err)
+	s.Equal(map[uint64]Occupancy{1: {Orders: 2, WeightKg: 6}, 2: {Orders: 1, WeightKg: 5}}, occupancy)
+
+	s.NoError(repo.Create(ctx, Order{Id: 5, CustomerId: 20, WeightKg: 1, AddDate: baseDate}))
+	occupancy, err = repo.Occupancy(ctx, 2)
+	s.NoError(err)
+	s.Equal(map[uint64]Occupancy{2: {Orders: 1, WeightKg: 5}}, occupancy)
+}
+
+func (s *FileRepositoryTestSuite) Test_IndexesFollowChanges() {
+	ctx := context.Background()
+	repo := newFileRepository(sampleOrders())
+
+	s.NoError(repo.Create(ctx, Order{Id: 5, CustomerId: 20, AddDate: baseDate.Add(10 * time.Hour)}))
+	orders, err := repo.ListByCustomer(ctx, 20)
+	s.NoError(err)
+	s.Equal([]uint64{5, 4}, ids(orders))
+
+	o, err := repo.Get(ctx, 2)
+	s.NoError(err)
+	o.IsReturned = true
+	o.ReturnDate = baseDate.Add(6 * time.Hour)
+	s.NoError(repo.Update(ctx, o))
+	returned, total, err := repo.ListReturned(ctx, 0, 10)
+	s.NoError(err)
+	s.Equal([]uint64{2, 4, 3}, ids(returned))
+	s.Equal(3, total)
+	given, err := repo.ListByStatus(StatusGiven)
+	s.NoError(err)
+	s.Empty(given)
+
+	s.NoError(repo.Delete(ctx, 3))
+	returned, total, err = repo.ListReturned(ctx, 0, 10)
+	s.NoError(err)
+	s.Equal([]uint64{2, 4}, ids(returned))
+	s.Equal(2, total)
+	orders, err = repo.ListByCustomer(ctx, 10)
+	s.NoError(err)
+	s.Equal([]uint64{2, 1}, ids(orders))
+
+	s.ErrorIs(repo.Delete(ctx, 3), ErrNoItemFound)
+	s.ErrorIs(repo.Create(ctx, Order{Id: 1}), ErrIdAlreadyExists)
+}
+
+func (s *FileRepositoryTestSuite) Test_ConcurrentProcesses() {
+	ctx := context.Background()
+	path := filepath.Join(s.T().TempDir(), "orders.json")
+	first, err := OpenFileRepository(path, 0600)
+	s.Require().NoError(err)
+	second, err := OpenFileRepository(path, 0600)
+	s.Require().NoError(err)
+
+	s.NoError(first.Create(ctx, Order{Id: 1, CustomerId: 10, AddDate: baseDate}))
+	s.ErrorIs(second.Create(ctx, Order{Id: 1, CustomerId: 20, AddDate: baseDate}), ErrIdAlreadyExists)
+
+	stale, err := first.Get(ctx, 1)
+	s.NoError(err)
+	o, err := second.Get(ctx, 1)
+	s.NoError(err)
+	o.IsGiven = true
+	s.NoError(second.Update(ctx, o))
+
+	stale.PriceRub = 100
+	s.ErrorIs(first.Update(ctx, stale), ErrChanged)
+	fresh, err := first.Get(ctx, 1)
+	s.NoError(err)
+	s.True(fresh.IsGiven)
+	fresh.PriceRub = 100
+	s.NoError(first.Update(ctx, fresh))
+	orders, err := first.ListByCustomer(ctx, 10, StatusGiven)
+	s.NoError(err)
+	s.Equal([]uint64{1}, ids(orders))
+
+	o.Id, o.CustomerId = 2, 20
+	s.NoError(second.Create(ctx, o))
+	orders, err = first.ListByCustomer(ctx, 20)
+	s.NoError(err)
+	s.Equal([]uint64{2}, ids(orders), "lists see orders created by other processes")
+	all, err := first.List(ctx)
+	s.NoError(err)
+	s.Len(all, 2)
+	given, err := first.ListByStatus(StatusGiven)
+	s.NoError(err)
+	s.Len(given, 2)
+
+	s.NoError(first.Close())
+	s.NoError(second.Close())
+
+	repo, err := OpenFileRepository(path, 0600)
+	s.Require().NoError(err)
+	o, err = repo.Get(ctx, 1)
+	s.NoError(err)
+	s.Equal(fresh, o)
+	s.NoError(repo.Close())
+}
+
+func TestFileRepository(t *testing.T) {
+	suite.Run(t, new(FileRepositoryTestSuite))
+}
+
+const (
+	benchOrders    = 50000
+	benchCustomers = 5000
+)
+
+func benchRepository() *FileRepository {
+	orders := make(map[uint64]Order, benchOrders)
+	for i := uint64(1); i <= benchOrders; i++ {
+		o := Order{
+			Id:         i,
+			CustomerId: i%benchCustomers + 1,
+			AddDate:    baseDate.Add(time.Duration(i) * time.Minute),
+		}
+		if i%3 == 0 {
+			o.IsGiven = true
+			o.GiveDate = o.AddDate.Add(time.Hour)
+		}
+		if i%6 == 0 {
+			o.IsReturned = true
+			o.ReturnDate = o.GiveDate.Add(time.Hour)
+		}
+		orders[i] = o
+	}
+	return newFileRepository(orders)
+}
+
+// scanByCustomer reproduces listing via a full scan and sort.
+func scanByCustomer(repo *FileRepository, customerId uint64) []Order {
+	ctx := context.Background()
+	orders := make([]Order, 0)
+	all, _ := repo.List(ctx)
+	for _, o := range all {
+		if o.CustomerId == customerId {
+			orders = append(orders, o)
+		}
+	}
+	slices.SortFunc(orders, func(a, b Order) int {
+		return b.AddDate.Compare(a.AddDate)
+	})
+	return orders
+}
+
+// scanReturned reproduces listing of returns via a full scan and sort.
+func scanReturned(repo *FileRepository, offset int, count int) []Order {
+	ctx := context.Background()
+	orders := make([]Order, 0)
+	all, _ := repo.List(ctx)
+	for _, o := range all {
+		if o.IsReturned {
+			orders = append(orders, o)
+		}
+	}
+	slices.SortFunc(orders, func(a, b Order) int {
+		return b.ReturnDate.Compare(a.ReturnDate)
+	})
+	return orders[offset : offset+count]
+}
+
+func BenchmarkListByCustomer(b *testing.B) {
+	ctx := context.Background()
+	repo := benchRepository()
+	b.ResetTimer()
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			repo.ListByCustomer(ctx, uint64(i%benchCustomers+1))
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scanByCustomer(repo, uint64(i%benchCustomers+1))
+		}
+	})
+}
+
+func BenchmarkListReturned(b *testing.B) {
+	ctx := context.Background()
+	repo := benchRepository()
+	b.ResetTimer()
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			repo.ListReturned(ctx, 100, 20)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scanReturned(repo, 100, 20)
+		}
+	})
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	ctx := context.Background()
+	repo := benchRepository()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := uint64(i%benchOrders + 1)
+		o := repo.orders[id]
+		o.PriceRub++
+		if err := repo.Update(ctx, o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,109 +1,122 @@
 package order
 
 import (
+	"context"
 	"errors"
-	"slices"
 	"time"
 )
 
 type Repository interface {
-	Create(order Order) error
-	List() []Order
-	Get(id uint64) (Order, error)
-	Update(order Order) error
-	Delete(id uint64) error
+	Create(ctx context.Context, order Order) error
+	CreateAdmitted(ctx context.Context, order Order, admit func(taken Occupancy) error) error
+	List(ctx context.Context) ([]Order, error)
+	ListByCustomer(ctx context.Context, customerId uint64, statuses ...Status) ([]Order, error)
+	ListReturned(ctx context.Context, offset int, count int) ([]Order, int, error)
+	Get(ctx context.Context, id uint64) (Order, error)
+	Update(ctx context.Context, order Order) error
+	Delete(ctx context.Context, id uint64) error
+	Occupancy(ctx context.Context, pickUpPointIds ...uint64) (map[uint64]Occupancy, error)
+}
+
+type TransactionManager interface {
+	RunSerializable(ctx context.Context, f func(ctxTX context.Context) error) error
 }
 
 var ErrIdAlreadyExists = errors.New("item with such id already exists")
 var ErrNoItemFound = errors.New("no such item found")
+var ErrChanged = errors.New("item was changed by another process, try again")
 
 // Service provides methods to work with orders.
 type Service struct {
 	repo Repository
+	tm   TransactionManager
 }
 
 // NewService creates a new Service.
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository, tm TransactionManager) *Service {
+	return &Service{repo: repo, tm: tm}
 }
 
 // AddOrder creates a new order with provided orderId, customerId and keepDate.
-func (s *Service) AddOrder(o Order) error {
-	return s.repo.Create(o)
+func (s *Service) AddOrder(ctx context.Context, o Order) error {
+	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		return s.repo.Create(ctxTX, o)
+	})
 }
 
-// RemoveOrder removes order associated with provided orderId.
-func (s *Service) RemoveOrder(id uint64) error {
-	order, err := s.repo.Get(id)
-	if err != nil {
-		return err
-	}
-	if order.IsGiven && !order.IsReturned {
-		return errors.New("order has already been given to customer")
-	}
-	if order.KeepDate.After(time.Now()) {
-		return errors.New("keep date has not arrived yet")
-	}
-	err = s.repo.Delete(id)
-	return err
+// AddOrderAdmitted creates a new order unless admit rejects the space already taken at its pick-up point,
+// no other order can take that space before the new one is created.
+func (s *Service) AddOrderAdmitted(ctx context.Context, o Order, admit func(taken Occupancy) error) error {
+	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		return s.repo.CreateAdmitted(ctxTX, o, admit)
+	})
 }
 
-// GiveOrders marks orders represented by provided ids as given to customer.
-func (s *Service) GiveOrders(ids []uint64) error {
-	orders := make([]Order, len(ids))
-	now := time.Now()
-	var customerId uint64
-	for i, id := range ids {
-		order, err := s.repo.Get(id)
+// RemoveOrder removes order associated with provided orderId.
+func (s *Service) RemoveOrder(ctx context.Context, id uint64) error {
+	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		order, err := s.repo.Get(ctxTX, id)
 		if err != nil {
 			return err
 		}
-		if order.IsGiven {
-			return errors.New("order has already been given")
+		if order.IsGiven && !order.IsReturned {
+			return errors.New("order has already been given to customer")
 		}
-		if order.KeepDate.Before(now) {
-			return errors.New("keep date has already expired")
+		if order.KeepDate.After(time.Now()) {
+			return errors.New("keep date has not arrived yet")
 		}
-		if i == 0 {
-			customerId = order.CustomerId
-		} else if order.CustomerId != customerId {
-			return errors.New("orders belong to different customers")
+		return s.repo.Delete(ctxTX, id)
+	})
+}
+
+// GiveOrders marks orders represented by provided ids as given to customer.
+func (s *Service) GiveOrders(ctx context.Context, ids []uint64) error {
+	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		orders := make([]Order, len(ids))
+		now := time.Now()
+		var customerId uint64
+		for i, id := range ids {
+			order, err := s.repo.Get(ctxTX, id)
+			if err != nil {
+				return err
+			}
+			if order.IsGiven {
+				return errors.New("order has already been given")
+			}
+			if order.KeepDate.Before(now) {
+				return errors.New("keep date has already expired")
+			}
+			if i == 0 {
+				customerId = order.CustomerId
+			} else if order.CustomerId != customerId {
+				return errors.New("orders belong to different customers")
+			}
+			orders[i] = order
 		}
-		orders[i] = order
-	}
-	for _, order := range orders {
-		order.IsGiven = true
-		order.GiveDate = now
-		err := s.repo.Update(order)
-		if err != nil {
-			return err
+		for _, order := range orders {
+			order.IsGiven = true
+			order.GiveDate = now
+			err := s.repo.Update(ctxTX, order)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // GetOrders returns slice of orders belonging to customer with provided customerId.
-func (s *Service) GetOrders(customerId uint64, n int, filterGiven bool) ([]Order, error) {
-	l := s.repo.List()
-	orders := make([]Order, 0)
-	for _, order := range l {
-		if filterGiven && order.IsGiven && !order.IsReturned {
-			continue
-		}
-		if order.CustomerId != customerId {
-			continue
-		}
-		orders = append(orders, order)
+func (s *Service) GetOrders(ctx context.Context, customerId uint64, n int, filterGiven bool) ([]Order, error) {
+	var orders []Order
+	var err error
+	if filterGiven {
+		orders, err = s.repo.ListByCustomer(ctx, customerId, StatusStored, StatusReturned)
+	} else {
+		orders, err = s.repo.ListByCustomer(ctx, customerId)
+	}
+	if err != nil {
+		return nil, err
 	}
-	slices.SortFunc(orders, func(a, b Order) int {
-		if a.AddDate.Before(b.AddDate) {
-			return 1
-		}
-		if a.AddDate.After(b.AddDate) {
-			return -1
-		}
-		return 0
-	})
 	if n > 0 && n < len(orders) {
 		orders = orders[:n]
 	}
@@ -111,57 +124,49 @@ func (s *Service) GetOrders(customerId uint64, n int, filterGiven bool) ([]Order
 }
 
 // AcceptReturn marks order as returned by customer.
-func (s *Service) AcceptReturn(orderId uint64, customerId uint64) error {
-	order, err := s.repo.Get(orderId)
-	if err != nil {
-		return err
-	}
-	if order.CustomerId != customerId {
-		return errors.New("order does not belong to customer")
-	}
-	if !order.IsGiven {
-		return errors.New("order was not given")
-	}
-	if order.IsReturned {
-		return errors.New("order was already returned")
-	}
-	now := time.Now()
-	returnExpirationDate := order.GiveDate.AddDate(0, 0, 2)
-	if returnExpirationDate.Before(now) {
-		return errors.New("too much time passed since give")
-	}
-	order.IsReturned = true
-	order.ReturnDate = now
-	return s.repo.Update(order)
-}
-
-// ListReturns returns a slice of orders which were returned by customer.
-func (s *Service) GetReturns(count int, pageNum int) ([]Order, error) {
-	l := s.repo.List()
-	orders := make([]Order, 0)
-	for _, order := range l {
-		if !order.IsReturned {
-			continue
+func (s *Service) AcceptReturn(ctx context.Context, orderId uint64, customerId uint64) error {
+	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		order, err := s.repo.Get(ctxTX, orderId)
+		if err != nil {
+			return err
 		}
-		orders = append(orders, order)
-	}
-	slices.SortFunc(orders, func(a, b Order) int {
-		if a.ReturnDate.Before(b.ReturnDate) {
-			return 1
+		if order.CustomerId != customerId {
+			return errors.New("order does not belong to customer")
+		}
+		if !order.IsGiven {
+			return errors.New("order was not given")
+		}
+		if order.IsReturned {
+			return errors.New("order was already returned")
 		}
-		if a.ReturnDate.After(b.ReturnDate) {
-			return -1
+		now := time.Now()
+		returnExpirationDate := order.GiveDate.AddDate(0, 0, 2)
+		if returnExpirationDate.Before(now) {
+			return errors.New("too much time passed since give")
 		}
-		return 0
+		order.IsReturned = true
+		order.ReturnDate = now
+		return s.repo.Update(ctxTX, order)
 	})
-	if len(orders) == 0 && pageNum == 0 {
+}
+
+// GetOccupancy returns the space taken by orders stored at each pick-up point.
+// When pickUpPointIds are provided, only those points are counted.
+func (s *Service) GetOccupancy(ctx context.Context, pickUpPointIds ...uint64) (map[uint64]Occupancy, error) {
+	return s.repo.Occupancy(ctx, pickUpPointIds...)
+}
+
+// ListReturns returns a slice of orders which were returned by customer.
+func (s *Service) GetReturns(ctx context.Context, count int, pageNum int) ([]Order, error) {
+	orders, total, err := s.repo.ListReturned(ctx, pageNum*count, count)
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 && pageNum == 0 {
 		return orders, nil
 	}
-	if pageNum*count >= len(orders) {
+	if pageNum*count >= total {
 		return nil, errors.New("page number is too large")
 	}
-	if (pageNum+1)*count > len(orders) {
-		return orders[pageNum*count:], nil
-	}
-	return orders[pageNum*count : (pageNum+1)*count], nil
+	return orders, nil
 }
@@ -0,0 +1,66 @@
+package pickuppoint
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Address is the postal address of a pick-up point.
+type Address struct {
+	Country  string `json:"country"`
+	City     string `json:"city"`
+	Street   string `json:"street"`
+	Building string `json:"building"`
+	Postcode string `json:"postcode,omitempty"`
+}
+
+func (a Address) check(errs *fieldErrors) {
+	errs.check("address.country", ValidateRequired(a.Country))
+	errs.check("address.city", ValidateRequired(a.City))
+	errs.check("address.street", ValidateRequired(a.Street))
+	errs.check("address.building", ValidateRequired(a.Building))
+	if a.Postcode != "" {
+		errs.check("address.postcode", ValidatePostcode(a.Postcode))
+	}
+}
+
+// String returns the address in a single line, from the building to the country.
+func (a Address) String() string {
+	parts := make([]string, 0, 5)
+	for _, part := range []string{a.Building, a.Street, a.City, a.Postcode, a.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// UnmarshalJSON reads the address from JSON object.
+// A plain string written before addresses were structured is kept as the street.
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if json.Unmarshal(data, &legacy) == nil {
+		*a = Address{Street: legacy}
+		return nil
+	}
+	type address Address
+	return json.Unmarshal(data, (*address)(a))
+}
+
+// Value stores the address in a database as JSON.
+func (a Address) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Scan reads the address stored in a database as JSON.
+func (a *Address) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	}
+	return fmt.Errorf("cannot scan %T into an address", src)
+}
@@ -0,0 +1,230 @@
+package pickuppoint
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+var (
+	ErrInvalidBatch  = errors.New("some pick-up points of the batch are invalid, none were created")
+	ErrInvalidFormat = errors.New("format must be csv or json")
+)
+
+// Format is an encoding of a batch of pick-up points.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+// ParseFormat checks that s names a supported format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatJSON, FormatCSV:
+		return f, nil
+	}
+	return "", ErrInvalidFormat
+}
+
+// csvHeader names CSV columns, the schedule is kept as a JSON object.
+var csvHeader = []string{"id", "name", "country", "city", "street", "building", "postcode", "email", "phone", "lat", "lon", "slots", "max_weight_kg", "schedule"}
+
+// BatchRow is a pick-up point read from a batch, Err tells why the row is invalid.
+type BatchRow struct {
+	Point PickUpPoint
+	Err   error
+}
+
+// RowStatus tells what happened to a row of a batch.
+type RowStatus string
+
+const (
+	RowCreated RowStatus = "created"
+	// RowValid marks a row that would be created if it were not a dry run.
+	RowValid  RowStatus = "valid"
+	RowFailed RowStatus = "failed"
+)
+
+// RowResult reports a row of a batch, rows are numbered from 1 in the order they are read.
+type RowResult struct {
+	Row    int          `json:"row"`
+	Id     uint64       `json:"id,omitempty"`
+	Status RowStatus    `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// BatchReport lists results of every row of a batch.
+type BatchReport struct {
+	DryRun  bool        `json:"dry_run"`
+	Created int         `json:"created"`
+	Failed  int         `json:"failed"`
+	Rows    []RowResult `json:"rows"`
+}
+
+func (r *BatchReport) fail(i int, point PickUpPoint, err error) {
+	result := RowResult{Row: i + 1, Id: point.Id, Status: RowFailed, Error: err.Error()}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		result.Fields = validationErr.Fields
+	}
+	r.Rows = append(r.Rows, result)
+	r.Failed++
+}
+
+// DecodeBatch reads pick-up points encoded in the format.
+// A row that cannot be decoded is returned with an error, the whole batch fails only when it is malformed.
+func DecodeBatch(r io.Reader, format Format) ([]BatchRow, error) {
+	switch format {
+	case FormatJSON:
+		return decodeJSONBatch(r)
+	case FormatCSV:
+		return decodeCSVBatch(r)
+	}
+	return nil, ErrInvalidFormat
+}
+
+func decodeJSONBatch(r io.Reader) ([]BatchRow, error) {
+	var items []json.RawMessage
+	err := json.NewDecoder(r).Decode(&items)
+	if err != nil {
+		return nil, fmt.Errorf("batch must be a JSON array of pick-up points: %w", err)
+	}
+	rows := make([]BatchRow, len(items))
+	for i, item := range items {
+		rows[i].Err = json.Unmarshal(item, &rows[i].Point)
+	}
+	return rows, nil
+}
+
+func decodeCSVBatch(r io.Reader) ([]BatchRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(csvHeader)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	if !slices.Equal(header, csvHeader) {
+		return nil, fmt.Errorf("CSV header must be %v", csvHeader)
+	}
+	var rows []BatchRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var row BatchRow
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				return nil, err
+			}
+			row.Err = err
+		} else {
+			row.Point, row.Err = pointFromRecord(record)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func pointFromRecord(record []string) (PickUpPoint, error) {
+	var point PickUpPoint
+	var err error
+	parse := func(column string, s string, parse func(s string) error) {
+		if err == nil && s != "" {
+			err = parse(s)
+			if err != nil {
+				err = fmt.Errorf("column %s: %w", column, err)
+			}
+		}
+	}
+	parse("id", record[0], func(s string) (err error) {
+		point.Id, err = strconv.ParseUint(s, 10, 64)
+		return err
+	})
+	point.Name = record[1]
+	point.Address = Address{Country: record[2], City: record[3], Street: record[4], Building: record[5], Postcode: record[6]}
+	point.Contact = Contact{Email: record[7], Phone: record[8]}
+	if record[9] != "" || record[10] != "" {
+		point.Location = &Location{}
+		parse("lat", record[9], func(s string) (err error) {
+			point.Location.Latitude, err = strconv.ParseFloat(s, 64)
+			return err
+		})
+		parse("lon", record[10], func(s string) (err error) {
+			point.Location.Longitude, err = strconv.ParseFloat(s, 64)
+			return err
+		})
+	}
+	parse("slots", record[11], func(s string) (err error) {
+		point.Capacity.Slots, err = strconv.Atoi(s)
+		return err
+	})
+	parse("max_weight_kg", record[12], func(s string) (err error) {
+		point.Capacity.MaxWeightKg, err = strconv.ParseFloat(s, 64)
+		return err
+	})
+	parse("schedule", record[13], func(s string) error {
+		return json.Unmarshal([]byte(s), &point.Schedule)
+	})
+	return point, err
+}
+
+// EncodeBatch writes pick-up points in the format, so that they can be read back by DecodeBatch.
+func EncodeBatch(w io.Writer, format Format, points []PickUpPoint) error {
+	switch format {
+	case FormatJSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		if points == nil {
+			points = []PickUpPoint{}
+		}
+		return e.Encode(points)
+	case FormatCSV:
+		return encodeCSVBatch(w, points)
+	}
+	return ErrInvalidFormat
+}
+
+func encodeCSVBatch(w io.Writer, points []PickUpPoint) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+	for _, point := range points {
+		var lat, lon string
+		if point.Location != nil {
+			lat = strconv.FormatFloat(point.Location.Latitude, 'f', -1, 64)
+			lon = strconv.FormatFloat(point.Location.Longitude, 'f', -1, 64)
+		}
+		var slots, maxWeight string
+		if point.Capacity.Slots != 0 {
+			slots = strconv.Itoa(point.Capacity.Slots)
+		}
+		if point.Capacity.MaxWeightKg != 0 {
+			maxWeight = strconv.FormatFloat(point.Capacity.MaxWeightKg, 'f', -1, 64)
+		}
+		schedule, err := json.Marshal(point.Schedule)
+		if err != nil {
+			return err
+		}
+		a, c := point.Address, point.Contact
+		err = writer.Write([]string{
+			strconv.FormatUint(point.Id, 10), point.Name,
+			a.Country, a.City, a.Street, a.Building, a.Postcode,
+			c.Email, c.Phone, lat, lon, slots, maxWeight, string(schedule),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
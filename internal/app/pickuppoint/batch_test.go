@@ -0,0 +1,110 @@
+package pickuppoint
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func Test_DecodeBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		input   string
+		want    []PickUpPoint
+		rowErrs []bool
+		wantErr bool
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			input: "id,name,country,city,street,building,postcode,email,phone,lat,lon,slots,max_weight_kg,schedule\n" +
+				"1,Generic pick-up point,Russia,Moscow,Test st.,5,,test@example.com,,55.75,37.61,10,50.5,{}\n" +
+				",Another pick-up point,Russia,Moscow,Sample st.,19,,,+74951234567,,,,,\n",
+			want: []PickUpPoint{
+				{
+					Id:       1,
+					Name:     "Generic pick-up point",
+					Address:  Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+					Contact:  Contact{Email: "test@example.com"},
+					Location: &Location{Latitude: 55.75, Longitude: 37.61},
+					Capacity: Capacity{Slots: 10, MaxWeightKg: 50.5},
+				},
+				{
+					Name:    "Another pick-up point",
+					Address: Address{Country: "Russia", City: "Moscow", Street: "Sample st.", Building: "19"},
+					Contact: Contact{Phone: "+74951234567"},
+				},
+			},
+			rowErrs: []bool{false, false},
+		},
+		{
+			name:   "csv row errors",
+			format: FormatCSV,
+			input: "id,name,country,city,street,building,postcode,email,phone,lat,lon,slots,max_weight_kg,schedule\n" +
+				"x,Generic pick-up point,Russia,Moscow,Test st.,5,,test@example.com,,,,,,\n" +
+				"2,too few columns\n",
+			want:    []PickUpPoint{{Name: "Generic pick-up point", Address: Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"}, Contact: Contact{Email: "test@example.com"}}, {}},
+			rowErrs: []bool{true, true},
+		},
+		{
+			name:    "csv wrong header",
+			format:  FormatCSV,
+			input:   "id,name\n1,Generic pick-up point\n",
+			wantErr: true,
+		},
+		{
+			name:    "json",
+			format:  FormatJSON,
+			input:   `[{"id":1,"name":"Generic pick-up point"},{"name":5}]`,
+			want:    []PickUpPoint{{Id: 1, Name: "Generic pick-up point"}, {}},
+			rowErrs: []bool{false, true},
+		},
+		{
+			name:    "json not an array",
+			format:  FormatJSON,
+			input:   `{"id":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := DecodeBatch(strings.NewReader(tt.input), tt.format)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, rows, len(tt.want)) {
+				for i, row := range rows {
+					assert.Equal(t, tt.rowErrs[i], row.Err != nil, "row %d: %v", i+1, row.Err)
+					if row.Err == nil {
+						assert.Equal(t, tt.want[i], row.Point)
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_EncodeBatch(t *testing.T) {
+	points := append([]PickUpPoint{}, SamplePickUpPointSlice...)
+	points[0].Location = &Location{Latitude: 55.7558, Longitude: 37.6173}
+	points[0].Capacity = Capacity{Slots: 10}
+	points[1].Schedule = SampleSchedule
+	for _, format := range []Format{FormatCSV, FormatJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, EncodeBatch(&buf, format, points))
+			rows, err := DecodeBatch(&buf, format)
+			assert.NoError(t, err)
+			decoded := make([]PickUpPoint, len(rows))
+			for i, row := range rows {
+				assert.NoError(t, row.Err)
+				decoded[i] = row.Point
+			}
+			assert.Equal(t, points, decoded)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package pickuppoint
+
+import (
+	"context"
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"homework/internal/app/kvstore"
+)
+
+var pointsBucket = []byte("pickup_points")
+
+// BoltRepository provides a pick-up point Repository with an embedded key-value store as a backend.
+type BoltRepository struct {
+	db *kvstore.DB
+}
+
+// NewBoltRepository returns a new BoltRepository with provided store.
+func NewBoltRepository(db *kvstore.DB) (*BoltRepository, error) {
+	err := db.Update(context.Background(), func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(pointsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltRepository{db: db}, nil
+}
+
+// Create creates a new pick-up point.
+// When the point id is zero, the next value of the bucket sequence is assigned.
+func (s *BoltRepository) Create(ctx context.Context, point PickUpPoint) (PickUpPoint, error) {
+	err := s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(pointsBucket)
+		if point.Id == 0 {
+			for point.Id == 0 || b.Get(kvstore.Key(point.Id)) != nil {
+				id, err := b.NextSequence()
+				if err != nil {
+					return err
+				}
+				point.Id = id
+			}
+		} else if point.Id > b.Sequence() {
+			err := b.SetSequence(point.Id)
+			if err != nil {
+				return err
+			}
+		}
+		k := kvstore.Key(point.Id)
+		if b.Get(k) != nil {
+			return ErrIdAlreadyExists
+		}
+		return putPoint(b, k, point)
+	})
+	if err != nil {
+		return PickUpPoint{}, err
+	}
+	return point, nil
+}
+
+// List returns a slice of pick-up points stored selected by opts.
+func (s *BoltRepository) List(ctx context.Context, opts ListOptions) ([]PickUpPoint, error) {
+	slice := make([]PickUpPoint, 0)
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		return tx.Bucket(pointsBucket).ForEach(func(k, v []byte) error {
+			var point PickUpPoint
+			err := json.Unmarshal(v, &point)
+			if err != nil {
+				return err
+			}
+			slice = append(slice, point)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return opts.apply(slice), nil
+}
+
+// Get returns the pick-up point represented by id.
+func (s *BoltRepository) Get(ctx context.Context, id uint64) (PickUpPoint, error) {
+	var point PickUpPoint
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		v := tx.Bucket(pointsBucket).Get(kvstore.Key(id))
+		if v == nil {
+			return ErrNoItemFound
+		}
+		return json.Unmarshal(v, &point)
+	})
+	return point, err
+}
+
+// Update sets the parameters of a pick-up point to those provided.
+func (s *BoltRepository) Update(ctx context.Context, point PickUpPoint) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(pointsBucket)
+		k := kvstore.Key(point.Id)
+		if b.Get(k) == nil {
+			return ErrNoItemFound
+		}
+		return putPoint(b, k, point)
+	})
+}
+
+// Delete deletes a pick-up point for good.
+func (s *BoltRepository) Delete(ctx context.Context, id uint64) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(pointsBucket)
+		k := kvstore.Key(id)
+		if b.Get(k) == nil {
+			return ErrNoItemFound
+		}
+		return b.Delete(k)
+	})
+}
+
+func putPoint(b *bbolt.Bucket, k []byte, point PickUpPoint) error {
+	v, err := json.Marshal(point)
+	if err != nil {
+		return err
+	}
+	return b.Put(k, v)
+}
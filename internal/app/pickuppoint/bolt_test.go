@@ -0,0 +1,351 @@
+package pickuppoint
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"homework/internal/app/auth"
+	"homework/internal/app/kvstore"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type BoltRepositoryTestSuite struct {
+	suite.Suite
+	db   *kvstore.DB
+	repo *BoltRepository
+}
+
+func (s *BoltRepositoryTestSuite) SetupTest() {
+	var err error
+	s.db, err = kvstore.Open(filepath.Join(s.T().TempDir(), "storage.db"), 0600)
+	s.Require().NoError(err)
+	s.repo, err = NewBoltRepository(s.db)
+	s.Require().NoError(err)
+}
+
+func (s *BoltRepositoryTestSuite) TearDownTest() {
+	s.NoError(s.db.Close())
+}
+
+func (s *BoltRepositoryTestSuite) Test_Create() {
+	ctx := context.Background()
+	_, err := s.repo.Create(ctx, SamplePickUpPoint)
+	s.NoError(err)
+	_, err = s.repo.Create(ctx, SamplePickUpPoint)
+	s.ErrorIs(err, ErrIdAlreadyExists)
+	point, err := s.repo.Get(ctx, SamplePickUpPoint.Id)
+	s.NoError(err)
+	s.Equal(SamplePickUpPoint, point)
+
+	generated := SamplePickUpPointSlice[1]
+	generated.Id = 0
+	point, err = s.repo.Create(ctx, generated)
+	s.NoError(err)
+	s.Equal(SamplePickUpPointSlice[1], point)
+}
+
+func (s *BoltRepositoryTestSuite) Test_List() {
+	ctx := context.Background()
+	points, err := s.repo.List(ctx, ListOptions{})
+	s.NoError(err)
+	s.Empty(points)
+	for _, point := range SamplePickUpPointSlice {
+		_, err = s.repo.Create(ctx, point)
+		s.NoError(err)
+	}
+	points, err = s.repo.List(ctx, ListOptions{})
+	s.NoError(err)
+	s.Equal(SamplePickUpPointSlice, points)
+}
+
+func (s *BoltRepositoryTestSuite) Test_Update() {
+	ctx := context.Background()
+	s.ErrorIs(s.repo.Update(ctx, SamplePickUpPoint), ErrNoItemFound)
+	_, err := s.repo.Create(ctx, SamplePickUpPoint)
+	s.NoError(err)
+	updated := SamplePickUpPoint
+	updated.Contact = Contact{Email: "updated@example.com"}
+	s.NoError(s.repo.Update(ctx, updated))
+	point, err := s.repo.Get(ctx, updated.Id)
+	s.NoError(err)
+	s.Equal(updated, point)
+}
+
+func (s *BoltRepositoryTestSuite) Test_Delete() {
+	ctx := context.Background()
+	s.ErrorIs(s.repo.Delete(ctx, SamplePickUpPoint.Id), ErrNoItemFound)
+	_, err := s.repo.Create(ctx, SamplePickUpPoint)
+	s.NoError(err)
+	s.NoError(s.repo.Delete(ctx, SamplePickUpPoint.Id))
+	_, err = s.repo.Get(ctx, SamplePickUpPoint.Id)
+	s.ErrorIs(err, ErrNoItemFound)
+}
+
+func (s *BoltRepositoryTestSuite) Test_Rollback() {
+	ctx := context.Background()
+	err := s.db.RunSerializable(ctx, func(ctxTX context.Context) error {
+		_, err := s.repo.Create(ctxTX, SamplePickUpPoint)
+		s.NoError(err)
+		_, err = s.repo.Get(ctxTX, SamplePickUpPoint.Id)
+		s.NoError(err)
+		return assert.AnError
+	})
+	s.ErrorIs(err, assert.AnError)
+	_, err = s.repo.Get(ctx, SamplePickUpPoint.Id)
+	s.ErrorIs(err, ErrNoItemFound)
+
+	svc := NewService(s.repo, s.db)
+	_, err = svc.CreatePoint(ctx, SamplePickUpPoint)
+	s.NoError(err)
+	point, err := svc.GetPoint(ctx, SamplePickUpPoint.Id)
+	s.NoError(err)
+	want := SamplePickUpPoint
+	want.Version = 1
+	s.Equal(want, point)
+}
+
+func (s *BoltRepositoryTestSuite) Test_Versions() {
+	ctx := context.Background()
+	svc := NewService(s.repo, s.db)
+	created, err := svc.CreatePoint(ctx, SamplePickUpPoint)
+	s.Require().NoError(err)
+	s.Equal(uint64(1), created.Version)
+
+	changed := created
+	changed.Name = "Changed pick-up point"
+	updated, err := svc.UpdatePoint(ctx, changed)
+	s.NoError(err)
+	s.Equal(uint64(2), updated.Version)
+
+	_, err = svc.UpdatePoint(ctx, changed)
+	s.ErrorIs(err, ErrVersionMismatch, "stale version must not overwrite the point")
+	err = svc.DeletePoint(ctx, created.Id, created.Version)
+	s.ErrorIs(err, ErrVersionMismatch)
+
+	_, err = svc.SetSchedule(ctx, created.Id, created.Version, Schedule{TimeZone: "Europe/Moscow"})
+	s.ErrorIs(err, ErrVersionMismatch)
+	scheduled, err := svc.SetSchedule(ctx, created.Id, updated.Version, Schedule{TimeZone: "Europe/Moscow"})
+	s.NoError(err)
+	s.Equal(uint64(3), scheduled.Version)
+	s.Equal("Changed pick-up point", scheduled.Name)
+
+	_, err = svc.ModifyPoint(ctx, created.Id, 2, func(point PickUpPoint) (PickUpPoint, error) {
+		s.Fail("stale point must not be modified")
+		return point, nil
+	})
+	s.ErrorIs(err, ErrVersionMismatch)
+
+	err = svc.DeletePoint(ctx, created.Id, scheduled.Version)
+	s.NoError(err)
+	_, err = svc.GetPoint(ctx, created.Id)
+	s.ErrorIs(err, ErrNoItemFound)
+}
+
+func (s *BoltRepositoryTestSuite) Test_SoftDelete() {
+	ctx := context.Background()
+	svc := NewService(s.repo, s.db)
+	for _, point := range SamplePickUpPointSlice {
+		_, err := svc.CreatePoint(ctx, point)
+		s.Require().NoError(err)
+	}
+	id := SamplePickUpPointSlice[0].Id
+
+	s.NoError(svc.DeletePoint(ctx, id, 0))
+	_, err := svc.GetPoint(ctx, id)
+	s.ErrorIs(err, ErrNoItemFound)
+	deleted, err := svc.GetPointIncludingDeleted(ctx, id)
+	s.NoError(err)
+	s.Require().True(deleted.Deleted())
+	s.WithinDuration(time.Now(), *deleted.DeletedAt, time.Minute)
+	s.Equal(uint64(2), deleted.Version)
+
+	page, err := svc.ListPoints(ctx, ListOptions{})
+	s.NoError(err)
+	s.Len(page.Points, len(SamplePickUpPointSlice)-1)
+	page, err = svc.ListPoints(ctx, ListOptions{IncludeDeleted: true})
+	s.NoError(err)
+	s.Len(page.Points, len(SamplePickUpPointSlice))
+
+	s.ErrorIs(svc.DeletePoint(ctx, id, 0), ErrNoItemFound)
+	_, err = svc.SetSchedule(ctx, id, 0, Schedule{})
+	s.ErrorIs(err, ErrNoItemFound, "deleted point must not be changed")
+
+	_, err = svc.RestorePoint(ctx, id, 1)
+	s.ErrorIs(err, ErrVersionMismatch)
+	restored, err := svc.RestorePoint(ctx, id, deleted.Version)
+	s.NoError(err)
+	s.False(restored.Deleted())
+	s.Equal(uint64(3), restored.Version)
+	_, err = svc.RestorePoint(ctx, id, 0)
+	s.ErrorIs(err, ErrNotDeleted)
+
+	s.NoError(svc.DeletePoint(ctx, id, 0))
+	purged, err := svc.PurgePoints(ctx, time.Now().Add(-time.Hour))
+	s.NoError(err)
+	s.Zero(purged, "recently deleted point must be kept")
+	purged, err = svc.PurgePoints(ctx, time.Now().Add(time.Second))
+	s.NoError(err)
+	s.Equal(1, purged)
+	_, err = svc.GetPointIncludingDeleted(ctx, id)
+	s.ErrorIs(err, ErrNoItemFound)
+	page, err = svc.ListPoints(ctx, ListOptions{IncludeDeleted: true})
+	s.NoError(err)
+	s.Len(page.Points, len(SamplePickUpPointSlice)-1)
+}
+
+func (s *BoltRepositoryTestSuite) Test_ListPages() {
+	ctx := context.Background()
+	svc := NewService(s.repo, s.db)
+	names := []string{"Delta", "alpha", "Charlie", "Bravo", "Echo"}
+	for i, name := range names {
+		point := SamplePickUpPoint
+		point.Id = uint64(i + 1)
+		point.Name = name
+		_, err := svc.CreatePoint(ctx, point)
+		s.Require().NoError(err)
+	}
+
+	page, err := svc.ListPoints(ctx, ListOptions{Search: "ALPHA"})
+	s.NoError(err)
+	s.Require().Len(page.Points, 1)
+	s.Equal("alpha", page.Points[0].Name)
+	s.Nil(page.Next)
+
+	opts := ListOptions{SortBy: SortByName, Desc: true, Limit: 2}
+	var listed []string
+	for {
+		page, err = svc.ListPoints(ctx, opts)
+		s.Require().NoError(err)
+		for _, point := range page.Points {
+			listed = append(listed, point.Name)
+		}
+		if page.Next == nil {
+			break
+		}
+		opts.After = page.Next
+	}
+	s.Equal([]string{"alpha", "Echo", "Delta", "Charlie", "Bravo"}, listed)
+
+	cursor, err := ParseCursor(Cursor{SortBy: SortByName, Key: "Bravo", Id: 4}.String())
+	s.NoError(err)
+	page, err = svc.ListPoints(ctx, ListOptions{SortBy: SortByName, After: &cursor})
+	s.NoError(err)
+	s.Len(page.Points, 4)
+	_, err = ParseCursor("not a cursor")
+	s.ErrorIs(err, ErrInvalidCursor)
+	_, err = svc.ListPoints(ctx, ListOptions{Limit: 2, After: &Cursor{SortBy: SortByName, Id: 1}})
+	s.ErrorIs(err, ErrInvalidCursor)
+}
+
+func (s *BoltRepositoryTestSuite) Test_ImportPoints() {
+	ctx := context.Background()
+	svc := NewService(s.repo, s.db)
+	_, err := svc.CreatePoint(ctx, SamplePickUpPoint)
+	s.Require().NoError(err)
+
+	generated := SamplePickUpPointSlice[1]
+	generated.Id = 0
+	taken := SamplePickUpPointSlice[1]
+	taken.Id = SamplePickUpPoint.Id
+	rows := []BatchRow{
+		{Point: generated},
+		{Point: taken},
+		{Err: assert.AnError},
+	}
+	report, err := svc.ImportPoints(ctx, rows, false)
+	s.ErrorIs(err, ErrInvalidBatch)
+	s.Equal(BatchReport{
+		Failed: 2,
+		Rows: []RowResult{
+			{Row: 1, Status: RowValid},
+			{Row: 2, Id: SamplePickUpPoint.Id, Status: RowFailed, Error: ErrIdAlreadyExists.Error()},
+			{Row: 3, Status: RowFailed, Error: assert.AnError.Error()},
+		},
+	}, report)
+	page, err := svc.ListPoints(ctx, ListOptions{})
+	s.NoError(err)
+	s.Len(page.Points, 1, "no point of an invalid batch must be created")
+
+	rows = []BatchRow{{Point: generated}, {Point: SamplePickUpPointSlice[1]}}
+	report, err = svc.ImportPoints(ctx, rows, true)
+	s.NoError(err)
+	s.Equal(BatchReport{DryRun: true, Rows: []RowResult{{Row: 1, Status: RowValid}, {Row: 2, Id: 2, Status: RowValid}}}, report)
+	page, err = svc.ListPoints(ctx, ListOptions{})
+	s.NoError(err)
+	s.Len(page.Points, 1, "dry run must not create points")
+
+	report, err = svc.ImportPoints(ctx, rows, false)
+	s.NoError(err)
+	s.Equal(2, report.Created)
+	s.Equal([]RowResult{{Row: 1, Id: 3, Status: RowCreated}, {Row: 2, Id: 2, Status: RowCreated}}, report.Rows)
+	point, err := svc.GetPoint(ctx, 2)
+	s.NoError(err)
+	s.Equal(uint64(1), point.Version)
+}
+
+func (s *BoltRepositoryTestSuite) Test_History() {
+	ctx := auth.WithUser(context.Background(), "user")
+	history, err := NewBoltHistoryRepository(s.db)
+	s.Require().NoError(err)
+	svc := NewService(s.repo, s.db)
+	svc.SetHistory(history)
+
+	_, err = svc.History(ctx, SamplePickUpPoint.Id, HistoryOptions{})
+	s.ErrorIs(err, ErrNoItemFound)
+
+	created, err := svc.CreatePoint(ctx, SamplePickUpPoint)
+	s.Require().NoError(err)
+	_, err = svc.CreatePoint(ctx, SamplePickUpPointSlice[1])
+	s.Require().NoError(err)
+	updated := created
+	updated.Name = "Renamed pick-up point"
+	updated, err = svc.UpdatePoint(ctx, updated)
+	s.Require().NoError(err)
+	s.Require().NoError(svc.DeletePoint(ctx, created.Id, 0))
+	_, err = svc.RestorePoint(ctx, created.Id, 0)
+	s.Require().NoError(err)
+
+	page, err := svc.History(ctx, created.Id, HistoryOptions{})
+	s.NoError(err)
+	s.Require().Len(page.Entries, 4)
+	s.Zero(page.Next)
+	var actions []Action
+	for _, entry := range page.Entries {
+		s.Equal(created.Id, entry.PointId)
+		s.Equal("user", entry.User)
+		s.WithinDuration(time.Now(), entry.ChangedAt, time.Minute)
+		actions = append(actions, entry.Action)
+	}
+	s.Equal([]Action{ActionRestore, ActionDelete, ActionUpdate, ActionCreate}, actions)
+	s.Equal(Snapshot(created), *page.Entries[2].Old)
+	s.Equal(Snapshot(updated), *page.Entries[2].New)
+	s.Nil(page.Entries[3].Old)
+	s.Equal(uint64(4), page.Entries[0].Version)
+
+	page, err = svc.History(ctx, created.Id, HistoryOptions{Limit: 3})
+	s.NoError(err)
+	s.Len(page.Entries, 3)
+	page, err = svc.History(ctx, created.Id, HistoryOptions{Before: page.Next, Limit: 3})
+	s.NoError(err)
+	s.Require().Len(page.Entries, 1)
+	s.Equal(ActionCreate, page.Entries[0].Action)
+	s.Zero(page.Next)
+	page, err = svc.History(ctx, created.Id, HistoryOptions{Before: 1000})
+	s.NoError(err)
+	s.Len(page.Entries, 4)
+
+	s.Require().NoError(svc.DeletePoint(ctx, created.Id, 0))
+	_, err = svc.PurgePoints(ctx, time.Now().Add(time.Second))
+	s.Require().NoError(err)
+	page, err = svc.History(ctx, created.Id, HistoryOptions{Limit: 1})
+	s.NoError(err, "history must outlive purged points")
+	s.Require().Len(page.Entries, 1)
+	s.Equal(ActionPurge, page.Entries[0].Action)
+	s.Nil(page.Entries[0].New)
+}
+
+func TestBoltRepository(t *testing.T) {
+	suite.Run(t, new(BoltRepositoryTestSuite))
+}
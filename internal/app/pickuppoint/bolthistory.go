@@ -0,0 +1,84 @@
+package pickuppoint
+
+import (
+	"context"
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"homework/internal/app/kvstore"
+)
+
+// historyBucket holds a nested bucket of entries for each pick-up point, entries are keyed by the sequence of historyBucket.
+var historyBucket = []byte("pickup_points_history")
+
+// BoltHistoryRepository provides a HistoryRepository with an embedded key-value store as a backend.
+type BoltHistoryRepository struct {
+	db *kvstore.DB
+}
+
+// NewBoltHistoryRepository returns a new BoltHistoryRepository with provided store.
+func NewBoltHistoryRepository(db *kvstore.DB) (*BoltHistoryRepository, error) {
+	err := db.Update(context.Background(), func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(historyBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltHistoryRepository{db: db}, nil
+}
+
+// Add stores the entry, its id is the next value of the bucket sequence.
+func (s *BoltHistoryRepository) Add(ctx context.Context, entry HistoryEntry) error {
+	return s.db.Update(ctx, func(tx *bbolt.Tx) error {
+		b := tx.Bucket(historyBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		entry.Id = id
+		points, err := b.CreateBucketIfNotExists(kvstore.Key(entry.PointId))
+		if err != nil {
+			return err
+		}
+		bytes, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return points.Put(kvstore.Key(entry.Id), bytes)
+	})
+}
+
+// List returns history entries of the pick-up point selected by opts, newest first.
+func (s *BoltHistoryRepository) List(ctx context.Context, pointId uint64, opts HistoryOptions) ([]HistoryEntry, error) {
+	slice := make([]HistoryEntry, 0)
+	err := s.db.View(ctx, func(tx *bbolt.Tx) error {
+		points := tx.Bucket(historyBucket).Bucket(kvstore.Key(pointId))
+		if points == nil {
+			return nil
+		}
+		c := points.Cursor()
+		var k, v []byte
+		if opts.Before != 0 {
+			k, _ = c.Seek(kvstore.Key(opts.Before))
+		}
+		// step back from the first entry not older than Before, or start from the newest one
+		if k != nil {
+			k, v = c.Prev()
+		} else {
+			k, v = c.Last()
+		}
+		for ; k != nil && (opts.Limit == 0 || len(slice) < opts.Limit); k, v = c.Prev() {
+			var entry HistoryEntry
+			err := json.Unmarshal(v, &entry)
+			if err != nil {
+				return err
+			}
+			slice = append(slice, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slice, nil
+}
@@ -0,0 +1,105 @@
+package pickuppoint
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrInvalidCapacity = errors.New("invalid capacity")
+	ErrFull            = errors.New("pick-up point is full")
+)
+
+// Capacity limits the orders a pick-up point can store at once.
+// A zero limit means there is no such limit.
+type Capacity struct {
+	Slots       int     `json:"slots,omitempty"`
+	MaxWeightKg float64 `json:"max_weight_kg,omitempty"`
+}
+
+// Validate checks that the limits are not negative.
+func (c Capacity) Validate() error {
+	if c.Slots < 0 {
+		return fmt.Errorf("%w: slots %d must not be negative", ErrInvalidCapacity, c.Slots)
+	}
+	if math.IsNaN(c.MaxWeightKg) || math.IsInf(c.MaxWeightKg, 0) || c.MaxWeightKg < 0 {
+		return fmt.Errorf("%w: max weight %v must not be negative", ErrInvalidCapacity, c.MaxWeightKg)
+	}
+	return nil
+}
+
+// Admits reports whether orders of the total weight fit within the limits.
+func (c Capacity) Admits(orders int, weightKg float64) bool {
+	if c.Slots > 0 && orders > c.Slots {
+		return false
+	}
+	if c.MaxWeightKg > 0 && weightKg > c.MaxWeightKg {
+		return false
+	}
+	return true
+}
+
+// Utilisation returns how much of the capacity is taken by orders of the total weight.
+func (c Capacity) Utilisation(orders int, weightKg float64) Utilisation {
+	return Utilisation{
+		Capacity: c,
+		Orders:   orders,
+		WeightKg: weightKg,
+		Full:     c.Slots > 0 && orders >= c.Slots || c.MaxWeightKg > 0 && weightKg >= c.MaxWeightKg,
+	}
+}
+
+func (c Capacity) String() string {
+	slots, weight := "unlimited", "unlimited"
+	if c.Slots > 0 {
+		slots = fmt.Sprint(c.Slots)
+	}
+	if c.MaxWeightKg > 0 {
+		weight = fmt.Sprintf("%.3f kg", c.MaxWeightKg)
+	}
+	return fmt.Sprintf("slots %s, max weight %s", slots, weight)
+}
+
+// Value stores the capacity in a database as JSON.
+func (c Capacity) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan reads the capacity stored in a database as JSON.
+func (c *Capacity) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return fmt.Errorf("cannot scan %T into a capacity", src)
+}
+
+// Utilisation is the amount of capacity taken by orders stored at a pick-up point.
+// The point is Full when all of its slots are taken or its weight limit is reached.
+type Utilisation struct {
+	Capacity Capacity `json:"capacity"`
+	Orders   int      `json:"orders"`
+	WeightKg float64  `json:"weight_kg"`
+	Full     bool     `json:"full"`
+}
+
+// OrdersString returns the number of orders along with the slots limit, if any.
+func (u Utilisation) OrdersString() string {
+	if u.Capacity.Slots > 0 {
+		return fmt.Sprintf("%d/%d", u.Orders, u.Capacity.Slots)
+	}
+	return fmt.Sprint(u.Orders)
+}
+
+// WeightString returns the weight of orders along with the weight limit, if any.
+func (u Utilisation) WeightString() string {
+	if u.Capacity.MaxWeightKg > 0 {
+		return fmt.Sprintf("%.3f/%.3f", u.WeightKg, u.Capacity.MaxWeightKg)
+	}
+	return fmt.Sprintf("%.3f", u.WeightKg)
+}
@@ -0,0 +1,75 @@
+package pickuppoint
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func Test_CapacityValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity Capacity
+		wantErr  bool
+	}{
+		{name: "unlimited"},
+		{name: "limited", capacity: Capacity{Slots: 10, MaxWeightKg: 50}},
+		{name: "negative slots", capacity: Capacity{Slots: -1}, wantErr: true},
+		{name: "negative weight", capacity: Capacity{MaxWeightKg: -1}, wantErr: true},
+		{name: "infinite weight", capacity: Capacity{MaxWeightKg: math.Inf(1)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.capacity.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCapacity)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_CapacityUtilisation(t *testing.T) {
+	tests := []struct {
+		name      string
+		capacity  Capacity
+		orders    int
+		weightKg  float64
+		wantAdmit bool
+		wantFull  bool
+	}{
+		{name: "unlimited", orders: 1000, weightKg: 1000, wantAdmit: true},
+		{name: "free slots", capacity: Capacity{Slots: 2}, orders: 1, weightKg: 100, wantAdmit: true},
+		{name: "all slots taken", capacity: Capacity{Slots: 2}, orders: 2, wantAdmit: true, wantFull: true},
+		{name: "too many orders", capacity: Capacity{Slots: 2}, orders: 3, wantFull: true},
+		{name: "weight limit reached", capacity: Capacity{Slots: 10, MaxWeightKg: 5}, orders: 1, weightKg: 5, wantAdmit: true, wantFull: true},
+		{name: "too heavy", capacity: Capacity{Slots: 10, MaxWeightKg: 5}, orders: 1, weightKg: 5.5, wantFull: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantAdmit, tt.capacity.Admits(tt.orders, tt.weightKg))
+			assert.Equal(t, Utilisation{Capacity: tt.capacity, Orders: tt.orders, WeightKg: tt.weightKg, Full: tt.wantFull},
+				tt.capacity.Utilisation(tt.orders, tt.weightKg))
+		})
+	}
+}
+
+func Test_UtilisationJSON(t *testing.T) {
+	data, err := json.Marshal(Capacity{Slots: 10}.Utilisation(3, 1.5))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"capacity":{"slots":10},"orders":3,"weight_kg":1.5,"full":false}`, string(data))
+}
+
+func Test_ListUtilisation(t *testing.T) {
+	points := []PickUpPoint{
+		{Id: 1, Name: "first", Capacity: Capacity{Slots: 2, MaxWeightKg: 10}},
+		{Id: 2, Name: "second"},
+	}
+	utilisation := map[uint64]Utilisation{1: points[0].Capacity.Utilisation(2, 3)}
+	assert.Equal(t, "Id  Name    Address  Contact  Orders  Weight kg     Full\n"+
+		"1   first                     2/2     3.000/10.000  true\n"+
+		"2   second                    0       0.000         false\n",
+		ListUtilisation(points, utilisation))
+}
@@ -0,0 +1,67 @@
+package pickuppoint
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Contact tells how to reach a pick-up point, at least one of the fields is required.
+type Contact struct {
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+func (c Contact) check(errs *fieldErrors) {
+	if c.Email == "" && c.Phone == "" {
+		errs.check("contact", errNoContact)
+		return
+	}
+	if c.Email != "" {
+		errs.check("contact.email", ValidateEmail(c.Email))
+	}
+	if c.Phone != "" {
+		errs.check("contact.phone", ValidatePhone(c.Phone))
+	}
+}
+
+func (c Contact) String() string {
+	if c.Email != "" && c.Phone != "" {
+		return c.Email + ", " + c.Phone
+	}
+	return c.Email + c.Phone
+}
+
+// UnmarshalJSON reads the contact from JSON object.
+// A plain string written before contacts were typed is kept as the email when it has @ in it,
+// or as the phone otherwise.
+func (c *Contact) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if json.Unmarshal(data, &legacy) == nil {
+		if strings.Contains(legacy, "@") {
+			*c = Contact{Email: legacy}
+		} else {
+			*c = Contact{Phone: legacy}
+		}
+		return nil
+	}
+	type contact Contact
+	return json.Unmarshal(data, (*contact)(c))
+}
+
+// Value stores the contact in a database as JSON.
+func (c Contact) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan reads the contact stored in a database as JSON.
+func (c *Contact) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return fmt.Errorf("cannot scan %T into a contact", src)
+}
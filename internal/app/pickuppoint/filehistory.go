@@ -0,0 +1,94 @@
+package pickuppoint
+
+import (
+	"context"
+	"go.uber.org/multierr"
+	"homework/internal/app/journal"
+	"os"
+	"sync"
+)
+
+// FileHistoryRepository provides a HistoryRepository with a JSON file as a backend.
+type FileHistoryRepository struct {
+	entries map[uint64]HistoryEntry
+	journal *journal.Journal[HistoryEntry]
+	mutex   sync.Mutex
+}
+
+// NewFileHistoryRepository returns a new in-memory FileHistoryRepository.
+func NewFileHistoryRepository() *FileHistoryRepository {
+	return &FileHistoryRepository{entries: make(map[uint64]HistoryEntry)}
+}
+
+// OpenFileHistoryRepository returns a new FileHistoryRepository persisted to the file stored in the provided path.
+func OpenFileHistoryRepository(path string, perm os.FileMode) (*FileHistoryRepository, error) {
+	j, entries, err := journal.Open[HistoryEntry](path, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &FileHistoryRepository{entries: entries, journal: j}, nil
+}
+
+// Close writes a snapshot of history entries into file when needed and releases it.
+func (s *FileHistoryRepository) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.Close(s.entries)
+}
+
+// sync runs f with history entries brought up to date with entries added by other processes,
+// keeping them from adding new ones until f returns.
+func (s *FileHistoryRepository) sync(f func() error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
+		return f()
+	}
+	_, err := s.journal.Lock(s.entries)
+	if err != nil {
+		return err
+	}
+	err = f()
+	return multierr.Combine(err, s.journal.Unlock())
+}
+
+// Add stores the entry, its id is the next one after the largest stored.
+func (s *FileHistoryRepository) Add(ctx context.Context, entry HistoryEntry) error {
+	return s.sync(func() error {
+		for id := range s.entries {
+			entry.Id = max(entry.Id, id)
+		}
+		entry.Id++
+		if s.journal != nil {
+			if s.journal.NeedsCompaction() {
+				err := s.journal.Compact(s.entries)
+				if err != nil {
+					return err
+				}
+			}
+			err := s.journal.Put(entry.Id, entry)
+			if err != nil {
+				return err
+			}
+		}
+		s.entries[entry.Id] = entry
+		return nil
+	})
+}
+
+// List returns history entries of the pick-up point selected by opts, newest first.
+func (s *FileHistoryRepository) List(ctx context.Context, pointId uint64, opts HistoryOptions) ([]HistoryEntry, error) {
+	slice := make([]HistoryEntry, 0)
+	err := s.sync(func() error {
+		for _, entry := range s.entries {
+			if entry.PointId == pointId {
+				slice = append(slice, entry)
+			}
+		}
+		return nil
+	})
+	return opts.apply(slice), err
+}
@@ -0,0 +1,42 @@
+package pickuppoint
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func Test_FileHistoryRepository(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "points_history.json")
+	repo, err := OpenFileHistoryRepository(path, 0600)
+	require.NoError(t, err)
+	for _, entry := range []HistoryEntry{
+		{PointId: 1, Version: 1, Action: ActionCreate},
+		{PointId: 2, Version: 1, Action: ActionCreate},
+		{PointId: 1, Version: 2, Action: ActionUpdate},
+		{PointId: 1, Version: 3, Action: ActionDelete},
+	} {
+		require.NoError(t, repo.Add(ctx, entry))
+	}
+	require.NoError(t, repo.Close())
+
+	repo, err = OpenFileHistoryRepository(path, 0600)
+	require.NoError(t, err)
+	defer repo.Close()
+	entries, err := repo.List(ctx, 1, HistoryOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []HistoryEntry{
+		{Id: 4, PointId: 1, Version: 3, Action: ActionDelete},
+		{Id: 3, PointId: 1, Version: 2, Action: ActionUpdate},
+		{Id: 1, PointId: 1, Version: 1, Action: ActionCreate},
+	}, entries)
+	entries, err = repo.List(ctx, 1, HistoryOptions{Before: 4, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []HistoryEntry{{Id: 3, PointId: 1, Version: 2, Action: ActionUpdate}}, entries)
+	entries, err = repo.List(ctx, 3, HistoryOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -3,18 +3,22 @@ package pickuppoint
 import (
 	"context"
 	"encoding/json"
+	"go.uber.org/multierr"
+	"homework/internal/app/journal"
 	"io"
+	"os"
 	"sync"
 )
 
 // FileRepository provides a pick-up point Repository with a JSON file as a backend.
 type FileRepository struct {
-	points  map[uint64]PickUpPoint
-	changed bool
-	mutex   sync.RWMutex
+	points   map[uint64]PickUpPoint
+	journal  *journal.Journal[PickUpPoint]
+	sequence uint64
+	mutex    sync.RWMutex
 }
 
-// NewFileRepository returns a new FileRepository with file stored in the provided path.
+// NewFileRepository returns a new in-memory FileRepository filled with pick-up points read from r.
 func NewFileRepository(r io.Reader) (*FileRepository, error) {
 	bytes, err := io.ReadAll(r)
 	if err != nil {
@@ -32,83 +36,204 @@ func NewFileRepository(r io.Reader) (*FileRepository, error) {
 	return &FileRepository{points: points}, nil
 }
 
-// Close saves cached pick-up points information into file when needed.
-func (s *FileRepository) Close(w io.Writer) error {
-	s.mutex.RLock()
-	if !s.changed {
-		s.mutex.RUnlock()
+// OpenFileRepository returns a new FileRepository persisted to the file stored in the provided path.
+func OpenFileRepository(path string, perm os.FileMode) (*FileRepository, error) {
+	j, points, err := journal.Open[PickUpPoint](path, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &FileRepository{points: points, journal: j}, nil
+}
+
+// Close writes a snapshot of pick-up points into file when needed and releases it.
+func (s *FileRepository) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.journal == nil {
 		return nil
 	}
+	return s.journal.Close(s.points)
+}
+
+// Export writes all pick-up points into w as JSON.
+func (s *FileRepository) Export(w io.Writer) error {
+	s.mutex.RLock()
 	bytes, err := json.Marshal(s.points)
 	s.mutex.RUnlock()
 	if err != nil {
 		return err
 	}
 	_, err = w.Write(bytes)
+	return err
+}
+
+func (s *FileRepository) logPut(point PickUpPoint) error {
+	if s.journal == nil {
+		return nil
+	}
+	err := s.compact()
 	if err != nil {
 		return err
 	}
-	s.changed = false
-	return nil
+	return s.journal.Put(point.Id, point)
 }
 
-// Create creates a new pick-up point.
-func (s *FileRepository) Create(ctx context.Context, point PickUpPoint) error {
+func (s *FileRepository) logDelete(id uint64) error {
+	if s.journal == nil {
+		return nil
+	}
+	err := s.compact()
+	if err != nil {
+		return err
+	}
+	return s.journal.Delete(id)
+}
+
+// sync runs f with pick-up points brought up to date with changes made by other processes,
+// keeping them from making new changes until f returns.
+// The ids of pick-up points changed by other processes are passed to f.
+func (s *FileRepository) sync(f func(changed map[uint64]struct{}) error) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	_, exists := s.points[point.Id]
-	if exists {
-		return ErrIdAlreadyExists
+	if s.journal == nil {
+		return f(nil)
+	}
+	changed, err := s.journal.Lock(s.points)
+	if err != nil {
+		return err
 	}
-	s.points[point.Id] = point
-	s.changed = true
-	return nil
+	err = f(changed)
+	return multierr.Combine(err, s.journal.Unlock())
 }
 
-// List returns a slice of all pick-up points stored.
-func (s *FileRepository) List(ctx context.Context) ([]PickUpPoint, error) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-	slice := make([]PickUpPoint, 0)
-	for _, point := range s.points {
-		slice = append(slice, point)
+func (s *FileRepository) compact() error {
+	if !s.journal.NeedsCompaction() {
+		return nil
+	}
+	return s.journal.Compact(s.points)
+}
+
+// Create creates a new pick-up point.
+// When the point id is zero, the next id after the largest one ever assigned is assigned,
+// so ids of purged points are not reused.
+func (s *FileRepository) Create(ctx context.Context, point PickUpPoint) (PickUpPoint, error) {
+	err := s.sync(func(changed map[uint64]struct{}) error {
+		lastId := s.lastId()
+		if point.Id == 0 {
+			point.Id = lastId + 1
+		}
+		_, exists := s.points[point.Id]
+		if exists {
+			return ErrIdAlreadyExists
+		}
+		if point.Id > lastId {
+			err := s.setLastId(point.Id)
+			if err != nil {
+				return err
+			}
+		}
+		err := s.logPut(point)
+		if err != nil {
+			return err
+		}
+		s.points[point.Id] = point
+		return nil
+	})
+	if err != nil {
+		return PickUpPoint{}, err
+	}
+	return point, nil
+}
+
+// lastId returns the largest id ever assigned, kept as the sequence of the journal.
+// Stores written before it was kept start from the largest id stored.
+func (s *FileRepository) lastId() uint64 {
+	lastId := s.sequence
+	if s.journal != nil {
+		lastId = s.journal.Sequence()
+	}
+	if lastId == 0 {
+		for id := range s.points {
+			lastId = max(lastId, id)
+		}
+	}
+	return lastId
+}
+
+func (s *FileRepository) setLastId(id uint64) error {
+	if s.journal == nil {
+		s.sequence = id
+		return nil
 	}
-	return slice, nil
+	err := s.compact()
+	if err != nil {
+		return err
+	}
+	return s.journal.SetSequence(id)
+}
+
+// List returns a slice of pick-up points stored selected by opts.
+func (s *FileRepository) List(ctx context.Context, opts ListOptions) ([]PickUpPoint, error) {
+	slice := make([]PickUpPoint, 0)
+	err := s.sync(func(changed map[uint64]struct{}) error {
+		for _, point := range s.points {
+			slice = append(slice, point)
+		}
+		return nil
+	})
+	return opts.apply(slice), err
 }
 
 // Get returns the pick-up point represented by id.
 func (s *FileRepository) Get(ctx context.Context, id uint64) (PickUpPoint, error) {
-	s.mutex.RLock()
-	point, found := s.points[id]
-	s.mutex.RUnlock()
-	if found {
-		return point, nil
-	}
-	return PickUpPoint{}, ErrNoItemFound
+	var point PickUpPoint
+	err := s.sync(func(changed map[uint64]struct{}) error {
+		var found bool
+		point, found = s.points[id]
+		if !found {
+			return ErrNoItemFound
+		}
+		return nil
+	})
+	return point, err
 }
 
 // Update sets the parameters of a pick-up point to those provided.
+// It fails with ErrChanged when the point was changed by another process since it was read.
 func (s *FileRepository) Update(ctx context.Context, point PickUpPoint) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	_, found := s.points[point.Id]
-	if !found {
-		return ErrNoItemFound
-	}
-	s.points[point.Id] = point
-	s.changed = true
-	return nil
+	return s.sync(func(changed map[uint64]struct{}) error {
+		_, found := s.points[point.Id]
+		if !found {
+			return ErrNoItemFound
+		}
+		if _, ok := changed[point.Id]; ok {
+			return ErrChanged
+		}
+		err := s.logPut(point)
+		if err != nil {
+			return err
+		}
+		s.points[point.Id] = point
+		return nil
+	})
 }
 
 // Delete deletes a pick-up point.
+// It fails with ErrChanged when the point was changed by another process since it was read.
 func (s *FileRepository) Delete(ctx context.Context, id uint64) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	_, found := s.points[id]
-	if !found {
-		return ErrNoItemFound
-	}
-	delete(s.points, id)
-	s.changed = true
-	return nil
+	return s.sync(func(changed map[uint64]struct{}) error {
+		_, found := s.points[id]
+		if !found {
+			return ErrNoItemFound
+		}
+		if _, ok := changed[id]; ok {
+			return ErrChanged
+		}
+		err := s.logDelete(id)
+		if err != nil {
+			return err
+		}
+		delete(s.points, id)
+		return nil
+	})
 }
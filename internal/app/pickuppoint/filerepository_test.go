@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"github.com/stretchr/testify/suite"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -21,7 +22,7 @@ func (s *FileRepositoryTestSuite) Test_Open() {
 	}{
 		{
 			name: "valid",
-			json: "{\"1\":{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}}",
+			json: "{\"1\":{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}}",
 			points: map[uint64]PickUpPoint{
 				1: SamplePickUpPoint,
 			},
@@ -56,7 +57,7 @@ func (s *FileRepositoryTestSuite) Test_Open() {
 	}
 }
 
-func (s *FileRepositoryTestSuite) Test_Close() {
+func (s *FileRepositoryTestSuite) Test_Export() {
 	tests := []struct {
 		name    string
 		json    string
@@ -65,7 +66,7 @@ func (s *FileRepositoryTestSuite) Test_Close() {
 	}{
 		{
 			name: "valid",
-			json: "{\"1\":{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}}",
+			json: "{\"1\":{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{}}}",
 			points: map[uint64]PickUpPoint{
 				1: SamplePickUpPoint,
 			},
@@ -79,9 +80,8 @@ func (s *FileRepositoryTestSuite) Test_Close() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			repo := &FileRepository{points: tt.points}
-			repo.changed = true
 			var buf bytes.Buffer
-			err := repo.Close(&buf)
+			err := repo.Export(&buf)
 			if tt.wantErr {
 				s.Error(err)
 			} else {
@@ -124,22 +124,60 @@ func (s *FileRepositoryTestSuite) Test_Create() {
 			wantErr: true,
 			err:     ErrIdAlreadyExists,
 		},
+		{
+			name: "generated id",
+			point: PickUpPoint{
+				Name:    "Another pick-up point",
+				Address: Address{Country: "Russia", City: "Moscow", Street: "Sample st.", Building: "19"},
+				Contact: Contact{Email: "sample@example.com"},
+			},
+			beforePoints: map[uint64]PickUpPoint{
+				1: SamplePickUpPoint,
+			},
+			afterPoints: map[uint64]PickUpPoint{
+				1: SamplePickUpPoint,
+				2: SamplePickUpPointSlice[1],
+			},
+			changed: true,
+		},
 	}
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			repo := &FileRepository{points: tt.beforePoints}
-			err := repo.Create(context.Background(), tt.point)
+			point, err := repo.Create(context.Background(), tt.point)
 			if tt.wantErr {
 				s.Error(err)
 				s.ErrorIs(err, tt.err)
 			} else {
 				s.NoError(err)
+				s.Equal(tt.afterPoints[point.Id], point)
 			}
 			s.Equal(tt.afterPoints, repo.points)
 		})
 	}
 }
 
+func (s *FileRepositoryTestSuite) Test_Create_IdsNotReused() {
+	ctx := context.Background()
+	path := filepath.Join(s.T().TempDir(), "points.json")
+	repo, err := OpenFileRepository(path, 0600)
+	s.Require().NoError(err)
+	_, err = repo.Create(ctx, PickUpPoint{Id: 10, Name: "imported"})
+	s.NoError(err)
+	point, err := repo.Create(ctx, PickUpPoint{Name: "next"})
+	s.NoError(err)
+	s.Equal(uint64(11), point.Id, "explicit ids advance the sequence")
+	s.NoError(repo.Delete(ctx, 11))
+	s.NoError(repo.Close())
+
+	repo, err = OpenFileRepository(path, 0600)
+	s.Require().NoError(err)
+	point, err = repo.Create(ctx, PickUpPoint{Name: "after purge"})
+	s.NoError(err)
+	s.Equal(uint64(12), point.Id, "the id of the purged point is not reused")
+	s.NoError(repo.Close())
+}
+
 func (s *FileRepositoryTestSuite) Test_List() {
 	tests := []struct {
 		name    string
@@ -154,14 +192,14 @@ func (s *FileRepositoryTestSuite) Test_List() {
 				1: {
 					Id:      1,
 					Name:    "Generic pick-up point",
-					Address: "5, Test st., Moscow",
-					Contact: "test@example.com",
+					Address: Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+					Contact: Contact{Email: "test@example.com"},
 				},
 				2: {
 					Id:      2,
 					Name:    "Another pick-up point",
-					Address: "19, Sample st., Moscow",
-					Contact: "sample@example.com",
+					Address: Address{Country: "Russia", City: "Moscow", Street: "Sample st.", Building: "19"},
+					Contact: Contact{Email: "sample@example.com"},
 				},
 			},
 			want: SamplePickUpPointSlice,
@@ -170,7 +208,7 @@ func (s *FileRepositoryTestSuite) Test_List() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			repo := &FileRepository{points: tt.points}
-			points, err := repo.List(context.Background())
+			points, err := repo.List(context.Background(), ListOptions{})
 			if tt.wantErr {
 				s.Error(err)
 				s.ErrorIs(err, tt.err)
@@ -241,8 +279,8 @@ func (s *FileRepositoryTestSuite) Test_Update() {
 				1: {
 					Id:      1,
 					Name:    "Generic pick-up point before",
-					Address: "5, Test st., Moscow before",
-					Contact: "test@example.com before",
+					Address: Address{Country: "Russia", City: "Moscow", Street: "Before st.", Building: "5"},
+					Contact: Contact{Email: "before@example.com"},
 				},
 			},
 			afterPoints: map[uint64]PickUpPoint{
@@ -0,0 +1,108 @@
+package pickuppoint
+
+import (
+	"cmp"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Action is a kind of change made to a pick-up point.
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+	ActionPurge   Action = "purge"
+)
+
+// HistoryEntry records a change of a pick-up point: its values before and after the change,
+// who made it and when. Old is nil for created points, New is nil for purged ones.
+type HistoryEntry struct {
+	Id        uint64    `json:"id" db:"id"`
+	PointId   uint64    `json:"point_id" db:"point_id"`
+	Version   uint64    `json:"version" db:"version"`
+	Action    Action    `json:"action" db:"action"`
+	Old       *Snapshot `json:"old,omitempty" db:"old_value"`
+	New       *Snapshot `json:"new,omitempty" db:"new_value"`
+	User      string    `json:"user,omitempty" db:"user_name"`
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// Snapshot is a pick-up point as it was at some moment.
+type Snapshot PickUpPoint
+
+func snapshot(point PickUpPoint) *Snapshot {
+	s := Snapshot(point)
+	return &s
+}
+
+// Value stores the snapshot in a database as JSON.
+func (s Snapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the snapshot stored in a database as JSON.
+func (s *Snapshot) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return fmt.Errorf("cannot scan %T into a snapshot", src)
+}
+
+// HistoryOptions select history entries of a pick-up point, newest first.
+type HistoryOptions struct {
+	// Before, when set, selects entries older than the entry with this id.
+	Before uint64
+	// Limit caps the number of entries, zero means no limit.
+	Limit int
+}
+
+func (o HistoryOptions) apply(entries []HistoryEntry) []HistoryEntry {
+	selected := make([]HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if o.Before == 0 || entry.Id < o.Before {
+			selected = append(selected, entry)
+		}
+	}
+	slices.SortFunc(selected, func(a, b HistoryEntry) int {
+		return cmp.Compare(b.Id, a.Id)
+	})
+	if o.Limit > 0 && len(selected) > o.Limit {
+		selected = selected[:o.Limit]
+	}
+	return selected
+}
+
+// HistoryPage is a part of history entries, Next is the Before option for the next page, zero on the last page.
+type HistoryPage struct {
+	Entries []HistoryEntry
+	Next    uint64
+}
+
+// HistoryRepository keeps history entries of pick-up points.
+type HistoryRepository interface {
+	// Add stores the entry assigning it the next id.
+	Add(ctx context.Context, entry HistoryEntry) error
+	List(ctx context.Context, pointId uint64, opts HistoryOptions) ([]HistoryEntry, error)
+}
+
+// NilHistory discards history entries.
+type NilHistory struct {
+}
+
+func (n NilHistory) Add(ctx context.Context, entry HistoryEntry) error {
+	return nil
+}
+
+func (n NilHistory) List(ctx context.Context, pointId uint64, opts HistoryOptions) ([]HistoryEntry, error) {
+	return nil, nil
+}
@@ -0,0 +1,200 @@
+package pickuppoint
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSort   = errors.New("sort must be id, name or city, optionally prefixed with - for descending order")
+	ErrInvalidCursor = errors.New("cursor is malformed or was made for another sort order")
+)
+
+// SortField is a field pick-up points are listed by, ties are broken by id.
+type SortField string
+
+const (
+	SortById   SortField = "id"
+	SortByName SortField = "name"
+	SortByCity SortField = "city"
+)
+
+// ParseSort reads a sort field optionally prefixed with - for descending order, e.g. -name.
+func ParseSort(s string) (SortField, bool, error) {
+	desc := strings.HasPrefix(s, "-")
+	field := SortField(strings.TrimPrefix(s, "-"))
+	switch field {
+	case SortById, SortByName, SortByCity:
+		return field, desc, nil
+	}
+	return "", false, ErrInvalidSort
+}
+
+func (f SortField) key(point PickUpPoint) string {
+	switch f {
+	case SortByName:
+		return point.Name
+	case SortByCity:
+		return point.Address.City
+	}
+	return ""
+}
+
+// Cursor tells where the next page of pick-up points starts: right after the point with Key and Id.
+type Cursor struct {
+	SortBy SortField `json:"s"`
+	Desc   bool      `json:"d,omitempty"`
+	Key    string    `json:"k,omitempty"`
+	Id     uint64    `json:"i"`
+}
+
+// String encodes the cursor to be passed in a URL.
+func (c Cursor) String() string {
+	bytes, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+// ParseCursor decodes a cursor made by Cursor.String.
+func ParseCursor(s string) (Cursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	err = json.Unmarshal(bytes, &c)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// ListOptions select pick-up points to list, deleted points are left out by default.
+type ListOptions struct {
+	IncludeDeleted bool
+	// DeletedBefore, when set, selects only points deleted before it.
+	DeletedBefore time.Time
+	// Search selects points having it in the name or a part of the address, ignoring case.
+	Search string
+	// SortBy orders points, by id when empty.
+	SortBy SortField
+	Desc   bool
+	// Limit caps the number of points listed, zero means no limit.
+	Limit int
+	// After, when set, selects points following the cursor in the sort order.
+	After *Cursor
+}
+
+func (o ListOptions) sortBy() SortField {
+	if o.SortBy == "" {
+		return SortById
+	}
+	return o.SortBy
+}
+
+// Validate checks that the cursor was made for the same sort order.
+func (o ListOptions) Validate() error {
+	if o.SortBy != "" {
+		_, _, err := ParseSort(string(o.SortBy))
+		if err != nil {
+			return err
+		}
+	}
+	if o.After != nil && (o.After.SortBy != o.sortBy() || o.After.Desc != o.Desc) {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// Key identifies the options, e.g. to cache points listed with them.
+func (o ListOptions) Key() string {
+	v := url.Values{}
+	v.Set("sort", string(o.sortBy()))
+	if o.Desc {
+		v.Set("desc", "true")
+	}
+	if o.IncludeDeleted {
+		v.Set("include_deleted", "true")
+	}
+	if !o.DeletedBefore.IsZero() {
+		v.Set("deleted_before", o.DeletedBefore.UTC().Format(time.RFC3339Nano))
+	}
+	if o.Search != "" {
+		v.Set("search", o.Search)
+	}
+	if o.Limit != 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.After != nil {
+		v.Set("after", o.After.String())
+	}
+	return v.Encode()
+}
+
+// searchText joins the name and address parts the search looks through, one per line
+// so that a search does not match across them.
+func searchText(point PickUpPoint) string {
+	a := point.Address
+	return strings.ToLower(strings.Join([]string{point.Name, a.Building, a.Street, a.City, a.Postcode, a.Country}, "\n"))
+}
+
+// cursor returns the cursor pointing right after the point.
+func (o ListOptions) cursor(point PickUpPoint) Cursor {
+	sortBy := o.sortBy()
+	return Cursor{SortBy: sortBy, Desc: o.Desc, Key: sortBy.key(point), Id: point.Id}
+}
+
+// compare orders points by the sort field, then by id.
+func (o ListOptions) compare(a Cursor, b Cursor) int {
+	c := cmp.Compare(a.Key, b.Key)
+	if c == 0 {
+		c = cmp.Compare(a.Id, b.Id)
+	}
+	if o.Desc {
+		return -c
+	}
+	return c
+}
+
+func (o ListOptions) match(point PickUpPoint) bool {
+	if !o.DeletedBefore.IsZero() {
+		if !point.Deleted() || !point.DeletedAt.Before(o.DeletedBefore) {
+			return false
+		}
+	} else if !o.IncludeDeleted && point.Deleted() {
+		return false
+	}
+	if o.Search != "" && !strings.Contains(searchText(point), strings.ToLower(o.Search)) {
+		return false
+	}
+	return o.After == nil || o.compare(o.cursor(point), *o.After) > 0
+}
+
+// apply selects, sorts and limits points the way a database would.
+func (o ListOptions) apply(points []PickUpPoint) []PickUpPoint {
+	selected := make([]PickUpPoint, 0, len(points))
+	for _, point := range points {
+		if o.match(point) {
+			selected = append(selected, point)
+		}
+	}
+	slices.SortFunc(selected, func(a, b PickUpPoint) int {
+		return o.compare(o.cursor(a), o.cursor(b))
+	})
+	if o.Limit > 0 && len(selected) > o.Limit {
+		selected = selected[:o.Limit]
+	}
+	return selected
+}
+
+// Page is a part of pick-up points listed, Next is nil on the last page.
+type Page struct {
+	Points []PickUpPoint `json:"points"`
+	Next   *Cursor       `json:"next,omitempty"`
+}
@@ -0,0 +1,96 @@
+package pickuppoint
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// EarthRadiusKm is the mean radius of the Earth.
+const EarthRadiusKm = 6371.0088
+
+var ErrInvalidLocation = errors.New("invalid location")
+
+// Location is a geographic position in degrees.
+type Location struct {
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lon"`
+}
+
+// Validate checks that the latitude and longitude are within their ranges.
+func (l Location) Validate() error {
+	if math.IsNaN(l.Latitude) || l.Latitude < -90 || l.Latitude > 90 {
+		return fmt.Errorf("%w: latitude %v must be between -90 and 90", ErrInvalidLocation, l.Latitude)
+	}
+	if math.IsNaN(l.Longitude) || l.Longitude < -180 || l.Longitude > 180 {
+		return fmt.Errorf("%w: longitude %v must be between -180 and 180", ErrInvalidLocation, l.Longitude)
+	}
+	return nil
+}
+
+// DistanceKm returns the great-circle distance to other.
+func (l Location) DistanceKm(other Location) float64 {
+	lat1, lat2 := radians(l.Latitude), radians(other.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(other.Longitude - l.Longitude)
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(min(h, 1)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Value stores the location in a database as JSON.
+func (l Location) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Scan reads the location stored in a database as JSON.
+func (l *Location) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return fmt.Errorf("cannot scan %T into a location", src)
+}
+
+// Nearby is a pick-up point found near some location.
+type Nearby struct {
+	PickUpPoint
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// Nearest returns up to limit points located closest to loc, nearest first,
+// skipping points without a location and those rejected by filter when it is not nil.
+// It checks every point, so an index should be preferred for large sets of points.
+func Nearest(points []PickUpPoint, loc Location, limit int, filter func(PickUpPoint) bool) []Nearby {
+	result := make([]Nearby, 0)
+	for _, point := range points {
+		if point.Location == nil || (filter != nil && !filter(point)) {
+			continue
+		}
+		result = append(result, Nearby{PickUpPoint: point, DistanceKm: loc.DistanceKm(*point.Location)})
+	}
+	SortNearby(result)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// SortNearby orders points by distance, breaking ties by id.
+func SortNearby(nearby []Nearby) {
+	slices.SortFunc(nearby, func(a, b Nearby) int {
+		if c := cmp.Compare(a.DistanceKm, b.DistanceKm); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+}
@@ -0,0 +1,74 @@
+package pickuppoint
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+var (
+	moscow          = Location{Latitude: 55.7558, Longitude: 37.6173}
+	saintPetersburg = Location{Latitude: 59.9386, Longitude: 30.3141}
+	vladivostok     = Location{Latitude: 43.1155, Longitude: 131.8855}
+)
+
+func Test_LocationValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		location Location
+		wantErr  bool
+	}{
+		{name: "valid", location: moscow},
+		{name: "poles and antimeridian", location: Location{Latitude: -90, Longitude: 180}},
+		{name: "latitude out of range", location: Location{Latitude: 91}, wantErr: true},
+		{name: "longitude out of range", location: Location{Longitude: -181}, wantErr: true},
+		{name: "not a number", location: Location{Latitude: math.NaN()}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.location.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidLocation)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_DistanceKm(t *testing.T) {
+	assert.Zero(t, moscow.DistanceKm(moscow))
+	assert.InDelta(t, 634, moscow.DistanceKm(saintPetersburg), 1)
+	assert.InDelta(t, moscow.DistanceKm(saintPetersburg), saintPetersburg.DistanceKm(moscow), 1e-9)
+	assert.InDelta(t, math.Pi*EarthRadiusKm, Location{Latitude: 90}.DistanceKm(Location{Latitude: -90}), 1e-6)
+}
+
+func Test_Nearest(t *testing.T) {
+	points := []PickUpPoint{
+		{Id: 1, Location: &vladivostok},
+		{Id: 2},
+		{Id: 3, Location: &saintPetersburg},
+		{Id: 4, Location: &moscow},
+		{Id: 5, Location: &moscow},
+	}
+	nearest := Nearest(points, moscow, 3, nil)
+	ids := make([]uint64, len(nearest))
+	for i, n := range nearest {
+		ids[i] = n.Id
+	}
+	assert.Equal(t, []uint64{4, 5, 3}, ids)
+
+	nearest = Nearest(points, moscow, 5, func(point PickUpPoint) bool { return point.Id%2 == 1 })
+	ids = ids[:0]
+	for _, n := range nearest {
+		ids = append(ids, n.Id)
+	}
+	assert.Equal(t, []uint64{5, 3, 1}, ids)
+}
+
+func Test_NearbyJSON(t *testing.T) {
+	data, err := json.Marshal(Nearby{PickUpPoint: PickUpPoint{Id: 1, Name: "name", Location: &moscow}, DistanceKm: 1.5})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"name":"name","address":{"country":"","city":"","street":"","building":""},"contact":{},"schedule":{},"location":{"lat":55.7558,"lon":37.6173},"capacity":{},"distance_km":1.5}`, string(data))
+}
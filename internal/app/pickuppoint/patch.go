@@ -0,0 +1,75 @@
+package pickuppoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidPatch = errors.New("merge patch must be a JSON object matching pick-up point fields")
+
+// MergePatch returns the point with a JSON Merge Patch (RFC 7386) applied:
+// fields of the patch replace those of the point, objects are merged recursively and null removes a field.
+func (p PickUpPoint) MergePatch(patch []byte) (PickUpPoint, error) {
+	var patchDoc any
+	err := decodeJSON(patch, &patchDoc)
+	if err != nil {
+		return PickUpPoint{}, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	if _, ok := patchDoc.(map[string]any); !ok {
+		return PickUpPoint{}, ErrInvalidPatch
+	}
+	pointJson, err := json.Marshal(p)
+	if err != nil {
+		return PickUpPoint{}, err
+	}
+	var doc any
+	err = decodeJSON(pointJson, &doc)
+	if err != nil {
+		return PickUpPoint{}, err
+	}
+	merged, err := json.Marshal(mergePatch(doc, patchDoc))
+	if err != nil {
+		return PickUpPoint{}, err
+	}
+	var patched PickUpPoint
+	err = json.Unmarshal(merged, &patched)
+	if err != nil {
+		return PickUpPoint{}, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	return patched, nil
+}
+
+func mergePatch(target any, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = mergePatch(targetObj[k], v)
+	}
+	return targetObj
+}
+
+// decodeJSON keeps numbers as they are written so that ids are not rounded to float64.
+func decodeJSON(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	err := d.Decode(v)
+	if err != nil {
+		return err
+	}
+	if d.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
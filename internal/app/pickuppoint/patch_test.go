@@ -0,0 +1,83 @@
+package pickuppoint
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_MergePatch(t *testing.T) {
+	point := PickUpPoint{
+		Id:       1,
+		Name:     "Generic pick-up point",
+		Address:  Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5", Postcode: "101000"},
+		Contact:  Contact{Email: "test@example.com", Phone: "+74951234567"},
+		Location: &Location{Latitude: 55.7558, Longitude: 37.6173},
+		Capacity: Capacity{Slots: 10},
+		Version:  2,
+	}
+	tests := []struct {
+		name    string
+		patch   string
+		want    func(point PickUpPoint) PickUpPoint
+		wantErr error
+	}{
+		{
+			name:  "empty",
+			patch: `{}`,
+			want:  func(point PickUpPoint) PickUpPoint { return point },
+		},
+		{
+			name:  "replace field",
+			patch: `{"name":"Patched pick-up point"}`,
+			want: func(point PickUpPoint) PickUpPoint {
+				point.Name = "Patched pick-up point"
+				return point
+			},
+		},
+		{
+			name:  "merge nested object",
+			patch: `{"address":{"street":"Patched st.","postcode":null},"capacity":{"max_weight_kg":50}}`,
+			want: func(point PickUpPoint) PickUpPoint {
+				point.Address.Street = "Patched st."
+				point.Address.Postcode = ""
+				point.Capacity.MaxWeightKg = 50
+				return point
+			},
+		},
+		{
+			name:  "remove fields",
+			patch: `{"contact":{"phone":null},"location":null}`,
+			want: func(point PickUpPoint) PickUpPoint {
+				point.Contact.Phone = ""
+				point.Location = nil
+				return point
+			},
+		},
+		{
+			name:    "not an object",
+			patch:   `["name"]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "malformed",
+			patch:   `{"name":`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "wrong type",
+			patch:   `{"capacity":{"slots":"many"}}`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, err := point.MergePatch([]byte(tt.patch))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want(point), patched)
+		})
+	}
+}
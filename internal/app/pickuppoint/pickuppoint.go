@@ -4,14 +4,36 @@ import (
 	"bytes"
 	"fmt"
 	"text/tabwriter"
+	"time"
 )
 
-// PickUpPoint contains fields relevant to a pick-up point
+// PickUpPoint contains fields relevant to a pick-up point.
+// Version is increased on every change of the point, it is zero for points stored before versions were introduced.
+// A deleted point is kept with DeletedAt set until it is purged.
 type PickUpPoint struct {
-	Id      uint64 `json:"id" db:"id"`
-	Name    string `json:"name" db:"name"`
-	Address string `json:"address" db:"address"`
-	Contact string `json:"contact" db:"contact"`
+	Id        uint64     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Address   Address    `json:"address" db:"address"`
+	Contact   Contact    `json:"contact" db:"contact"`
+	Schedule  Schedule   `json:"schedule" db:"schedule"`
+	Location  *Location  `json:"location,omitempty" db:"location"`
+	Capacity  Capacity   `json:"capacity" db:"capacity"`
+	Version   uint64     `json:"version,omitempty" db:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// Equal reports whether both points have the same fields.
+func (p PickUpPoint) Equal(other PickUpPoint) bool {
+	return p.Id == other.Id && p.Name == other.Name && p.Address == other.Address && p.Contact == other.Contact &&
+		p.Schedule.Equal(other.Schedule) &&
+		(p.Location == other.Location || p.Location != nil && other.Location != nil && *p.Location == *other.Location) &&
+		p.Capacity == other.Capacity && p.Version == other.Version &&
+		(p.DeletedAt == other.DeletedAt || p.DeletedAt != nil && other.DeletedAt != nil && p.DeletedAt.Equal(*other.DeletedAt))
+}
+
+// Deleted reports whether the point is deleted and waits to be purged.
+func (p PickUpPoint) Deleted() bool {
+	return p.DeletedAt != nil
 }
 
 func (p PickUpPoint) String() string {
@@ -39,3 +61,34 @@ func ListPoints(points []PickUpPoint) string {
 	w.Flush()
 	return buf.String()
 }
+
+// ListUtilisation returns points along with their utilisation as a table.
+func ListUtilisation(points []PickUpPoint, utilisation map[uint64]Utilisation) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(
+		w,
+		"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		"Id",
+		"Name",
+		"Address",
+		"Contact",
+		"Orders",
+		"Weight kg",
+		"Full")
+	for _, point := range points {
+		u := utilisation[point.Id]
+		fmt.Fprintf(
+			w,
+			"%d\t%s\t%s\t%s\t%s\t%s\t%t\n",
+			point.Id,
+			point.Name,
+			point.Address,
+			point.Contact,
+			u.OrdersString(),
+			u.WeightString(),
+			u.Full)
+	}
+	w.Flush()
+	return buf.String()
+}
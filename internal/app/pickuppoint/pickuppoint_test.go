@@ -3,21 +3,21 @@ package pickuppoint
 var SamplePickUpPoint = PickUpPoint{
 	Id:      1,
 	Name:    "Generic pick-up point",
-	Address: "5, Test st., Moscow",
-	Contact: "test@example.com",
+	Address: Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+	Contact: Contact{Email: "test@example.com"},
 }
 
 var SamplePickUpPointSlice = []PickUpPoint{
 	{
 		Id:      1,
 		Name:    "Generic pick-up point",
-		Address: "5, Test st., Moscow",
-		Contact: "test@example.com",
+		Address: Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+		Contact: Contact{Email: "test@example.com"},
 	},
 	{
 		Id:      2,
 		Name:    "Another pick-up point",
-		Address: "19, Sample st., Moscow",
-		Contact: "sample@example.com",
+		Address: Address{Country: "Russia", City: "Moscow", Street: "Sample st.", Building: "19"},
+		Contact: Contact{Email: "sample@example.com"},
 	},
 }
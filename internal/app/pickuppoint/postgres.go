@@ -6,6 +6,8 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"homework/internal/app/db"
+	"strconv"
+	"strings"
 )
 
 // PostgresRepository provides a pick-up point Repository with a PostgreSQL database as a backend.
@@ -19,29 +21,95 @@ func NewPostgresRepository(db db.Database) *PostgresRepository {
 }
 
 // Create creates a new pick-up point.
-func (s *PostgresRepository) Create(ctx context.Context, point PickUpPoint) error {
-	_, err := s.db.Exec(ctx, "INSERT INTO pickup_points (id, name, address, contact) VALUES ($1, $2, $3, $4);", point.Id, point.Name, point.Address, point.Contact)
+// When the point id is zero, it is generated by the database.
+func (s *PostgresRepository) Create(ctx context.Context, point PickUpPoint) (PickUpPoint, error) {
+	var err error
+	if point.Id == 0 {
+		err = s.db.ExecQueryRow(ctx, "INSERT INTO pickup_points (name, address, contact, schedule, location, capacity, version, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;", point.Name, point.Address, point.Contact, point.Schedule, point.Location, point.Capacity, point.Version, point.DeletedAt).Scan(&point.Id)
+	} else {
+		_, err = s.db.Exec(ctx, "INSERT INTO pickup_points (id, name, address, contact, schedule, location, capacity, version, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);", point.Id, point.Name, point.Address, point.Contact, point.Schedule, point.Location, point.Capacity, point.Version, point.DeletedAt)
+		if err == nil {
+			// keep generated ids clear of the explicitly provided one
+			_, err = s.db.Exec(ctx, "SELECT setval(pg_get_serial_sequence('pickup_points', 'id'), (SELECT max(id) FROM pickup_points));")
+		}
+	}
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) && pgErr.ConstraintName != "" {
-		return ErrIdAlreadyExists
+		return PickUpPoint{}, ErrIdAlreadyExists
+	}
+	if err != nil {
+		return PickUpPoint{}, err
 	}
-	return err
+	return point, nil
 }
 
-// List returns a slice of all pick-up points stored.
-func (s *PostgresRepository) List(ctx context.Context) ([]PickUpPoint, error) {
+// searchExpr is the SQL counterpart of searchText, indexed by pickup_points_search_idx.
+const searchExpr = `lower(name || E'\n' || coalesce(address->>'building', '') || E'\n' || coalesce(address->>'street', '') || E'\n' || coalesce(address->>'city', '') || E'\n' || coalesce(address->>'postcode', '') || E'\n' || coalesce(address->>'country', ''))`
+
+var sortExprs = map[SortField]string{
+	SortByName: "name",
+	SortByCity: "coalesce(address->>'city', '')",
+}
+
+// List returns a slice of pick-up points stored selected by opts.
+func (s *PostgresRepository) List(ctx context.Context, opts ListOptions) ([]PickUpPoint, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	switch {
+	case !opts.DeletedBefore.IsZero():
+		conds = append(conds, "deleted_at < "+arg(opts.DeletedBefore))
+	case !opts.IncludeDeleted:
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	if opts.Search != "" {
+		conds = append(conds, searchExpr+" LIKE "+arg("%"+escapeLike(strings.ToLower(opts.Search))+"%"))
+	}
+	sortExpr, byKey := sortExprs[opts.sortBy()]
+	op, dir := ">", ""
+	if opts.Desc {
+		op, dir = "<", " DESC"
+	}
+	if opts.After != nil {
+		if byKey {
+			conds = append(conds, "("+sortExpr+", id) "+op+" ("+arg(opts.After.Key)+", "+arg(opts.After.Id)+")")
+		} else {
+			conds = append(conds, "id "+op+" "+arg(opts.After.Id))
+		}
+	}
+
+	query := "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY "
+	if byKey {
+		query += sortExpr + dir + ", "
+	}
+	query += "id" + dir
+	if opts.Limit > 0 {
+		query += " LIMIT " + arg(opts.Limit)
+	}
 	var slice []PickUpPoint
-	err := s.db.Select(ctx, &slice, "SELECT id, name, address, contact FROM pickup_points;")
+	err := s.db.Select(ctx, &slice, query+";", args...)
 	if err != nil {
 		return nil, err
 	}
 	return slice, nil
 }
 
+// escapeLike makes LIKE match wildcards and the escape character literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 // Get returns the pick-up point represented by id.
 func (s *PostgresRepository) Get(ctx context.Context, id uint64) (PickUpPoint, error) {
 	var point PickUpPoint
-	err := s.db.Get(ctx, &point, "SELECT id, name, address, contact FROM pickup_points WHERE id = $1;", id)
+	err := s.db.Get(ctx, &point, "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points WHERE id = $1;", id)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return point, ErrNoItemFound
 	}
@@ -50,7 +118,7 @@ func (s *PostgresRepository) Get(ctx context.Context, id uint64) (PickUpPoint, e
 
 // Update sets the parameters of a pick-up point to those provided.
 func (s *PostgresRepository) Update(ctx context.Context, point PickUpPoint) error {
-	tag, err := s.db.Exec(ctx, "UPDATE pickup_points SET name = $2, address = $3, contact = $4 WHERE id = $1;", point.Id, point.Name, point.Address, point.Contact)
+	tag, err := s.db.Exec(ctx, "UPDATE pickup_points SET name = $2, address = $3, contact = $4, schedule = $5, location = $6, capacity = $7, version = $8, deleted_at = $9 WHERE id = $1;", point.Id, point.Name, point.Address, point.Contact, point.Schedule, point.Location, point.Capacity, point.Version, point.DeletedAt)
 	if err != nil {
 		return err
 	}
@@ -60,7 +128,7 @@ func (s *PostgresRepository) Update(ctx context.Context, point PickUpPoint) erro
 	return nil
 }
 
-// Delete deletes a pick-up point.
+// Delete deletes a pick-up point for good.
 func (s *PostgresRepository) Delete(ctx context.Context, id uint64) error {
 	tag, err := s.db.Exec(ctx, "DELETE FROM pickup_points WHERE id = $1;", id)
 	if err != nil {
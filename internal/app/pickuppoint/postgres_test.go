@@ -9,6 +9,7 @@ import (
 	"go.uber.org/mock/gomock"
 	"homework/internal/app/db/mocks"
 	"testing"
+	"time"
 )
 
 type PostgresRepositoryTestSuite struct {
@@ -49,34 +50,143 @@ func (s *PostgresRepositoryTestSuite) Test_Create() {
 			repo := NewPostgresRepository(db)
 			db.EXPECT().
 				Exec(gomock.Any(),
-					"INSERT INTO pickup_points (id, name, address, contact) VALUES ($1, $2, $3, $4);",
-					tt.point.Id, tt.point.Name, tt.point.Address, tt.point.Contact).
+					"INSERT INTO pickup_points (id, name, address, contact, schedule, location, capacity, version, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);",
+					tt.point.Id, tt.point.Name, tt.point.Address, tt.point.Contact, tt.point.Schedule, tt.point.Location, tt.point.Capacity, tt.point.Version, tt.point.DeletedAt).
 				Return(nil, tt.dbErr)
-			err := repo.Create(context.Background(), tt.point)
+			if tt.dbErr == nil {
+				db.EXPECT().
+					Exec(gomock.Any(), "SELECT setval(pg_get_serial_sequence('pickup_points', 'id'), (SELECT max(id) FROM pickup_points));").
+					Return(nil, nil)
+			}
+			point, err := repo.Create(context.Background(), tt.point)
+			if tt.wantErr {
+				s.Error(err)
+				s.ErrorIs(err, tt.err)
+			} else {
+				s.NoError(err)
+				s.Equal(tt.point, point)
+			}
+		})
+	}
+}
+
+type row struct {
+	id  uint64
+	err error
+}
+
+func (r row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*uint64) = r.id
+	return nil
+}
+
+func (s *PostgresRepositoryTestSuite) Test_CreateGeneratedId() {
+	tests := []struct {
+		name    string
+		row     row
+		want    PickUpPoint
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "valid",
+			row:  row{id: 1},
+			want: SamplePickUpPoint,
+		},
+		{
+			name:    "error",
+			row:     row{err: assert.AnError},
+			wantErr: true,
+			err:     assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ctrl := gomock.NewController(s.T())
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresRepository(db)
+			point := SamplePickUpPoint
+			point.Id = 0
+			db.EXPECT().
+				ExecQueryRow(gomock.Any(),
+					"INSERT INTO pickup_points (name, address, contact, schedule, location, capacity, version, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;",
+					point.Name, point.Address, point.Contact, point.Schedule, point.Location, point.Capacity, point.Version, point.DeletedAt).
+				Return(tt.row)
+			created, err := repo.Create(context.Background(), point)
 			if tt.wantErr {
 				s.Error(err)
 				s.ErrorIs(err, tt.err)
 			} else {
 				s.NoError(err)
+				s.Equal(tt.want, created)
 			}
 		})
 	}
 }
 
 func (s *PostgresRepositoryTestSuite) Test_List() {
+	deletedBefore := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
 	tests := []struct {
 		name    string
+		opts    ListOptions
+		query   string
+		args    []any
 		dbErr   error
 		want    []PickUpPoint
 		wantErr bool
 		err     error
 	}{
 		{
-			name: "ok",
-			want: SamplePickUpPointSlice,
+			name:  "ok",
+			query: "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points WHERE deleted_at IS NULL ORDER BY id;",
+			want:  SamplePickUpPointSlice,
+		},
+		{
+			name:  "include deleted",
+			opts:  ListOptions{IncludeDeleted: true},
+			query: "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points ORDER BY id;",
+			want:  SamplePickUpPointSlice,
+		},
+		{
+			name:  "deleted before",
+			opts:  ListOptions{DeletedBefore: deletedBefore},
+			query: "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points WHERE deleted_at < $1 ORDER BY id;",
+			args:  []any{deletedBefore},
+			want:  SamplePickUpPointSlice,
+		},
+		{
+			name:  "search",
+			opts:  ListOptions{Search: "Tver_50%"},
+			query: "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points WHERE deleted_at IS NULL AND " + searchExpr + " LIKE $1 ORDER BY id;",
+			args:  []any{`%tver\_50\%%`},
+			want:  SamplePickUpPointSlice,
+		},
+		{
+			name:  "page by id",
+			opts:  ListOptions{Limit: 2, After: &Cursor{SortBy: SortById, Id: 5}},
+			query: "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points WHERE deleted_at IS NULL AND id > $1 ORDER BY id LIMIT $2;",
+			args:  []any{uint64(5), 2},
+			want:  SamplePickUpPointSlice,
+		},
+		{
+			name:  "page by city descending",
+			opts:  ListOptions{SortBy: SortByCity, Desc: true, Limit: 10, After: &Cursor{SortBy: SortByCity, Desc: true, Key: "Moscow", Id: 3}},
+			query: "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points WHERE deleted_at IS NULL AND (coalesce(address->>'city', ''), id) < ($1, $2) ORDER BY coalesce(address->>'city', '') DESC, id DESC LIMIT $3;",
+			args:  []any{"Moscow", uint64(3), 10},
+			want:  SamplePickUpPointSlice,
+		},
+		{
+			name:  "sort by name",
+			opts:  ListOptions{SortBy: SortByName, IncludeDeleted: true},
+			query: "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points ORDER BY name, id;",
+			want:  SamplePickUpPointSlice,
 		},
 		{
 			name:    "error",
+			query:   "SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points WHERE deleted_at IS NULL ORDER BY id;",
 			dbErr:   assert.AnError,
 			wantErr: true,
 			err:     assert.AnError,
@@ -88,13 +198,12 @@ func (s *PostgresRepositoryTestSuite) Test_List() {
 			db := mocks.NewMockDatabase(ctrl)
 			repo := NewPostgresRepository(db)
 			db.EXPECT().
-				Select(gomock.Any(), gomock.Any(),
-					"SELECT id, name, address, contact FROM pickup_points;").
+				Select(gomock.Any(), gomock.Any(), tt.query, tt.args...).
 				DoAndReturn(func(ctx context.Context, dest *[]PickUpPoint, query string, args ...interface{}) error {
 					*dest = tt.want
 					return tt.err
 				})
-			point, err := repo.List(context.Background())
+			point, err := repo.List(context.Background(), tt.opts)
 			if tt.wantErr {
 				s.Error(err)
 				s.ErrorIs(err, tt.err)
@@ -142,7 +251,7 @@ func (s *PostgresRepositoryTestSuite) Test_Get() {
 			repo := NewPostgresRepository(db)
 			db.EXPECT().
 				Get(gomock.Any(), gomock.Any(),
-					"SELECT id, name, address, contact FROM pickup_points WHERE id = $1;",
+					"SELECT id, name, address, contact, schedule, location, capacity, version, deleted_at FROM pickup_points WHERE id = $1;",
 					tt.id).
 				DoAndReturn(func(ctx context.Context, dest *PickUpPoint, query string, args ...interface{}) error {
 					*dest = tt.want
@@ -197,8 +306,8 @@ func (s *PostgresRepositoryTestSuite) Test_Update() {
 			tag.EXPECT().RowsAffected().AnyTimes().Return(tt.rowsAffected)
 			db.EXPECT().
 				Exec(gomock.Any(),
-					"UPDATE pickup_points SET name = $2, address = $3, contact = $4 WHERE id = $1;",
-					tt.point.Id, tt.point.Name, tt.point.Address, tt.point.Contact).
+					"UPDATE pickup_points SET name = $2, address = $3, contact = $4, schedule = $5, location = $6, capacity = $7, version = $8, deleted_at = $9 WHERE id = $1;",
+					tt.point.Id, tt.point.Name, tt.point.Address, tt.point.Contact, tt.point.Schedule, tt.point.Location, tt.point.Capacity, tt.point.Version, tt.point.DeletedAt).
 				Return(tag, tt.dbErr)
 			err := repo.Update(context.Background(), tt.point)
 			if tt.wantErr {
@@ -0,0 +1,44 @@
+package pickuppoint
+
+import (
+	"context"
+	"homework/internal/app/db"
+	"strconv"
+)
+
+// PostgresHistoryRepository provides a HistoryRepository with a PostgreSQL database as a backend.
+type PostgresHistoryRepository struct {
+	db db.Database
+}
+
+// NewPostgresHistoryRepository returns a new PostgresHistoryRepository with provided database.
+func NewPostgresHistoryRepository(db db.Database) *PostgresHistoryRepository {
+	return &PostgresHistoryRepository{db: db}
+}
+
+// Add stores the entry, its id is generated by the database.
+func (s *PostgresHistoryRepository) Add(ctx context.Context, entry HistoryEntry) error {
+	_, err := s.db.Exec(ctx, "INSERT INTO pickup_points_history (point_id, version, action, old_value, new_value, user_name, changed_at) VALUES ($1, $2, $3, $4, $5, $6, $7);", entry.PointId, entry.Version, entry.Action, entry.Old, entry.New, entry.User, entry.ChangedAt)
+	return err
+}
+
+// List returns history entries of the pick-up point selected by opts, newest first.
+func (s *PostgresHistoryRepository) List(ctx context.Context, pointId uint64, opts HistoryOptions) ([]HistoryEntry, error) {
+	query := "SELECT id, point_id, version, action, old_value, new_value, user_name, changed_at FROM pickup_points_history WHERE point_id = $1"
+	args := []any{pointId}
+	if opts.Before != 0 {
+		args = append(args, opts.Before)
+		query += " AND id < $" + strconv.Itoa(len(args))
+	}
+	query += " ORDER BY id DESC"
+	if opts.Limit > 0 {
+		args = append(args, opts.Limit)
+		query += " LIMIT $" + strconv.Itoa(len(args))
+	}
+	var slice []HistoryEntry
+	err := s.db.Select(ctx, &slice, query+";", args...)
+	if err != nil {
+		return nil, err
+	}
+	return slice, nil
+}
@@ -0,0 +1,85 @@
+package pickuppoint
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"homework/internal/app/db/mocks"
+	"testing"
+	"time"
+)
+
+func Test_PostgresHistoryRepository_Add(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := mocks.NewMockDatabase(ctrl)
+	repo := NewPostgresHistoryRepository(db)
+	entry := HistoryEntry{
+		PointId:   1,
+		Version:   2,
+		Action:    ActionUpdate,
+		Old:       snapshot(SamplePickUpPoint),
+		New:       snapshot(SamplePickUpPoint),
+		User:      "user",
+		ChangedAt: time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC),
+	}
+	db.EXPECT().
+		Exec(gomock.Any(),
+			"INSERT INTO pickup_points_history (point_id, version, action, old_value, new_value, user_name, changed_at) VALUES ($1, $2, $3, $4, $5, $6, $7);",
+			entry.PointId, entry.Version, entry.Action, entry.Old, entry.New, entry.User, entry.ChangedAt).
+		Return(nil, nil)
+	assert.NoError(t, repo.Add(context.Background(), entry))
+}
+
+func Test_PostgresHistoryRepository_List(t *testing.T) {
+	entries := []HistoryEntry{{Id: 2, PointId: 1, Version: 2, Action: ActionUpdate}, {Id: 1, PointId: 1, Version: 1, Action: ActionCreate}}
+	tests := []struct {
+		name    string
+		opts    HistoryOptions
+		query   string
+		args    []any
+		dbErr   error
+		want    []HistoryEntry
+		wantErr bool
+	}{
+		{
+			name:  "all",
+			query: "SELECT id, point_id, version, action, old_value, new_value, user_name, changed_at FROM pickup_points_history WHERE point_id = $1 ORDER BY id DESC;",
+			args:  []any{uint64(1)},
+			want:  entries,
+		},
+		{
+			name:  "page",
+			opts:  HistoryOptions{Before: 10, Limit: 2},
+			query: "SELECT id, point_id, version, action, old_value, new_value, user_name, changed_at FROM pickup_points_history WHERE point_id = $1 AND id < $2 ORDER BY id DESC LIMIT $3;",
+			args:  []any{uint64(1), uint64(10), 2},
+			want:  entries,
+		},
+		{
+			name:    "error",
+			query:   "SELECT id, point_id, version, action, old_value, new_value, user_name, changed_at FROM pickup_points_history WHERE point_id = $1 ORDER BY id DESC;",
+			args:    []any{uint64(1)},
+			dbErr:   assert.AnError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			db := mocks.NewMockDatabase(ctrl)
+			repo := NewPostgresHistoryRepository(db)
+			db.EXPECT().
+				Select(gomock.Any(), gomock.Any(), tt.query, tt.args...).
+				DoAndReturn(func(ctx context.Context, dest *[]HistoryEntry, query string, args ...interface{}) error {
+					*dest = tt.want
+					return tt.dbErr
+				})
+			got, err := repo.List(context.Background(), 1, tt.opts)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.dbErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
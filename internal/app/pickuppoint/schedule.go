@@ -0,0 +1,264 @@
+package pickuppoint
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	dayMinutes = 24 * 60
+	// searchDays limits how far ahead the next opening is looked for.
+	searchDays = 366 + 7
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Clock is a time of day in minutes after midnight, written as "15:04".
+// The end of the day is written as "24:00".
+type Clock int
+
+// ParseClock parses a time of day written as "15:04".
+func ParseClock(s string) (Clock, error) {
+	var h, m int
+	n, err := fmt.Sscanf(s, "%d:%d", &h, &m)
+	if err != nil || n != 2 || len(s) != len("15:04") || h < 0 || m < 0 || m > 59 || h*60+m > dayMinutes {
+		return 0, fmt.Errorf("%w: time of day %q must be written as HH:MM", ErrInvalidSchedule, s)
+	}
+	return Clock(h*60 + m), nil
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c/60, c%60)
+}
+
+func (c Clock) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Clock) UnmarshalText(text []byte) error {
+	var err error
+	*c, err = ParseClock(string(text))
+	return err
+}
+
+// Weekday is a day of the week, written as its lowercase English name.
+type Weekday time.Weekday
+
+// ParseWeekday parses a lowercase English day name.
+func ParseWeekday(s string) (Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) {
+			return Weekday(d), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown day %q", ErrInvalidSchedule, s)
+}
+
+func (d Weekday) String() string {
+	return strings.ToLower(time.Weekday(d).String())
+}
+
+func (d Weekday) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Weekday) UnmarshalText(text []byte) error {
+	var err error
+	*d, err = ParseWeekday(string(text))
+	return err
+}
+
+// Hours are working hours on a day of the week.
+// A day may have several intervals, e.g. around a lunch break.
+type Hours struct {
+	Day   Weekday `json:"day"`
+	Open  Clock   `json:"open"`
+	Close Clock   `json:"close"`
+}
+
+// Holiday overrides the weekly hours on a date.
+// The point is closed for the whole day when Close is zero.
+type Holiday struct {
+	Date  string `json:"date"`
+	Open  Clock  `json:"open,omitempty"`
+	Close Clock  `json:"close,omitempty"`
+}
+
+// Closed reports whether the point is closed for the whole day.
+func (h Holiday) Closed() bool {
+	return h.Close == 0
+}
+
+// Schedule describes when a pick-up point is open.
+// Times are local to TimeZone, which is an IANA name and defaults to UTC.
+// A point without weekly hours has no schedule and is never considered open.
+type Schedule struct {
+	TimeZone string    `json:"time_zone,omitempty"`
+	Hours    []Hours   `json:"hours,omitempty"`
+	Holidays []Holiday `json:"holidays,omitempty"`
+}
+
+// Equal reports whether both schedules are the same.
+func (s Schedule) Equal(other Schedule) bool {
+	return s.TimeZone == other.TimeZone && slices.Equal(s.Hours, other.Hours) && slices.Equal(s.Holidays, other.Holidays)
+}
+
+func (s Schedule) String() string {
+	var b strings.Builder
+	tz := s.TimeZone
+	if tz == "" {
+		tz = "UTC"
+	}
+	fmt.Fprintf(&b, "time zone %s\n", tz)
+	for _, h := range s.Hours {
+		fmt.Fprintf(&b, "%s %s-%s\n", h.Day, h.Open, h.Close)
+	}
+	for _, h := range s.Holidays {
+		if h.Closed() {
+			fmt.Fprintf(&b, "%s closed\n", h.Date)
+		} else {
+			fmt.Fprintf(&b, "%s %s-%s\n", h.Date, h.Open, h.Close)
+		}
+	}
+	return b.String()
+}
+
+// Location returns the time zone of the schedule.
+func (s Schedule) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidSchedule, s.TimeZone)
+	}
+	return loc, nil
+}
+
+// Validate checks that the time zone is known, every interval closes after it opens
+// and intervals of the same day do not overlap.
+func (s Schedule) Validate() error {
+	_, err := s.Location()
+	if err != nil {
+		return err
+	}
+	byDay := make(map[Weekday][]Hours)
+	for _, h := range s.Hours {
+		if h.Day < Weekday(time.Sunday) || h.Day > Weekday(time.Saturday) {
+			return fmt.Errorf("%w: unknown day %d", ErrInvalidSchedule, h.Day)
+		}
+		if h.Open >= h.Close || h.Open < 0 || h.Close > dayMinutes {
+			return fmt.Errorf("%w: %s hours %s-%s must close after they open", ErrInvalidSchedule, h.Day, h.Open, h.Close)
+		}
+		for _, other := range byDay[h.Day] {
+			if h.Open < other.Close && other.Open < h.Close {
+				return fmt.Errorf("%w: %s hours %s-%s and %s-%s overlap", ErrInvalidSchedule, h.Day, h.Open, h.Close, other.Open, other.Close)
+			}
+		}
+		byDay[h.Day] = append(byDay[h.Day], h)
+	}
+	dates := make(map[string]struct{})
+	for _, h := range s.Holidays {
+		_, err := time.Parse(dateLayout, h.Date)
+		if err != nil {
+			return fmt.Errorf("%w: holiday date %q must be written as YYYY-MM-DD", ErrInvalidSchedule, h.Date)
+		}
+		if _, ok := dates[h.Date]; ok {
+			return fmt.Errorf("%w: holiday %s is listed twice", ErrInvalidSchedule, h.Date)
+		}
+		dates[h.Date] = struct{}{}
+		if !h.Closed() && (h.Open >= h.Close || h.Open < 0 || h.Close > dayMinutes) {
+			return fmt.Errorf("%w: holiday %s hours %s-%s must close after they open", ErrInvalidSchedule, h.Date, h.Open, h.Close)
+		}
+	}
+	return nil
+}
+
+// OpenStatus tells whether a point is open at some moment.
+type OpenStatus struct {
+	Open        bool       `json:"open"`
+	ClosesAt    *time.Time `json:"closes_at,omitempty"`
+	NextOpening *time.Time `json:"next_opening,omitempty"`
+}
+
+type interval struct {
+	open, close time.Time
+}
+
+// intervals returns the working intervals of the date of day in loc, ordered by opening time.
+func (s Schedule) intervals(day time.Time, loc *time.Location) []interval {
+	y, m, d := day.Date()
+	at := func(c Clock) time.Time {
+		return time.Date(y, m, d, 0, int(c), 0, 0, loc)
+	}
+	date := day.Format(dateLayout)
+	for _, h := range s.Holidays {
+		if h.Date == date {
+			if h.Closed() {
+				return nil
+			}
+			return []interval{{open: at(h.Open), close: at(h.Close)}}
+		}
+	}
+	var result []interval
+	for _, h := range s.Hours {
+		if h.Day == Weekday(day.Weekday()) {
+			result = append(result, interval{open: at(h.Open), close: at(h.Close)})
+		}
+	}
+	slices.SortFunc(result, func(a, b interval) int {
+		return a.open.Compare(b.open)
+	})
+	return result
+}
+
+// Status tells whether the point is open at t, when it closes if it is,
+// and when it opens next otherwise. Adjacent intervals are considered continuous.
+func (s Schedule) Status(t time.Time) (OpenStatus, error) {
+	loc, err := s.Location()
+	if err != nil {
+		return OpenStatus{}, err
+	}
+	t = t.In(loc)
+	var status OpenStatus
+	y, m, d := t.Date()
+	for i := 0; i < searchDays; i++ {
+		day := time.Date(y, m, d+i, 12, 0, 0, 0, loc)
+		for _, in := range s.intervals(day, loc) {
+			opens, closes := in.open, in.close
+			switch {
+			case status.Open && in.open.After(*status.ClosesAt):
+				return status, nil
+			case status.Open && in.close.After(*status.ClosesAt):
+				status.ClosesAt = &closes
+			case !status.Open && !in.open.After(t) && in.close.After(t):
+				status = OpenStatus{Open: true, ClosesAt: &closes}
+			case !status.Open && in.open.After(t):
+				return OpenStatus{NextOpening: &opens}, nil
+			}
+		}
+	}
+	return status, nil
+}
+
+// Value stores the schedule in a database as JSON.
+func (s Schedule) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the schedule stored in a database as JSON.
+func (s *Schedule) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = Schedule{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return fmt.Errorf("cannot scan %T into a schedule", src)
+}
@@ -0,0 +1,206 @@
+package pickuppoint
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+var SampleSchedule = Schedule{
+	TimeZone: "Europe/Moscow",
+	Hours: []Hours{
+		{Day: Weekday(time.Monday), Open: 9 * 60, Close: 13 * 60},
+		{Day: Weekday(time.Monday), Open: 14 * 60, Close: 21 * 60},
+		{Day: Weekday(time.Tuesday), Open: 9 * 60, Close: 24 * 60},
+		{Day: Weekday(time.Wednesday), Open: 0, Close: 21 * 60},
+		{Day: Weekday(time.Friday), Open: 9 * 60, Close: 21 * 60},
+	},
+	Holidays: []Holiday{
+		{Date: "2024-05-01"},
+		{Date: "2024-05-03", Open: 10 * 60, Close: 16 * 60},
+	},
+}
+
+type ScheduleTestSuite struct {
+	suite.Suite
+	loc *time.Location
+}
+
+func (s *ScheduleTestSuite) SetupSuite() {
+	var err error
+	s.loc, err = time.LoadLocation("Europe/Moscow")
+	s.Require().NoError(err)
+}
+
+func (s *ScheduleTestSuite) at(date string, clock string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", date+" "+clock, s.loc)
+	s.Require().NoError(err)
+	return t
+}
+
+func (s *ScheduleTestSuite) Test_JSON() {
+	data, err := json.Marshal(SampleSchedule)
+	s.NoError(err)
+	s.JSONEq(`{
+		"time_zone": "Europe/Moscow",
+		"hours": [
+			{"day": "monday", "open": "09:00", "close": "13:00"},
+			{"day": "monday", "open": "14:00", "close": "21:00"},
+			{"day": "tuesday", "open": "09:00", "close": "24:00"},
+			{"day": "wednesday", "open": "00:00", "close": "21:00"},
+			{"day": "friday", "open": "09:00", "close": "21:00"}
+		],
+		"holidays": [
+			{"date": "2024-05-01"},
+			{"date": "2024-05-03", "open": "10:00", "close": "16:00"}
+		]
+	}`, string(data))
+
+	var schedule Schedule
+	s.NoError(json.Unmarshal(data, &schedule))
+	s.True(SampleSchedule.Equal(schedule))
+
+	s.ErrorIs(json.Unmarshal([]byte(`{"hours":[{"day":"someday","open":"09:00","close":"21:00"}]}`), &schedule), ErrInvalidSchedule)
+	s.ErrorIs(json.Unmarshal([]byte(`{"hours":[{"day":"monday","open":"9:00","close":"21:00"}]}`), &schedule), ErrInvalidSchedule)
+}
+
+func (s *ScheduleTestSuite) Test_Validate() {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  bool
+	}{
+		{
+			name:     "valid",
+			schedule: SampleSchedule,
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:     "unknown time zone",
+			schedule: Schedule{TimeZone: "Moscow"},
+			wantErr:  true,
+		},
+		{
+			name: "closes before it opens",
+			schedule: Schedule{Hours: []Hours{
+				{Day: Weekday(time.Monday), Open: 21 * 60, Close: 9 * 60},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "overlap",
+			schedule: Schedule{Hours: []Hours{
+				{Day: Weekday(time.Monday), Open: 9 * 60, Close: 14 * 60},
+				{Day: Weekday(time.Monday), Open: 13 * 60, Close: 21 * 60},
+			}},
+			wantErr: true,
+		},
+		{
+			name:     "invalid holiday date",
+			schedule: Schedule{Holidays: []Holiday{{Date: "01.05.2024"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "duplicate holiday",
+			schedule: Schedule{Holidays: []Holiday{{Date: "2024-05-01"}, {Date: "2024-05-01"}}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			err := tt.schedule.Validate()
+			if tt.wantErr {
+				s.ErrorIs(err, ErrInvalidSchedule)
+			} else {
+				s.NoError(err)
+			}
+		})
+	}
+}
+
+func (s *ScheduleTestSuite) Test_Status() {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		at       time.Time
+		want     OpenStatus
+	}{
+		{
+			name:     "open",
+			schedule: SampleSchedule,
+			at:       s.at("2024-04-29", "10:00"),
+			want:     OpenStatus{Open: true, ClosesAt: ptr(s.at("2024-04-29", "13:00"))},
+		},
+		{
+			name:     "lunch break",
+			schedule: SampleSchedule,
+			at:       s.at("2024-04-29", "13:00"),
+			want:     OpenStatus{NextOpening: ptr(s.at("2024-04-29", "14:00"))},
+		},
+		{
+			name:     "before opening",
+			schedule: SampleSchedule,
+			at:       s.at("2024-04-30", "08:00"),
+			want:     OpenStatus{NextOpening: ptr(s.at("2024-04-30", "09:00"))},
+		},
+		{
+			name:     "open through midnight",
+			schedule: SampleSchedule,
+			at:       s.at("2024-05-07", "22:00"),
+			want:     OpenStatus{Open: true, ClosesAt: ptr(s.at("2024-05-08", "21:00"))},
+		},
+		{
+			name:     "closes at midnight before holiday",
+			schedule: SampleSchedule,
+			at:       s.at("2024-04-30", "22:00"),
+			want:     OpenStatus{Open: true, ClosesAt: ptr(s.at("2024-05-01", "00:00"))},
+		},
+		{
+			name:     "next opening after holidays",
+			schedule: SampleSchedule,
+			at:       s.at("2024-05-01", "12:00"),
+			want:     OpenStatus{NextOpening: ptr(s.at("2024-05-03", "10:00"))},
+		},
+		{
+			name:     "other time zone",
+			schedule: SampleSchedule,
+			at:       s.at("2024-05-03", "12:00").UTC(),
+			want:     OpenStatus{Open: true, ClosesAt: ptr(s.at("2024-05-03", "16:00"))},
+		},
+		{
+			name: "no schedule",
+			at:   s.at("2024-05-03", "12:00"),
+			want: OpenStatus{},
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			status, err := tt.schedule.Status(tt.at)
+			s.NoError(err)
+			s.Equal(tt.want.Open, status.Open)
+			s.equalTime(tt.want.ClosesAt, status.ClosesAt)
+			s.equalTime(tt.want.NextOpening, status.NextOpening)
+		})
+	}
+}
+
+func (s *ScheduleTestSuite) equalTime(want, got *time.Time) {
+	if want == nil {
+		s.Nil(got)
+		return
+	}
+	if s.NotNil(got) {
+		s.True(want.Equal(*got), "want %v, got %v", want, got)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestSchedule(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
@@ -3,11 +3,14 @@ package pickuppoint
 import (
 	"context"
 	"errors"
+	"fmt"
+	"homework/internal/app/auth"
+	"time"
 )
 
 type Repository interface {
-	Create(ctx context.Context, point PickUpPoint) error
-	List(ctx context.Context) ([]PickUpPoint, error)
+	Create(ctx context.Context, point PickUpPoint) (PickUpPoint, error)
+	List(ctx context.Context, opts ListOptions) ([]PickUpPoint, error)
 	Get(ctx context.Context, id uint64) (PickUpPoint, error)
 	Update(ctx context.Context, point PickUpPoint) error
 	Delete(ctx context.Context, id uint64) error
@@ -19,41 +22,175 @@ type TransactionManager interface {
 
 var ErrIdAlreadyExists = errors.New("item with such id already exists")
 var ErrNoItemFound = errors.New("no such item found")
+var ErrChanged = errors.New("item was changed by another process, try again")
+var ErrVersionMismatch = errors.New("item version does not match the expected one")
+var ErrNotDeleted = errors.New("item is not deleted")
 
 // Service allows concurrent working on pick-up points.
 type Service struct {
-	repo Repository
-	tm   TransactionManager
+	repo    Repository
+	tm      TransactionManager
+	history HistoryRepository
 }
 
-// NewService creates a new Service.
+// NewService creates a new Service, changes are not recorded until a history is set.
 func NewService(repo Repository, tm TransactionManager) *Service {
 	return &Service{
-		repo: repo,
-		tm:   tm,
+		repo:    repo,
+		tm:      tm,
+		history: NilHistory{},
 	}
 }
 
-// CreatePoint creates a pick-up point.
-func (s *Service) CreatePoint(ctx context.Context, point PickUpPoint) error {
-	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
-		return s.repo.Create(ctxTX, point)
+// SetHistory makes the service record every change of pick-up points in the history.
+func (s *Service) SetHistory(history HistoryRepository) {
+	s.history = history
+}
+
+// record adds a history entry of the change made by the user carried by ctx.
+func (s *Service) record(ctx context.Context, action Action, before *PickUpPoint, after *PickUpPoint) error {
+	entry := HistoryEntry{
+		Action:    action,
+		User:      auth.UserName(ctx),
+		ChangedAt: time.Now().UTC(),
+	}
+	if before != nil {
+		entry.PointId, entry.Version, entry.Old = before.Id, before.Version, snapshot(*before)
+	}
+	if after != nil {
+		entry.PointId, entry.Version, entry.New = after.Id, after.Version, snapshot(*after)
+	}
+	return s.history.Add(ctx, entry)
+}
+
+// CreatePoint creates a pick-up point with the first version.
+// When the point id is zero, a new id is assigned.
+func (s *Service) CreatePoint(ctx context.Context, point PickUpPoint) (PickUpPoint, error) {
+	point.Version = 1
+	var created PickUpPoint
+	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		var err error
+		created, err = s.repo.Create(ctxTX, point)
+		if err != nil {
+			return err
+		}
+		return s.record(ctxTX, ActionCreate, nil, &created)
 	})
+	return created, err
 }
 
-// ListPoints prints a slice of all pick-up points.
-func (s *Service) ListPoints(ctx context.Context) ([]PickUpPoint, error) {
-	var points []PickUpPoint
+// ImportPoints creates pick-up points of the batch in a single transaction, all of them or none.
+// Rows are checked for errors and ids taken by other rows or stored points before anything is created,
+// so that storages without rollback are left intact too. When any row fails, ErrInvalidBatch is returned along with the report.
+// With dryRun set rows are only checked.
+func (s *Service) ImportPoints(ctx context.Context, rows []BatchRow, dryRun bool) (BatchReport, error) {
+	report := BatchReport{DryRun: dryRun}
 	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		report.Rows, report.Created, report.Failed = nil, 0, 0
+		seen := make(map[uint64]struct{}, len(rows))
+		for i, row := range rows {
+			err := row.Err
+			if err == nil && row.Point.Id != 0 {
+				if _, ok := seen[row.Point.Id]; ok {
+					err = ErrIdAlreadyExists
+				} else {
+					seen[row.Point.Id] = struct{}{}
+					_, err = s.repo.Get(ctxTX, row.Point.Id)
+					switch {
+					case err == nil:
+						err = ErrIdAlreadyExists
+					case errors.Is(err, ErrNoItemFound):
+						err = nil
+					default:
+						return err
+					}
+				}
+			}
+			if err != nil {
+				report.fail(i, row.Point, err)
+				continue
+			}
+			report.Rows = append(report.Rows, RowResult{Row: i + 1, Id: row.Point.Id, Status: RowValid})
+		}
+		if report.Failed > 0 || dryRun {
+			return nil
+		}
+		// points with provided ids go first, so that ids generated for the rest do not take them
+		for _, explicitId := range []bool{true, false} {
+			for i, row := range rows {
+				if (row.Point.Id != 0) != explicitId {
+					continue
+				}
+				point := row.Point
+				point.Version = 1
+				point.DeletedAt = nil
+				created, err := s.repo.Create(ctxTX, point)
+				if err != nil {
+					return fmt.Errorf("row %d: %w", i+1, err)
+				}
+				err = s.record(ctxTX, ActionCreate, nil, &created)
+				if err != nil {
+					return err
+				}
+				report.Rows[i].Id = created.Id
+				report.Rows[i].Status = RowCreated
+				report.Created++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return BatchReport{}, err
+	}
+	if report.Failed > 0 {
+		return report, ErrInvalidBatch
+	}
+	return report, nil
+}
+
+// ListPoints prints a page of pick-up points selected by opts, with a cursor to the next one when opts.Limit is set.
+func (s *Service) ListPoints(ctx context.Context, opts ListOptions) (Page, error) {
+	err := opts.Validate()
+	if err != nil {
+		return Page{}, err
+	}
+	limit := opts.Limit
+	if limit > 0 {
+		// one extra point tells whether there is a next page
+		opts.Limit++
+	}
+	var points []PickUpPoint
+	err = s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
 		var err error
-		points, err = s.repo.List(ctxTX)
+		points, err = s.repo.List(ctxTX, opts)
 		return err
 	})
-	return points, err
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{Points: points}
+	if limit > 0 && len(points) > limit {
+		page.Points = points[:limit]
+		next := opts.cursor(page.Points[limit-1])
+		page.Next = &next
+	}
+	return page, nil
 }
 
-// GetPoint prints a specified pick-up point.
+// GetPoint prints a specified pick-up point unless it is deleted.
 func (s *Service) GetPoint(ctx context.Context, id uint64) (PickUpPoint, error) {
+	point, err := s.GetPointIncludingDeleted(ctx, id)
+	if err != nil {
+		return PickUpPoint{}, err
+	}
+	if point.Deleted() {
+		return PickUpPoint{}, ErrNoItemFound
+	}
+	return point, nil
+}
+
+// GetPointIncludingDeleted prints a specified pick-up point even when it is deleted.
+func (s *Service) GetPointIncludingDeleted(ctx context.Context, id uint64) (PickUpPoint, error) {
 	var point PickUpPoint
 	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
 		var err error
@@ -63,16 +200,139 @@ func (s *Service) GetPoint(ctx context.Context, id uint64) (PickUpPoint, error)
 	return point, err
 }
 
-// UpdatePoint updates a pick-up point info.
-func (s *Service) UpdatePoint(ctx context.Context, point PickUpPoint) error {
-	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
-		return s.repo.Update(ctxTX, point)
+// UpdatePoint replaces a pick-up point info and returns the point with its new version.
+// Unless point.Version is zero, it fails with ErrVersionMismatch when the stored point has another version.
+func (s *Service) UpdatePoint(ctx context.Context, point PickUpPoint) (PickUpPoint, error) {
+	return s.ModifyPoint(ctx, point.Id, point.Version, func(PickUpPoint) (PickUpPoint, error) {
+		return point, nil
 	})
 }
 
-// DeletePoint deletes a pick-up point.
-func (s *Service) DeletePoint(ctx context.Context, id uint64) error {
-	return s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
-		return s.repo.Delete(ctxTX, id)
+// ModifyPoint replaces a pick-up point with the one modify makes of it and returns the point with its new version.
+// Unless version is zero, it fails with ErrVersionMismatch when the stored point has another version.
+func (s *Service) ModifyPoint(ctx context.Context, id uint64, version uint64, modify func(point PickUpPoint) (PickUpPoint, error)) (PickUpPoint, error) {
+	return s.change(ctx, id, version, false, ActionUpdate, func(point PickUpPoint) (PickUpPoint, error) {
+		modified, err := modify(point)
+		modified.DeletedAt = nil
+		return modified, err
+	})
+}
+
+// DeletePoint marks a pick-up point deleted, it is kept until purged and may be restored meanwhile.
+// Unless version is zero, it fails with ErrVersionMismatch when the stored point has another version.
+func (s *Service) DeletePoint(ctx context.Context, id uint64, version uint64) error {
+	_, err := s.change(ctx, id, version, false, ActionDelete, func(point PickUpPoint) (PickUpPoint, error) {
+		deletedAt := time.Now().UTC()
+		point.DeletedAt = &deletedAt
+		return point, nil
+	})
+	return err
+}
+
+// RestorePoint brings back a deleted pick-up point and returns it with its new version.
+// It fails with ErrNotDeleted when the point is not deleted.
+// Unless version is zero, it fails with ErrVersionMismatch when the stored point has another version.
+func (s *Service) RestorePoint(ctx context.Context, id uint64, version uint64) (PickUpPoint, error) {
+	return s.change(ctx, id, version, true, ActionRestore, func(point PickUpPoint) (PickUpPoint, error) {
+		point.DeletedAt = nil
+		return point, nil
+	})
+}
+
+// PurgePoints removes pick-up points deleted before the provided time for good and returns how many were removed.
+func (s *Service) PurgePoints(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var purged int
+	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		points, err := s.repo.List(ctxTX, ListOptions{DeletedBefore: deletedBefore})
+		if err != nil {
+			return err
+		}
+		for _, point := range points {
+			err = s.repo.Delete(ctxTX, point.Id)
+			if err != nil {
+				return err
+			}
+			err = s.record(ctxTX, ActionPurge, &point, nil)
+			if err != nil {
+				return err
+			}
+		}
+		purged = len(points)
+		return nil
+	})
+	return purged, err
+}
+
+// change replaces a pick-up point with the one change makes of it, increasing its version, and records the action in the history.
+// The point must be deleted or not as told by deleted, otherwise it is reported missing or not deleted.
+func (s *Service) change(ctx context.Context, id uint64, version uint64, deleted bool, action Action, change func(point PickUpPoint) (PickUpPoint, error)) (PickUpPoint, error) {
+	var changed PickUpPoint
+	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		point, err := s.repo.Get(ctxTX, id)
+		if err != nil {
+			return err
+		}
+		if point.Deleted() != deleted {
+			if deleted {
+				return ErrNotDeleted
+			}
+			return ErrNoItemFound
+		}
+		if version != 0 && point.Version != version {
+			return ErrVersionMismatch
+		}
+		changed, err = change(point)
+		if err != nil {
+			return err
+		}
+		changed.Id = id
+		changed.Version = point.Version + 1
+		err = s.repo.Update(ctxTX, changed)
+		if err != nil {
+			return err
+		}
+		return s.record(ctxTX, action, &point, &changed)
+	})
+	if err != nil {
+		return PickUpPoint{}, err
+	}
+	return changed, nil
+}
+
+// History returns a page of history entries of a pick-up point, newest first, with the Before option of the next one when opts.Limit is set.
+// It fails with ErrNoItemFound when the point never existed.
+func (s *Service) History(ctx context.Context, id uint64, opts HistoryOptions) (HistoryPage, error) {
+	limit := opts.Limit
+	if limit > 0 {
+		// one extra entry tells whether there is a next page
+		opts.Limit++
+	}
+	var entries []HistoryEntry
+	err := s.tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		var err error
+		entries, err = s.history.List(ctxTX, id, opts)
+		if err != nil || len(entries) > 0 || opts.Before != 0 {
+			return err
+		}
+		_, err = s.repo.Get(ctxTX, id)
+		return err
+	})
+	if err != nil {
+		return HistoryPage{}, err
+	}
+	page := HistoryPage{Entries: entries}
+	if limit > 0 && len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.Next = page.Entries[limit-1].Id
+	}
+	return page, nil
+}
+
+// SetSchedule replaces the schedule of a pick-up point and returns the point with its new version.
+// Unless version is zero, it fails with ErrVersionMismatch when the stored point has another version.
+func (s *Service) SetSchedule(ctx context.Context, id uint64, version uint64, schedule Schedule) (PickUpPoint, error) {
+	return s.ModifyPoint(ctx, id, version, func(point PickUpPoint) (PickUpPoint, error) {
+		point.Schedule = schedule
+		return point, nil
 	})
 }
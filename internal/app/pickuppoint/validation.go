@@ -0,0 +1,106 @@
+package pickuppoint
+
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidPoint = errors.New("invalid pick-up point")
+
+var (
+	errEmpty           = errors.New("must not be empty")
+	errInvalidEmail    = errors.New("must be an email address like name@example.com")
+	errInvalidPhone    = errors.New("must be a phone number of 7 to 15 digits, optionally starting with +")
+	errInvalidPostcode = errors.New("must be 3 to 10 letters, digits, spaces or hyphens")
+	errNoContact       = errors.New("either email or phone is required")
+)
+
+var (
+	postcodeRegexp = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z -]{1,8}[0-9A-Za-z]$`)
+	phoneRegexp    = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+)
+
+// FieldError tells why a field of a pick-up point is invalid.
+// Nested fields are named with dots, e.g. address.city.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists invalid fields of a pick-up point, it matches ErrInvalidPoint.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = f.Field + " " + f.Message
+	}
+	return ErrInvalidPoint.Error() + ": " + strings.Join(fields, ", ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidPoint
+}
+
+type fieldErrors []FieldError
+
+func (f *fieldErrors) check(field string, err error) {
+	if err != nil {
+		*f = append(*f, FieldError{Field: field, Message: err.Error()})
+	}
+}
+
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: f}
+}
+
+// ValidateRequired checks that s is not blank.
+func ValidateRequired(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return errEmpty
+	}
+	return nil
+}
+
+// ValidateEmail checks that s is a bare email address without a display name.
+func ValidateEmail(s string) error {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return errInvalidEmail
+	}
+	return nil
+}
+
+// ValidatePhone checks that s is a phone number, spaces, hyphens and parentheses are ignored.
+func ValidatePhone(s string) error {
+	digits := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(s)
+	if !phoneRegexp.MatchString(digits) {
+		return errInvalidPhone
+	}
+	return nil
+}
+
+// ValidatePostcode checks that s looks like a postcode of any country.
+func ValidatePostcode(s string) error {
+	if !postcodeRegexp.MatchString(s) {
+		return errInvalidPostcode
+	}
+	return nil
+}
+
+// ValidateDetails checks the name, address and contact of a pick-up point,
+// returning a ValidationError listing all invalid fields.
+func ValidateDetails(name string, address Address, contact Contact) error {
+	var errs fieldErrors
+	errs.check("name", ValidateRequired(name))
+	address.check(&errs)
+	contact.check(&errs)
+	return errs.err()
+}
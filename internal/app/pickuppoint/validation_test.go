@@ -0,0 +1,87 @@
+package pickuppoint
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_ValidateDetails(t *testing.T) {
+	address := Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5", Postcode: "101000"}
+	tests := []struct {
+		name       string
+		pointName  string
+		address    Address
+		contact    Contact
+		wantFields []FieldError
+	}{
+		{
+			name:      "valid",
+			pointName: "point",
+			address:   address,
+			contact:   Contact{Email: "test@example.com", Phone: "+7 (495) 123-45-67"},
+		},
+		{
+			name:      "phone only",
+			pointName: "point",
+			address:   Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"},
+			contact:   Contact{Phone: "84951234567"},
+		},
+		{
+			name: "empty",
+			wantFields: []FieldError{
+				{Field: "name", Message: "must not be empty"},
+				{Field: "address.country", Message: "must not be empty"},
+				{Field: "address.city", Message: "must not be empty"},
+				{Field: "address.street", Message: "must not be empty"},
+				{Field: "address.building", Message: "must not be empty"},
+				{Field: "contact", Message: "either email or phone is required"},
+			},
+		},
+		{
+			name:      "malformed",
+			pointName: " ",
+			address:   Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5", Postcode: "#1"},
+			contact:   Contact{Email: "Test <test@example.com>", Phone: "call me"},
+			wantFields: []FieldError{
+				{Field: "name", Message: "must not be empty"},
+				{Field: "address.postcode", Message: "must be 3 to 10 letters, digits, spaces or hyphens"},
+				{Field: "contact.email", Message: "must be an email address like name@example.com"},
+				{Field: "contact.phone", Message: "must be a phone number of 7 to 15 digits, optionally starting with +"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDetails(tt.pointName, tt.address, tt.contact)
+			if tt.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidPoint)
+			var validationErr *ValidationError
+			if assert.ErrorAs(t, err, &validationErr) {
+				assert.Equal(t, tt.wantFields, validationErr.Fields)
+			}
+		})
+	}
+}
+
+func Test_LegacyDetailsJSON(t *testing.T) {
+	var point PickUpPoint
+	err := json.Unmarshal([]byte(`{"id":1,"name":"point","address":"5, Test st., Moscow","contact":"test@example.com"}`), &point)
+	assert.NoError(t, err)
+	assert.Equal(t, Address{Street: "5, Test st., Moscow"}, point.Address)
+	assert.Equal(t, Contact{Email: "test@example.com"}, point.Contact)
+
+	err = json.Unmarshal([]byte(`{"contact":"+74951234567"}`), &point)
+	assert.NoError(t, err)
+	assert.Equal(t, Contact{Phone: "+74951234567"}, point.Contact)
+}
+
+func Test_DetailsString(t *testing.T) {
+	assert.Equal(t, "5, Test st., Moscow, 101000, Russia",
+		Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5", Postcode: "101000"}.String())
+	assert.Equal(t, "test@example.com, +74951234567", Contact{Email: "test@example.com", Phone: "+74951234567"}.String())
+	assert.Equal(t, "+74951234567", Contact{Phone: "+74951234567"}.String())
+}
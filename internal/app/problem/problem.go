@@ -0,0 +1,42 @@
+package problem
+
+import (
+	"encoding/json"
+	"homework/internal/app/requestid"
+	"net/http"
+)
+
+// ContentType is the type of every error response body.
+const ContentType = "application/problem+json"
+
+// Problem is the body of every error response, in the spirit of RFC 9457 problem details.
+type Problem struct {
+	// Code tells the kind of the problem to programs, it does not change with the message.
+	Code    string  `json:"code"`
+	Message string  `json:"message"`
+	Fields  []Field `json:"fields,omitempty"`
+	// RequestId is the id of the request to find it in the logs.
+	RequestId string `json:"request_id,omitempty"`
+}
+
+// Field tells why a field of the request is invalid.
+type Field struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Response returns an error response with the status, for handlers returning their responses.
+func Response(req *http.Request, header http.Header, status int, p Problem) (int, []byte) {
+	p.RequestId = requestid.FromContext(req.Context())
+	// a problem is made of strings only and is always marshalled
+	body, _ := json.Marshal(p)
+	header.Set("Content-Type", ContentType)
+	return status, body
+}
+
+// Write writes an error response with the status, for middlewares writing their responses.
+func Write(w http.ResponseWriter, req *http.Request, status int, p Problem) {
+	status, body := Response(req, w.Header(), status, p)
+	w.WriteHeader(status)
+	w.Write(body)
+}
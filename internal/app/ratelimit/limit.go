@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New("rate limit must look like <requests>/<period>[:<burst>], e.g. 20/1s:40, or be 0")
+
+// Limit allows Requests per Period on average and up to Burst at once, the zero Limit allows anything.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// ParseLimit reads a limit written as <requests>/<period>[:<burst>], the burst is the number of requests by default.
+// "0" makes the zero Limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "0" {
+		return Limit{}, nil
+	}
+	rate, burstStr, hasBurst := strings.Cut(s, ":")
+	requestsStr, periodStr, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Limit{}, ErrInvalidLimit
+	}
+	requests, err := strconv.Atoi(requestsStr)
+	if err != nil || requests <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+	limit := Limit{Requests: requests, Period: period, Burst: requests}
+	if hasBurst {
+		limit.Burst, err = strconv.Atoi(burstStr)
+		if err != nil || limit.Burst <= 0 {
+			return Limit{}, ErrInvalidLimit
+		}
+	}
+	return limit, nil
+}
+
+// Unlimited reports whether the limit allows anything.
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Period <= 0 || l.Burst <= 0
+}
+
+// Rate returns how many requests are allowed per second on average.
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "0"
+	}
+	return fmt.Sprintf("%d/%s:%d", l.Requests, l.Period, l.Burst)
+}
+
+// Set parses the limit of a command line flag.
+func (l *Limit) Set(s string) error {
+	limit, err := ParseLimit(s)
+	if err != nil {
+		return err
+	}
+	*l = limit
+	return nil
+}
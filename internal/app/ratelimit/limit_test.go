@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Limit
+		wantErr bool
+	}{
+		{s: "20/1s:40", want: Limit{Requests: 20, Period: time.Second, Burst: 40}},
+		{s: "100/1m", want: Limit{Requests: 100, Period: time.Minute, Burst: 100}},
+		{s: "0", want: Limit{}},
+		{s: "20", wantErr: true},
+		{s: "0/1s", wantErr: true},
+		{s: "20/0s", wantErr: true},
+		{s: "20/1x", wantErr: true},
+		{s: "20/1s:0", wantErr: true},
+		{s: "20/1s:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			limit, err := ParseLimit(tt.s)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidLimit)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, limit)
+		})
+	}
+}
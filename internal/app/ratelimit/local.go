@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bucket holds tokens of a key, a request takes one and they are refilled at the rate of the limit up to its burst.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// refill adds tokens earned since the bucket was last updated.
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate())
+		b.updated = now
+	}
+}
+
+// Local limits requests of a single process with token buckets kept in memory.
+type Local struct {
+	buckets           map[string]*bucket
+	mutex             sync.Mutex
+	collectorInterval time.Duration
+	now               func() time.Time
+}
+
+func NewLocal(collectorInterval time.Duration) *Local {
+	return &Local{
+		buckets:           make(map[string]*bucket),
+		collectorInterval: collectorInterval,
+		now:               time.Now,
+	}
+}
+
+// Run drops buckets refilled to their burst every collector interval, as they are no different from new ones.
+func (l *Local) Run(ctx context.Context) error {
+	t := time.NewTicker(l.collectorInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			l.collect()
+		}
+	}
+}
+
+func (l *Local) collect() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Allow takes a token from the bucket of the key, when there is none it tells how long to wait for one.
+func (l *Local) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Burst), updated: now, limit: limit}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / limit.Rate() * float64(time.Second)), nil
+}
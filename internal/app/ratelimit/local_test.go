@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLocalAllow(t *testing.T) {
+	now := time.Date(2024, 7, 17, 12, 0, 0, 0, time.UTC)
+	l := NewLocal(time.Minute)
+	l.now = func() time.Time {
+		return now
+	}
+	limit := Limit{Requests: 2, Period: time.Second, Burst: 3}
+	allow := func(key string) (bool, time.Duration) {
+		allowed, retryAfter, err := l.Allow(context.Background(), key, limit)
+		assert.NoError(t, err)
+		return allowed, retryAfter
+	}
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := allow("a")
+		assert.True(t, allowed, "burst request %d", i)
+	}
+	allowed, retryAfter := allow("a")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	allowed, _ = allow("b")
+	assert.True(t, allowed, "keys have their own buckets")
+
+	now = now.Add(250 * time.Millisecond)
+	allowed, retryAfter = allow("a")
+	assert.False(t, allowed)
+	assert.Equal(t, 250*time.Millisecond, retryAfter)
+
+	now = now.Add(250 * time.Millisecond)
+	allowed, _ = allow("a")
+	assert.True(t, allowed)
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		allowed, _ := allow("a")
+		assert.True(t, allowed, "tokens are refilled up to the burst only")
+	}
+	allowed, _ = allow("a")
+	assert.False(t, allowed)
+}
+
+func TestLocalUnlimited(t *testing.T) {
+	l := NewLocal(time.Minute)
+	for i := 0; i < 100; i++ {
+		allowed, _, err := l.Allow(context.Background(), "a", Limit{})
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+}
+
+func TestLocalCollect(t *testing.T) {
+	now := time.Date(2024, 7, 17, 12, 0, 0, 0, time.UTC)
+	l := NewLocal(time.Minute)
+	l.now = func() time.Time {
+		return now
+	}
+	limit := Limit{Requests: 1, Period: time.Second, Burst: 1}
+	_, _, _ = l.Allow(context.Background(), "a", limit)
+	l.collect()
+	assert.Len(t, l.buckets, 1, "buckets still refilling are kept")
+
+	now = now.Add(time.Second)
+	l.collect()
+	assert.Empty(t, l.buckets)
+}
@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"homework/internal/app/idempotency"
+	"time"
+)
+
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
+
+// Start stores a new record expiring when it says unless its key is used.
+func (r *Redis) Start(ctx context.Context, rec idempotency.Record, now time.Time) (idempotency.Record, bool, error) {
+	bytes, err := json.Marshal(rec)
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	started, err := r.client.SetNX(ctx, idempotencyKey(rec.Key), bytes, rec.ExpiresAt.Sub(now)).Result()
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	if started {
+		return rec, true, nil
+	}
+	item, err := r.client.Get(ctx, idempotencyKey(rec.Key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return idempotency.Record{}, false, idempotency.ErrNoRecord
+	}
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	var stored idempotency.Record
+	err = json.Unmarshal(item, &stored)
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	stored.Key = rec.Key
+	return stored, false, nil
+}
+
+// Finish replaces the record along with its expiration.
+func (r *Redis) Finish(ctx context.Context, rec idempotency.Record) error {
+	bytes, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	err = r.client.SetArgs(ctx, idempotencyKey(rec.Key), bytes, redis.SetArgs{Mode: "XX", ExpireAt: rec.ExpiresAt}).Err()
+	if errors.Is(err, redis.Nil) {
+		return idempotency.ErrNoRecord
+	}
+	return err
+}
+
+// Abort deletes the record of the key.
+func (r *Redis) Abort(ctx context.Context, key string) error {
+	return r.client.Del(ctx, idempotencyKey(key)).Err()
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"homework/internal/app/ratelimit"
+	"strconv"
+	"time"
+)
+
+// allowScript takes a token from the bucket at KEYS[1] refilled with ARGV[1] tokens per millisecond up to ARGV[2].
+// It returns the number of milliseconds to wait for a token, 0 when one was taken.
+// Time is read from the server so that instances with skewed clocks share a bucket fairly.
+var allowScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+	tokens = burst
+	updated = now
+end
+if now > updated then
+	tokens = math.min(burst, tokens + (now - updated) * rate)
+	updated = now
+end
+
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", updated)
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return wait
+`)
+
+// Allow takes a token from the bucket of the key shared by every instance using the same Redis,
+// when there is none it tells how long to wait for one.
+func (r *Redis) Allow(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+	perMillisecond := limit.Rate() / 1000
+	wait, err := allowScript.Run(ctx, r.client, []string{"ratelimit:" + key},
+		strconv.FormatFloat(perMillisecond, 'g', -1, 64), limit.Burst).Int64()
+	if err != nil {
+		return false, 0, err
+	}
+	return wait == 0, time.Duration(wait) * time.Millisecond, nil
+}
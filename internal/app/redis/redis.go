@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/redis/go-redis/v9"
+	"homework/internal/app/metrics"
 	"homework/internal/app/pickuppoint"
 	"time"
 )
@@ -14,47 +15,37 @@ type Redis struct {
 }
 
 func NewRedis(opt *redis.Options, ttl time.Duration) *Redis {
+	client := redis.NewClient(opt)
+	client.AddHook(tracingHook{})
 	return &Redis{
-		redis.NewClient(opt),
+		client,
 		ttl,
 	}
 }
 
-func (r *Redis) GetPointList(ctx context.Context) ([]pickuppoint.PickUpPoint, error) {
-	err := r.client.Get(ctx, "points_valid").Err()
+// pageKey keeps a page listed with different options under its own key.
+func pageKey(opts pickuppoint.ListOptions) string {
+	return "points:" + opts.Key()
+}
+
+func (r *Redis) GetPointPage(ctx context.Context, opts pickuppoint.ListOptions) (pickuppoint.Page, error) {
+	item, err := r.client.Get(ctx, pageKey(opts)).Bytes()
+	metrics.Lookup(metrics.RedisCache, err == nil, commandError(err))
 	if err != nil {
-		return nil, err
+		return pickuppoint.Page{}, err
 	}
-	items, err := r.client.LRange(ctx, "points", 0, -1).Result()
+	var page pickuppoint.Page
+	err = json.Unmarshal(item, &page)
 	if err != nil {
-		return nil, err
+		return pickuppoint.Page{}, err
 	}
-	points := make([]pickuppoint.PickUpPoint, len(items))
-	for i, item := range items {
-		err := json.Unmarshal([]byte(item), &(points[i]))
-		if err != nil {
-			return nil, err
-		}
-	}
-	return points, nil
+	return page, nil
 }
 
-func (r *Redis) SetPointList(ctx context.Context, points []pickuppoint.PickUpPoint) error {
-	err := r.client.Del(ctx, "points").Err()
-	if err != nil {
-		return err
-	}
-	values := make([]interface{}, len(points))
-	for i, point := range points {
-		bytes, err := json.Marshal(point)
-		if err != nil {
-			return err
-		}
-		values[i] = string(bytes)
-	}
-	err = r.client.RPush(ctx, "points", values...).Err()
+func (r *Redis) SetPointPage(ctx context.Context, opts pickuppoint.ListOptions, page pickuppoint.Page) error {
+	bytes, err := json.Marshal(page)
 	if err != nil {
 		return err
 	}
-	return r.client.Set(ctx, "points_valid", true, r.ttl).Err()
+	return r.client.Set(ctx, pageKey(opts), bytes, r.ttl).Err()
 }
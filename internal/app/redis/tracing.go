@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"homework/internal/app/tracing"
+	"net"
+)
+
+// tracingHook traces every command sent as a span named by the command, its arguments are not recorded.
+type tracingHook struct{}
+
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, span := tracing.Start(ctx, "dial", trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis))
+		conn, err := next(ctx, network, addr)
+		tracing.End(span, err)
+		return conn, err
+	}
+}
+
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := tracing.Start(ctx, cmd.Name(), trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperation(cmd.Name())))
+		err := next(ctx, cmd)
+		tracing.End(span, commandError(err))
+		return err
+	}
+}
+
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := tracing.Start(ctx, "pipeline", trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperation("pipeline")))
+		err := next(ctx, cmds)
+		tracing.End(span, commandError(err))
+		return err
+	}
+}
+
+// commandError returns err unless it only tells that a key is missing, which is a cache miss rather than a failure.
+func commandError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
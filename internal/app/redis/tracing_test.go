@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"homework/internal/app/tracing"
+	"testing"
+)
+
+func TestTracingHook(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+	}{
+		{
+			name:     "ok",
+			wantCode: codes.Unset,
+		},
+		{
+			name:     "missing key",
+			err:      redis.Nil,
+			wantCode: codes.Unset,
+		},
+		{
+			name:     "failed",
+			err:      errors.New("connection refused"),
+			wantCode: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracing.InMemory()
+			process := tracingHook{}.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+				return tt.err
+			})
+
+			err := process(context.Background(), redis.NewStringCmd(context.Background(), "get", "points:secret"))
+
+			assert.Equal(t, tt.err, err)
+			spans := exporter.GetSpans()
+			assert.Len(t, spans, 1)
+			assert.Equal(t, "get", spans[0].Name)
+			assert.Equal(t, tt.wantCode, spans[0].Status.Code)
+			for _, attr := range spans[0].Attributes {
+				assert.NotContains(t, attr.Value.Emit(), "secret", "arguments are not recorded")
+			}
+		})
+	}
+}
@@ -1,13 +1,15 @@
 package reqlog
 
 import (
+	"context"
 	"encoding/json"
 	"homework/internal/app/kafka"
 	"homework/internal/app/logger"
 )
 
 func LogHandler(log logger.Logger) kafka.MessageHandler {
-	return func(bytes []byte) {
+	return func(ctx context.Context, bytes []byte) {
+		log := logger.WithContext(ctx, log)
 		var msg Message
 		err := json.Unmarshal(bytes, &msg)
 		if err != nil {
@@ -12,4 +12,7 @@ type Message struct {
 	Headers   http.Header
 	Params    map[string]string
 	Body      string
+	// RequestId and Traceparent tell the request apart and link it to its trace, they are also sent as message headers.
+	RequestId   string
+	Traceparent string
 }
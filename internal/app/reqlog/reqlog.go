@@ -1,6 +1,7 @@
 package reqlog
 
 import (
+	"context"
 	"encoding/json"
 	"homework/internal/app/kafka"
 )
@@ -14,11 +15,11 @@ func NewLogger(producer *kafka.Producer, consumer *kafka.Consumer) *Logger {
 	return &Logger{producer: producer, consumer: consumer}
 }
 
-func (l *Logger) Log(msg Message) {
+func (l *Logger) Log(ctx context.Context, msg Message) {
 	bytes, err := json.Marshal(msg)
 	if err != nil {
 		return
 	}
 
-	l.producer.SendMessage(bytes)
+	l.producer.SendMessage(ctx, bytes)
 }
@@ -0,0 +1,67 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// Header carries the id of a request chosen by the client or the server.
+	Header = "X-Request-Id"
+	// TraceparentHeader carries the W3C trace context of a request.
+	TraceparentHeader = "traceparent"
+
+	maxIdLen = 128
+)
+
+type contextKey struct{}
+
+// ids of a request carried by its context.
+type ids struct {
+	id          string
+	traceparent Traceparent
+}
+
+// With returns a context of the request with the id and the trace context.
+func With(ctx context.Context, id string, traceparent Traceparent) context.Context {
+	return context.WithValue(ctx, contextKey{}, ids{id: id, traceparent: traceparent})
+}
+
+// FromContext returns the id of the request of ctx, empty when there is none.
+func FromContext(ctx context.Context) string {
+	ids, _ := ctx.Value(contextKey{}).(ids)
+	return ids.id
+}
+
+// TraceparentFromContext returns the trace context of the request of ctx.
+func TraceparentFromContext(ctx context.Context) (Traceparent, bool) {
+	ids, ok := ctx.Value(contextKey{}).(ids)
+	return ids.traceparent, ok
+}
+
+// Valid reports whether a request id given by a client may be used: it must be short and printable with no spaces,
+// so that it is safe to put into logs and headers.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxIdLen {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// random returns n random bytes, it never fails as crypto/rand does not.
+func random(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return b
+}
+
+// New returns a random request id.
+func New() string {
+	return hex.EncodeToString(random(16))
+}
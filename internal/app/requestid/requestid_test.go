@@ -0,0 +1,32 @@
+package requestid
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("6f1d2c1e-5b0a-4c1e-9a8e-2f0c1d3b4a5e"))
+	assert.True(t, Valid(New()))
+	assert.False(t, Valid(""))
+	assert.False(t, Valid("with space"))
+	assert.False(t, Valid("line\nbreak"))
+	assert.False(t, Valid("не ascii"))
+	assert.False(t, Valid(strings.Repeat("a", maxIdLen+1)))
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, FromContext(ctx))
+	_, ok := TraceparentFromContext(ctx)
+	assert.False(t, ok)
+
+	traceparent := NewTraceparent()
+	ctx = With(ctx, "42", traceparent)
+	assert.Equal(t, "42", FromContext(ctx))
+	got, ok := TraceparentFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, traceparent, got)
+}
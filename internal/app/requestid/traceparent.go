@@ -0,0 +1,78 @@
+package requestid
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidTraceparent = errors.New("traceparent must look like 00-<32 hex digits>-<16 hex digits>-<2 hex digits>")
+
+const traceparentLen = 55
+
+// Traceparent is the W3C trace context of a request: the trace it belongs to and the span that made it.
+type Traceparent struct {
+	TraceId  [16]byte
+	ParentId [8]byte
+	Flags    byte
+}
+
+// NewTraceparent starts a new sampled trace.
+func NewTraceparent() Traceparent {
+	var t Traceparent
+	copy(t.TraceId[:], random(len(t.TraceId)))
+	copy(t.ParentId[:], random(len(t.ParentId)))
+	t.Flags = 1
+	return t
+}
+
+// ParseTraceparent reads a traceparent header.
+// Versions newer than 00 are read as 00, as the specification requires, ff is invalid.
+func ParseTraceparent(s string) (Traceparent, error) {
+	if len(s) < traceparentLen || len(s) > traceparentLen && (s[:2] == "00" || s[traceparentLen] != '-') {
+		return Traceparent{}, ErrInvalidTraceparent
+	}
+	parts := strings.Split(s[:traceparentLen], "-")
+	if len(parts) != 4 || parts[0] == "ff" {
+		return Traceparent{}, ErrInvalidTraceparent
+	}
+	var t Traceparent
+	var version, flags [1]byte
+	for _, part := range []struct {
+		s   string
+		dst []byte
+	}{
+		{parts[0], version[:]},
+		{parts[1], t.TraceId[:]},
+		{parts[2], t.ParentId[:]},
+		{parts[3], flags[:]},
+	} {
+		// upper case digits are not allowed
+		if len(part.s) != 2*len(part.dst) || strings.ToLower(part.s) != part.s {
+			return Traceparent{}, ErrInvalidTraceparent
+		}
+		if _, err := hex.Decode(part.dst, []byte(part.s)); err != nil {
+			return Traceparent{}, ErrInvalidTraceparent
+		}
+	}
+	if t.TraceId == [16]byte{} || t.ParentId == [8]byte{} {
+		return Traceparent{}, ErrInvalidTraceparent
+	}
+	t.Flags = flags[0]
+	return t, nil
+}
+
+// Child returns the trace context of a span made by the span of t in the same trace.
+func (t Traceparent) Child() Traceparent {
+	copy(t.ParentId[:], random(len(t.ParentId)))
+	return t
+}
+
+// TraceIdString returns the trace id as hex digits.
+func (t Traceparent) TraceIdString() string {
+	return hex.EncodeToString(t.TraceId[:])
+}
+
+func (t Traceparent) String() string {
+	return "00-" + hex.EncodeToString(t.TraceId[:]) + "-" + hex.EncodeToString(t.ParentId[:]) + "-" + hex.EncodeToString([]byte{t.Flags})
+}
@@ -0,0 +1,50 @@
+package requestid
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		wantErr bool
+	}{
+		{name: "sampled", s: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "not sampled", s: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{name: "future version", s: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "empty", s: "", wantErr: true},
+		{name: "version 00 with more fields", s: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: true},
+		{name: "invalid version", s: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "upper case", s: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "zero trace id", s: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{name: "zero parent id", s: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		{name: "not hex", s: "00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01", wantErr: true},
+		{name: "misplaced dash", s: "00-4bf92f3577b34da6a3ce929d0e0e47360-0f067aa0ba902b7-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceparent, err := ParseTraceparent(tt.s)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidTraceparent)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceparent.TraceIdString())
+			assert.Equal(t, "00"+tt.s[2:55], traceparent.String())
+		})
+	}
+}
+
+func TestTraceparentChild(t *testing.T) {
+	parent := NewTraceparent()
+	child := parent.Child()
+	assert.Equal(t, parent.TraceId, child.TraceId)
+	assert.Equal(t, parent.Flags, child.Flags)
+	assert.NotEqual(t, parent.ParentId, child.ParentId)
+
+	parsed, err := ParseTraceparent(child.String())
+	assert.NoError(t, err)
+	assert.Equal(t, child, parsed)
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/app/order"
+	"homework/internal/app/pickuppoint"
+	"time"
+)
+
+// MigrationCounts contains numbers of items processed by Migrate.
+type MigrationCounts struct {
+	Source    int
+	Created   int
+	Updated   int
+	Unchanged int
+	Verified  int
+}
+
+// MigrationReport contains results of Migrate.
+type MigrationReport struct {
+	Points MigrationCounts
+	Orders MigrationCounts
+}
+
+// Migrate copies pick-up points and orders from one storage to another.
+// Items already present in the destination are updated, so running it again is safe.
+// After copying, every item is read back from the destination and compared to the source.
+func Migrate(ctx context.Context, from *Storage, to *Storage) (MigrationReport, error) {
+	var report MigrationReport
+	var err error
+	report.Points, err = migratePoints(ctx, from, to)
+	if err != nil {
+		return report, fmt.Errorf("pick-up points: %w", err)
+	}
+	report.Orders, err = migrateOrders(ctx, from, to)
+	if err != nil {
+		return report, fmt.Errorf("orders: %w", err)
+	}
+	return report, nil
+}
+
+func migratePoints(ctx context.Context, from *Storage, to *Storage) (MigrationCounts, error) {
+	var counts MigrationCounts
+	var points []pickuppoint.PickUpPoint
+	err := from.Tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		var err error
+		points, err = from.Points.List(ctxTX, pickuppoint.ListOptions{IncludeDeleted: true})
+		return err
+	})
+	if err != nil {
+		return counts, err
+	}
+	err = to.Tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		counts = MigrationCounts{Source: len(points)}
+		for _, point := range points {
+			existing, err := to.Points.Get(ctxTX, point.Id)
+			switch {
+			case errors.Is(err, pickuppoint.ErrNoItemFound):
+				_, err = to.Points.Create(ctxTX, point)
+				counts.Created++
+			case err != nil:
+			case existing.Equal(point):
+				counts.Unchanged++
+			default:
+				err = to.Points.Update(ctxTX, point)
+				counts.Updated++
+			}
+			if err != nil {
+				return fmt.Errorf("id %d: %w", point.Id, err)
+			}
+		}
+		for _, point := range points {
+			migrated, err := to.Points.Get(ctxTX, point.Id)
+			if err != nil {
+				return fmt.Errorf("verifying id %d: %w", point.Id, err)
+			}
+			if !migrated.Equal(point) {
+				return fmt.Errorf("verifying id %d: stored point differs from source", point.Id)
+			}
+			counts.Verified++
+		}
+		return nil
+	})
+	return counts, err
+}
+
+func migrateOrders(ctx context.Context, from *Storage, to *Storage) (MigrationCounts, error) {
+	var counts MigrationCounts
+	var orders []order.Order
+	err := from.Tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		var err error
+		orders, err = from.Orders.List(ctxTX)
+		return err
+	})
+	if err != nil {
+		return counts, err
+	}
+	err = to.Tm.RunSerializable(ctx, func(ctxTX context.Context) error {
+		counts = MigrationCounts{Source: len(orders)}
+		for _, o := range orders {
+			existing, err := to.Orders.Get(ctxTX, o.Id)
+			switch {
+			case errors.Is(err, order.ErrNoItemFound):
+				err = to.Orders.Create(ctxTX, o)
+				counts.Created++
+			case err != nil:
+			case sameOrder(existing, o):
+				counts.Unchanged++
+			default:
+				err = to.Orders.Update(ctxTX, o)
+				counts.Updated++
+			}
+			if err != nil {
+				return fmt.Errorf("id %d: %w", o.Id, err)
+			}
+		}
+		for _, o := range orders {
+			migrated, err := to.Orders.Get(ctxTX, o.Id)
+			if err != nil {
+				return fmt.Errorf("verifying id %d: %w", o.Id, err)
+			}
+			if !sameOrder(migrated, o) {
+				return fmt.Errorf("verifying id %d: stored order differs from source", o.Id)
+			}
+			counts.Verified++
+		}
+		return nil
+	})
+	return counts, err
+}
+
+// sameOrder compares orders ignoring time zones and sub-microsecond precision,
+// which are not preserved by every backend.
+func sameOrder(a, b order.Order) bool {
+	for _, o := range []*order.Order{&a, &b} {
+		o.AddDate = o.AddDate.UTC().Truncate(time.Microsecond)
+		o.KeepDate = o.KeepDate.UTC().Truncate(time.Microsecond)
+		o.GiveDate = o.GiveDate.UTC().Truncate(time.Microsecond)
+		o.ReturnDate = o.ReturnDate.UTC().Truncate(time.Microsecond)
+	}
+	return a == b
+}
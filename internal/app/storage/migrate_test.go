@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"homework/internal/app/order"
+	"homework/internal/app/pickuppoint"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type MigrateTestSuite struct {
+	suite.Suite
+	from *Storage
+	to   *Storage
+}
+
+func (s *MigrateTestSuite) SetupTest() {
+	dir := s.T().TempDir()
+	var err error
+	s.from, err = Open(context.Background(), Config{
+		Type:        File,
+		OrdersFile:  filepath.Join(dir, "orders.json"),
+		PointsFile:  filepath.Join(dir, "points.json"),
+		HistoryFile: filepath.Join(dir, "points_history.json"),
+		UsersFile:   filepath.Join(dir, "users.json"),
+		KeysFile:    filepath.Join(dir, "api_keys.json"),
+		RevokedFile: filepath.Join(dir, "revoked_tokens.json"),
+		FilePerm:    0600,
+	})
+	s.Require().NoError(err)
+	s.to, err = Open(context.Background(), Config{
+		Type:     Bolt,
+		BoltFile: filepath.Join(dir, "storage.db"),
+		FilePerm: 0600,
+	})
+	s.Require().NoError(err)
+}
+
+func (s *MigrateTestSuite) TearDownTest() {
+	s.NoError(s.from.Close())
+	s.NoError(s.to.Close())
+}
+
+func (s *MigrateTestSuite) Test_Migrate() {
+	ctx := context.Background()
+	point := pickuppoint.PickUpPoint{Id: 1, Name: "Generic pick-up point", Address: pickuppoint.Address{Country: "Russia", City: "Moscow", Street: "Test st.", Building: "5"}, Contact: pickuppoint.Contact{Email: "test@example.com"}}
+	_, err := s.from.Points.Create(ctx, point)
+	s.NoError(err)
+	_, err = s.from.Points.Create(ctx, pickuppoint.PickUpPoint{Id: 2, Name: "Another pick-up point"})
+	s.NoError(err)
+	now := time.Now()
+	s.NoError(s.from.Orders.Create(ctx, order.Order{Id: 1, CustomerId: 1, AddDate: now, KeepDate: now.Add(time.Hour)}))
+
+	report, err := Migrate(ctx, s.from, s.to)
+	s.NoError(err)
+	s.Equal(MigrationReport{
+		Points: MigrationCounts{Source: 2, Created: 2, Verified: 2},
+		Orders: MigrationCounts{Source: 1, Created: 1, Verified: 1},
+	}, report)
+
+	point.Contact = pickuppoint.Contact{Email: "changed@example.com"}
+	s.NoError(s.from.Points.Update(ctx, point))
+	report, err = Migrate(ctx, s.from, s.to)
+	s.NoError(err)
+	s.Equal(MigrationReport{
+		Points: MigrationCounts{Source: 2, Updated: 1, Unchanged: 1, Verified: 2},
+		Orders: MigrationCounts{Source: 1, Unchanged: 1, Verified: 1},
+	}, report)
+
+	migrated, err := s.to.Points.Get(ctx, 1)
+	s.NoError(err)
+	s.Equal(point, migrated)
+}
+
+func (s *MigrateTestSuite) Test_UnknownStorage() {
+	_, err := Open(context.Background(), Config{Type: "tape"})
+	s.Error(err)
+}
+
+func TestMigrate(t *testing.T) {
+	suite.Run(t, new(MigrateTestSuite))
+}
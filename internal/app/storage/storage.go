@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/multierr"
+	"homework/internal/app/auth"
+	"homework/internal/app/db"
+	"homework/internal/app/idempotency"
+	"homework/internal/app/kvstore"
+	"homework/internal/app/order"
+	"homework/internal/app/pickuppoint"
+	"io"
+	"os"
+)
+
+const (
+	File     = "file"
+	Bolt     = "bolt"
+	Postgres = "postgres"
+)
+
+// Config describes where orders and pick-up points are stored.
+type Config struct {
+	Type       string
+	OrdersFile string
+	PointsFile string
+	// HistoryFile keeps the history of pick-up point changes with file storage.
+	HistoryFile string
+	// UsersFile keeps users allowed to use the API with file storage.
+	UsersFile string
+	// KeysFile keeps API keys of users with file storage.
+	KeysFile string
+	// RevokedFile keeps ids of revoked access tokens with file storage.
+	RevokedFile string
+	BoltFile    string
+	FilePerm    os.FileMode
+}
+
+// Storage provides repositories of a storage backend.
+type Storage struct {
+	Points  pickuppoint.Repository
+	History pickuppoint.HistoryRepository
+	Tm      pickuppoint.TransactionManager
+	Orders  order.Repository
+	Users   auth.Repository
+	Keys    auth.KeyRepository
+	Revoked auth.RevocationList
+	// Idempotency keeps responses to requests with idempotency keys, only PostgreSQL provides it.
+	Idempotency idempotency.Store
+	closers     []io.Closer
+}
+
+// Open opens the storage backend selected by cfg.
+func Open(ctx context.Context, cfg Config) (*Storage, error) {
+	switch cfg.Type {
+	case File:
+		return openFile(cfg)
+	case Bolt:
+		return openBolt(cfg)
+	case Postgres:
+		return openPostgres(ctx)
+	default:
+		return nil, fmt.Errorf("unknown storage %q, expected one of %s, %s, %s", cfg.Type, File, Bolt, Postgres)
+	}
+}
+
+func openFile(cfg Config) (*Storage, error) {
+	points, err := pickuppoint.OpenFileRepository(cfg.PointsFile, cfg.FilePerm)
+	if err != nil {
+		return nil, err
+	}
+	history, err := pickuppoint.OpenFileHistoryRepository(cfg.HistoryFile, cfg.FilePerm)
+	if err != nil {
+		return nil, multierr.Combine(err, points.Close())
+	}
+	orders, err := order.OpenFileRepository(cfg.OrdersFile, cfg.FilePerm)
+	if err != nil {
+		return nil, multierr.Combine(err, points.Close(), history.Close())
+	}
+	users, err := auth.OpenFileRepository(cfg.UsersFile, cfg.FilePerm)
+	if err != nil {
+		return nil, multierr.Combine(err, points.Close(), history.Close(), orders.Close())
+	}
+	keys, err := auth.OpenFileKeyRepository(cfg.KeysFile, cfg.FilePerm)
+	if err != nil {
+		return nil, multierr.Combine(err, points.Close(), history.Close(), orders.Close(), users.Close())
+	}
+	revoked, err := auth.OpenFileRevocationList(cfg.RevokedFile, cfg.FilePerm)
+	if err != nil {
+		return nil, multierr.Combine(err, points.Close(), history.Close(), orders.Close(), users.Close(), keys.Close())
+	}
+	return &Storage{
+		Points:  points,
+		History: history,
+		Tm:      db.Dummy{},
+		Orders:  orders,
+		Users:   users,
+		Keys:    keys,
+		Revoked: revoked,
+		closers: []io.Closer{points, history, orders, users, keys, revoked},
+	}, nil
+}
+
+func openBolt(cfg Config) (*Storage, error) {
+	kv, err := kvstore.Open(cfg.BoltFile, cfg.FilePerm)
+	if err != nil {
+		return nil, err
+	}
+	points, err := pickuppoint.NewBoltRepository(kv)
+	if err != nil {
+		return nil, multierr.Combine(err, kv.Close())
+	}
+	history, err := pickuppoint.NewBoltHistoryRepository(kv)
+	if err != nil {
+		return nil, multierr.Combine(err, kv.Close())
+	}
+	orders, err := order.NewBoltRepository(kv)
+	if err != nil {
+		return nil, multierr.Combine(err, kv.Close())
+	}
+	users, err := auth.NewBoltRepository(kv)
+	if err != nil {
+		return nil, multierr.Combine(err, kv.Close())
+	}
+	keys, err := auth.NewBoltKeyRepository(kv)
+	if err != nil {
+		return nil, multierr.Combine(err, kv.Close())
+	}
+	revoked, err := auth.NewBoltRevocationList(kv)
+	if err != nil {
+		return nil, multierr.Combine(err, kv.Close())
+	}
+	return &Storage{
+		Points:  points,
+		History: history,
+		Tm:      kv,
+		Orders:  orders,
+		Users:   users,
+		Keys:    keys,
+		Revoked: revoked,
+		closers: []io.Closer{kv},
+	}, nil
+}
+
+func openPostgres(ctx context.Context) (*Storage, error) {
+	tm, err := db.NewTransactionManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+	database := db.NewDatabase(tm)
+	return &Storage{
+		Points:      pickuppoint.NewPostgresRepository(database),
+		History:     pickuppoint.NewPostgresHistoryRepository(database),
+		Tm:          tm,
+		Orders:      order.NewPostgresRepository(database),
+		Users:       auth.NewPostgresRepository(database),
+		Keys:        auth.NewPostgresKeyRepository(database),
+		Revoked:     auth.NewPostgresRevocationList(database),
+		Idempotency: idempotency.NewPostgresStore(database),
+		closers:     []io.Closer{tm},
+	}, nil
+}
+
+// Close releases the storage backend.
+func (s *Storage) Close() error {
+	var err error
+	for _, c := range s.closers {
+		err = multierr.Append(err, c.Close())
+	}
+	return err
+}
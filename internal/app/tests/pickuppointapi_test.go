@@ -4,14 +4,18 @@ package tests
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/stretchr/testify/suite"
 	"homework/cmd/app/httpserv"
+	"homework/internal/app/auth"
 	"homework/internal/app/core"
 	"homework/internal/app/db"
 	"homework/internal/app/logger"
 	"homework/internal/app/pickuppoint"
+	"homework/internal/app/problem"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -23,6 +27,18 @@ type PickUpPointApiIntegrationTestSuite struct {
 	h       *httpserv.PickUpPointHandlers
 }
 
+// assertBody checks the body of a response, error responses must be problems unless another body is wanted.
+func (s *PickUpPointApiIntegrationTestSuite) assertBody(wantCode int, wantBody []byte, body []byte) {
+	if wantBody == nil && wantCode >= http.StatusBadRequest {
+		var p problem.Problem
+		s.Require().NoError(json.Unmarshal(body, &p))
+		s.NotEmpty(p.Code)
+		s.NotEmpty(p.Message)
+		return
+	}
+	s.Equal(wantBody, body)
+}
+
 func (s *PickUpPointApiIntegrationTestSuite) SetupSuite() {
 	var err error
 	tm, err := db.NewTransactionManager(context.Background())
@@ -32,6 +48,7 @@ func (s *PickUpPointApiIntegrationTestSuite) SetupSuite() {
 	s.db = db.NewDatabase(tm)
 	repo := pickuppoint.NewPostgresRepository(s.db)
 	svc := pickuppoint.NewService(repo, tm)
+	svc.SetHistory(pickuppoint.NewPostgresHistoryRepository(s.db))
 	log := logger.NewLogger()
 	var ctx context.Context
 	ctx, s.stopLog = context.WithCancel(context.Background())
@@ -46,8 +63,12 @@ func (s *PickUpPointApiIntegrationTestSuite) TearDownSuite() {
 
 func (s *PickUpPointApiIntegrationTestSuite) SetupTest() {
 	_, err := s.db.Exec(context.Background(), `INSERT INTO pickup_points VALUES
-                              (1, 'Generic pick-up point', '5, Test st., Moscow', 'test@example.com'),
-                              (2, 'Another pick-up point', '19, Sample st., Moscow', 'sample@example.com');`)
+                              (1, 'Generic pick-up point', '{"country":"Russia","city":"Moscow","street":"Test st.","building":"5"}', '{"email":"test@example.com"}'),
+                              (2, 'Another pick-up point', '{"country":"Russia","city":"Moscow","street":"Sample st.","building":"19"}', '{"email":"sample@example.com"}');`)
+	if err != nil {
+		panic(err)
+	}
+	_, err = s.db.Exec(context.Background(), "SELECT setval(pg_get_serial_sequence('pickup_points', 'id'), 2);")
 	if err != nil {
 		panic(err)
 	}
@@ -58,38 +79,66 @@ func (s *PickUpPointApiIntegrationTestSuite) TearDownTest() {
 	if err != nil {
 		panic(err)
 	}
+	_, err = s.db.Exec(context.Background(), "DELETE FROM pickup_points_history;")
+	if err != nil {
+		panic(err)
+	}
 }
 
 func (s *PickUpPointApiIntegrationTestSuite) Test_CreateHandler() {
 	tests := []struct {
 		name     string
+		target   string
 		reqBody  string
 		wantCode int
 		wantBody []byte
 	}{
 		{
 			name:     "ok",
-			reqBody:  "{\"id\":3,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
+			target:   "/pickup-point",
+			reqBody:  "{\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			wantCode: http.StatusCreated,
+			wantBody: []byte("{\"id\":3,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":1}"),
+		},
+		{
+			name:     "import",
+			target:   "/pickup-point?mode=import",
+			reqBody:  "{\"id\":10,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
 			wantCode: http.StatusCreated,
-			wantBody: []byte("{\"id\":3,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}"),
+			wantBody: []byte("{\"id\":10,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":1}"),
 		},
 		{
 			name:     "invalid body",
+			target:   "/pickup-point",
 			reqBody:  "lhlihiuhilnjiklhni",
 			wantCode: http.StatusBadRequest,
 		},
+		{
+			name:     "unexpected id",
+			target:   "/pickup-point",
+			reqBody:  "{\"id\":3,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
+			wantCode: http.StatusBadRequest,
+		},
 		{
 			name:     "existing id",
-			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
+			target:   "/pickup-point?mode=import",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
 			wantCode: http.StatusConflict,
 		},
+		{
+			name:     "invalid details",
+			target:   "/pickup-point",
+			reqBody:  "{\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\"},\"contact\":{\"email\":\"test\"}}",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: []byte("{\"code\":\"invalid_point\",\"message\":\"invalid pick-up point\",\"fields\":[{\"field\":\"address.building\",\"message\":\"must not be empty\"},{\"field\":\"contact.email\",\"message\":\"must be an email address like name@example.com\"}]}"),
+		},
 	}
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			req := httptest.NewRequest(http.MethodPost, "/pickup-points", strings.NewReader(tt.reqBody))
-			code, body := s.h.CreateHandler(req, nil)
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.reqBody))
+			code, body := s.h.CreateHandler(req, nil, http.Header{})
 			s.Equal(tt.wantCode, code)
-			s.Equal(tt.wantBody, body)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
 		})
 	}
 }
@@ -97,21 +146,39 @@ func (s *PickUpPointApiIntegrationTestSuite) Test_CreateHandler() {
 func (s *PickUpPointApiIntegrationTestSuite) Test_ListHandler() {
 	tests := []struct {
 		name     string
+		target   string
 		wantCode int
 		wantBody []byte
+		wantNext bool
 	}{
 		{
 			name:     "ok",
+			target:   "/pickup-point",
+			wantCode: http.StatusOK,
+			wantBody: []byte("[{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":1},{\"id\":2,\"name\":\"Another pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Sample st.\",\"building\":\"19\"},\"contact\":{\"email\":\"sample@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":1}]"),
+		},
+		{
+			name:     "search",
+			target:   "/pickup-point?search=SAMPLE%20st",
 			wantCode: http.StatusOK,
-			wantBody: []byte("[{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"},{\"id\":2,\"name\":\"Another pick-up point\",\"address\":\"19, Sample st., Moscow\",\"contact\":\"sample@example.com\"}]"),
+			wantBody: []byte("[{\"id\":2,\"name\":\"Another pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Sample st.\",\"building\":\"19\"},\"contact\":{\"email\":\"sample@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":1}]"),
+		},
+		{
+			name:     "first page",
+			target:   "/pickup-point?sort=-id&limit=1",
+			wantCode: http.StatusOK,
+			wantBody: []byte("[{\"id\":2,\"name\":\"Another pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Sample st.\",\"building\":\"19\"},\"contact\":{\"email\":\"sample@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":1}]"),
+			wantNext: true,
 		},
 	}
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			req := httptest.NewRequest(http.MethodGet, "/pickup-points", nil)
-			code, body := s.h.ListHandler(req, nil)
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			header := http.Header{}
+			code, body := s.h.ListHandler(req, nil, header)
 			s.Equal(tt.wantCode, code)
-			s.Equal(tt.wantBody, body)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+			s.Equal(tt.wantNext, header.Get("Link") != "")
 		})
 	}
 }
@@ -127,7 +194,7 @@ func (s *PickUpPointApiIntegrationTestSuite) Test_GetHandler() {
 			name:     "ok",
 			idStr:    "1",
 			wantCode: http.StatusOK,
-			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}"),
+			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":1}"),
 		},
 		{
 			name:     "invalid id string",
@@ -143,9 +210,9 @@ func (s *PickUpPointApiIntegrationTestSuite) Test_GetHandler() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			req := httptest.NewRequest(http.MethodGet, "/pickup-points", nil)
-			code, body := s.h.GetHandler(req, map[string]string{"id": tt.idStr})
+			code, body := s.h.GetHandler(req, map[string]string{"id": tt.idStr}, http.Header{})
 			s.Equal(tt.wantCode, code)
-			s.Equal(tt.wantBody, body)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
 		})
 	}
 }
@@ -154,6 +221,7 @@ func (s *PickUpPointApiIntegrationTestSuite) Test_UpdateHandler() {
 	tests := []struct {
 		name     string
 		idStr    string
+		ifMatch  string
 		reqBody  string
 		wantCode int
 		wantBody []byte
@@ -161,9 +229,24 @@ func (s *PickUpPointApiIntegrationTestSuite) Test_UpdateHandler() {
 		{
 			name:     "ok",
 			idStr:    "1",
-			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point updated\",\"address\":\"5, Updated st., Moscow\",\"contact\":\"updated@example.com\"}",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point updated\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Updated st.\",\"building\":\"5\"},\"contact\":{\"email\":\"updated@example.com\"}}",
+			wantCode: http.StatusOK,
+			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point updated\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Updated st.\",\"building\":\"5\"},\"contact\":{\"email\":\"updated@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":2}"),
+		},
+		{
+			name:     "if match",
+			idStr:    "1",
+			ifMatch:  "\"2\"",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point updated\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Updated st.\",\"building\":\"5\"},\"contact\":{\"email\":\"updated@example.com\"}}",
 			wantCode: http.StatusOK,
-			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point updated\",\"address\":\"5, Updated st., Moscow\",\"contact\":\"updated@example.com\"}"),
+			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point updated\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Updated st.\",\"building\":\"5\"},\"contact\":{\"email\":\"updated@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":3}"),
+		},
+		{
+			name:     "version mismatch",
+			idStr:    "1",
+			ifMatch:  "\"2\"",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point updated\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Updated st.\",\"building\":\"5\"},\"contact\":{\"email\":\"updated@example.com\"}}",
+			wantCode: http.StatusPreconditionFailed,
 		},
 		{
 			name:     "invalid id string",
@@ -179,22 +262,77 @@ func (s *PickUpPointApiIntegrationTestSuite) Test_UpdateHandler() {
 		{
 			name:     "id and body mismatch",
 			idStr:    "2",
-			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point updated\",\"address\":\"5, Updated st., Moscow\",\"contact\":\"updated@example.com\"}",
+			reqBody:  "{\"id\":1,\"name\":\"Generic pick-up point updated\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Updated st.\",\"building\":\"5\"},\"contact\":{\"email\":\"updated@example.com\"}}",
 			wantCode: http.StatusBadRequest,
 		},
 		{
 			name:     "not found",
 			idStr:    "3",
-			reqBody:  "{\"id\":3,\"name\":\"Generic pick-up point\",\"address\":\"5, Test st., Moscow\",\"contact\":\"test@example.com\"}",
+			reqBody:  "{\"id\":3,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"}}",
 			wantCode: http.StatusNotFound,
 		},
 	}
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			req := httptest.NewRequest(http.MethodPut, "/pickup-points", strings.NewReader(tt.reqBody))
-			code, body := s.h.UpdateHandler(req, map[string]string{"id": tt.idStr})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			code, body := s.h.UpdateHandler(req, map[string]string{"id": tt.idStr}, http.Header{})
+			s.Equal(tt.wantCode, code)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
+		})
+	}
+}
+
+func (s *PickUpPointApiIntegrationTestSuite) Test_PatchHandler() {
+	tests := []struct {
+		name     string
+		idStr    string
+		ifMatch  string
+		reqBody  string
+		wantCode int
+		wantBody []byte
+	}{
+		{
+			name:     "ok",
+			idStr:    "1",
+			ifMatch:  "\"1\"",
+			reqBody:  "{\"address\":{\"street\":\"Patched st.\"},\"contact\":{\"phone\":\"+74951234567\"}}",
+			wantCode: http.StatusOK,
+			wantBody: []byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Patched st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\",\"phone\":\"+74951234567\"},\"schedule\":{},\"capacity\":{},\"version\":2}"),
+		},
+		{
+			name:     "version mismatch",
+			idStr:    "1",
+			ifMatch:  "\"1\"",
+			reqBody:  "{\"name\":\"Patched pick-up point\"}",
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "invalid details",
+			idStr:    "1",
+			reqBody:  "{\"contact\":null}",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: []byte("{\"code\":\"invalid_point\",\"message\":\"invalid pick-up point\",\"fields\":[{\"field\":\"contact\",\"message\":\"either email or phone is required\"}]}"),
+		},
+		{
+			name:     "not found",
+			idStr:    "3",
+			reqBody:  "{\"name\":\"Patched pick-up point\"}",
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			req := httptest.NewRequest(http.MethodPatch, "/pickup-points", strings.NewReader(tt.reqBody))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			code, body := s.h.PatchHandler(req, map[string]string{"id": tt.idStr}, http.Header{})
 			s.Equal(tt.wantCode, code)
-			s.Equal(tt.wantBody, body)
+			s.assertBody(tt.wantCode, tt.wantBody, body)
 		})
 	}
 }
@@ -203,11 +341,19 @@ func (s *PickUpPointApiIntegrationTestSuite) Test_DeleteHandler() {
 	tests := []struct {
 		name     string
 		idStr    string
+		ifMatch  string
 		wantCode int
 	}{
+		{
+			name:     "version mismatch",
+			idStr:    "1",
+			ifMatch:  "\"2\"",
+			wantCode: http.StatusPreconditionFailed,
+		},
 		{
 			name:     "ok",
 			idStr:    "1",
+			ifMatch:  "\"1\"",
 			wantCode: http.StatusNoContent,
 		},
 		{
@@ -224,12 +370,91 @@ func (s *PickUpPointApiIntegrationTestSuite) Test_DeleteHandler() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			req := httptest.NewRequest(http.MethodDelete, "/pickup-points", nil)
-			code, _ := s.h.DeleteHandler(req, map[string]string{"id": tt.idStr})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			code, _ := s.h.DeleteHandler(req, map[string]string{"id": tt.idStr}, http.Header{})
 			s.Equal(tt.wantCode, code)
 		})
 	}
 }
 
+func (s *PickUpPointApiIntegrationTestSuite) Test_RestoreHandler() {
+	vars := map[string]string{"id": "1"}
+	code, _ := s.h.DeleteHandler(httptest.NewRequest(http.MethodDelete, "/pickup-point/1", nil), vars, http.Header{})
+	s.Require().Equal(http.StatusNoContent, code)
+
+	code, _ = s.h.GetHandler(httptest.NewRequest(http.MethodGet, "/pickup-point/1", nil), vars, http.Header{})
+	s.Equal(http.StatusNotFound, code)
+	code, body := s.h.ListHandler(httptest.NewRequest(http.MethodGet, "/pickup-point", nil), nil, http.Header{})
+	s.Equal(http.StatusOK, code)
+	s.NotContains(string(body), "Generic pick-up point")
+	header := http.Header{}
+	code, _ = s.h.GetHandler(httptest.NewRequest(http.MethodGet, "/pickup-point/1?include_deleted=true", nil), vars, header)
+	s.Equal(http.StatusOK, code)
+	s.Equal("\"2\"", header.Get("ETag"))
+
+	code, _ = s.h.PatchHandler(httptest.NewRequest(http.MethodPatch, "/pickup-point/1", strings.NewReader("{\"name\":\"Patched\"}")), vars, http.Header{})
+	s.Equal(http.StatusNotFound, code)
+
+	req := httptest.NewRequest(http.MethodPost, "/pickup-point/1/restore", nil)
+	req.Header.Set("If-Match", "\"2\"")
+	code, body = s.h.RestoreHandler(req, vars, http.Header{})
+	s.Equal(http.StatusOK, code)
+	s.Equal([]byte("{\"id\":1,\"name\":\"Generic pick-up point\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Test st.\",\"building\":\"5\"},\"contact\":{\"email\":\"test@example.com\"},\"schedule\":{},\"capacity\":{},\"version\":3}"), body)
+
+	code, _ = s.h.RestoreHandler(httptest.NewRequest(http.MethodPost, "/pickup-point/1/restore", nil), vars, http.Header{})
+	s.Equal(http.StatusConflict, code)
+}
+
+func (s *PickUpPointApiIntegrationTestSuite) Test_BatchHandler() {
+	batch := "[{\"name\":\"North\",\"address\":{\"country\":\"Russia\",\"city\":\"Moscow\",\"street\":\"Tverskaya st.\",\"building\":\"1\"},\"contact\":{\"email\":\"north@example.com\"}}," +
+		"{\"id\":2,\"name\":\"South\",\"address\":{\"country\":\"Russia\",\"city\":\"Kazan\",\"street\":\"Baumana st.\",\"building\":\"2\"},\"contact\":{\"email\":\"south@example.com\"}}]"
+	code, body := s.h.BatchHandler(httptest.NewRequest(http.MethodPost, "/pickup-point/batch", strings.NewReader(batch)), nil, http.Header{})
+	s.Equal(http.StatusUnprocessableEntity, code)
+	s.Equal([]byte("{\"dry_run\":false,\"created\":0,\"failed\":1,\"rows\":[{\"row\":1,\"status\":\"valid\"},{\"row\":2,\"id\":2,\"status\":\"failed\",\"error\":\"item with such id already exists\"}]}"), body)
+
+	batch = strings.Replace(batch, "\"id\":2", "\"id\":10", 1)
+	code, body = s.h.BatchHandler(httptest.NewRequest(http.MethodPost, "/pickup-point/batch?dry_run=true", strings.NewReader(batch)), nil, http.Header{})
+	s.Equal(http.StatusOK, code)
+	s.Equal([]byte("{\"dry_run\":true,\"created\":0,\"failed\":0,\"rows\":[{\"row\":1,\"status\":\"valid\"},{\"row\":2,\"id\":10,\"status\":\"valid\"}]}"), body)
+
+	code, body = s.h.BatchHandler(httptest.NewRequest(http.MethodPost, "/pickup-point/batch", strings.NewReader(batch)), nil, http.Header{})
+	s.Equal(http.StatusCreated, code)
+	s.Equal([]byte("{\"dry_run\":false,\"created\":2,\"failed\":0,\"rows\":[{\"row\":1,\"id\":11,\"status\":\"created\"},{\"row\":2,\"id\":10,\"status\":\"created\"}]}"), body)
+}
+
+func (s *PickUpPointApiIntegrationTestSuite) Test_HistoryHandler() {
+	vars := map[string]string{"id": "1"}
+	req := httptest.NewRequest(http.MethodPatch, "/pickup-point/1", strings.NewReader("{\"name\":\"Renamed pick-up point\"}"))
+	code, _ := s.h.PatchHandler(req.WithContext(auth.WithUser(req.Context(), "user")), vars, http.Header{})
+	s.Require().Equal(http.StatusOK, code)
+	code, _ = s.h.DeleteHandler(httptest.NewRequest(http.MethodDelete, "/pickup-point/1", nil), vars, http.Header{})
+	s.Require().Equal(http.StatusNoContent, code)
+
+	header := http.Header{}
+	code, body := s.h.HistoryHandler(httptest.NewRequest(http.MethodGet, "/pickup-point/1/history?limit=1", nil), vars, header)
+	s.Equal(http.StatusOK, code)
+	var entries []pickuppoint.HistoryEntry
+	s.Require().NoError(json.Unmarshal(body, &entries))
+	s.Require().Len(entries, 1)
+	s.Equal(pickuppoint.ActionDelete, entries[0].Action)
+	s.Equal(uint64(3), entries[0].Version)
+	s.Require().NotEmpty(header.Get("Link"))
+
+	code, body = s.h.HistoryHandler(httptest.NewRequest(http.MethodGet, "/pickup-point/1/history?before="+strconv.FormatUint(entries[0].Id, 10), nil), vars, http.Header{})
+	s.Equal(http.StatusOK, code)
+	s.Require().NoError(json.Unmarshal(body, &entries))
+	s.Require().Len(entries, 1, "points inserted directly have no create entry")
+	s.Equal(pickuppoint.ActionUpdate, entries[0].Action)
+	s.Equal("user", entries[0].User)
+	s.Equal("Generic pick-up point", entries[0].Old.Name)
+	s.Equal("Renamed pick-up point", entries[0].New.Name)
+
+	code, _ = s.h.HistoryHandler(httptest.NewRequest(http.MethodGet, "/pickup-point/3/history", nil), map[string]string{"id": "3"}, http.Header{})
+	s.Equal(http.StatusNotFound, code)
+}
+
 func TestPickUpPointApi(t *testing.T) {
 	suite.Run(t, new(PickUpPointApiIntegrationTestSuite))
 }
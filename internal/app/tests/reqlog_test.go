@@ -13,6 +13,7 @@ import (
 	"homework/internal/app/logger"
 	"homework/internal/app/middleware"
 	"homework/internal/app/reqlog"
+	"homework/internal/app/requestid"
 	"io"
 	log2 "log"
 	"net/http"
@@ -39,7 +40,11 @@ func TestLogMiddleware(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	})
 
-	resChan := make(chan reqlog.Message)
+	type received struct {
+		msg reqlog.Message
+		id  string
+	}
+	resChan := make(chan received)
 
 	logCtx, logStop := context.WithCancel(context.Background())
 	defer logStop()
@@ -48,11 +53,11 @@ func TestLogMiddleware(t *testing.T) {
 	prod, err := kafka.NewProducer(brokers, log, topic)
 	assert.NoError(t, err)
 	defer prod.Close()
-	cons, err := kafka.NewConsumer(brokers, topic, func(bytes []byte) {
+	cons, err := kafka.NewConsumer(brokers, topic, func(ctx context.Context, bytes []byte) {
 		var msg reqlog.Message
 		_ = json.Unmarshal(bytes, &msg)
 
-		resChan <- msg
+		resChan <- received{msg: msg, id: requestid.FromContext(ctx)}
 	})
 	assert.NoError(t, err)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -117,8 +122,7 @@ func TestLogMiddleware(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := middleware.LogMiddleware(reqLog)
-			h := m(h)
+			h := middleware.RequestIdMiddleware()(middleware.LogMiddleware(reqLog)(h))
 			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
 			req = mux.SetURLVars(req, tt.params)
 			req.Header = tt.headers
@@ -130,7 +134,10 @@ func TestLogMiddleware(t *testing.T) {
 			select {
 			case <-ctx.Done():
 				assert.Fail(t, "context cancelled", ctx.Err())
-			case msg := <-resChan:
+			case res := <-resChan:
+				msg := res.msg
+				assert.Equal(t, w.Header().Get(requestid.Header), msg.RequestId)
+				assert.Equal(t, msg.RequestId, res.id, "request id is sent in message headers")
 				assert.Equal(t, tt.method, msg.Method)
 				assert.Equal(t, tt.path, msg.Path)
 				assert.Equal(t, tt.headers, msg.Headers)
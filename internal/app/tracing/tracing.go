@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"homework/internal/app/requestid"
+)
+
+const (
+	// None leaves spans unrecorded.
+	None = "none"
+	// OTLP exports spans to an OpenTelemetry collector over HTTP.
+	OTLP = "otlp"
+
+	serviceName = "pickup-points"
+	tracerName  = "homework"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+type Config struct {
+	// Exporter is None or OTLP, spans are not recorded when it is empty.
+	Exporter string
+	// Endpoint is the URL of the collector, $OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318 when it is empty.
+	Endpoint string
+}
+
+// Setup installs the tracer provider exporting spans as cfg tells,
+// shutdown exports the spans left and stops the provider.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "", None:
+		return func(context.Context) error { return nil }, nil
+	case OTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w %q, expected one of %s, %s", ErrUnknownExporter, cfg.Exporter, None, OTLP)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// InMemory installs a tracer provider keeping every span ended in the returned exporter, for tests.
+func InMemory() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	return exporter
+}
+
+// Start starts a span named name, a child of the span of ctx, and returns ctx with it.
+// The span is not recorded until Setup installs a provider.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	// the tracer is taken every time to follow the provider installed last
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// End ends span, marking it failed when err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// WithRemoteParent returns ctx in which spans continue the trace of traceparent received from another service.
+func WithRemoteParent(ctx context.Context, traceparent requestid.Traceparent) context.Context {
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceparent.TraceId,
+		SpanID:     traceparent.ParentId,
+		TraceFlags: trace.TraceFlags(traceparent.Flags),
+		Remote:     true,
+	}))
+}
+
+// Traceparent returns the trace context of the span of ctx if it is recorded.
+func Traceparent(ctx context.Context) (requestid.Traceparent, bool) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return requestid.Traceparent{}, false
+	}
+	sc := span.SpanContext()
+	return requestid.Traceparent{
+		TraceId:  sc.TraceID(),
+		ParentId: sc.SpanID(),
+		Flags:    byte(sc.TraceFlags()),
+	}, true
+}
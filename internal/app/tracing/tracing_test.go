@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"homework/internal/app/requestid"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{Exporter: None})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), Config{Exporter: "zipkin"})
+	assert.ErrorIs(t, err, ErrUnknownExporter)
+}
+
+func TestStartEnd(t *testing.T) {
+	exporter := InMemory()
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	End(child, errors.New("failed"))
+	End(parent, nil)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "failed", spans[0].Status.Description)
+	assert.Len(t, spans[0].Events, 1, "the error is recorded")
+	assert.Equal(t, "parent", spans[1].Name)
+	assert.Equal(t, codes.Unset, spans[1].Status.Code)
+	assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+}
+
+func TestTraceparent(t *testing.T) {
+	exporter := InMemory()
+
+	_, ok := Traceparent(context.Background())
+	assert.False(t, ok)
+
+	remote := requestid.NewTraceparent()
+	ctx, span := Start(WithRemoteParent(context.Background(), remote), "span")
+	traceparent, ok := Traceparent(ctx)
+	assert.True(t, ok)
+	span.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, remote.TraceId, traceparent.TraceId, "the span continues the remote trace")
+	assert.Equal(t, [8]byte(spans[0].SpanContext.SpanID()), traceparent.ParentId)
+	assert.Equal(t, remote.ParentId, [8]byte(spans[0].Parent.SpanID()))
+	assert.True(t, spans[0].Parent.IsRemote())
+}
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS orders
+(
+    id          bigint primary key not null,
+    customer_id bigint             not null,
+    price       bigint             not null,
+    weight_kg   double precision   not null,
+    add_date    timestamptz        not null,
+    keep_date   timestamptz        not null,
+    is_given    boolean            not null,
+    give_date   timestamptz        not null,
+    is_returned boolean            not null,
+    return_date timestamptz        not null
+);
+CREATE INDEX IF NOT EXISTS orders_customer_id_add_date_idx ON orders (customer_id, add_date DESC);
+CREATE INDEX IF NOT EXISTS orders_return_date_idx ON orders (return_date DESC) WHERE is_returned;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS orders;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE pickup_points
+    ADD COLUMN IF NOT EXISTS schedule jsonb not null default '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pickup_points
+    DROP COLUMN IF EXISTS schedule;
+-- +goose StatementEnd
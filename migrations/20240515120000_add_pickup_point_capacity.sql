@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE pickup_points
+    ADD COLUMN IF NOT EXISTS capacity jsonb not null default '{}';
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS pickup_point_id bigint not null default 0;
+CREATE INDEX IF NOT EXISTS orders_pickup_point_id_idx ON orders (pickup_point_id) WHERE NOT is_given OR is_returned;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS orders_pickup_point_id_idx;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS pickup_point_id;
+ALTER TABLE pickup_points
+    DROP COLUMN IF EXISTS capacity;
+-- +goose StatementEnd
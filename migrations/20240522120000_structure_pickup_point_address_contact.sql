@@ -0,0 +1,40 @@
+-- The up migration loses data: the free-form address cannot be split into its parts, so all of it
+-- goes to street, and a contact is taken for an email when it contains "@" and for a phone otherwise.
+-- The original text is kept in legacy_address and legacy_contact, so that the down migration
+-- restores it for points not changed since; changed points get text built from their new parts.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE pickup_points
+    ADD COLUMN legacy_address text,
+    ADD COLUMN legacy_contact text;
+UPDATE pickup_points SET legacy_address = address, legacy_contact = contact;
+ALTER TABLE pickup_points
+    ALTER COLUMN address TYPE jsonb USING jsonb_build_object('street', address),
+    ALTER COLUMN contact TYPE jsonb USING CASE
+        WHEN contact LIKE '%@%' THEN jsonb_build_object('email', contact)
+        ELSE jsonb_build_object('phone', contact)
+    END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pickup_points
+    ALTER COLUMN address TYPE text USING CASE
+        WHEN address = jsonb_build_object('street', legacy_address) THEN legacy_address
+        ELSE concat_ws(', ',
+            nullif(address ->> 'building', ''),
+            nullif(address ->> 'street', ''),
+            nullif(address ->> 'city', ''),
+            nullif(address ->> 'postcode', ''),
+            nullif(address ->> 'country', ''))
+    END,
+    ALTER COLUMN contact TYPE text USING CASE
+        WHEN contact = jsonb_build_object('email', legacy_contact)
+            OR contact = jsonb_build_object('phone', legacy_contact) THEN legacy_contact
+        ELSE coalesce(nullif(contact ->> 'email', ''), contact ->> 'phone', '')
+    END;
+ALTER TABLE pickup_points
+    DROP COLUMN legacy_address,
+    DROP COLUMN legacy_contact;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE pickup_points
+    ADD COLUMN IF NOT EXISTS version bigint not null default 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pickup_points
+    DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
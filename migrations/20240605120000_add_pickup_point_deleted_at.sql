@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE pickup_points
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS pickup_points_deleted_at_idx ON pickup_points (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS pickup_points_deleted_at_idx;
+ALTER TABLE pickup_points
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS pickup_points_search_idx ON pickup_points USING gin (
+    lower(name || E'\n' || coalesce(address->>'building', '') || E'\n' || coalesce(address->>'street', '') || E'\n' || coalesce(address->>'city', '') || E'\n' || coalesce(address->>'postcode', '') || E'\n' || coalesce(address->>'country', '')) gin_trgm_ops
+);
+CREATE INDEX IF NOT EXISTS pickup_points_name_idx ON pickup_points (name, id);
+CREATE INDEX IF NOT EXISTS pickup_points_city_idx ON pickup_points ((coalesce(address->>'city', '')), id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS pickup_points_city_idx;
+DROP INDEX IF EXISTS pickup_points_name_idx;
+DROP INDEX IF EXISTS pickup_points_search_idx;
+-- +goose StatementEnd
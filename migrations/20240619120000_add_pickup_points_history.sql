@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS pickup_points_history
+(
+    id         bigserial primary key not null,
+    point_id   bigint                not null,
+    version    bigint                not null,
+    action     text                  not null,
+    old_value  jsonb,
+    new_value  jsonb,
+    user_name  text                  not null default '',
+    changed_at timestamptz           not null
+);
+CREATE INDEX IF NOT EXISTS pickup_points_history_point_id_idx ON pickup_points_history (point_id, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS pickup_points_history;
+-- +goose StatementEnd
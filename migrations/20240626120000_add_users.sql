@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS users
+(
+    id            bigserial primary key not null,
+    name          text unique           not null,
+    password_hash text                  not null,
+    disabled      boolean               not null default false,
+    created_at    timestamptz           not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS users;
+-- +goose StatementEnd
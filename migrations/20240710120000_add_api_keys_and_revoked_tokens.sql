@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id         bigserial primary key not null,
+    user_name  text                  not null,
+    name       text                  not null default '',
+    prefix     text                  not null,
+    key_hash   text unique           not null,
+    created_at timestamptz           not null,
+    revoked_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS api_keys_user_name_idx ON api_keys (user_name);
+CREATE TABLE IF NOT EXISTS revoked_tokens
+(
+    token_id   text primary key not null,
+    expires_at timestamptz      not null
+);
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
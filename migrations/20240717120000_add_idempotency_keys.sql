@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    key          text primary key not null,
+    request_hash text             not null,
+    completed    boolean          not null default false,
+    status       integer          not null default 0,
+    header       jsonb            not null default '{}',
+    body         bytea            not null default '',
+    expires_at   timestamptz      not null
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd